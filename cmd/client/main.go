@@ -8,9 +8,11 @@ import (
 	"obs-sync/infra/log"
 	"obs-sync/models"
 	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/pkg/object"
 	"obs-sync/pkg/tube"
 	"obs-sync/proto/sync/pb"
+	"os"
 	"sync"
 	"time"
 
@@ -22,13 +24,18 @@ var (
 	storageMap sync.Map
 	logger     *log.Logger
 
-	svrIP   = flag.String("svr", "0.0.0.0", "servr IP")
-	logPath = flag.String("log", "", "log path")
+	svrIP     = flag.String("svr", "0.0.0.0", "servr IP")
+	logPath   = flag.String("log", "", "log path")
+	endpoints = flag.String("endpoints", "", "endpoint config file, workers inside a VPC may use the internal endpoints")
 )
 
 func main() {
 	flag.Parse()
 	logger = log.NewLogger(*logPath)
+	if err := endpoint.Load(*endpoints); err != nil {
+		logger.Error().Err(err).Msg("load endpoints failed.")
+		return
+	}
 
 	//查询本机器IP
 	localIP, err := findLocalIP()
@@ -59,8 +66,10 @@ func main() {
 		return
 	}
 
+	// 上报主机名，服务端据此把数据在本机的任务优先分配给当前 worker
+	hostname, _ := os.Hostname()
 	for {
-		err = stream.Send(&pb.DataRequest{Sign: "free"})
+		err = stream.Send(&pb.DataRequest{Sign: "free", Host: hostname})
 		// 接收从 服务端返回的数据流
 		recv, err := stream.Recv()
 		if err != nil {
@@ -68,15 +77,26 @@ func main() {
 			break
 		}
 		logger.Info().Msgf("received task: %v", recv.Task)
-		success, failed, dealSize := doSync(recv.Task)
-		if len(success) != 0 || len(failed) != 0 {
-			_, err = client.PutResult(context.Background(), &pb.Result{
+		success, failed, restoring, warnings, dealSize := doSync(recv.Task)
+		if len(success) != 0 || len(failed) != 0 || len(restoring) != 0 {
+			result := &pb.Result{
 				BucketName: recv.Task.BucketName,
 				WorkIP:     localIP,
 				Success:    success,
 				Failed:     failed,
 				DeadlSize:  dealSize,
-			})
+				Warnings:   warnings,
+			}
+			if len(restoring) > 0 {
+				result.Restoring = &pb.TaskInfo{
+					BucketName: recv.Task.BucketName,
+					SrcUri:     recv.Task.SrcUri,
+					DestUri:    recv.Task.DestUri,
+					Objects:    restoring,
+					Config:     recv.Task.Config,
+				}
+			}
+			_, err = client.PutResult(context.Background(), result)
 			if err != nil {
 				logger.Error().Err(err).Msg("put task result failed")
 				return
@@ -131,7 +151,9 @@ func findLocalIP() (string, error) {
 	return "", errors.New("findLocalIP:: network not running")
 }
 
-func doSync(task *pb.TaskInfo) (success []string, failed []string, dealSzie int64) {
+// doSync 复制任务中的对象，源端的归档对象发起取回后放入 restoring，由服务端等待取回完成后重新下发；
+// 复制成功但部分属性未能写入的对象记入 warnings
+func doSync(task *pb.TaskInfo) (success []string, failed []string, restoring []*pb.Object, warnings []string, dealSzie int64) {
 	var (
 		src, dst object.ObjectStorage
 	)
@@ -148,6 +170,39 @@ func doSync(task *pb.TaskInfo) (success []string, failed []string, dealSzie int6
 		logger.Error().Msgf("dosync:: create storage failed, dest:%s,err:%v", task.DestUri, err)
 		return
 	}
+	// 服务端加密：目的端写入时加密，源端使用 SSE-C 密钥读取
+	if err = setSSE(dst, task.Config.GetSse(), task.Config.GetSseKmsKeyId(), task.Config.GetSseCustomerKeyFile()); err != nil {
+		logger.Error().Msgf("dosync:: set server-side encryption failed, dest:%s, err:%v", task.DestUri, err)
+		return
+	}
+	if err = setSSE(src, "", "", task.Config.GetSrcSseCustomerKeyFile()); err != nil {
+		logger.Error().Msgf("dosync:: set server-side encryption failed, src:%s, err:%v", task.SrcUri, err)
+		return
+	}
+	// 客户端加密：写入目的端时加密，读取源端时解密
+	if src, err = object.WithKeyFile(src, task.Config.GetDecryptKeyFile()); err != nil {
+		logger.Error().Msgf("dosync:: load decrypt key failed, src:%s, err:%v", task.SrcUri, err)
+		return
+	}
+	if dst, err = object.WithKeyFile(dst, task.Config.GetEncryptKeyFile()); err != nil {
+		logger.Error().Msgf("dosync:: load encrypt key failed, dest:%s, err:%v", task.DestUri, err)
+		return
+	}
+	object.ResetStorageClassWarnings()
+	// 端到端校验：目的端写入校验值，并与源端的校验值比对；
+	// 存储在任务间复用，每个任务都重新设置，避免沿用上个任务的算法和请求头
+	_ = dst.IsSetMd5(task.Config.GetSetObjectMetaMD5())
+	_ = src.SetCheckSumKey(task.Config.GetSrcMD5Header())
+	if cfg := task.Config; cfg != nil && (cfg.SetObjectMetaMD5 || cfg.SrcMD5Header != "") {
+		consumer.OpenChecksum(cfg.SetObjectMetaMD5)
+	}
+	if task.Config != nil {
+		consumer.SetStorageClass(task.Config.StorageClass)
+		if task.Config.CopyTags {
+			consumer.OpenTagging()
+		}
+		consumer.SetACL(task.Config.AclMode, task.Config.AclAccounts)
+	}
 
 	for _, o := range task.Objects {
 		wg.Add(1)
@@ -156,16 +211,48 @@ func doSync(task *pb.TaskInfo) (success []string, failed []string, dealSzie int6
 			if o.Key == "" {
 				return
 			}
-			var acl models.CannedACLType
-			acl, _ = src.GetObjectAcl(o.Key)
 			start := time.Now()
 			obj := object.UnmarshalObject(map[string]interface{}{
-				"key":   o.Key,
-				"mtime": o.Mtime,
-				"isdir": o.IsDir,
-				"size":  o.Size,
+				"key":          o.Key,
+				"mtime":        o.Mtime,
+				"isdir":        o.IsDir,
+				"size":         o.Size,
+				"storageClass": o.StorageClass,
 			})
-			err := consumer.Work(src, dst, obj, acl)
+			// 源端 Head 返回的对象带有元数据，复制时一并写入目的端
+			if task.Config != nil && task.Config.PreserveMetadata && len(o.Versions) == 0 {
+				if info, err := src.Head(o.Key); err != nil {
+					logger.Warn().Msgf("dosync:: head %s failed, metadata will not be copied: %v", o.Key, err)
+				} else if _, ok := info.(object.ObjectInfo); ok {
+					obj = info
+				}
+			}
+			var err error
+			if len(o.Versions) > 0 {
+				err = consumer.Replay(src, dst, objectVersions(o))
+			} else {
+				err = consumer.Work(src, dst, obj)
+			}
+			var warn *tube.Warning
+			if errors.As(err, &warn) {
+				lock.Lock()
+				warnings = append(warnings, task.SrcUri.BucketDomain+"://"+o.Key+": "+warn.Error())
+				lock.Unlock()
+				logger.Warn().Msgf("dosync %s: %s", o.Key, warn)
+				err = nil
+			}
+			if object.IsArchived(err) && task.Config != nil && task.Config.RestoreDays > 0 && len(o.Versions) == 0 {
+				if r, ok := src.(object.Restorer); ok {
+					if err = r.Restore(o.Key, int(task.Config.RestoreDays)); err == nil {
+						lock.Lock()
+						restoring = append(restoring, o)
+						lock.Unlock()
+						logger.Info().Msgf("dosync restoring archived object, obj_name:%s", o.Key)
+						return
+					}
+					logger.Error().Err(err).Msgf("dosync restore failed, obj_name:%s", o.Key)
+				}
+			}
 			logger.Info().Msgf("%v, success", obj)
 			if err != nil {
 				lock.Lock()
@@ -188,6 +275,34 @@ func doSync(task *pb.TaskInfo) (success []string, failed []string, dealSzie int6
 	return
 }
 
+// objectVersions 多版本复制时对象的各个版本
+func objectVersions(o *pb.Object) []object.Version {
+	var vs []object.Version
+	for _, v := range o.Versions {
+		if ov, ok := object.UnmarshalObject(map[string]interface{}{
+			"key":          o.Key,
+			"mtime":        time.Unix(v.Mtime, 0).UnixNano(), // UnmarshalObject 的 mtime 为纳秒
+			"isdir":        o.IsDir,
+			"size":         v.Size,
+			"storageClass": v.StorageClass,
+			"versionId":    v.VersionId,
+			"deleteMarker": v.DeleteMarker,
+		}).(object.Version); ok {
+			vs = append(vs, ov)
+		}
+	}
+	return vs
+}
+
+// setSSE 设置存储的服务端加密，存储在任务间复用，没有设置时清除上个任务的设置
+func setSSE(s object.ObjectStorage, mode, kmsKeyID, customerKeyFile string) error {
+	sse, err := object.ParseSSE(mode, kmsKeyID, customerKeyFile)
+	if err != nil {
+		return err
+	}
+	return object.SetSSE(s, sse)
+}
+
 // 缓存
 func createStorageCache(storageType string, info *pb.UriInfo) (object.ObjectStorage, error) {
 	key := storageType + "-" + info.BucketDomain
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"obs-sync/cmd/server/service"
+	"obs-sync/infra/log"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+	"obs-sync/proto/sync/pb"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// startStream Start 的服务端流，只记录进度
+type startStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *startStream) Context() context.Context { return s.ctx }
+func (s *startStream) Send(*pb.Status) error    { return nil }
+
+// dataStream DataStream 的服务端流，worker 的请求从 recv 读取，下发的任务写入 sent
+type dataStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv chan *pb.DataRequest
+	sent chan *pb.DataResponse
+}
+
+func (s *dataStream) Context() context.Context { return s.ctx }
+
+func (s *dataStream) Recv() (*pb.DataRequest, error) {
+	r, ok := <-s.recv
+	if !ok {
+		return nil, io.EOF
+	}
+	return r, nil
+}
+
+func (s *dataStream) Send(r *pb.DataResponse) error {
+	s.sent <- r
+	return nil
+}
+
+// TestSync 在同一进程中运行服务端和 worker，使用 mem 存储完成一次迁移：
+// both 两端都有，只复制目的端没有的对象；only 只在源端，创建目的桶后全部复制
+func TestSync(t *testing.T) {
+	logger = log.NewLogger("").SetLevel("error")
+	svr := service.NewServer("")
+	srcNS, dstNS := t.Name()+"-src", t.Name()+"-dst"
+	put := func(ns, name string, objs ...string) object.ObjectStorage {
+		s, err := object.CreateStorage(models.Mem, object.MemBucketURL(ns, name), "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Create()
+		for _, k := range objs {
+			if err = s.Put(k, bytes.NewReader([]byte("data of "+k)), models.Default); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return s
+	}
+	put(srcNS, "both", "a", "b", "c")
+	put(srcNS, "only", "x", "y")
+	put(dstNS, "both", "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res, err := svr.Sync(ctx, &pb.SyncInfo{
+		Src:  &pb.Auth{Type: string(models.Mem), Region: srcNS},
+		Dest: &pb.Auth{Type: string(models.Mem), Region: dstNS},
+	})
+	if err != nil || len(res.Buckets) != 2 {
+		t.Fatalf("sync: %v %v", res, err)
+	}
+	go svr.Start(&pb.Empty{}, &startStream{ctx: ctx})
+
+	ds := &dataStream{ctx: ctx, recv: make(chan *pb.DataRequest), sent: make(chan *pb.DataResponse)}
+	done := make(chan error)
+	go func() { done <- svr.DataStream(ds) }()
+	var synced []string
+	for i := 0; i < 2; i++ {
+		ds.recv <- &pb.DataRequest{Sign: "free", Host: "worker"}
+		var task *pb.TaskInfo
+		select {
+		case r := <-ds.sent:
+			task = r.Task
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no task after %v", synced)
+		}
+		success, failed, _, _, size := doSync(task)
+		if len(failed) != 0 {
+			t.Fatalf("%s failed: %v", task.BucketName, failed)
+		}
+		if _, err = svr.PutResult(ctx, &pb.Result{BucketName: task.BucketName, Success: success, DeadlSize: size}); err != nil {
+			t.Fatal(err)
+		}
+		synced = append(synced, success...)
+	}
+	close(ds.recv)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(synced)
+	want := []string{
+		object.MemBucketURL(srcNS, "both") + "://b", object.MemBucketURL(srcNS, "both") + "://c",
+		object.MemBucketURL(srcNS, "only") + "://x", object.MemBucketURL(srcNS, "only") + "://y",
+	}
+	if strings.Join(synced, " ") != strings.Join(want, " ") {
+		t.Fatalf("synced %v, expected %v", synced, want)
+	}
+	for name, n := range map[string]int64{"both": 2, "only": 2} {
+		v, ok := service.Stats.Load(name)
+		if !ok {
+			t.Fatalf("no stats of %s", name)
+		}
+		s := v.(models.Stats)
+		if s.Scanned != n || s.Copied != n || s.Failed != 0 || s.Size != n*int64(len("data of a")) || !s.FinishFlag {
+			t.Fatalf("stats of %s: %+v", name, s)
+		}
+	}
+	dst := put(dstNS, "only")
+	for _, k := range []string{"x", "y"} {
+		in, err := dst.Get(k, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(in)
+		in.Close()
+		if string(data) != "data of "+k {
+			t.Fatalf("%s: %q", k, data)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package execute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/proto/sync/pb"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v7/decor"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	planOutput string
+	planSave   string
+)
+
+// 演练迁移任务：列举并比对两端的对象，只统计不复制
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Plan a sync task without copying anything.",
+	Long:  "List and compare the buckets like start does, report the objects to copy, skip, only in the dest (kept, never deleted) or in conflict and the buckets to create.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			unpackGrpcError(cmd, args, errors.New("invalid args, expected two arguments, source uri and dest uri"))
+			return
+		}
+		req, err := syncRequest(args)
+		if err != nil {
+			ExecError(cmd, args, err.Error())
+			return
+		}
+		res, err := client.Plan(context.Background(), req)
+		if err != nil {
+			unpackGrpcError(cmd, args, err)
+			return
+		}
+		req.Plan = res.Buckets
+
+		if planSave != "" {
+			if err = os.WriteFile(planSave, marshalPlan(req), 0600); err != nil {
+				ExecError(cmd, args, err.Error())
+				return
+			}
+		}
+		switch planOutput {
+		case "json":
+			fmt.Println(string(marshalPlan(req)))
+		case "table":
+			printPlan(req)
+		default:
+			ExecError(cmd, args, fmt.Sprintf("unknown output %q, expected table or json", planOutput))
+		}
+	},
+}
+
+// marshalPlan 保存的计划不包含密钥，执行时由命令行参数提供
+func marshalPlan(req *pb.SyncInfo) []byte {
+	plan := &pb.SyncInfo{
+		Src:    &pb.Auth{Type: req.Src.Type, Region: req.Src.Region},
+		Dest:   &pb.Auth{Type: req.Dest.Type, Region: req.Dest.Region},
+		Config: req.Config,
+		Plan:   req.Plan,
+	}
+	data, _ := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(plan)
+	return data
+}
+
+// applyPlan 使用计划中的桶和任务参数，两端的类型和区域必须与计划一致
+func applyPlan(req *pb.SyncInfo, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var plan pb.SyncInfo
+	if err = protojson.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("invalid plan %s: %s", file, err)
+	}
+	if plan.Src == nil || plan.Dest == nil || len(plan.Plan) == 0 {
+		return fmt.Errorf("invalid plan %s: no buckets", file)
+	}
+	if plan.Src.Type != req.Src.Type || plan.Src.Region != req.Src.Region ||
+		plan.Dest.Type != req.Dest.Type || plan.Dest.Region != req.Dest.Region {
+		return fmt.Errorf("plan %s is made for %s://%s and %s://%s", file,
+			plan.Src.Type, plan.Src.Region, plan.Dest.Type, plan.Dest.Region)
+	}
+	for _, b := range plan.Plan {
+		if b.Error != "" {
+			return fmt.Errorf("plan %s: bucket %s failed to plan: %s", file, b.Name, b.Error)
+		}
+	}
+	req.Config = plan.Config
+	req.Plan = plan.Plan
+	// 计划中只有选中的桶
+	req.Include, req.Exclude = nil, nil
+	return nil
+}
+
+func printPlan(req *pb.SyncInfo) {
+	count := func(c *pb.PlanCount) string {
+		if c == nil || c.Count == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%d (% .2f)", c.Count, decor.SizeB1024(c.Size))
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"源端bucket域名", "同步方向", "目的端bucket域名", "新建", "复制", "跳过", "目的端多出(保留)", "冲突", "错误"})
+	table.SetBorder(true)
+	var total [4]pb.PlanCount
+	created := 0
+	for _, b := range req.Plan {
+		create := ""
+		if b.Create {
+			create = "yes"
+			created++
+		}
+		ori := models.BucketOri{Orientation: models.Orientation(b.Orientation)}
+		table.Append([]string{b.SrcBucket, ori.Ori(), b.DestBucket, create,
+			count(b.Copy), count(b.Skip), count(b.Extra), count(b.Conflict), b.Error})
+		for i, c := range []*pb.PlanCount{b.Copy, b.Skip, b.Extra, b.Conflict} {
+			if c != nil {
+				total[i].Count += c.Count
+				total[i].Size += c.Size
+			}
+		}
+	}
+	table.SetFooter([]string{"", "", "total", fmt.Sprint(created),
+		count(&total[0]), count(&total[1]), count(&total[2]), count(&total[3]), ""})
+	table.Render()
+}
+
+func init() {
+	addTaskFlags(planCmd)
+	planCmd.Flags().StringVarP(&planOutput, "output", "o", "table", "output format, table or json")
+	planCmd.Flags().StringVar(&planSave, "save", "", "save the plan as json, run it later with \"obsync sync --plan\"")
+	rootCmd.AddCommand(planCmd)
+}
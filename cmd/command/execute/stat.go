@@ -2,6 +2,7 @@ package execute
 
 import (
 	"context"
+	"fmt"
 	"obs-sync/pkg/utils"
 	"obs-sync/proto/sync/pb"
 
@@ -27,6 +28,7 @@ var StatCmd = &cobra.Command{
 		CopiedBytes := progress.AddByteSpinner("Copied objects")
 		Skipped := progress.AddCountSpinner("Skipped objects")
 		Failed := progress.AddCountSpinner("Failed objects")
+		Restoring := progress.AddCountSpinner("Restoring objects")
 
 		for {
 			recv, err := res.Recv()
@@ -50,9 +52,18 @@ var StatCmd = &cobra.Command{
 			Copied.SetCurrent(v.Copied)
 			Skipped.SetCurrent(v.Skipped)
 			Failed.SetCurrent(v.Failed)
+			Restoring.SetCurrent(v.Restoring)
 			CopiedBytes.SetCurrent(v.Size)
 
 			if bucketFinished == bucketTotal {
+				for _, rb := range recv.BucketSummary {
+					if rb.Excluded {
+						fmt.Printf("桶 %s excluded\n", rb.Name)
+					}
+					for _, r := range rb.ConfigReport {
+						fmt.Printf("桶 %s 配置未迁移: %s\n", rb.Name, r)
+					}
+				}
 				ExecSuccess("对象同步已完成")
 				return
 			}
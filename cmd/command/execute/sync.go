@@ -1,18 +1,47 @@
 package execute
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
 	"obs-sync/proto/sync/pb"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+var (
+	setObjectMetaMD5 bool
+	srcMD5Header     string
+	preserveMeta     bool
+	storageClass     string
+	restoreDays      int32
+	copyTags         bool
+	versions         string
+	aclMode          string
+	aclAccounts      map[string]string
+	encryptKey       string
+	decryptKey       string
+	sse              string
+	sseKMSKeyID      string
+	sseCustomerKey   string
+	srcSSEKey        string
+	planFile         string
+	bucketMap        pb.BucketMapping
+	include          []string
+	exclude          []string
+	interactive      bool
+)
+
 // 提交迁移任务 {ak}:{sk}@s3://region
 var submitCmd = &cobra.Command{
 	Use:   "sync",
@@ -23,57 +52,164 @@ var submitCmd = &cobra.Command{
 			unpackGrpcError(cmd, args, errors.New("invalid args, expected two arguments, source uri and dest uri"))
 			return
 		}
-		srcUri, err := parseUri(args[0])
+		req, err := syncRequest(args)
 		if err != nil {
 			ExecError(cmd, args, err.Error())
 			return
 		}
-		destUri, err := parseUri(args[1])
-		if err != nil {
-			ExecError(cmd, args, err.Error())
-			return
+		if planFile != "" {
+			if err = applyPlan(req, planFile); err != nil {
+				ExecError(cmd, args, err.Error())
+				return
+			}
 		}
 
-		res, err := client.Sync(context.Background(), &pb.SyncInfo{
-			Src: &pb.Auth{
-				Type:      string(srcUri.Type),
-				AccessKey: srcUri.AccessKey,
-				SecretKey: srcUri.SecretKey,
-				Region:    srcUri.Region,
-			},
-			Dest: &pb.Auth{
-				Type:      string(destUri.Type),
-				AccessKey: destUri.AccessKey,
-				SecretKey: destUri.SecretKey,
-				Region:    destUri.Region,
-			},
-		})
+		res, err := client.Sync(context.Background(), req)
 		if err != nil {
 			ExecError(cmd, args, err.Error())
 		}
+		if interactive {
+			printSyncTable(req, res, true)
+			if req.Include, err = pickBuckets(res, os.Stdin); err != nil {
+				ExecError(cmd, args, err.Error())
+				return
+			}
+			req.Exclude = nil
+			if res, err = client.Sync(context.Background(), req); err != nil {
+				ExecError(cmd, args, err.Error())
+			}
+		}
 
 		fmt.Println("==>添加任务成功，任务信息如下：")
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"源端类型", "源端bucket域名", "同步方向", "目的端类型", "目的端bucket域名"})
-		table.SetBorder(true)
-		table.SetColumnColor(
-			tablewriter.Colors{},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlueColor},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
-			tablewriter.Colors{},
-			tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlueColor},
-		)
+		printSyncTable(req, res, false)
+	},
+}
+
+func printSyncTable(req *pb.SyncInfo, res *pb.SyncReplay, numbered bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"源端类型", "源端bucket域名", "同步方向", "目的端类型", "目的端bucket域名", "状态"}
+	colors := []tablewriter.Colors{
+		{},
+		{tablewriter.Bold, tablewriter.FgBlueColor},
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+		{},
+		{tablewriter.Bold, tablewriter.FgBlueColor},
+		{tablewriter.FgYellowColor},
+	}
+	if numbered {
+		header = append([]string{"#"}, header...)
+		colors = append([]tablewriter.Colors{{}}, colors...)
+	}
+	table.SetHeader(header)
+	table.SetBorder(true)
+	table.SetColumnColor(colors...)
 
-		for _, r := range res.Buckets {
-			table.Append([]string{string(srcUri.Type), r.Cells[0], r.Cells[1], string(destUri.Type), r.Cells[2]})
+	for i, r := range res.Buckets {
+		row := []string{req.Src.Type, r.Cells[0], r.Cells[1], req.Dest.Type, r.Cells[2], r.Cells[4]}
+		if numbered {
+			row = append([]string{fmt.Sprint(i + 1)}, row...)
 		}
-		table.Render()
-	},
+		table.Append(row)
+	}
+	table.Render()
+}
+
+// pickBuckets 交互选择要迁移的桶，输入编号或范围，如 1,3,5-7，all 表示全部
+func pickBuckets(res *pb.SyncReplay, in io.Reader) ([]string, error) {
+	fmt.Print("请输入要迁移的桶编号（如 1,3,5-7，all 表示全部）: ")
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	line = strings.TrimSpace(line)
+	if line == "all" {
+		return nil, nil
+	}
+	var names []string
+	for _, part := range strings.Split(line, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || start < 1 || end > len(res.Buckets) || start > end {
+			return nil, fmt.Errorf("invalid selection %q, expected numbers between 1 and %d", part, len(res.Buckets))
+		}
+		for i := start; i <= end; i++ {
+			names = append(names, res.Buckets[i-1].Cells[3])
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no bucket selected")
+	}
+	return names, nil
+}
+
+// syncRequest 根据源端、目的端 uri 和任务参数生成同步请求
+func syncRequest(args []string) (*pb.SyncInfo, error) {
+	srcUri, err := parseUri(args[0])
+	if err != nil {
+		return nil, err
+	}
+	destUri, err := parseUri(args[1])
+	if err != nil {
+		return nil, err
+	}
+	versionMode, versionsAsOf, err := parseVersions(versions)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SyncInfo{
+		Src: &pb.Auth{
+			Type:      string(srcUri.Type),
+			AccessKey: srcUri.AccessKey,
+			SecretKey: srcUri.SecretKey,
+			Region:    srcUri.Region,
+		},
+		Dest: &pb.Auth{
+			Type:      string(destUri.Type),
+			AccessKey: destUri.AccessKey,
+			SecretKey: destUri.SecretKey,
+			Region:    destUri.Region,
+		},
+		Config: &pb.TaskConfig{
+			SetObjectMetaMD5:      setObjectMetaMD5,
+			SrcMD5Header:          srcMD5Header,
+			PreserveMetadata:      preserveMeta,
+			StorageClass:          storageClass,
+			RestoreDays:           restoreDays,
+			CopyTags:              copyTags,
+			VersionMode:           versionMode,
+			VersionsAsOf:          versionsAsOf,
+			AclMode:               aclMode,
+			AclAccounts:           aclAccounts,
+			EncryptKeyFile:        encryptKey,
+			DecryptKeyFile:        decryptKey,
+			Sse:                   sse,
+			SseKmsKeyId:           sseKMSKeyID,
+			SseCustomerKeyFile:    sseCustomerKey,
+			SrcSseCustomerKeyFile: srcSSEKey,
+		},
+		Mapping: &bucketMap,
+		Include: include,
+		Exclude: exclude,
+	}, nil
 }
 
 //解析用户输入的uri
 /**
 ak:sk@cuc://nxyc , access_key:secret_key@云厂商类型://region名
+ak:sk@s3compat://http://minio.local:9000?region=us-east-1&style=path&signature=v4 , S3 兼容存储填写访问地址
+user:password@sftp://10.0.0.5:22/data , SFTP 服务器上 /data 下的目录作为桶，sk 也可以是私钥文件路径
+user:password@webdav://https://cloud.example.com/remote.php/dav/files/user , WebDAV 共享下的目录作为桶
+user:-@hdfs://nn1:9870,nn2:9870/warehouse , HDFS 下的目录作为桶
+ak:sk@chaos+s3compat://http://minio.local:9000#error=0.1&ops=get,put , 在被包装的存储上注入故障，用于测试
+sa@proj.iam.gserviceaccount.com:/path/key.json@gcs://my-project , GCS 使用服务账号时 sk 为 JSON 密钥文件路径
 **/
 func parseUri(uriStr string) (*models.Uri, error) {
 	var (
@@ -94,12 +230,72 @@ func parseUri(uriStr string) (*models.Uri, error) {
 		return nil, errors.New("uri must be a valid value, like: s3:ak@sk")
 	}
 	if strings.Contains(regionInfo, "://") {
-		parts := strings.Split(regionInfo, "://")
+		// s3compat 的地址本身可以带 scheme，如 s3compat://http://minio.local:9000
+		parts := strings.SplitN(regionInfo, "://", 2)
 		uri.Type, uri.Region = models.ResourceType(parts[0]), parts[1]
 	}
+	typ, region := uri.Type, uri.Region
+	if inner, addr, params, ok := object.SplitChaos(uri.Type, uri.Region); ok {
+		if _, err := object.ParseChaos(params); err != nil {
+			return nil, err
+		}
+		typ, region = inner, addr
+	}
+	if typ == models.S3Compat {
+		if _, err := endpoint.ParseCompat(region); err != nil {
+			return nil, err
+		}
+	}
 	return &uri, nil
 }
 
+// parseVersions 解析 --versions：all 重放所有版本，时间则复制该时刻的版本
+func parseVersions(v string) (string, int64, error) {
+	switch v {
+	case "":
+		return "", 0, nil
+	case models.VersionAll:
+		return models.VersionAll, 0, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return models.VersionAsOf, t.Unix(), nil
+		}
+	}
+	return "", 0, fmt.Errorf("invalid versions %q, expected \"all\" or a time like 2006-01-02 15:04:05", v)
+}
+
+// addTaskFlags 任务参数，sync 和 plan 共用
+func addTaskFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&setObjectMetaMD5, "md5", false, "store md5 instead of crc32c of the objects in the dest metadata")
+	cmd.Flags().StringVar(&srcMD5Header, "src-md5-header", "", "the header holding the checksum of the source objects, verified after copy")
+	cmd.Flags().BoolVar(&preserveMeta, "preserve-meta", false, "copy content-type, cache-control and other headers and the user metadata of the objects, one more HEAD request per object")
+	cmd.Flags().StringVar(&storageClass, "storage-class", "", "storage class of the dest objects, \"preserve\" to keep the one of the source, e.g. STANDARD, IA, ARCHIVE")
+	cmd.Flags().Int32Var(&restoreDays, "restore-days", 0, "restore the archived source objects automatically and keep the restored copies for days, 0 to disable, the restore requests are billed by the provider")
+	cmd.Flags().BoolVar(&copyTags, "tags", false, "copy the tags of the objects, tags rejected by the dest are reported as warnings")
+	cmd.Flags().StringVar(&versions, "versions", "", "copy the versions of versioned buckets, \"all\" to replay all versions in order into the dest buckets with versioning turned on, or a time to copy the versions as of it")
+	cmd.Flags().StringVar(&aclMode, "acl", models.ACLPreserve, "acl of the dest objects, \"preserve\" to keep the acl of every source object, \"bucket\" to inherit the acl of the dest bucket, or a canned acl like private to force it")
+	cmd.Flags().StringToStringVar(&aclAccounts, "acl-account", nil, "map the account ids of the source to the ones of the dest, e.g. src-id=dest-id, grants to unmapped accounts are reported as warnings")
+	cmd.Flags().StringVar(&encryptKey, "encrypt-key", "", "encrypt the dest objects with AES-256-GCM using the 32-byte key in the file, raw, hex or base64, the file must exist on the server and the workers")
+	cmd.Flags().StringVar(&decryptKey, "decrypt-key", "", "decrypt the source objects encrypted by --encrypt-key with the key in the file")
+	cmd.Flags().StringVar(&sse, "sse", "", "server-side encryption of the dest objects, \"provider\" for the keys managed by the provider or \"kms\", supported by s3, s3compat, cuc, cos, obs and oss")
+	cmd.Flags().StringVar(&sseKMSKeyID, "sse-kms-key", "", "the KMS key id used by --sse kms, the default key of the account if empty")
+	cmd.Flags().StringVar(&sseCustomerKey, "sse-c-key", "", "encrypt the dest objects with the customer-provided 32-byte key in the file (SSE-C), the file must exist on the server and the workers")
+	cmd.Flags().StringVar(&srcSSEKey, "src-sse-c-key", "", "read the source objects encrypted with the customer-provided key in the file (SSE-C)")
+	cmd.Flags().StringToStringVar(&bucketMap.Pairs, "bucket-map", nil, "dest bucket names of the source buckets, e.g. src-bucket=dest-bucket, takes precedence over the other rules")
+	cmd.Flags().StringVar(&bucketMap.StripPrefix, "bucket-strip-prefix", "", "strip the prefix from the source bucket names")
+	cmd.Flags().StringVar(&bucketMap.StripSuffix, "bucket-strip-suffix", "", "strip the suffix from the source bucket names")
+	cmd.Flags().StringVar(&bucketMap.Regex, "bucket-regex", "", "rewrite the source bucket names matching the regular expression with --bucket-replace")
+	cmd.Flags().StringVar(&bucketMap.Replace, "bucket-replace", "", "replacement of --bucket-regex, $1 refers to the first group")
+	cmd.Flags().StringVar(&bucketMap.AddPrefix, "bucket-add-prefix", "", "add the prefix to the dest bucket names")
+	cmd.Flags().StringVar(&bucketMap.AddSuffix, "bucket-add-suffix", "", "add the suffix to the dest bucket names")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "migrate only the buckets matching the names or globs like logs-*, the source or the dest name may match")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "leave out the buckets matching the names or globs, they are shown as excluded in stat")
+}
+
 func init() {
+	addTaskFlags(submitCmd)
+	submitCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "pick the buckets to migrate from the ranked list")
+	submitCmd.Flags().StringVar(&planFile, "plan", "", "run the plan saved by \"obsync plan --save\", the buckets and the task config are taken from it")
 	rootCmd.AddCommand(submitCmd)
 }
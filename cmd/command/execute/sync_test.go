@@ -0,0 +1,32 @@
+package execute
+
+import (
+	"obs-sync/proto/sync/pb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPickBuckets(t *testing.T) {
+	res := &pb.SyncReplay{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		res.Buckets = append(res.Buckets, &pb.SyncReplay_Row{Cells: []string{"src", "==>", "dest", name, ""}})
+	}
+	for in, want := range map[string][]string{
+		"all\n":       nil,
+		"2\n":         {"b"},
+		" 1, 3-4 ,\n": {"a", "c", "d"},
+		"5":           {"e"},
+		"4-5,1\n":     {"d", "e", "a"},
+	} {
+		names, err := pickBuckets(res, strings.NewReader(in))
+		if err != nil || !reflect.DeepEqual(names, want) {
+			t.Fatalf("pick %q: %v %v, expected %v", in, names, err, want)
+		}
+	}
+	for _, in := range []string{"\n", "0\n", "6\n", "3-2\n", "a\n", "1-\n", ""} {
+		if names, err := pickBuckets(res, strings.NewReader(in)); err == nil {
+			t.Fatalf("pick %q: %v", in, names)
+		}
+	}
+}
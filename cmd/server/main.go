@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"obs-sync/cmd/server/service"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/proto/sync/pb"
 
 	"google.golang.org/grpc"
@@ -12,10 +13,16 @@ import (
 )
 
 var (
-	logPath = flag.String("log", "", "log path")
+	logPath   = flag.String("log", "", "log path")
+	endpoints = flag.String("endpoints", "", "endpoint config file, e.g. {\"preset\": \"internal\"}")
 )
 
 func main() {
+	flag.Parse()
+	if err := endpoint.Load(*endpoints); err != nil {
+		fmt.Printf("load endpoints failed, err:%s\n", err.Error())
+		return
+	}
 	listen, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		fmt.Printf("server listen failed, err:%s\n", err.Error())
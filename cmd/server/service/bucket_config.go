@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/bucket"
+)
+
+// copyBucketConfig 将源桶的 CORS、生命周期等配置转换后写入新建的目的桶，返回无法迁移的配置，
+// 报告记在源桶名下
+func copyBucketConfig(src, dst models.Uri, name, dstName string) []string {
+	cfg, err := bucket.BucketStorage(src.Type, src.AccessKey, src.SecretKey).GetConfig(src.Region, name)
+	if err != nil {
+		return []string{fmt.Sprintf("get config from %s: %s", src.Type, err)}
+	}
+	report := cfg.Unmapped
+	if !cfg.IsEmpty() {
+		unmapped, err := bucket.BucketStorage(dst.Type, dst.AccessKey, dst.SecretKey).PutConfig(dst.Region, dstName, cfg)
+		if err != nil {
+			unmapped = append(unmapped, fmt.Sprintf("put config to %s: %s", dst.Type, err))
+		}
+		report = append(report, unmapped...)
+	}
+	for _, r := range report {
+		l.Warn().Msgf("bucket %s config not copied: %s", name, r)
+	}
+	updateStatsReport(name, report)
+	return report
+}
+
+// finishBucket 桶无法复制，原因记入报告并标记为结束
+func finishBucket(bucket, reason string) {
+	updateStatsReport(bucket, []string{reason})
+	v, _ := Stats.Load(bucket)
+	stats := v.(models.Stats)
+	stats.FinishFlag = true
+	Stats.Store(bucket, stats)
+}
+
+func updateStatsReport(bucket string, report []string) {
+	if len(report) == 0 {
+		return
+	}
+	if v, ok := Stats.Load(bucket); ok {
+		tmpValue := v.(models.Stats)
+		tmpValue.ConfigReport = append(tmpValue.ConfigReport, report...)
+		Stats.Store(bucket, tmpValue)
+	} else {
+		Stats.Store(bucket, models.Stats{ConfigReport: report})
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/proto/sync/pb"
+	"regexp"
+	"strings"
+)
+
+// bucketMapping 源端桶名到目的端桶名的映射：显式指定的桶名优先，
+// 否则依次去掉前缀和后缀、正则替换、添加前缀和后缀
+type bucketMapping struct {
+	pairs       map[string]string
+	stripPrefix string
+	stripSuffix string
+	re          *regexp.Regexp
+	replace     string
+	addPrefix   string
+	addSuffix   string
+}
+
+func newBucketMapping(m *pb.BucketMapping) (*bucketMapping, error) {
+	if m == nil {
+		return nil, nil
+	}
+	res := &bucketMapping{
+		pairs:       m.Pairs,
+		stripPrefix: m.StripPrefix,
+		stripSuffix: m.StripSuffix,
+		replace:     m.Replace,
+		addPrefix:   m.AddPrefix,
+		addSuffix:   m.AddSuffix,
+	}
+	if m.Regex != "" {
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket regex %q: %s", m.Regex, err)
+		}
+		res.re = re
+	}
+	return res, nil
+}
+
+// dest 源端桶在目的端的桶名
+func (m *bucketMapping) dest(name string) string {
+	if m == nil {
+		return name
+	}
+	if d, ok := m.pairs[name]; ok {
+		return d
+	}
+	name = strings.TrimPrefix(name, m.stripPrefix)
+	name = strings.TrimSuffix(name, m.stripSuffix)
+	if m.re != nil {
+		name = m.re.ReplaceAllString(name, m.replace)
+	}
+	return m.addPrefix + name + m.addSuffix
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"obs-sync/models"
+	"obs-sync/pkg/bucket"
+	"obs-sync/pkg/object"
+	"obs-sync/proto/sync/pb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBucketMapping(t *testing.T) {
+	m, err := newBucketMapping(&pb.BucketMapping{
+		Pairs:       map[string]string{"logs": "archive-logs"},
+		StripPrefix: "prod-",
+		StripSuffix: "-v1",
+		Regex:       `_`,
+		Replace:     "-",
+		AddPrefix:   "bj-",
+		AddSuffix:   "-bak",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"logs":             "archive-logs",
+		"prod-app_data-v1": "bj-app-data-bak",
+		"web":              "bj-web-bak",
+	} {
+		if got := m.dest(name); got != want {
+			t.Fatalf("dest of %s: %s, expected %s", name, got, want)
+		}
+	}
+	if m, _ = newBucketMapping(nil); m != nil || m.dest("web") != "web" {
+		t.Fatalf("nil mapping: %v", m)
+	}
+	if _, err = newBucketMapping(&pb.BucketMapping{Regex: "("}); err == nil {
+		t.Fatal("invalid regex accepted")
+	}
+}
+
+func TestRankBuckets(t *testing.T) {
+	buckets := func(region string, names ...string) []bucket.BucketInfo {
+		var res []bucket.BucketInfo
+		for _, name := range names {
+			res = append(res, bucket.BucketInfo{Name: name, Domain: object.MemBucketURL(region, name)})
+		}
+		return res
+	}
+	rank := func(src, dest []bucket.BucketInfo, m *pb.BucketMapping) ([]models.BucketOri, error) {
+		bm, err := newBucketMapping(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rankBuckets(src, dest, models.Mem, models.Mem, "s", "d", bm)
+	}
+	type row struct {
+		Name, Dest string
+		Ori        models.Orientation
+	}
+	rows := func(res []models.BucketOri) []row {
+		var r []row
+		for _, b := range res {
+			r = append(r, row{b.Name, b.DestName, b.Orientation})
+		}
+		return r
+	}
+
+	// a 映射到目的端已有的 x，与目的端同名的 a 不再比较；b 映射到不存在的 y；目的端只有 z
+	res, err := rank(buckets("s", "a", "b"), buckets("d", "a", "x", "z"),
+		&pb.BucketMapping{Pairs: map[string]string{"a": "x", "b": "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []row{{"a", "x", models.With}, {"z", "z", models.From}, {"b", "y", models.To}}
+	if !reflect.DeepEqual(rows(res), want) {
+		t.Fatalf("rank: %v, expected %v", rows(res), want)
+	}
+	if res[0].SrcBucket != object.MemBucketURL("s", "a") || res[0].DestBucket != object.MemBucketURL("d", "x") ||
+		res[2].DestBucket != object.MemBucketURL("d", "y") || res[1].SrcBucket != object.MemBucketURL("s", "z") {
+		t.Fatalf("rank domains: %+v", res)
+	}
+
+	// 目的端没有桶时全部按映射后的桶名创建
+	res, err = rank(buckets("s", "prod-a", "b"), nil, &pb.BucketMapping{StripPrefix: "prod-"})
+	if err != nil || !reflect.DeepEqual(rows(res), []row{{"prod-a", "a", models.To}, {"b", "b", models.To}}) {
+		t.Fatalf("rank without dest buckets: %v %v", rows(res), err)
+	}
+
+	// 多个桶映射到同一个桶名
+	if _, err = rank(buckets("s", "prod-a", "a"), nil, &pb.BucketMapping{StripPrefix: "prod-"}); err == nil ||
+		!strings.Contains(err.Error(), "both mapped to a") {
+		t.Fatalf("rank with a collision: %v", err)
+	}
+	if _, err = rank(buckets("s", "a"), nil, &pb.BucketMapping{Pairs: map[string]string{"a": ""}}); err == nil {
+		t.Fatal("rank with an empty name")
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"path"
+)
+
+// selectBuckets 按桶名或通配符（如 logs-*）选择要迁移的桶，源端或目的端桶名匹配即可：
+// 指定 include 时只迁移匹配的桶，再排除匹配 exclude 的桶，未选中的桶标记为 Excluded
+func selectBuckets(ranks []models.BucketOri, include, exclude []string) ([]models.BucketOri, error) {
+	for _, p := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid bucket pattern %q: %s", p, err)
+		}
+	}
+	match := func(r models.BucketOri, patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, r.Name); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, r.Dest()); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for i, r := range ranks {
+		ranks[i].Excluded = (len(include) > 0 && !match(r, include)) || match(r, exclude)
+	}
+	return ranks, nil
+}
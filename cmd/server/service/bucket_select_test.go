@@ -0,0 +1,46 @@
+package service
+
+import (
+	"obs-sync/models"
+	"reflect"
+	"testing"
+)
+
+func TestSelectBuckets(t *testing.T) {
+	ranks := func() []models.BucketOri {
+		return []models.BucketOri{
+			{Name: "logs-2023"},
+			{Name: "logs-2024", DestName: "archive-2024"},
+			{Name: "web"},
+		}
+	}
+	excluded := func(include, exclude []string) []bool {
+		res, err := selectBuckets(ranks(), include, exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r []bool
+		for _, b := range res {
+			r = append(r, b.Excluded)
+		}
+		return r
+	}
+	for _, c := range []struct {
+		include, exclude []string
+		want             []bool
+	}{
+		{nil, nil, []bool{false, false, false}},
+		{[]string{"logs-*"}, nil, []bool{false, false, true}},
+		{[]string{"logs-*"}, []string{"*-2023"}, []bool{true, false, true}},
+		// 目的端桶名匹配即可
+		{[]string{"archive-*"}, nil, []bool{true, false, true}},
+		{nil, []string{"archive-2024", "web"}, []bool{false, true, true}},
+	} {
+		if got := excluded(c.include, c.exclude); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("select %v, exclude %v: %v, expected %v", c.include, c.exclude, got, c.want)
+		}
+	}
+	if _, err := selectBuckets(ranks(), nil, []string{"logs-["}); err == nil {
+		t.Fatal("invalid pattern accepted")
+	}
+}
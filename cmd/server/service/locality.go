@@ -0,0 +1,182 @@
+package service
+
+import (
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+	"strings"
+	"sync"
+)
+
+// parkLimit 每个主机最多暂存的任务数，暂存的任务只能由该主机上的 worker 领取，
+// 数量很小，避免其它 worker 空闲而任务都压在少数节点上
+const parkLimit = 4
+
+// hostQueue 同一主机上的 worker 共享的任务队列
+type hostQueue struct {
+	tasks   chan models.Task
+	workers int
+}
+
+// locality 按数据所在的主机分配任务：任务优先交给 Hosts 中的主机上的 worker，
+// 这些主机上没有连接的 worker 或者暂存已满时，由领取到的 worker 直接执行
+type locality struct {
+	sync.Mutex
+	hosts map[string]*hostQueue
+}
+
+var scheduler = &locality{hosts: make(map[string]*hostQueue)}
+
+// lookup 查找主机的队列，s 需已加锁。主机名不区分大小写，全名相同时匹配；worker 上报的主机名和
+// DataNode 的主机名可能一个带域名一个不带，只有一方不带域名时才比较短名，对应多个主机时不匹配
+func (s *locality) lookup(host string) (string, *hostQueue) {
+	host = strings.ToLower(host)
+	if q := s.hosts[host]; q != nil {
+		return host, q
+	}
+	short, domain, _ := strings.Cut(host, ".")
+	var key string
+	var found *hostQueue
+	for k, q := range s.hosts {
+		if ks, kd, _ := strings.Cut(k, "."); ks == short && (domain == "" || kd == "") {
+			if found != nil {
+				return "", nil
+			}
+			key, found = k, q
+		}
+	}
+	return key, found
+}
+
+// join worker 连接时登记其主机，返回该主机的任务队列，没有上报主机名时返回 nil
+func (s *locality) join(host string) *hostQueue {
+	if host == "" {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	_, q := s.lookup(host)
+	if q == nil {
+		q = &hostQueue{tasks: make(chan models.Task, parkLimit)}
+		s.hosts[strings.ToLower(host)] = q
+	}
+	q.workers++
+	return q
+}
+
+// leave worker 断开时注销，主机上最后一个 worker 离开后把暂存的任务放回全局队列
+func (s *locality) leave(host string) {
+	if host == "" {
+		return
+	}
+	s.Lock()
+	key, q := s.lookup(host)
+	if q == nil {
+		s.Unlock()
+		return
+	}
+	q.workers--
+	if q.workers > 0 {
+		s.Unlock()
+		return
+	}
+	delete(s.hosts, key)
+	var tasks []models.Task
+	for len(q.tasks) > 0 {
+		tasks = append(tasks, <-q.tasks)
+	}
+	s.Unlock()
+	if len(tasks) > 0 {
+		go func() {
+			for _, t := range tasks {
+				TaskChan <- t
+			}
+		}()
+	}
+}
+
+// park 把任务暂存到 Hosts 中已连接 worker 的主机上，都不可用时返回 false
+func (s *locality) park(t models.Task) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, h := range t.Hosts {
+		_, q := s.lookup(h)
+		if q == nil {
+			continue
+		}
+		select {
+		case q.tasks <- t:
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// parked 暂存中的任务数
+func (s *locality) parked() int {
+	s.Lock()
+	defer s.Unlock()
+	n := 0
+	for _, q := range s.hosts {
+		n += len(q.tasks)
+	}
+	return n
+}
+
+// next 为 q 所在主机上的 worker 领取任务，优先领取暂存给该主机的任务
+func (s *locality) next(q *hostQueue) models.Task {
+	for {
+		var t models.Task
+		if q == nil {
+			t = <-TaskChan
+		} else {
+			select {
+			case t = <-q.tasks:
+				return t
+			case t = <-TaskChan:
+			}
+		}
+		if !s.local(t, q) && s.park(t) {
+			continue
+		}
+		return t
+	}
+}
+
+// local 任务的数据是否在 q 所在的主机上
+func (s *locality) local(t models.Task, q *hostQueue) bool {
+	if q == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	for _, h := range t.Hosts {
+		if _, hq := s.lookup(h); hq == q {
+			return true
+		}
+	}
+	return false
+}
+
+// taskHosts 取一批对象中最大的文件所在的主机，存储不支持 object.Locator 时返回 nil
+func taskHosts(storage object.ObjectStorage, objs []models.Obj) []string {
+	locator, ok := storage.(object.Locator)
+	if !ok {
+		return nil
+	}
+	var largest *models.Obj
+	for i := range objs {
+		if !objs[i].IsDir && (largest == nil || objs[i].Size > largest.Size) {
+			largest = &objs[i]
+		}
+	}
+	if largest == nil {
+		return nil
+	}
+	hosts, err := locator.Locations(largest.Key)
+	if err != nil {
+		l.Warn().Msgf("locate %s: %v", largest.Key, err)
+		return nil
+	}
+	return hosts
+}
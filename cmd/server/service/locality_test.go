@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLocality(t *testing.T) {
+	s := &locality{hosts: make(map[string]*hostQueue)}
+	task := func(name string, hosts ...string) models.Task {
+		return models.Task{BuckeNmae: name, Hosts: hosts}
+	}
+	dn1 := s.join("DN1.example.com")
+	dn2 := s.join("dn2")
+	if s.join("") != nil || s.join("dn1") != dn1 {
+		t.Fatal("join")
+	}
+	// 域名不同的同名主机是不同的主机，不带域名时无法确定是哪一个
+	dn3 := s.join("dn3.a.example.com")
+	if s.join("dn3.b.example.com") == dn3 {
+		t.Fatal("hosts in different domains share a queue")
+	}
+	if _, q := s.lookup("DN3.a.example.com"); q != dn3 {
+		t.Fatal("lookup by the full name")
+	}
+	if _, q := s.lookup("dn3"); q != nil {
+		t.Fatal("lookup an ambiguous short name")
+	}
+	s.leave("dn3.a.example.com")
+	s.leave("dn3.b.example.com")
+
+	// 数据在 dn1 上的任务被 dn2 领取时暂存给 dn1，dn2 继续领取下一个任务
+	TaskChan <- task("t1", "dn1.example.com")
+	TaskChan <- task("t2")
+	if got := s.next(dn2); got.BuckeNmae != "t2" || s.parked() != 1 {
+		t.Fatalf("dn2 got %s, %d parked", got.BuckeNmae, s.parked())
+	}
+	if got := s.next(dn1); got.BuckeNmae != "t1" || s.parked() != 0 {
+		t.Fatalf("dn1 got %s, %d parked", got.BuckeNmae, s.parked())
+	}
+	// 本地的任务直接执行，主机都未连接时由领取到的 worker 执行
+	TaskChan <- task("t3", "dn2", "dn1")
+	TaskChan <- task("t4", "dn3")
+	if got := s.next(dn1); got.BuckeNmae != "t3" || s.parked() != 0 {
+		t.Fatalf("dn1 got %s, %d parked", got.BuckeNmae, s.parked())
+	}
+	if got := s.next(nil); got.BuckeNmae != "t4" {
+		t.Fatalf("worker without host got %s", got.BuckeNmae)
+	}
+
+	// 暂存已满时由领取到的 worker 执行
+	for i := 0; i <= parkLimit; i++ {
+		TaskChan <- task(fmt.Sprint("p", i), "dn2")
+	}
+	if got := s.next(dn1); got.BuckeNmae != fmt.Sprint("p", parkLimit) || s.parked() != parkLimit {
+		t.Fatalf("dn1 got %s, %d parked", got.BuckeNmae, s.parked())
+	}
+
+	// dn1 还有一个 worker，只有最后一个 worker 离开后才放回暂存的任务
+	if !s.park(task("t5", "dn3", "dn1")) || s.park(task("t6", "dn3")) {
+		t.Fatal("park")
+	}
+	s.leave("dn1")
+	if s.parked() != parkLimit+1 {
+		t.Fatalf("%d parked after one of the dn1 workers left", s.parked())
+	}
+	s.leave("dn1")
+	s.leave("dn2")
+	if s.parked() != 0 || len(s.hosts) != 0 {
+		t.Fatalf("%d parked, hosts %v after all workers left", s.parked(), s.hosts)
+	}
+	var requeued []string
+	for len(requeued) < parkLimit+1 {
+		select {
+		case got := <-TaskChan:
+			requeued = append(requeued, got.BuckeNmae)
+		case <-time.After(time.Second):
+			t.Fatalf("requeued %v", requeued)
+		}
+	}
+	sort.Strings(requeued)
+	if fmt.Sprint(requeued) != "[p0 p1 p2 p3 t5]" {
+		t.Fatalf("requeued %v", requeued)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"obs-sync/models"
+	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/object"
+	"obs-sync/proto/sync/pb"
+	"sync"
+)
+
+// Plan implements pb.PipeServer. 与 Start 相同地列举和比对各个桶，只统计不下发复制任务，
+// 也不修改当前的任务信息
+func (s *server) Plan(ctx context.Context, r *pb.SyncInfo) (*pb.PlanReplay, error) {
+	l.Info().Msgf("plan: request: %v", r)
+	ranks, err := syncRanks(r)
+	if err != nil {
+		return nil, err
+	}
+	if ranks, err = selectBuckets(ranks, r.Include, r.Exclude); err != nil {
+		return nil, err
+	}
+	var selected []models.BucketOri
+	for _, rank := range ranks {
+		if !rank.Excluded {
+			selected = append(selected, rank)
+		}
+	}
+	ranks = selected
+	src := models.Uri{Type: models.ResourceType(r.Src.Type), AccessKey: r.Src.AccessKey, SecretKey: r.Src.SecretKey, Region: r.Src.Region}
+	dest := models.Uri{Type: models.ResourceType(r.Dest.Type), AccessKey: r.Dest.AccessKey, SecretKey: r.Dest.SecretKey, Region: r.Dest.Region}
+
+	res := &pb.PlanReplay{Buckets: make([]*pb.PlanBucket, len(ranks))}
+	wg := sync.WaitGroup{}
+	for i, rank := range ranks {
+		wg.Add(1)
+		go func(i int, rank models.BucketOri) {
+			defer wg.Done()
+			res.Buckets[i] = planBucket(src, dest, rank, r.Config)
+		}(i, rank)
+	}
+	wg.Wait()
+	l.Info().Msgf("plan: success, %d buckets", len(ranks))
+	return res, nil
+}
+
+// planBucket 统计一对桶需要复制、跳过、只在目的端（不删除）和冲突的对象，两端有密钥文件时按明文大小比较；
+// 多版本复制与 syncObj 一样不比较两端，所有待重放的版本都计入复制
+func planBucket(src, dest models.Uri, ori models.BucketOri, config *pb.TaskConfig) *pb.PlanBucket {
+	p := &pb.PlanBucket{
+		Name:        ori.Name,
+		SrcBucket:   ori.SrcBucket,
+		Orientation: int32(ori.Orientation),
+		DestBucket:  ori.DestBucket,
+		DestName:    ori.Dest(),
+		Copy:        &pb.PlanCount{},
+		Skip:        &pb.PlanCount{},
+		Extra:       &pb.PlanCount{},
+		Conflict:    &pb.PlanCount{},
+	}
+	add := func(c *pb.PlanCount, o object.Object) {
+		c.Count++
+		c.Size += o.Size()
+	}
+	encryptKey, decryptKey := config.GetEncryptKeyFile(), config.GetDecryptKeyFile()
+	from, to := src, dest
+	if ori.Orientation == models.From {
+		from, to = dest, src
+		ori = ori.Reverse()
+		encryptKey, decryptKey = decryptKey, encryptKey
+	}
+	store, err := planStorage(from, ori.SrcBucket, decryptKey)
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	p.Create = ori.Orientation != models.With
+	if v, ok := store.(object.Versioner); ok && config.GetVersionMode() != "" {
+		vch, err := listVersions(v, config.GetVersionMode(), config.GetVersionsAsOf())
+		if err != nil {
+			p.Error = err.Error()
+			return p
+		}
+		for o := range vch {
+			p.Copy.Count += int64(len(o.Versions))
+			p.Copy.Size += o.Size
+		}
+		return p
+	}
+	ch, err := listAll(store, "", "")
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	if ori.Orientation != models.With {
+		// 目的桶不存在，所有对象都需要复制
+		for o := range ch {
+			if o == nil {
+				p.Error = "list failed"
+				break
+			}
+			add(p.Copy, o)
+		}
+		return p
+	}
+
+	destStore, err := planStorage(to, ori.DestBucket, encryptKey)
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	destCh, err := listAll(destStore, "", "")
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	err = compareObjs(ch, destCh, func(o object.Object) {
+		add(p.Copy, o)
+	}, func(o, d object.Object) {
+		if o.Size() == d.Size() {
+			add(p.Skip, o)
+		} else {
+			add(p.Conflict, o)
+		}
+	}, func(d object.Object) {
+		add(p.Extra, d)
+	})
+	if err != nil {
+		p.Error = err.Error()
+	}
+	return p
+}
+
+func planStorage(u models.Uri, domain, keyFile string) (object.ObjectStorage, error) {
+	info := models.UriInfo{
+		Type:         u.Type,
+		BucketDomain: domain,
+		AccessKey:    u.AccessKey,
+		SecretKey:    u.SecretKey,
+	}
+	store, err := cloudstorage.CreateStorage(info)
+	if err == nil {
+		store, err = object.WithKeyFile(store, keyFile)
+	}
+	if err != nil {
+		l.Error().Msgf("plan create info:%v, error:%v", info, err)
+	}
+	return store, err
+}
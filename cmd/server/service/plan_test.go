@@ -0,0 +1,39 @@
+package service
+
+import (
+	"obs-sync/models"
+	"obs-sync/proto/sync/pb"
+	"testing"
+)
+
+func TestPlanBucket(t *testing.T) {
+	src := memBucket(t, "src", map[string]string{"a": "1", "c": "3", "d": "4", "f": "6"})
+	dst := memBucket(t, "dst", map[string]string{"b": "2", "c": "3", "e": "5", "f": "66", "g": "7"})
+	u := models.Uri{Type: models.Mem}
+	count := func(c *pb.PlanCount) [2]int64 {
+		return [2]int64{c.Count, c.Size}
+	}
+
+	p := planBucket(u, u, models.BucketOri{Name: "b", SrcBucket: src, Orientation: models.With, DestBucket: dst}, nil)
+	if p.Error != "" || p.Create || count(p.Copy) != [2]int64{2, 2} || count(p.Skip) != [2]int64{1, 1} ||
+		count(p.Conflict) != [2]int64{1, 1} || count(p.Extra) != [2]int64{3, 3} {
+		t.Fatalf("plan both sides: %v", p)
+	}
+
+	// mem 不支持多版本，只比较当前版本
+	p = planBucket(u, u, models.BucketOri{Name: "b", SrcBucket: src, Orientation: models.With, DestBucket: dst},
+		&pb.TaskConfig{VersionMode: models.VersionAll})
+	if p.Error != "" || count(p.Copy) != [2]int64{2, 2} || count(p.Extra) != [2]int64{3, 3} {
+		t.Fatalf("plan versions of a storage without versioning: %v", p)
+	}
+
+	// 目的桶不存在
+	p = planBucket(u, u, models.BucketOri{Name: "b", SrcBucket: src, Orientation: models.To}, nil)
+	if p.Error != "" || !p.Create || count(p.Copy) != [2]int64{4, 4} || count(p.Extra) != [2]int64{} {
+		t.Fatalf("plan source only: %v", p)
+	}
+	p = planBucket(u, u, models.BucketOri{Name: "b", Orientation: models.From, DestBucket: dst}, nil)
+	if p.Error != "" || !p.Create || count(p.Copy) != [2]int64{5, 6} {
+		t.Fatalf("plan dest only: %v", p)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/object"
+	"obs-sync/proto/sync/pb"
+	"sync"
+	"time"
+)
+
+const (
+	restorePollInterval = time.Minute
+	// restoreTimeout 等待取回的最长时间，深度归档的取回最长需要 48 小时，超时的对象记为失败
+	restoreTimeout = 72 * time.Hour
+)
+
+// restoreQueue 等待取回的归档对象，定时检查取回状态，完成后重新下发
+type restoreQueue struct {
+	sync.Mutex
+	tasks []restoreTask
+}
+
+// restoreTask 等待取回的任务，since 为加入队列的时间
+type restoreTask struct {
+	models.Task
+	since time.Time
+}
+
+var Restoring = &restoreQueue{}
+
+func (q *restoreQueue) Add(task models.Task) {
+	q.add(restoreTask{task, time.Now()})
+}
+
+func (q *restoreQueue) add(task restoreTask) {
+	q.Lock()
+	defer q.Unlock()
+	q.tasks = append(q.tasks, task)
+}
+
+func (q *restoreQueue) poll() {
+	for {
+		time.Sleep(restorePollInterval)
+		q.check()
+	}
+}
+
+func (q *restoreQueue) check() {
+	q.Lock()
+	tasks := q.tasks
+	q.tasks = nil
+	q.Unlock()
+
+	for _, task := range tasks {
+		var ready, waiting []models.Obj
+		expired := time.Since(task.since) > restoreTimeout
+		store, err := cloudstorage.CreateStorage(task.SrcInfo)
+		if err == nil {
+			// SSE-C 加密的对象需要密钥才能查询取回状态
+			err = setSrcSSE(store, task.Config.SrcSSECustomerKeyFile)
+		}
+		if err != nil {
+			l.Error().Msgf("restore: create storage failed, info:%v, error:%v", task.SrcInfo, err)
+			if expired {
+				failRestoring(task.BuckeNmae, task.Objs, err)
+			} else {
+				q.add(task)
+			}
+			continue
+		}
+		errs := map[string]error{}
+		restorer, ok := store.(object.Restorer)
+		if !ok {
+			// 不会发生：只有支持取回的存储才会提交
+			ready = task.Objs
+		} else {
+			for _, o := range task.Objs {
+				done, err := restorer.Restored(o.Key)
+				if err != nil {
+					l.Warn().Msgf("restore: head %s failed: %v", o.Key, err)
+					errs[o.Key] = err
+				}
+				if done {
+					ready = append(ready, o)
+				} else {
+					waiting = append(waiting, o)
+				}
+			}
+		}
+		if len(waiting) > 0 && expired {
+			for _, o := range waiting {
+				failRestoring(task.BuckeNmae, []models.Obj{o}, errs[o.Key])
+			}
+		} else if len(waiting) > 0 {
+			t := task
+			t.Objs = waiting
+			q.add(t)
+		}
+		if len(ready) > 0 {
+			task.Objs = ready
+			updateStatsRestoring(task.BuckeNmae, -len(ready))
+			TaskChan <- task.Task
+			l.Info().Msgf("restore: %d objects of bucket %s restored, send to channel", len(ready), task.BuckeNmae)
+		}
+	}
+}
+
+// failRestoring 超时仍未取回的对象记为失败并记入桶的报告，err 为最后一次查询的错误
+func failRestoring(bucket string, objs []models.Obj, err error) {
+	var report []string
+	for _, o := range objs {
+		reason := fmt.Sprintf("restore: %s is not restored in %s", o.Key, restoreTimeout)
+		if err != nil {
+			reason += ", last error: " + err.Error()
+		}
+		report = append(report, reason)
+		l.Error().Msgf("bucket %s: %s", bucket, reason)
+	}
+	updateStatsReport(bucket, report)
+	if v, ok := Stats.Load(bucket); ok {
+		stats := v.(models.Stats)
+		stats.Restoring -= int64(len(objs))
+		stats.Failed += int64(len(objs))
+		if stats.Copied+stats.Failed == stats.Scanned {
+			stats.FinishFlag = true
+		}
+		Stats.Store(bucket, stats)
+	}
+}
+
+func setSrcSSE(store object.ObjectStorage, keyFile string) error {
+	sse, err := object.ParseSSE("", "", keyFile)
+	if err != nil {
+		return err
+	}
+	return object.SetSSE(store, sse)
+}
+
+func updateStatsRestoring(bucket string, n int) {
+	if v, ok := Stats.Load(bucket); ok {
+		tmpValue := v.(models.Stats)
+		tmpValue.Restoring += int64(n)
+		Stats.Store(bucket, tmpValue)
+	}
+}
+
+func taskFromInfo(t *pb.TaskInfo) models.Task {
+	task := models.Task{
+		BuckeNmae: t.BucketName,
+		SrcInfo: models.UriInfo{
+			Type:         models.ResourceType(t.SrcUri.Type),
+			Scheme:       t.SrcUri.Scheme,
+			BucketDomain: t.SrcUri.BucketDomain,
+			AccessKey:    t.SrcUri.AccessKey,
+			SecretKey:    t.SrcUri.SecretKey,
+		},
+		DestInfo: models.UriInfo{
+			Type:         models.ResourceType(t.DestUri.Type),
+			Scheme:       t.DestUri.Scheme,
+			BucketDomain: t.DestUri.BucketDomain,
+			AccessKey:    t.DestUri.AccessKey,
+			SecretKey:    t.DestUri.SecretKey,
+		},
+	}
+	for _, o := range t.Objects {
+		task.Objs = append(task.Objs, models.Obj{
+			Key:          o.Key,
+			Size:         o.Size,
+			Mtime:        o.Mtime,
+			IsDir:        o.IsDir,
+			StorageClass: models.StorageClass(o.StorageClass),
+		})
+	}
+	if c := t.Config; c != nil {
+		task.Config.SetObjectMetaMD5 = c.SetObjectMetaMD5
+		task.Config.SrcMD5Header = c.SrcMD5Header
+		task.Config.PreserveMetadata = c.PreserveMetadata
+		task.Config.StorageClass = c.StorageClass
+		task.Config.RestoreDays = int(c.RestoreDays)
+		task.Config.CopyTags = c.CopyTags
+		task.Config.VersionMode = c.VersionMode
+		task.Config.VersionsAsOf = c.VersionsAsOf
+		task.Config.ACLMode = c.AclMode
+		task.Config.ACLAccounts = c.AclAccounts
+		task.Config.EncryptKeyFile = c.EncryptKeyFile
+		task.Config.DecryptKeyFile = c.DecryptKeyFile
+		task.Config.SSE = c.Sse
+		task.Config.SSEKMSKeyID = c.SseKmsKeyId
+		task.Config.SSECustomerKeyFile = c.SseCustomerKeyFile
+		task.Config.SrcSSECustomerKeyFile = c.SrcSseCustomerKeyFile
+	}
+	return task
+}
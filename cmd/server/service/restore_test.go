@@ -0,0 +1,54 @@
+package service
+
+import (
+	"bytes"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRestoreCheck 源端的 SSE-C 密钥在查询取回状态前设置，无法设置时任务留在队列中，直到超时
+func TestRestoreCheck(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(keyFile, bytes.Repeat([]byte{7}, 32), 0600)
+	src := memBucket(t, "src", map[string]string{"a": "1"})
+	task := models.Task{
+		BuckeNmae: "b",
+		SrcInfo:   models.UriInfo{Type: models.Mem, BucketDomain: src},
+		Objs:      []models.Obj{{Key: "a", Size: 1}},
+		Config:    models.TaskConfig{SrcSSECustomerKeyFile: keyFile},
+	}
+	q := &restoreQueue{}
+	// mem 不支持服务端加密
+	q.Add(task)
+	q.check()
+	if len(q.tasks) != 1 || len(TaskChan) != 0 {
+		t.Fatalf("%d tasks queued, %d sent", len(q.tasks), len(TaskChan))
+	}
+	q.tasks[0].Config.SrcSSECustomerKeyFile = ""
+	q.check()
+	if len(q.tasks) != 0 {
+		t.Fatalf("%d tasks queued", len(q.tasks))
+	}
+	if got := <-TaskChan; got.BuckeNmae != "b" || len(got.Objs) != 1 {
+		t.Fatalf("sent %+v", got)
+	}
+
+	// 超时后不再等待，对象记为失败并记入桶的报告
+	Stats.Store("b", models.Stats{Scanned: 1, Restoring: 1})
+	defer Stats.Delete("b")
+	q.Add(task)
+	q.tasks[0].since = time.Now().Add(-restoreTimeout - time.Minute)
+	q.check()
+	v, _ := Stats.Load("b")
+	stats := v.(models.Stats)
+	if len(q.tasks) != 0 || stats.Restoring != 0 || stats.Failed != 1 || !stats.FinishFlag {
+		t.Fatalf("%d tasks queued, stats %+v", len(q.tasks), stats)
+	}
+	if len(stats.ConfigReport) != 1 || !strings.Contains(stats.ConfigReport[0], "a is not restored") {
+		t.Fatalf("report: %v", stats.ConfigReport)
+	}
+}
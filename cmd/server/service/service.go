@@ -9,6 +9,7 @@ import (
 	"obs-sync/models"
 	"obs-sync/pkg/bucket"
 	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/pkg/object"
 	"obs-sync/proto/sync/pb"
 	"sync"
@@ -46,12 +47,16 @@ func (s *server) Stat(_ *pb.Empty, send pb.Pipe_StatServer) error {
 				value.Failed += stat.Failed
 				value.Size += stat.Size
 				value.Skipped += stat.Skipped
+				value.Restoring += stat.Restoring
 				buckets = append(buckets, &pb.BucketSummary{
-					Name:    k.(string),
-					Scan:    stat.Scanned,
-					Success: stat.Copied,
-					Fail:    stat.Failed,
-					Finish:  stat.FinishFlag,
+					Name:         k.(string),
+					Scan:         stat.Scanned,
+					Success:      stat.Copied,
+					Fail:         stat.Failed,
+					Finish:       stat.FinishFlag,
+					Restoring:    stat.Restoring,
+					ConfigReport: stat.ConfigReport,
+					Excluded:     stat.Excluded,
 				})
 				return true
 			})
@@ -71,6 +76,11 @@ func (s *server) Stat(_ *pb.Empty, send pb.Pipe_StatServer) error {
 // DataStream implements pb.PipeServer.
 func (s *server) DataStream(stream pb.Pipe_DataStreamServer) error {
 	ctx := stream.Context()
+	var (
+		host  string
+		queue *hostQueue
+	)
+	defer func() { scheduler.leave(host) }()
 	for {
 		select {
 		case <-ctx.Done():
@@ -96,14 +106,20 @@ func (s *server) DataStream(stream pb.Pipe_DataStreamServer) error {
 				}
 				return nil
 			case "free": //空闲指令
-				task := <-TaskChan
+				if host == "" && recv.Host != "" {
+					host = recv.Host
+					queue = scheduler.join(host)
+				}
+				task := scheduler.next(queue)
 				var objs []*pb.Object
 				for _, o := range task.Objs {
 					objs = append(objs, &pb.Object{
-						Key:   o.Key,
-						Size:  o.Size,
-						Mtime: o.Mtime,
-						IsDir: o.IsDir,
+						Key:          o.Key,
+						Size:         o.Size,
+						Mtime:        o.Mtime,
+						IsDir:        o.IsDir,
+						StorageClass: string(o.StorageClass),
+						Versions:     pbVersions(o.Versions),
 					})
 				}
 				if err = stream.Send(&pb.DataResponse{Task: &pb.TaskInfo{
@@ -123,6 +139,24 @@ func (s *server) DataStream(stream pb.Pipe_DataStreamServer) error {
 						SecretKey:    task.DestInfo.SecretKey,
 					},
 					Objects: objs,
+					Config: &pb.TaskConfig{
+						SetObjectMetaMD5:      task.Config.SetObjectMetaMD5,
+						SrcMD5Header:          task.Config.SrcMD5Header,
+						PreserveMetadata:      task.Config.PreserveMetadata,
+						StorageClass:          task.Config.StorageClass,
+						RestoreDays:           int32(task.Config.RestoreDays),
+						CopyTags:              task.Config.CopyTags,
+						VersionMode:           task.Config.VersionMode,
+						VersionsAsOf:          task.Config.VersionsAsOf,
+						AclMode:               task.Config.ACLMode,
+						AclAccounts:           task.Config.ACLAccounts,
+						EncryptKeyFile:        task.Config.EncryptKeyFile,
+						DecryptKeyFile:        task.Config.DecryptKeyFile,
+						Sse:                   task.Config.SSE,
+						SseKmsKeyId:           task.Config.SSEKMSKeyID,
+						SseCustomerKeyFile:    task.Config.SSECustomerKeyFile,
+						SrcSseCustomerKeyFile: task.Config.SrcSSECustomerKeyFile,
+					},
 				}}); err != nil {
 					l.Error().Err(err).Msg("发送对象列表失败")
 					return err
@@ -142,7 +176,7 @@ func (s *server) DataStream(stream pb.Pipe_DataStreamServer) error {
 // HasMore implements pb.PipeServer.
 func (s *server) HasMore(context.Context, *pb.Empty) (*pb.HasMoreReplay, error) {
 	return &pb.HasMoreReplay{
-		Has: !(len(TaskChan) == 0),
+		Has: len(TaskChan)+scheduler.parked() != 0,
 	}, nil
 }
 
@@ -154,6 +188,10 @@ func (s *server) PutResult(ctx context.Context, r *pb.Result) (*pb.Replay, error
 		newVule.Copied += int64(len(r.Success))
 		newVule.Failed += int64(len(r.Failed))
 		newVule.Size += r.DeadlSize
+		if r.Restoring != nil && len(r.Restoring.Objects) > 0 {
+			newVule.Restoring += int64(len(r.Restoring.Objects))
+			Restoring.Add(taskFromInfo(r.Restoring))
+		}
 		if newVule.Copied+newVule.Failed == newVule.Scanned {
 			newVule.FinishFlag = true
 			l.Info().Msgf("put result: bucket:%s sync finished.", r.BucketName)
@@ -162,6 +200,9 @@ func (s *server) PutResult(ctx context.Context, r *pb.Result) (*pb.Replay, error
 	} else {
 		return &pb.Replay{Status: "-1"}, errors.New("bucket stat not found")
 	}
+	for _, w := range r.Warnings {
+		l.Warn().Msgf("put result: bucket:%s %s", r.BucketName, w)
+	}
 	l.Info().Msgf("put result: worker:%s success:%v failed:%v", r.WorkIP, r.Success, r.Failed)
 	return &pb.Replay{Status: "0"}, nil
 }
@@ -172,13 +213,19 @@ func (s *server) Start(_ *pb.Empty, send pb.Pipe_StartServer) error {
 	ctx := send.Context()
 	if !IsRunning {
 		for _, r := range SyncInfo.BucketRanks {
+			if r.Excluded {
+				// 未选中的桶在 stat 中显示为 excluded
+				Stats.Store(r.Name, models.Stats{Excluded: true, FinishFlag: true})
+				continue
+			}
 			switch r.Orientation {
 			case models.To:
-				err := bucket.BucketStorage(SyncInfo.DestUri.Type, SyncInfo.DestUri.AccessKey, SyncInfo.DestUri.SecretKey).Create(SyncInfo.DestUri.Region, r.Name)
+				err := bucket.BucketStorage(SyncInfo.DestUri.Type, SyncInfo.DestUri.AccessKey, SyncInfo.DestUri.SecretKey).Create(SyncInfo.DestUri.Region, r.Dest())
 				if err != nil {
-					l.Error().Msgf("error creating info: %v ,bucket: %s ,err: %v", SyncInfo.DestUri, r.Name, err)
+					l.Error().Msgf("error creating info: %v ,bucket: %s ,err: %v", SyncInfo.DestUri, r.Dest(), err)
 					continue
 				}
+				copyBucketConfig(SyncInfo.SrcUri, SyncInfo.DestUri, r.Name, r.Dest())
 				go listAllObj(SyncInfo.SrcUri, SyncInfo.DestUri, r)
 			case models.From:
 				err := bucket.BucketStorage(SyncInfo.SrcUri.Type, SyncInfo.SrcUri.AccessKey, SyncInfo.SrcUri.SecretKey).Create(SyncInfo.SrcUri.Region, r.Name)
@@ -186,7 +233,8 @@ func (s *server) Start(_ *pb.Empty, send pb.Pipe_StartServer) error {
 					l.Error().Msgf("error creating info: %v ,bucket: %s ,err: %v", SyncInfo.SrcUri, r.Name, err)
 					continue
 				}
-				go listAllObj(SyncInfo.DestUri, SyncInfo.SrcUri, r)
+				copyBucketConfig(SyncInfo.DestUri, SyncInfo.SrcUri, r.Dest(), r.Name)
+				go listAllObj(SyncInfo.DestUri, SyncInfo.SrcUri, r.Reverse())
 			case models.With:
 				go syncObj(r)
 			}
@@ -210,6 +258,7 @@ func (s *server) Start(_ *pb.Empty, send pb.Pipe_StartServer) error {
 				value.Failed += stats.Failed
 				value.Size += stats.Size
 				value.Skipped += stats.Skipped
+				value.Restoring += stats.Restoring
 				return true
 			})
 			err := send.Send(&pb.Status{Value: &value})
@@ -230,28 +279,80 @@ func (s *server) Stop(context.Context, *pb.Empty) (*pb.StopResult, error) {
 // Sync implements pb.PipeServer.
 func (s *server) Sync(ctx context.Context, r *pb.SyncInfo) (*pb.SyncReplay, error) {
 	l.Info().Msgf("sync: request: %v", r)
-	srcBuckets, err := bucket.BucketStorage(models.ResourceType(r.Src.Type), r.Src.AccessKey, r.Src.SecretKey).List(r.Src.Region)
+	ranks, err := syncRanks(r)
 	if err != nil {
-		l.Error().Msgf("sync: failed to list bucket, info:%v, error: %v", r.Src, err)
 		return nil, err
 	}
-	destBuckets, err := bucket.BucketStorage(models.ResourceType(r.Dest.Type), r.Dest.AccessKey, r.Dest.SecretKey).List(r.Dest.Region)
-	if err != nil {
-		l.Error().Msgf("sync: failed to list bucket, info:%v, error: %v", r.Dest, err)
+	if ranks, err = selectBuckets(ranks, r.Include, r.Exclude); err != nil {
 		return nil, err
 	}
-
-	ranks := rankBuckets(srcBuckets, destBuckets, models.ResourceType(r.Src.Type), models.ResourceType(r.Dest.Type), r.Src.Region, r.Dest.Region)
 	var buckets []*pb.SyncReplay_Row
 	for _, rank := range ranks {
+		status := ""
+		if rank.Excluded {
+			status = "excluded"
+		}
 		buckets = append(buckets, &pb.SyncReplay_Row{
-			Cells: []string{rank.SrcBucket, rank.Ori(), rank.DestBucket},
+			Cells: []string{rank.SrcBucket, rank.Ori(), rank.DestBucket, rank.Name, status},
 		})
 	}
 	SyncInfo = &models.SyncInfo{
 		SrcUri:      models.Uri{Type: models.ResourceType(r.Src.Type), AccessKey: r.Src.AccessKey, SecretKey: r.Src.SecretKey, Region: r.Src.Region},
 		DestUri:     models.Uri{Type: models.ResourceType(r.Dest.Type), AccessKey: r.Dest.AccessKey, SecretKey: r.Dest.SecretKey, Region: r.Dest.Region},
 		BucketRanks: ranks,
+		Include:     r.Include,
+		Exclude:     r.Exclude,
+	}
+	if r.Config != nil {
+		if sc := r.Config.StorageClass; sc != "" && sc != models.PreserveStorageClass {
+			class, err := object.ParseStorageClass(sc)
+			if err != nil {
+				return nil, err
+			}
+			r.Config.StorageClass = string(class)
+		}
+		SyncInfo.Config.StorageClass = r.Config.StorageClass
+		SyncInfo.Config.RestoreDays = int(r.Config.RestoreDays)
+		SyncInfo.Config.SetObjectMetaMD5 = r.Config.SetObjectMetaMD5
+		SyncInfo.Config.SrcMD5Header = r.Config.SrcMD5Header
+		SyncInfo.Config.PreserveMetadata = r.Config.PreserveMetadata
+		SyncInfo.Config.CopyTags = r.Config.CopyTags
+		switch r.Config.VersionMode {
+		case "", models.VersionAll, models.VersionAsOf:
+		default:
+			return nil, fmt.Errorf("unknown version mode %q", r.Config.VersionMode)
+		}
+		SyncInfo.Config.VersionMode = r.Config.VersionMode
+		SyncInfo.Config.VersionsAsOf = r.Config.VersionsAsOf
+		switch r.Config.AclMode {
+		case "", models.ACLBucket, models.ACLPreserve, string(models.Private), string(models.PublicRead),
+			string(models.PublicReadWrite), string(models.AuthenticatedRead):
+		default:
+			return nil, fmt.Errorf("unknown acl mode %q", r.Config.AclMode)
+		}
+		SyncInfo.Config.ACLMode = r.Config.AclMode
+		SyncInfo.Config.ACLAccounts = r.Config.AclAccounts
+		// 密钥文件在服务端读取不到时提前报错
+		for _, path := range []string{r.Config.EncryptKeyFile, r.Config.DecryptKeyFile} {
+			if path == "" {
+				continue
+			}
+			if _, err := object.LoadEncryptionKey(path); err != nil {
+				return nil, err
+			}
+		}
+		SyncInfo.Config.EncryptKeyFile = r.Config.EncryptKeyFile
+		SyncInfo.Config.DecryptKeyFile = r.Config.DecryptKeyFile
+		if _, err := object.ParseSSE(r.Config.Sse, r.Config.SseKmsKeyId, r.Config.SseCustomerKeyFile); err != nil {
+			return nil, err
+		}
+		if _, err := object.ParseSSE("", "", r.Config.SrcSseCustomerKeyFile); err != nil {
+			return nil, err
+		}
+		SyncInfo.Config.SSE = r.Config.Sse
+		SyncInfo.Config.SSEKMSKeyID = r.Config.SseKmsKeyId
+		SyncInfo.Config.SSECustomerKeyFile = r.Config.SseCustomerKeyFile
+		SyncInfo.Config.SrcSSECustomerKeyFile = r.Config.SrcSseCustomerKeyFile
 	}
 	l.Info().Msgf("sync: success, ranked buckets:%v ", ranks)
 	return &pb.SyncReplay{
@@ -260,31 +361,70 @@ func (s *server) Sync(ctx context.Context, r *pb.SyncInfo) (*pb.SyncReplay, erro
 	}, nil
 }
 
+// syncRanks 匹配两端的桶，请求带有审核过的计划时直接使用计划中的桶
+func syncRanks(r *pb.SyncInfo) ([]models.BucketOri, error) {
+	if len(r.Plan) > 0 {
+		var ranks []models.BucketOri
+		for _, p := range r.Plan {
+			ranks = append(ranks, models.BucketOri{SrcBucket: p.SrcBucket, Orientation: models.Orientation(p.Orientation), DestBucket: p.DestBucket, Name: p.Name, DestName: p.DestName})
+		}
+		return ranks, nil
+	}
+	srcBuckets, err := bucket.BucketStorage(models.ResourceType(r.Src.Type), r.Src.AccessKey, r.Src.SecretKey).List(r.Src.Region)
+	if err != nil {
+		l.Error().Msgf("sync: failed to list bucket, info:%v, error: %v", r.Src, err)
+		return nil, err
+	}
+	destBuckets, err := bucket.BucketStorage(models.ResourceType(r.Dest.Type), r.Dest.AccessKey, r.Dest.SecretKey).List(r.Dest.Region)
+	if err != nil {
+		l.Error().Msgf("sync: failed to list bucket, info:%v, error: %v", r.Dest, err)
+		return nil, err
+	}
+	m, err := newBucketMapping(r.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	return rankBuckets(srcBuckets, destBuckets, models.ResourceType(r.Src.Type), models.ResourceType(r.Dest.Type), r.Src.Region, r.Dest.Region, m)
+}
+
 func NewServer(path string) pb.PipeServer {
 	l = log.NewLogger(path).SetLevel("INFO")
+	go Restoring.poll()
 	return &server{}
 }
 
 func listAllObj(s, d models.Uri, ori models.BucketOri) error {
 	info := models.UriInfo{
 		Type:         s.Type,
-		Scheme:       "http",
 		BucketDomain: ori.SrcBucket,
 		AccessKey:    s.AccessKey,
 		SecretKey:    s.SecretKey,
 	}
 	destInfo := models.UriInfo{
 		Type:         d.Type,
-		Scheme:       "http",
 		BucketDomain: ori.DestBucket,
 		AccessKey:    d.AccessKey,
 		SecretKey:    d.SecretKey,
 	}
+	config := taskConfig(ori)
+	if ori.Orientation == models.From && SyncInfo.Config.SSE != "" {
+		reason := fmt.Sprintf("sse: --sse %s is not applied to %s, objects copied back to the source side use its default encryption", SyncInfo.Config.SSE, info.BucketDomain)
+		l.Warn().Msgf("bucket %s: %s", ori.Name, reason)
+		updateStatsReport(ori.Name, []string{reason})
+	}
 	storage, err := cloudstorage.CreateStorage(info)
+	if err == nil {
+		storage, err = object.WithKeyFile(storage, config.DecryptKeyFile)
+	}
 	if err != nil {
 		l.Error().Msgf("list all obj create info:%v, error:%v \n", info, err)
 		return err
 	}
+	if v, err := prepareVersions(storage, ori, destInfo); err != nil {
+		return err
+	} else if v != nil {
+		return listAllVersions(v, ori, info, destInfo, config)
+	}
 	ch, err := listAll(storage, "", "")
 	if err != nil {
 		l.Error().Msgf("list all obj create info:%v, error:%v \n", info, err)
@@ -297,10 +437,11 @@ func listAllObj(s, d models.Uri, ori models.BucketOri) error {
 	)
 	for o := range ch {
 		objs = append(objs, models.Obj{
-			Key:   o.Key(),
-			Size:  o.Size(),
-			Mtime: o.Mtime().Unix(),
-			IsDir: o.IsDir(),
+			Key:          o.Key(),
+			Size:         o.Size(),
+			Mtime:        o.Mtime().Unix(),
+			IsDir:        o.IsDir(),
+			StorageClass: object.StorageClassOf(o),
 		})
 		if len(objs) == batchNumber {
 			task = models.Task{
@@ -308,6 +449,8 @@ func listAllObj(s, d models.Uri, ori models.BucketOri) error {
 				SrcInfo:   info,
 				DestInfo:  destInfo,
 				Objs:      objs,
+				Config:    config,
+				Hosts:     taskHosts(storage, objs),
 			}
 			TaskChan <- task
 			updateStatsScaned(ori.Name, len(objs))
@@ -321,6 +464,8 @@ func listAllObj(s, d models.Uri, ori models.BucketOri) error {
 			SrcInfo:   info,
 			DestInfo:  destInfo,
 			Objs:      objs,
+			Config:    config,
+			Hosts:     taskHosts(storage, objs),
 		}
 		TaskChan <- task
 		updateStatsScaned(ori.Name, len(objs))
@@ -329,6 +474,19 @@ func listAllObj(s, d models.Uri, ori models.BucketOri) error {
 	return nil
 }
 
+// taskConfig 按 <== 方向从目的端复制到源端时，两端的密钥文件随之交换，
+// 使任务中的 EncryptKeyFile 总是对应写入的一端；SSE-C 密钥同样交换，
+// 服务端加密方式和 KMS 密钥属于目的端账号，不用于写入源端，listAllObj 将此记入桶的报告
+func taskConfig(ori models.BucketOri) models.TaskConfig {
+	config := SyncInfo.Config
+	if ori.Orientation == models.From {
+		config.EncryptKeyFile, config.DecryptKeyFile = config.DecryptKeyFile, config.EncryptKeyFile
+		config.SSECustomerKeyFile, config.SrcSSECustomerKeyFile = config.SrcSSECustomerKeyFile, config.SSECustomerKeyFile
+		config.SSE, config.SSEKMSKeyID = "", ""
+	}
+	return config
+}
+
 func updateStatsScaned(bucket string, scaned int) {
 	if v, ok := Stats.Load(bucket); ok {
 		tmpValue := v.(models.Stats)
@@ -342,30 +500,40 @@ func updateStatsScaned(bucket string, scaned int) {
 func syncObj(ori models.BucketOri) error {
 	srcInfo := models.UriInfo{
 		Type:         SyncInfo.SrcUri.Type,
-		Scheme:       "http",
 		BucketDomain: ori.SrcBucket,
 		AccessKey:    SyncInfo.SrcUri.AccessKey,
 		SecretKey:    SyncInfo.SrcUri.SecretKey,
 	}
 	src, err := cloudstorage.CreateStorage(srcInfo)
+	if err == nil {
+		src, err = object.WithKeyFile(src, SyncInfo.Config.DecryptKeyFile)
+	}
 	if err != nil {
 		l.Error().Msgf("sync obj create info:%v, error:%v", srcInfo, err)
 		return nil
 	}
-	srcKeysChan, err := listAll(src, "", "")
-	if err != nil {
-		l.Error().Msgf("sync obj listAll info:%v, error:%v", srcInfo, err)
-		return err
-	}
-
 	destInfo := models.UriInfo{
 		Type:         SyncInfo.DestUri.Type,
-		Scheme:       "http",
 		BucketDomain: ori.DestBucket,
 		AccessKey:    SyncInfo.DestUri.AccessKey,
 		SecretKey:    SyncInfo.DestUri.SecretKey,
 	}
+	// 多版本复制不比较两端，与只在源端存在的桶一样重放版本
+	if v, err := prepareVersions(src, ori, destInfo); err != nil {
+		return err
+	} else if v != nil {
+		return listAllVersions(v, ori, srcInfo, destInfo, SyncInfo.Config)
+	}
+	srcKeysChan, err := listAll(src, "", "")
+	if err != nil {
+		l.Error().Msgf("sync obj listAll info:%v, error:%v", srcInfo, err)
+		return err
+	}
+
 	dest, err := cloudstorage.CreateStorage(destInfo)
+	if err == nil {
+		dest, err = object.WithKeyFile(dest, SyncInfo.Config.EncryptKeyFile)
+	}
 	if err != nil {
 		l.Error().Msgf("sync obj create info:%v, error:%v", destInfo, err)
 		return nil
@@ -376,65 +544,35 @@ func syncObj(ori models.BucketOri) error {
 		return err
 	}
 
-	var (
-		dObj     object.Object
-		srcObjs  []models.Obj
-		destObjs []models.Obj
-	)
-
-	for obj := range srcKeysChan {
-		objKey := obj.Key()
-		if dObj != nil && objKey > dObj.Key() {
-			dObj = nil
-		}
-		if dObj == nil {
-			for dObj = range dstKeysChan {
-				if dObj == nil {
-					return nil
-				}
-				if objKey <= dObj.Key() {
-					break
-				}
-				// TODO: 确定是否需要删除
-				dObj = nil
-			}
-		}
-
-		if dObj == nil || objKey < dObj.Key() {
-			srcObjs = append(srcObjs, models.Obj{
-				Key:   obj.Key(),
-				IsDir: obj.IsDir(),
-				Mtime: obj.Mtime().Unix(),
-				Size:  obj.Size(),
-			})
-		} else if objKey == dObj.Key() {
-			l.Info().Msgf("skip %s", objKey)
-		}
-
+	var srcObjs []models.Obj
+	err = compareObjs(srcKeysChan, dstKeysChan, func(obj object.Object) {
+		srcObjs = append(srcObjs, models.Obj{
+			Key:          obj.Key(),
+			IsDir:        obj.IsDir(),
+			Mtime:        obj.Mtime().Unix(),
+			Size:         obj.Size(),
+			StorageClass: object.StorageClassOf(obj),
+		})
 		if len(srcObjs) == batchNumber {
 			task := models.Task{
 				BuckeNmae: ori.Name,
 				SrcInfo:   srcInfo,
 				DestInfo:  destInfo,
 				Objs:      srcObjs,
+				Config:    SyncInfo.Config,
+				Hosts:     taskHosts(src, srcObjs),
 			}
 			TaskChan <- task
 			updateStatsScaned(ori.Name, len(srcObjs))
 			srcObjs = nil
 			l.Debug().Msgf("send to channel success, task:%v", task)
 		}
-		if len(destObjs) == batchNumber {
-			task := models.Task{
-				BuckeNmae: ori.Name,
-				SrcInfo:   destInfo,
-				DestInfo:  srcInfo,
-				Objs:      destObjs,
-			}
-			TaskChan <- task
-			updateStatsScaned(ori.Name, len(destObjs))
-			destObjs = nil
-			l.Debug().Msgf("send to channel success, task:%v", task)
-		}
+	}, func(obj, _ object.Object) {
+		l.Info().Msgf("skip %s", obj.Key())
+	}, nil) // TODO: 确定是否需要删除目的端多出的对象
+	if err != nil {
+		l.Error().Msgf("sync obj compare %s with %s, error:%v", srcInfo.BucketDomain, destInfo.BucketDomain, err)
+		return err
 	}
 	if len(srcObjs) > 0 {
 		task := models.Task{
@@ -442,24 +580,59 @@ func syncObj(ori models.BucketOri) error {
 			SrcInfo:   srcInfo,
 			DestInfo:  destInfo,
 			Objs:      srcObjs,
+			Config:    SyncInfo.Config,
+			Hosts:     taskHosts(src, srcObjs),
 		}
 		TaskChan <- task
 		updateStatsScaned(ori.Name, len(srcObjs))
 		l.Debug().Msgf("send to channel success, task:%v", task)
 	}
+	return nil
+}
 
-	if len(destObjs) > 0 {
-		task := models.Task{
-			BuckeNmae: ori.Name,
-			SrcInfo:   destInfo,
-			DestInfo:  srcInfo,
-			Objs:      destObjs,
+// compareObjs 按 key 合并两端有序的列举结果，分别回调只在源端、两端都有、只在目的端的对象；
+// onDst 为 nil 时源端列举完即结束。列举失败（收到 nil）时返回错误
+func compareObjs(src, dst <-chan object.Object, onSrc func(object.Object), onBoth func(s, d object.Object), onDst func(object.Object)) error {
+	var d object.Object
+	next := func() error {
+		o, ok := <-dst
+		if ok && o == nil {
+			return errors.New("list dest failed")
 		}
-		TaskChan <- task
-		updateStatsScaned(ori.Name, len(destObjs))
-		l.Debug().Msgf("send to channel success, task:%v", task)
+		d = o
+		return nil
 	}
-	return err
+	if err := next(); err != nil {
+		return err
+	}
+	for o := range src {
+		if o == nil {
+			return errors.New("list source failed")
+		}
+		for d != nil && d.Key() < o.Key() {
+			if onDst != nil {
+				onDst(d)
+			}
+			if err := next(); err != nil {
+				return err
+			}
+		}
+		if d != nil && d.Key() == o.Key() {
+			onBoth(o, d)
+			if err := next(); err != nil {
+				return err
+			}
+		} else {
+			onSrc(o)
+		}
+	}
+	for onDst != nil && d != nil {
+		onDst(d)
+		if err := next(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func listAll(store object.ObjectStorage, start, end string) (<-chan object.Object, error) {
@@ -549,58 +722,85 @@ func listAll(store object.ObjectStorage, start, end string) (<-chan object.Objec
 	return out, nil
 }
 
-func rankBuckets(src, dest []bucket.BucketInfo, sType, dType models.ResourceType, sRegion, dRegion string) (res []models.BucketOri) {
+// rankBuckets 按映射规则匹配两端的桶。目的端没有对应源端桶的按同名反向复制，
+// 若该桶名在源端已映射到其他桶则跳过
+func rankBuckets(src, dest []bucket.BucketInfo, sType, dType models.ResourceType, sRegion, dRegion string, m *bucketMapping) (res []models.BucketOri, err error) {
+	mapped := make(map[string]string) // 目的端桶名 -> 源端桶名
+	srcNames := make(map[string]bool)
+	for _, s := range src {
+		d := m.dest(s.Name)
+		if d == "" {
+			return nil, fmt.Errorf("bucket %s is mapped to an empty name", s.Name)
+		}
+		if other, ok := mapped[d]; ok {
+			return nil, fmt.Errorf("buckets %s and %s are both mapped to %s", other, s.Name, d)
+		}
+		mapped[d] = s.Name
+		srcNames[s.Name] = true
+	}
 	if len(src) == 0 {
 		for _, d := range dest {
 			domain := coverBucketDomain(sType, d.Name, sRegion)
-			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.From, DestBucket: d.Domain, Name: d.Name})
+			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.From, DestBucket: d.Domain, Name: d.Name, DestName: d.Name})
 		}
 		return
 	}
 	if len(dest) == 0 {
-		for _, s := range dest {
-			domain := coverBucketDomain(dType, s.Name, dRegion)
-			res = append(res, models.BucketOri{SrcBucket: s.Domain, Orientation: models.To, DestBucket: domain, Name: s.Name})
+		for _, s := range src {
+			name := m.dest(s.Name)
+			domain := coverBucketDomain(dType, name, dRegion)
+			res = append(res, models.BucketOri{SrcBucket: s.Domain, Orientation: models.To, DestBucket: domain, Name: s.Name, DestName: name})
 		}
 		return
 	}
 
-	mapSrc := make(map[string]bool)
-	for _, v := range src {
-		mapSrc[v.Name] = true
-	}
 	for _, d := range dest {
-		domain := coverBucketDomain(sType, d.Name, sRegion)
-		if mapSrc[d.Name] {
-			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.With, DestBucket: d.Domain, Name: d.Name})
-			mapSrc[d.Name] = false
+		if name, ok := mapped[d.Name]; ok {
+			domain := coverBucketDomain(sType, name, sRegion)
+			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.With, DestBucket: d.Domain, Name: name, DestName: d.Name})
+			delete(mapped, d.Name)
+		} else if srcNames[d.Name] {
+			l.Warn().Msgf("rank buckets: skip dest bucket %s, the source bucket of the same name is mapped to %s", d.Name, m.dest(d.Name))
 		} else {
-			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.From, DestBucket: d.Domain, Name: d.Name})
+			domain := coverBucketDomain(sType, d.Name, sRegion)
+			res = append(res, models.BucketOri{SrcBucket: domain, Orientation: models.From, DestBucket: d.Domain, Name: d.Name, DestName: d.Name})
 		}
 	}
-	for n, v := range mapSrc {
-		if v {
-			s := coverBucketDomain(sType, n, sRegion)
-			d := coverBucketDomain(dType, n, dRegion)
-			res = append(res, models.BucketOri{SrcBucket: s, Orientation: models.To, DestBucket: d, Name: n})
+	for _, s := range src {
+		name := m.dest(s.Name)
+		if _, ok := mapped[name]; ok {
+			sd := coverBucketDomain(sType, s.Name, sRegion)
+			dd := coverBucketDomain(dType, name, dRegion)
+			res = append(res, models.BucketOri{SrcBucket: sd, Orientation: models.To, DestBucket: dd, Name: s.Name, DestName: name})
 		}
 	}
-	return res
+	return res, nil
 }
 
+// coverBucketDomain 按域名模板生成桶的访问域名
 func coverBucketDomain(t models.ResourceType, name string, region string) string {
-	switch t {
-	case models.Cos:
-		return fmt.Sprintf("%s.cos.%s.myqcloud.com", name, region)
-	case models.Cuc:
-		return fmt.Sprintf("%s.obs-%s-internal.cucloud.cn", name, region)
-	case models.Obs:
-		return fmt.Sprintf("%s.obs.%s.myhuaweicloud.com", name, region)
-	case models.Oss:
-		return fmt.Sprintf("%s.%s.aliyuncs.com", name, region)
-	case models.S3:
-		return fmt.Sprintf("%s.%s.amazonaws.com", name, region)
-	default:
-		return ""
+	if inner, addr, params, ok := object.SplitChaos(t, region); ok {
+		return coverBucketDomain(inner, name, addr) + "#" + params
+	}
+	if t == models.S3Compat {
+		// 地址已在列举桶时校验
+		c, err := endpoint.ParseCompat(region)
+		if err != nil {
+			return ""
+		}
+		return c.BucketURL(name)
+	}
+	if t == models.Sftp {
+		return object.SftpBucketURL(region, name)
+	}
+	if t == models.Webdav {
+		return object.WebdavBucketURL(region, name)
+	}
+	if t == models.Hdfs {
+		return object.HdfsBucketURL(region, name)
+	}
+	if t == models.Mem {
+		return object.MemBucketURL(region, name)
 	}
+	return endpoint.Get(t, region).BucketDomain(name, region)
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"obs-sync/infra/log"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	l = log.NewLogger("").SetLevel("error")
+	os.Exit(m.Run())
+}
+
+// memBucket 在测试的命名空间下创建桶并写入对象，返回桶的访问地址
+func memBucket(t *testing.T, name string, objs map[string]string) string {
+	domain := object.MemBucketURL(t.Name(), name)
+	s, err := object.CreateStorage(models.Mem, domain, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range objs {
+		if err = s.Put(k, strings.NewReader(v), models.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return domain
+}
+
+func memList(t *testing.T, domain string) <-chan object.Object {
+	s, _ := object.CreateStorage(models.Mem, domain, "", "")
+	ch, err := listAll(s, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+func TestCompareObjs(t *testing.T) {
+	src := memBucket(t, "src", map[string]string{"a": "1", "c": "3", "d": "4", "f": "6"})
+	dst := memBucket(t, "dst", map[string]string{"b": "2", "c": "3", "e": "5", "f": "66", "g": "7"})
+
+	var onSrc, onBoth, onDst []string
+	err := compareObjs(memList(t, src), memList(t, dst), func(o object.Object) {
+		onSrc = append(onSrc, o.Key())
+	}, func(o, d object.Object) {
+		onBoth = append(onBoth, o.Key()+d.Key())
+	}, func(d object.Object) {
+		onDst = append(onDst, d.Key())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(onSrc, []string{"a", "d"}) || !reflect.DeepEqual(onBoth, []string{"cc", "ff"}) ||
+		!reflect.DeepEqual(onDst, []string{"b", "e", "g"}) {
+		t.Fatalf("compare: src %v, both %v, dst %v", onSrc, onBoth, onDst)
+	}
+
+	// onDst 为 nil 时源端列举完即结束
+	onSrc = nil
+	if err = compareObjs(memList(t, src), memList(t, dst), func(o object.Object) {
+		onSrc = append(onSrc, o.Key())
+	}, func(_, _ object.Object) {}, nil); err != nil || !reflect.DeepEqual(onSrc, []string{"a", "d"}) {
+		t.Fatalf("compare without dest callback: %v %v", onSrc, err)
+	}
+
+	// 列举失败
+	failed := func() <-chan object.Object {
+		ch := make(chan object.Object, 8)
+		for o := range memList(t, src) {
+			ch <- o
+		}
+		ch <- nil
+		close(ch)
+		return ch
+	}
+	nop := func(object.Object) {}
+	if err = compareObjs(failed(), memList(t, dst), nop, func(_, _ object.Object) {}, nop); err == nil || !strings.Contains(err.Error(), "source") {
+		t.Fatalf("compare with a failed source listing: %v", err)
+	}
+	if err = compareObjs(memList(t, src), failed(), nop, func(_, _ object.Object) {}, nop); err == nil || !strings.Contains(err.Error(), "dest") {
+		t.Fatalf("compare with a failed dest listing: %v", err)
+	}
+}
+
+// TestTaskConfig <== 方向的桶交换两端的 SSE-C 密钥，不使用目的端的服务端加密，并记入桶的报告
+func TestTaskConfig(t *testing.T) {
+	defer func(s *models.SyncInfo) { SyncInfo = s }(SyncInfo)
+	dst := memBucket(t, "dst", map[string]string{"a": "1"})
+	SyncInfo = &models.SyncInfo{
+		SrcUri:  models.Uri{Type: models.Mem, Region: t.Name() + "-src"},
+		DestUri: models.Uri{Type: models.Mem, Region: t.Name()},
+		Config:  models.TaskConfig{SSE: object.SSEKMS, SSEKMSKeyID: "key-1", SSECustomerKeyFile: "dst-c", SrcSSECustomerKeyFile: "src-c"},
+	}
+	ori := models.BucketOri{Name: "b", SrcBucket: object.MemBucketURL(t.Name()+"-src", "b"), Orientation: models.From, DestBucket: dst}
+	if c := taskConfig(models.BucketOri{Orientation: models.To}); !reflect.DeepEqual(c, SyncInfo.Config) {
+		t.Fatalf("config to the dest: %+v", c)
+	}
+	c := taskConfig(ori)
+	if c.SSE != "" || c.SSEKMSKeyID != "" || c.SSECustomerKeyFile != "src-c" || c.SrcSSECustomerKeyFile != "dst-c" {
+		t.Fatalf("config from the dest: %+v", c)
+	}
+
+	if err := listAllObj(SyncInfo.DestUri, SyncInfo.SrcUri, ori.Reverse()); err != nil {
+		t.Fatal(err)
+	}
+	if task := <-TaskChan; len(task.Objs) != 1 || !reflect.DeepEqual(task.Config, c) {
+		t.Fatalf("task: %+v", task)
+	}
+	v, _ := Stats.Load("b")
+	if report := v.(models.Stats).ConfigReport; len(report) != 1 || !strings.Contains(report[0], "--sse kms") {
+		t.Fatalf("report: %v", report)
+	}
+	Stats.Delete("b")
+}
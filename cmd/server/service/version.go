@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/object"
+	"obs-sync/proto/sync/pb"
+	"time"
+)
+
+// prepareVersions 多版本复制前的准备：源端不支持多版本时返回 nil，只复制当前版本；
+// all 模式先开启目的端桶的多版本，否则重放的各版本相互覆盖只剩最新的一个，
+// 无法开启时该桶不复制，原因记入桶的报告
+func prepareVersions(src object.ObjectStorage, ori models.BucketOri, destInfo models.UriInfo) (object.Versioner, error) {
+	if SyncInfo.Config.VersionMode == "" {
+		return nil, nil
+	}
+	v, ok := src.(object.Versioner)
+	if !ok {
+		l.Warn().Msgf("%s does not support versioning, only the current versions are copied", src)
+		return nil, nil
+	}
+	if SyncInfo.Config.VersionMode != models.VersionAll {
+		return v, nil
+	}
+	dest, err := cloudstorage.CreateStorage(destInfo)
+	if err == nil {
+		err = object.EnableVersioning(dest)
+	}
+	if err != nil {
+		err = fmt.Errorf("versioning: enable versioning of %s: %s, versions are not replayed", destInfo.BucketDomain, err)
+		l.Error().Msgf("bucket %s: %s", ori.Name, err)
+		finishBucket(ori.Name, err.Error())
+		return nil, err
+	}
+	return v, nil
+}
+
+// listAllVersions 多版本复制：列举源端所有版本，同一对象的版本合并为一个 models.Obj 下发，
+// 保证同一对象的各个版本由同一个客户端按顺序写入
+func listAllVersions(v object.Versioner, ori models.BucketOri, info, destInfo models.UriInfo, config models.TaskConfig) error {
+	ch, err := listVersions(v, SyncInfo.Config.VersionMode, SyncInfo.Config.VersionsAsOf)
+	if err != nil {
+		l.Error().Msgf("list all versions info:%v, error:%v \n", info, err)
+		return err
+	}
+	var objs []models.Obj
+	for o := range ch {
+		objs = append(objs, o)
+		if len(objs) == batchNumber {
+			task := models.Task{
+				BuckeNmae: ori.Name,
+				SrcInfo:   info,
+				DestInfo:  destInfo,
+				Objs:      objs,
+				Config:    config,
+			}
+			TaskChan <- task
+			updateStatsScaned(ori.Name, len(objs))
+			l.Info().Msgf("list all versions and send to channel success, task:%v", task)
+			objs = []models.Obj{}
+		}
+	}
+	if len(objs) > 0 {
+		task := models.Task{
+			BuckeNmae: ori.Name,
+			SrcInfo:   info,
+			DestInfo:  destInfo,
+			Objs:      objs,
+			Config:    config,
+		}
+		TaskChan <- task
+		updateStatsScaned(ori.Name, len(objs))
+		l.Info().Msgf("list all versions and send to channel success, task:%v", task)
+	}
+	return nil
+}
+
+// listVersions 按对象分组列举版本，列举失败时丢弃未列完的对象
+func listVersions(v object.Versioner, mode string, asOf int64) (<-chan models.Obj, error) {
+	var maxResults int64 = 1000
+	vs, nextKey, nextVersion, err := v.ListVersions("", "", "", maxResults)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan models.Obj, maxResults)
+	go func() {
+		defer close(out)
+		var group []object.Version
+		for {
+			for _, ver := range vs {
+				if len(group) > 0 && group[0].Key() != ver.Key() {
+					if o, ok := versionObj(group, mode, asOf); ok {
+						out <- o
+					}
+					group = nil
+				}
+				group = append(group, ver)
+			}
+			if nextKey == "" && nextVersion == "" {
+				break
+			}
+			keyMarker, versionMarker := nextKey, nextVersion
+			vs, nextKey, nextVersion, err = v.ListVersions("", keyMarker, versionMarker, maxResults)
+			for count := 1; err != nil && count <= 3; count++ {
+				// slow down
+				time.Sleep(time.Millisecond * time.Duration(100*count))
+				vs, nextKey, nextVersion, err = v.ListVersions("", keyMarker, versionMarker, maxResults)
+			}
+			if err != nil {
+				l.Error().Err(err).Msgf("list versions after %s %s failed", keyMarker, versionMarker)
+				return
+			}
+		}
+		if len(group) > 0 {
+			if o, ok := versionObj(group, mode, asOf); ok {
+				out <- o
+			}
+		}
+	}()
+	return out, nil
+}
+
+// versionObj 将同一对象的版本（从新到旧）转换为待复制的对象：all 模式按从旧到新排列所有版本，
+// asof 模式只保留该时刻的版本，彼时对象不存在或已删除则跳过
+func versionObj(group []object.Version, mode string, asOf int64) (models.Obj, bool) {
+	latest := group[0]
+	o := models.Obj{
+		Key:          latest.Key(),
+		Mtime:        latest.Mtime().Unix(),
+		IsDir:        latest.IsDir(),
+		StorageClass: object.StorageClassOf(latest),
+	}
+	if mode == models.VersionAsOf {
+		for _, v := range group {
+			if v.Mtime().Unix() > asOf {
+				continue
+			}
+			if v.IsDeleteMarker() {
+				return o, false
+			}
+			o.Size, o.Mtime, o.StorageClass = v.Size(), v.Mtime().Unix(), object.StorageClassOf(v)
+			o.Versions = []models.ObjVersion{objVersion(v)}
+			return o, true
+		}
+		return o, false
+	}
+	for i := len(group) - 1; i >= 0; i-- {
+		o.Size += group[i].Size()
+		o.Versions = append(o.Versions, objVersion(group[i]))
+	}
+	return o, true
+}
+
+func objVersion(v object.Version) models.ObjVersion {
+	return models.ObjVersion{
+		ID:           v.VersionID(),
+		Size:         v.Size(),
+		Mtime:        v.Mtime().Unix(),
+		DeleteMarker: v.IsDeleteMarker(),
+		StorageClass: object.StorageClassOf(v),
+	}
+}
+
+func pbVersions(vs []models.ObjVersion) []*pb.Version {
+	var res []*pb.Version
+	for _, v := range vs {
+		res = append(res, &pb.Version{
+			VersionId:    v.ID,
+			Size:         v.Size,
+			Mtime:        v.Mtime,
+			DeleteMarker: v.DeleteMarker,
+			StorageClass: string(v.StorageClass),
+		})
+	}
+	return res
+}
@@ -16,6 +16,7 @@ require (
 	github.com/tencentyun/cos-go-sdk-v5 v0.7.52
 	github.com/vbauerster/mpb/v7 v7.5.3
 	github.com/viki-org/dnscache v0.0.0-20130720023526-c70c1f23c5d8
+	golang.org/x/crypto v0.24.0
 	golang.org/x/net v0.26.0
 	google.golang.org/grpc v1.62.1
 	google.golang.org/protobuf v1.33.0
@@ -41,7 +42,6 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/stretchr/testify v1.9.0 // indirect
-	golang.org/x/crypto v0.24.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
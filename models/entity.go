@@ -11,6 +11,22 @@ const (
 	Obs  ResourceType = "obs"
 	Cos  ResourceType = "cos"
 	Url  ResourceType = "url"
+	Bos  ResourceType = "bos"
+	Kodo ResourceType = "kodo"
+	// Azblob Azure Blob，ak 为存储账号名，sk 为账号密钥，区域处填写账号名
+	Azblob ResourceType = "azblob"
+	// Gcs Google Cloud Storage，ak、sk 为 HMAC 密钥，或 sk 为服务账号 JSON 密钥文件的路径，区域处填写项目 ID
+	Gcs ResourceType = "gcs"
+	// Sftp SFTP 服务器，ak 为用户名，sk 为密码或私钥文件的路径，区域处填写 host[:port][/path]，其下的目录作为桶
+	Sftp ResourceType = "sftp"
+	// Webdav WebDAV 共享，ak、sk 为用户名和密码，区域处填写 http(s)://host[:port]/path，其下的目录作为桶
+	Webdav ResourceType = "webdav"
+	// Hdfs 通过 WebHDFS 访问的 HDFS，ak 为用户名，sk 被忽略，区域处填写 namenode:port[/path]，其下的目录作为桶
+	Hdfs ResourceType = "hdfs"
+	// Mem 保存在进程内存中的存储，用于测试和演示，区域处填写命名空间，ak 为所有者账号 ID
+	Mem ResourceType = "mem"
+	// S3Compat MinIO、Ceph RGW 等 S3 兼容存储，区域处填写访问地址
+	S3Compat ResourceType = "s3compat"
 )
 
 type Orientation int
@@ -43,17 +59,56 @@ const (
 	PublicReadWrite   CannedACLType = "public-read-write"
 )
 
+// StorageClass 与厂商无关的存储类型，各厂商的取值见 object 包中的映射表
+type StorageClass string
+
+const (
+	Standard           StorageClass = "STANDARD"
+	InfrequentAccess   StorageClass = "IA"
+	Archive            StorageClass = "ARCHIVE"
+	DeepArchive        StorageClass = "DEEP_ARCHIVE"
+	IntelligentTiering StorageClass = "INTELLIGENT_TIERING"
+)
+
+// PreserveStorageClass 目的端沿用源端对象的存储类型
+const PreserveStorageClass = "preserve"
+
+// 多版本复制模式
+const (
+	VersionAll  = "all"  // 按时间顺序重放所有版本
+	VersionAsOf = "asof" // 只复制指定时刻的版本
+)
+
+// 目的端对象的 ACL 模式，其他取值为强制使用的标准 ACL，如 private
+const (
+	ACLBucket   = "bucket"   // 对象以 Default 写入，沿用目的端桶的 ACL
+	ACLPreserve = "preserve" // 默认，逐个读取并沿用源端对象的 ACL，与源端桶相同时以 Default 写入
+)
+
 type Obj struct {
-	Key   string
-	Size  int64
-	Mtime int64
-	IsDir bool
+	Key          string
+	Size         int64
+	Mtime        int64
+	IsDir        bool
+	StorageClass StorageClass
+	Versions     []ObjVersion // 多版本复制时按写入顺序排列的版本
+}
+
+// ObjVersion 对象的一个版本或删除标记
+type ObjVersion struct {
+	ID           string
+	Size         int64
+	Mtime        int64
+	DeleteMarker bool
+	StorageClass StorageClass
 }
 type Task struct {
 	BuckeNmae string
 	SrcInfo   UriInfo
 	DestInfo  UriInfo
 	Objs      []Obj
+	Config    TaskConfig
+	Hosts     []string // 优先执行任务的 worker 主机，如持有 HDFS 数据块的 DataNode
 }
 
 type TaskInfo struct {
@@ -74,6 +129,9 @@ type UriInfo struct {
 
 type Stats struct {
 	Scanned, Skipped, Copied, Failed, Size int64
+	Restoring                              int64    // 等待取回的归档对象
+	ConfigReport                           []string // 未能迁移的桶配置
+	Excluded                               bool     // 未选中的桶
 	FinishFlag                             bool
 }
 
@@ -89,6 +147,16 @@ type BucketOri struct {
 	Orientation Orientation `json:"to"`
 	DestBucket  string      `json:"destBucket"`
 	Name        string      `json:"name"`
+	DestName    string      `json:"destName"` // 按映射规则得到的目的端桶名
+	Excluded    bool        `json:"excluded"` // 未选中，不迁移
+}
+
+// Dest 目的端桶名，未映射时与源端同名
+func (b BucketOri) Dest() string {
+	if b.DestName != "" {
+		return b.DestName
+	}
+	return b.Name
 }
 
 func (b BucketOri) Ori() string {
@@ -103,8 +171,16 @@ func (b BucketOri) Ori() string {
 	return ""
 }
 
+// Reverse 交换两端的桶，用于按 <== 方向从目的端复制到源端
+func (b BucketOri) Reverse() BucketOri {
+	return BucketOri{SrcBucket: b.DestBucket, Orientation: b.Orientation, DestBucket: b.SrcBucket, Name: b.Name}
+}
+
 type SyncInfo struct {
 	SrcUri      Uri         `json:"srcUri"`
 	DestUri     Uri         `json:"destUri"`
 	BucketRanks []BucketOri `json:"bucketRanks"`
+	Include     []string    `json:"include"` // 选择迁移的桶名或通配符，为空则全部迁移
+	Exclude     []string    `json:"exclude"` // 排除的桶名或通配符
+	Config      TaskConfig  `json:"config"`
 }
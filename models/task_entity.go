@@ -1,37 +1,51 @@
 package models
 
 type TaskConfig struct {
-	TaskName                  string        `toml:"taskName"`
-	SrcType                   ResourceType  `toml:"srcType"`
-	SrcAccessKey              string        `toml:"srcAccessKey"`
-	SrcSecretKey              string        `toml:"srcSecretKey"`
-	SrcDomain                 string        `toml:"srcDomain"`
-	SrcScheme                 string        `toml:"srcScheme"`
-	SrcBucket                 string        `toml:"srcBucket"`
-	SrcPrefix                 string        `toml:"srcPrefix"`
-	SrcFileName               string        `toml:"srcFileName"`
-	SrcStart                  string        `toml:"srcStart"`
-	SrcEnd                    string        `toml:"srcEnd"`
-	DestType                  ResourceType  `toml:"destType"`
-	DestAccessKey             string        `toml:"destAccessKey"`
-	DestSecretKey             string        `toml:"destSecretKey"`
-	DestDomain                string        `toml:"destDomain"`
-	DestScheme                string        `toml:"destScheme"`
-	DestBucket                string        `toml:"destBucket"`
-	DestPrefix                string        `toml:"destPrefix"`
-	CosAppID                  string        `toml:"cosAppID"`
-	Filters                   []string      `toml:"filters"`
-	MaxThroughput             int           `toml:"maxThroughput"`
-	CannedAcl                 CannedACLType `toml:"cannedAcl"`
-	ModifyTimeRange           string        `toml:"modifyTimeRange"`
-	MultipartUploadThreshold  int           `toml:"multipartUploadThreshold"`
-	MultipartUploadPartSize   int           `toml:"multipartUploadPartSize"`
-	MaxNetThroughputTimeRange string        `toml:"maxNetThroughputTimeRange"`
-	MaxNetThroughputRange     string        `toml:"maxNetThroughputRange"`
-	IncrementalMode           bool          `toml:"incrementalMode"`
-	IncrementalModeInterval   int           `toml:"incrementalModeInterval"`
-	IncrementalModeCount      int           `toml:"incrementalModeCount"`
-	IsSkipExistFile           int           `toml:"isSkipExistFile"`
-	SetObjectMetaMD5          bool          `toml:"setObjectMetaMD5"`
-	SrcMD5Header              string        `toml:"srcMD5Header"`
+	TaskName                  string            `toml:"taskName"`
+	SrcType                   ResourceType      `toml:"srcType"`
+	SrcAccessKey              string            `toml:"srcAccessKey"`
+	SrcSecretKey              string            `toml:"srcSecretKey"`
+	SrcDomain                 string            `toml:"srcDomain"`
+	SrcScheme                 string            `toml:"srcScheme"`
+	SrcBucket                 string            `toml:"srcBucket"`
+	SrcPrefix                 string            `toml:"srcPrefix"`
+	SrcFileName               string            `toml:"srcFileName"`
+	SrcStart                  string            `toml:"srcStart"`
+	SrcEnd                    string            `toml:"srcEnd"`
+	DestType                  ResourceType      `toml:"destType"`
+	DestAccessKey             string            `toml:"destAccessKey"`
+	DestSecretKey             string            `toml:"destSecretKey"`
+	DestDomain                string            `toml:"destDomain"`
+	DestScheme                string            `toml:"destScheme"`
+	DestBucket                string            `toml:"destBucket"`
+	DestPrefix                string            `toml:"destPrefix"`
+	CosAppID                  string            `toml:"cosAppID"`
+	Filters                   []string          `toml:"filters"`
+	MaxThroughput             int               `toml:"maxThroughput"`
+	CannedAcl                 CannedACLType     `toml:"cannedAcl"`
+	ModifyTimeRange           string            `toml:"modifyTimeRange"`
+	MultipartUploadThreshold  int               `toml:"multipartUploadThreshold"`
+	MultipartUploadPartSize   int               `toml:"multipartUploadPartSize"`
+	MaxNetThroughputTimeRange string            `toml:"maxNetThroughputTimeRange"`
+	MaxNetThroughputRange     string            `toml:"maxNetThroughputRange"`
+	IncrementalMode           bool              `toml:"incrementalMode"`
+	IncrementalModeInterval   int               `toml:"incrementalModeInterval"`
+	IncrementalModeCount      int               `toml:"incrementalModeCount"`
+	IsSkipExistFile           int               `toml:"isSkipExistFile"`
+	SetObjectMetaMD5          bool              `toml:"setObjectMetaMD5"`
+	SrcMD5Header              string            `toml:"srcMD5Header"`
+	PreserveMetadata          bool              `toml:"preserveMetadata"`
+	StorageClass              string            `toml:"storageClass"`          // preserve 沿用源端，其他值强制使用该类型
+	RestoreDays               int               `toml:"restoreDays"`           // 自动取回归档对象，取回的副本保留天数，0 不取回
+	CopyTags                  bool              `toml:"copyTags"`              // 复制对象标签
+	VersionMode               string            `toml:"versionMode"`           // all 重放所有版本，asof 复制 VersionsAsOf 时刻的版本，空则只复制当前版本
+	VersionsAsOf              int64             `toml:"versionsAsOf"`          // unix 时间戳
+	ACLMode                   string            `toml:"aclMode"`               // preserve、bucket 或强制使用的标准 ACL，见 models.ACLPreserve
+	ACLAccounts               map[string]string `toml:"aclAccounts"`           // 源端账号 ID 到目的端账号 ID 的映射
+	EncryptKeyFile            string            `toml:"encryptKeyFile"`        // 加密目的端对象的密钥文件，服务端和 worker 上都需要
+	DecryptKeyFile            string            `toml:"decryptKeyFile"`        // 解密源端对象的密钥文件
+	SSE                       string            `toml:"sse"`                   // 目的端的服务端加密，provider 或 kms，见 object.SSEProvider
+	SSEKMSKeyID               string            `toml:"sseKmsKeyId"`           // kms 加密使用的密钥 ID，空则使用默认密钥
+	SSECustomerKeyFile        string            `toml:"sseCustomerKeyFile"`    // 目的端 SSE-C 的密钥文件
+	SrcSSECustomerKeyFile     string            `toml:"srcSseCustomerKeyFile"` // 读取源端 SSE-C 加密的对象的密钥文件
 }
@@ -0,0 +1,61 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+)
+
+// azblobBucket Azure Blob 的容器，region 为存储账号名
+type azblobBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (a azblobBucket) Create(region string, name string) error {
+	return object.CreateAzblobContainer(endpoint.Get(models.Azblob, region).ServiceURL(region), a.accessKey, a.secretKey, name)
+}
+
+// List implements BucketOp.
+func (a azblobBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListAzblobContainers(endpoint.Get(models.Azblob, region).ServiceURL(region), a.accessKey, a.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Azblob,
+			Name:     name,
+			Location: region,
+			Domain:   endpoint.Get(models.Azblob, region).BucketDomain(name, region),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (a azblobBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of azblob is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (a azblobBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of azblob is not supported")
+}
+
+func (a azblobBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	a.accessKey = accessKey
+	a.secretKey = secretKey
+	return a
+}
+
+func init() {
+	RegisterBucket(models.Azblob, azblobBucket{})
+}
@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+)
+
+type bosBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (b bosBucket) Create(region string, name string) error {
+	return object.CreateBosBucket(endpoint.Get(models.Bos, region).ServiceURL(region), b.accessKey, b.secretKey, name)
+}
+
+// List implements BucketOp.
+func (b bosBucket) List(region string) ([]BucketInfo, error) {
+	buckets, err := object.ListBosBuckets(endpoint.Get(models.Bos, region).ServiceURL(region), b.accessKey, b.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, bk := range buckets {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Bos,
+			Name:     bk.Name,
+			Location: bk.Location,
+			Domain:   endpoint.Get(models.Bos, bk.Location).BucketDomain(bk.Name, bk.Location),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (b bosBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of bos is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (b bosBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of bos is not supported")
+}
+
+func (b bosBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	b.accessKey = accessKey
+	b.secretKey = secretKey
+	return b
+}
+
+func init() {
+	RegisterBucket(models.Bos, bosBucket{})
+}
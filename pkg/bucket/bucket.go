@@ -3,6 +3,7 @@ package bucket
 import (
 	"errors"
 	"obs-sync/models"
+	"obs-sync/pkg/object"
 )
 
 type BucketInfo struct {
@@ -16,6 +17,10 @@ type BucketOp interface {
 	SetAuth(accessKey, secretKey string) BucketOp
 	List(region string) ([]BucketInfo, error)
 	Create(regino, name string) error
+	// GetConfig 读取桶的 CORS、生命周期、策略、静态网站、防盗链和默认加密配置
+	GetConfig(region, name string) (*Config, error)
+	// PutConfig 写入桶配置，返回无法转换或写入失败的配置项
+	PutConfig(region, name string, c *Config) ([]string, error)
 }
 
 var buckets = make(map[models.ResourceType]BucketOp)
@@ -36,12 +41,25 @@ func (d defaultBucket) List(region string) ([]BucketInfo, error) {
 	return nil, errors.New("unimplemented")
 }
 
+// GetConfig implements BucketOp.
+func (d defaultBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("unimplemented")
+}
+
+// PutConfig implements BucketOp.
+func (d defaultBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("unimplemented")
+}
+
 // SetAuth implements BucketOp.
 func (d defaultBucket) SetAuth(accessKey string, secretKey string) BucketOp {
 	return nil
 }
 
 func BucketStorage(name models.ResourceType, accessKey, secretKey string) BucketOp {
+	if inner, _, _, ok := object.SplitChaos(name, ""); ok {
+		return chaosBucket{name, BucketStorage(inner, accessKey, secretKey)}
+	}
 	b, ok := buckets[name]
 	if ok {
 		return b.SetAuth(accessKey, secretKey)
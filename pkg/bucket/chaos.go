@@ -0,0 +1,52 @@
+package bucket
+
+import (
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+)
+
+// chaosBucket chaos+ 类型的桶操作由被包装的类型完成，region 中 # 之后的故障参数附加到桶的地址上，
+// 桶操作本身不注入故障
+type chaosBucket struct {
+	typ   models.ResourceType
+	inner BucketOp
+}
+
+func (s chaosBucket) split(region string) (string, string) {
+	_, addr, params, _ := object.SplitChaos(s.typ, region)
+	return addr, params
+}
+
+// Create implements BucketOp.
+func (s chaosBucket) Create(region string, name string) error {
+	addr, _ := s.split(region)
+	return s.inner.Create(addr, name)
+}
+
+// List implements BucketOp.
+func (s chaosBucket) List(region string) ([]BucketInfo, error) {
+	addr, params := s.split(region)
+	infos, err := s.inner.List(addr)
+	for i := range infos {
+		infos[i].Type = s.typ
+		infos[i].Domain += "#" + params
+	}
+	return infos, err
+}
+
+// GetConfig implements BucketOp.
+func (s chaosBucket) GetConfig(region, name string) (*Config, error) {
+	addr, _ := s.split(region)
+	return s.inner.GetConfig(addr, name)
+}
+
+// PutConfig implements BucketOp.
+func (s chaosBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	addr, _ := s.split(region)
+	return s.inner.PutConfig(addr, name, c)
+}
+
+func (s chaosBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.inner = s.inner.SetAuth(accessKey, secretKey)
+	return s
+}
@@ -0,0 +1,198 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// Config 与厂商无关的桶配置，未配置的项为空
+type Config struct {
+	CORS       []CORSRule      `json:"cors,omitempty"`
+	Lifecycle  []LifecycleRule `json:"lifecycle,omitempty"`
+	Policy     *Policy         `json:"policy,omitempty"`
+	Website    *Website        `json:"website,omitempty"`
+	Referer    *Referer        `json:"referer,omitempty"`
+	Encryption *Encryption     `json:"encryption,omitempty"`
+	// Unmapped 读取时无法用该模型表示的配置
+	Unmapped []string `json:"unmapped,omitempty"`
+}
+
+type CORSRule struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty"`
+}
+
+type LifecycleRule struct {
+	ID                       string       `json:"id"`
+	Prefix                   string       `json:"prefix"`
+	Enabled                  bool         `json:"enabled"`
+	ExpirationDays           int          `json:"expirationDays,omitempty"`
+	Transitions              []Transition `json:"transitions,omitempty"`
+	NoncurrentExpirationDays int          `json:"noncurrentExpirationDays,omitempty"`
+	AbortMultipartDays       int          `json:"abortMultipartDays,omitempty"`
+}
+
+type Transition struct {
+	Days         int                 `json:"days"`
+	StorageClass models.StorageClass `json:"storageClass"`
+}
+
+// Policy 桶策略的格式因厂商而异，只原样写入同类型的目的端
+type Policy struct {
+	Type     models.ResourceType `json:"type"`
+	Bucket   string              `json:"bucket"`
+	Document string              `json:"document"`
+}
+
+type Website struct {
+	IndexDocument string `json:"indexDocument"`
+	ErrorDocument string `json:"errorDocument,omitempty"`
+}
+
+// Referer 防盗链白名单
+type Referer struct {
+	AllowEmpty bool     `json:"allowEmpty"`
+	Allowed    []string `json:"allowed"`
+}
+
+// 默认加密算法
+const (
+	SSEAES256 = "AES256"
+	SSEKMS    = "KMS"
+)
+
+type Encryption struct {
+	Algorithm string `json:"algorithm"`
+	KMSKeyID  string `json:"kmsKeyID,omitempty"`
+}
+
+// IsEmpty 桶没有任何可迁移的配置
+func (c *Config) IsEmpty() bool {
+	return len(c.CORS) == 0 && len(c.Lifecycle) == 0 && c.Policy == nil && c.Website == nil &&
+		c.Referer == nil && c.Encryption == nil
+}
+
+func (c *Config) unmapped(format string, args ...interface{}) {
+	c.Unmapped = append(c.Unmapped, fmt.Sprintf(format, args...))
+}
+
+// encryption 统一各厂商的加密算法名称
+func (c *Config) encryption(alg, keyID string) {
+	switch {
+	case strings.EqualFold(alg, SSEAES256):
+		c.Encryption = &Encryption{Algorithm: SSEAES256}
+	case strings.Contains(strings.ToLower(alg), "kms"):
+		c.Encryption = &Encryption{Algorithm: SSEKMS, KMSKeyID: keyID}
+	case alg != "":
+		c.unmapped("encryption: algorithm %s", alg)
+	}
+}
+
+// notConfiguredCodes 读取未设置的配置时各厂商返回的错误码，桶不存在（NoSuchBucket）等其他错误不在其中
+var notConfiguredCodes = map[string]bool{
+	"NoSuchCORSConfiguration":                        true,
+	"NoSuchLifecycleConfiguration":                   true,
+	"NoSuchLifecycle":                                true, // OSS
+	"NoSuchBucketPolicy":                             true,
+	"NoSuchWebsiteConfiguration":                     true,
+	"ServerSideEncryptionConfigurationNotFoundError": true, // S3
+	"NoSuchServerSideEncryptionRule":                 true, // OSS
+	"NoSuchEncryptionConfiguration":                  true, // COS、OBS
+}
+
+// errorCode 厂商 SDK 返回的错误码，不是厂商的错误时为空
+func errorCode(err error) string {
+	var (
+		ae awserr.Error
+		ce *cos.ErrorResponse
+		oe oss.ServiceError
+		he obs.ObsError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &ae):
+		return ae.Code()
+	case errors.As(err, &ce):
+		return ce.Code
+	case errors.As(err, &oe):
+		return oe.Code
+	case errors.As(err, &he):
+		return he.Code
+	}
+	return ""
+}
+
+// notConfigured 读取的配置未设置
+func notConfigured(err error) bool {
+	return notConfiguredCodes[errorCode(err)]
+}
+
+// configWriter 写入各项配置，失败或无法转换的项记入报告后继续写入其他项
+type configWriter struct {
+	t      models.ResourceType
+	name   string
+	report []string
+}
+
+func (w *configWriter) unmapped(format string, args ...interface{}) {
+	w.report = append(w.report, fmt.Sprintf(format, args...))
+}
+
+func (w *configWriter) check(item string, err error) {
+	if err != nil {
+		w.unmapped("%s: %s", item, err)
+	}
+}
+
+// policy 桶策略无法在不同厂商间转换，桶名不同时也需要人工修改
+func (w *configWriter) policy(p *Policy) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	if p.Type != w.t {
+		w.unmapped("policy: written for %s, can't be applied to %s", p.Type, w.t)
+		return "", false
+	}
+	if p.Bucket != w.name {
+		w.unmapped("policy: refers to bucket %s, can't be applied to %s", p.Bucket, w.name)
+		return "", false
+	}
+	return p.Document, true
+}
+
+// transitions 转换为目的端的存储类型，不支持的转换规则丢弃
+func (w *configWriter) transitions(r LifecycleRule) []Transition {
+	var res []Transition
+	for _, t := range r.Transitions {
+		if object.NativeStorageClass(w.t, t.StorageClass) == "" {
+			w.unmapped("lifecycle %s: storage class %s not supported by %s", r.ID, t.StorageClass, w.t)
+			continue
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// encryption 源端的 KMS 密钥不能在目的端使用，改用目的端默认的 KMS 密钥
+func (w *configWriter) encryption(e *Encryption) *Encryption {
+	if e == nil {
+		return nil
+	}
+	if e.KMSKeyID != "" {
+		w.unmapped("encryption: kms key %s replaced by the default key of %s", e.KMSKeyID, w.t)
+		return &Encryption{Algorithm: e.Algorithm}
+	}
+	return e
+}
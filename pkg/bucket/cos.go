@@ -2,10 +2,12 @@ package bucket
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
+	"net/url"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/pkg/object"
 
 	"github.com/tencentyun/cos-go-sdk-v5"
@@ -54,7 +56,7 @@ func (c cosBucket) List(region string) ([]BucketInfo, error) {
 				Type:     models.Cos,
 				Name:     b.Name,
 				Location: b.Region,
-				Domain:   fmt.Sprintf("%s.cos.%s.myqcloud.com", b.Name, b.Region),
+				Domain:   endpoint.Get(models.Cos, b.Region).BucketDomain(b.Name, b.Region),
 			})
 		}
 	}
@@ -74,3 +76,218 @@ func NewCosBucket() BucketOp {
 func init() {
 	RegisterBucket(models.Cos, NewCosBucket())
 }
+
+func (c cosBucket) client(region, name string) (*cos.Client, error) {
+	t := endpoint.Get(models.Cos, region)
+	u, err := url.Parse(t.Scheme + "://" + t.BucketDomain(name, region))
+	if err != nil {
+		return nil, err
+	}
+	client := cos.NewClient(&cos.BaseURL{BucketURL: u}, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  c.accessKey,
+			SecretKey: c.secretKey,
+		},
+	})
+	client.UserAgent = object.UserAgent
+	return client, nil
+}
+
+// GetConfig implements BucketOp.
+func (c cosBucket) GetConfig(region, name string) (*Config, error) {
+	client, err := c.client(region, name)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	cfg := &Config{}
+	cors, _, err := client.Bucket.GetCORS(ctx)
+	if err == nil {
+		for _, r := range cors.Rules {
+			cfg.CORS = append(cfg.CORS, CORSRule{
+				AllowedOrigins: r.AllowedOrigins,
+				AllowedMethods: r.AllowedMethods,
+				AllowedHeaders: r.AllowedHeaders,
+				ExposeHeaders:  r.ExposeHeaders,
+				MaxAgeSeconds:  r.MaxAgeSeconds,
+			})
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	lc, _, err := client.Bucket.GetLifecycle(ctx)
+	if err == nil {
+		for _, r := range lc.Rules {
+			rule := LifecycleRule{ID: r.ID, Enabled: r.Status == "Enabled"}
+			if f := r.Filter; f != nil {
+				rule.Prefix = f.Prefix
+				if f.Tag != nil || f.And != nil {
+					cfg.unmapped("lifecycle %s: tag filter", r.ID)
+				}
+			}
+			if e := r.Expiration; e != nil {
+				rule.ExpirationDays = e.Days
+				if e.Date != "" {
+					cfg.unmapped("lifecycle %s: expiration date", r.ID)
+				}
+			}
+			for _, tr := range r.Transition {
+				if tr.Days == 0 {
+					cfg.unmapped("lifecycle %s: transition date", r.ID)
+					continue
+				}
+				rule.Transitions = append(rule.Transitions, Transition{
+					Days:         tr.Days,
+					StorageClass: object.NeutralStorageClass(models.Cos, tr.StorageClass),
+				})
+			}
+			if len(r.NoncurrentVersionTransition) > 0 {
+				cfg.unmapped("lifecycle %s: noncurrent version transitions", r.ID)
+			}
+			if e := r.NoncurrentVersionExpiration; e != nil {
+				rule.NoncurrentExpirationDays = e.NoncurrentDays
+			}
+			if a := r.AbortIncompleteMultipartUpload; a != nil {
+				rule.AbortMultipartDays = a.DaysAfterInitiation
+			}
+			cfg.Lifecycle = append(cfg.Lifecycle, rule)
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	policy, _, err := client.Bucket.GetPolicy(ctx)
+	if err == nil && len(policy.Statement) > 0 {
+		doc, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Policy = &Policy{Type: models.Cos, Bucket: name, Document: string(doc)}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+
+	website, _, err := client.Bucket.GetWebsite(ctx)
+	if err == nil {
+		cfg.Website = &Website{IndexDocument: website.Index}
+		if website.Error != nil {
+			cfg.Website.ErrorDocument = website.Error.Key
+		}
+		if website.RedirectProtocol != nil || website.RoutingRules != nil {
+			cfg.unmapped("website: redirects and routing rules")
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	referer, _, err := client.Bucket.GetReferer(ctx)
+	if err == nil && referer.Status == "Enabled" {
+		if referer.RefererType == "White-List" {
+			cfg.Referer = &Referer{AllowEmpty: referer.EmptyReferConfiguration != "Deny", Allowed: referer.DomainList}
+		} else {
+			cfg.unmapped("referer: blacklist")
+		}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+
+	enc, _, err := client.Bucket.GetEncryption(ctx)
+	if err == nil && enc.Rule != nil {
+		cfg.encryption(enc.Rule.SSEAlgorithm, enc.Rule.KMSMasterKeyID)
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// PutConfig implements BucketOp.
+func (c cosBucket) PutConfig(region, name string, cfg *Config) ([]string, error) {
+	client, err := c.client(region, name)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	w := &configWriter{t: models.Cos, name: name}
+	if len(cfg.CORS) > 0 {
+		opt := &cos.BucketPutCORSOptions{}
+		for _, r := range cfg.CORS {
+			opt.Rules = append(opt.Rules, cos.BucketCORSRule{
+				AllowedOrigins: r.AllowedOrigins,
+				AllowedMethods: r.AllowedMethods,
+				AllowedHeaders: r.AllowedHeaders,
+				ExposeHeaders:  r.ExposeHeaders,
+				MaxAgeSeconds:  r.MaxAgeSeconds,
+			})
+		}
+		_, err = client.Bucket.PutCORS(ctx, opt)
+		w.check("cors", err)
+	}
+
+	if len(cfg.Lifecycle) > 0 {
+		opt := &cos.BucketPutLifecycleOptions{}
+		for _, r := range cfg.Lifecycle {
+			rule := cos.BucketLifecycleRule{ID: r.ID, Status: "Disabled", Filter: &cos.BucketLifecycleFilter{Prefix: r.Prefix}}
+			if r.Enabled {
+				rule.Status = "Enabled"
+			}
+			if r.ExpirationDays > 0 {
+				rule.Expiration = &cos.BucketLifecycleExpiration{Days: r.ExpirationDays}
+			}
+			for _, tr := range w.transitions(r) {
+				rule.Transition = append(rule.Transition, cos.BucketLifecycleTransition{
+					Days:         tr.Days,
+					StorageClass: object.NativeStorageClass(models.Cos, tr.StorageClass),
+				})
+			}
+			if r.NoncurrentExpirationDays > 0 {
+				rule.NoncurrentVersionExpiration = &cos.BucketLifecycleNoncurrentVersion{NoncurrentDays: r.NoncurrentExpirationDays}
+			}
+			if r.AbortMultipartDays > 0 {
+				rule.AbortIncompleteMultipartUpload = &cos.BucketLifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: r.AbortMultipartDays}
+			}
+			opt.Rules = append(opt.Rules, rule)
+		}
+		_, err = client.Bucket.PutLifecycle(ctx, opt)
+		w.check("lifecycle", err)
+	}
+
+	if doc, ok := w.policy(cfg.Policy); ok {
+		var opt cos.BucketPutPolicyOptions
+		if err = json.Unmarshal([]byte(doc), &opt); err == nil {
+			_, err = client.Bucket.PutPolicy(ctx, &opt)
+		}
+		w.check("policy", err)
+	}
+
+	if ws := cfg.Website; ws != nil {
+		opt := &cos.BucketPutWebsiteOptions{Index: ws.IndexDocument}
+		if ws.ErrorDocument != "" {
+			opt.Error = &cos.ErrorDocument{Key: ws.ErrorDocument}
+		}
+		_, err = client.Bucket.PutWebsite(ctx, opt)
+		w.check("website", err)
+	}
+
+	if r := cfg.Referer; r != nil {
+		opt := &cos.BucketPutRefererOptions{
+			Status:                  "Enabled",
+			RefererType:             "White-List",
+			DomainList:              r.Allowed,
+			EmptyReferConfiguration: "Deny",
+		}
+		if r.AllowEmpty {
+			opt.EmptyReferConfiguration = "Allow"
+		}
+		_, err = client.Bucket.PutReferer(ctx, opt)
+		w.check("referer", err)
+	}
+
+	if e := w.encryption(cfg.Encryption); e != nil {
+		_, err = client.Bucket.PutEncryption(ctx, &cos.BucketPutEncryptionOptions{
+			Rule: &cos.BucketEncryptionConfiguration{SSEAlgorithm: e.Algorithm},
+		})
+		w.check("encryption", err)
+	}
+	return w.report, nil
+}
@@ -3,6 +3,7 @@ package bucket
 import (
 	"fmt"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/pkg/object"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -27,7 +28,7 @@ func (c cucBucket) Create(region string, name string) error {
 		HTTPClient:  object.GetHttpClient(),
 		Credentials: credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
 		Region:      aws.String(region),
-		Endpoint:    aws.String(fmt.Sprintf("http://obs-%s.cucloud.cn", region)),
+		Endpoint:    aws.String(endpoint.Get(models.Cuc, region).ServiceURL(region)),
 	}
 	ses, err := session.NewSession(awsConfig)
 	if err != nil {
@@ -48,7 +49,7 @@ func (c cucBucket) List(region string) ([]BucketInfo, error) {
 		Credentials: credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
 	}
 	awsConfig.Region = aws.String(region)
-	awsConfig.Endpoint = aws.String(fmt.Sprintf("https://obs-%s.cucloud.cn", region))
+	awsConfig.Endpoint = aws.String(endpoint.Get(models.Cuc, region).ServiceURL(region))
 	awsConfig.DisableEndpointHostPrefix = aws.Bool(true)
 	ses, err := session.NewSession(awsConfig)
 	if err != nil {
@@ -69,7 +70,7 @@ func (c cucBucket) List(region string) ([]BucketInfo, error) {
 			Type:     models.Cuc,
 			Name:     *b.Name,
 			Location: region,
-			Domain:   fmt.Sprintf("%s.obs-%s.cucloud.cn", *b.Name, region),
+			Domain:   endpoint.Get(models.Cuc, region).BucketDomain(*b.Name, region),
 		})
 
 	}
@@ -90,3 +91,37 @@ func newCuCBucket() BucketOp {
 func init() {
 	RegisterBucket(models.Cuc, newCuCBucket())
 }
+
+func (c cucBucket) client(region string) (*s3.S3, error) {
+	awsConfig := &aws.Config{
+		HTTPClient:                object.GetHttpClient(),
+		Credentials:               credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
+		Region:                    aws.String(region),
+		Endpoint:                  aws.String(endpoint.Get(models.Cuc, region).ServiceURL(region)),
+		DisableEndpointHostPrefix: aws.Bool(true),
+	}
+	ses, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create aws session: %s", err)
+	}
+	ses.Handlers.Build.PushFront(object.DisableSha256Func)
+	return s3.New(ses), nil
+}
+
+// GetConfig implements BucketOp.
+func (c cucBucket) GetConfig(region, name string) (*Config, error) {
+	svc, err := c.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3GetConfig(svc, models.Cuc, name)
+}
+
+// PutConfig implements BucketOp.
+func (c cucBucket) PutConfig(region, name string, cfg *Config) ([]string, error) {
+	svc, err := c.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3PutConfig(svc, models.Cuc, name, cfg), nil
+}
@@ -0,0 +1,61 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+)
+
+// gcsBucket Google Cloud Storage 的桶，region 为项目 ID
+type gcsBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (g gcsBucket) Create(region string, name string) error {
+	return object.CreateGcsBucket(endpoint.Get(models.Gcs, region).ServiceURL(region), region, g.accessKey, g.secretKey, name)
+}
+
+// List implements BucketOp.
+func (g gcsBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListGcsBuckets(endpoint.Get(models.Gcs, region).ServiceURL(region), region, g.accessKey, g.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Gcs,
+			Name:     name,
+			Location: region,
+			Domain:   endpoint.Get(models.Gcs, region).BucketDomain(name, region),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (g gcsBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of gcs is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (g gcsBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of gcs is not supported")
+}
+
+func (g gcsBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	g.accessKey = accessKey
+	g.secretKey = secretKey
+	return g
+}
+
+func init() {
+	RegisterBucket(models.Gcs, gcsBucket{})
+}
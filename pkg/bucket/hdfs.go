@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+)
+
+// hdfsBucket HDFS 下的目录，region 为 [http(s)://]namenode:port[,namenode:port][/path]，ak 为用户名
+type hdfsBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (s hdfsBucket) Create(region string, name string) error {
+	return object.CreateHdfsDir(region, s.accessKey, name)
+}
+
+// List implements BucketOp.
+func (s hdfsBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListHdfsDirs(region, s.accessKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Hdfs,
+			Name:     name,
+			Location: region,
+			Domain:   object.HdfsBucketURL(region, name),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (s hdfsBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of hdfs is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (s hdfsBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of hdfs is not supported")
+}
+
+func (s hdfsBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.accessKey = accessKey
+	s.secretKey = secretKey
+	return s
+}
+
+func init() {
+	RegisterBucket(models.Hdfs, hdfsBucket{})
+}
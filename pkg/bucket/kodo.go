@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+)
+
+type kodoBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (k kodoBucket) Create(region string, name string) error {
+	return object.CreateKodoBucket(endpoint.Get(models.Kodo, region).ServiceURL(region), region, k.accessKey, k.secretKey, name)
+}
+
+// List implements BucketOp.
+func (k kodoBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListKodoBuckets(endpoint.Get(models.Kodo, region).ServiceURL(region), region, k.accessKey, k.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Kodo,
+			Name:     name,
+			Location: region,
+			Domain:   endpoint.Get(models.Kodo, region).BucketDomain(name, region),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (k kodoBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of kodo is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (k kodoBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of kodo is not supported")
+}
+
+func (k kodoBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	k.accessKey = accessKey
+	k.secretKey = secretKey
+	return k
+}
+
+func init() {
+	RegisterBucket(models.Kodo, kodoBucket{})
+}
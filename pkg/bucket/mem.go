@@ -0,0 +1,68 @@
+package bucket
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+	"sync"
+)
+
+// memConfigs 内存桶的配置，按桶的地址索引
+var memConfigs sync.Map
+
+// memBucket 进程内存中的桶，region 为命名空间，用于测试和演示
+type memBucket struct {
+	accessKey string
+}
+
+// Create implements BucketOp.
+func (s memBucket) Create(region string, name string) error {
+	store, err := object.CreateStorage(models.Mem, object.MemBucketURL(region, name), s.accessKey, "")
+	if err != nil {
+		return err
+	}
+	return store.Create()
+}
+
+// List implements BucketOp.
+func (s memBucket) List(region string) ([]BucketInfo, error) {
+	var bucketInfos []BucketInfo
+	for _, name := range object.ListMemBuckets(region) {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Mem,
+			Name:     name,
+			Location: region,
+			Domain:   object.MemBucketURL(region, name),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (s memBucket) GetConfig(region, name string) (*Config, error) {
+	c := &Config{}
+	if v, ok := memConfigs.Load(object.MemBucketURL(region, name)); ok {
+		*c = *v.(*Config)
+	}
+	return c, nil
+}
+
+// PutConfig implements BucketOp.
+func (s memBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	saved := *c
+	saved.Unmapped = nil
+	memConfigs.Store(object.MemBucketURL(region, name), &saved)
+	return nil, nil
+}
+
+func (s memBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.accessKey = accessKey
+	return s
+}
+
+func init() {
+	RegisterBucket(models.Mem, memBucket{})
+}
@@ -1,8 +1,9 @@
 package bucket
 
 import (
-	"fmt"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 )
@@ -20,8 +21,7 @@ func (o obsBucket) Create(regino string, name string) error {
 // BucketLister implements BucketOp.
 func (o obsBucket) List(region string) ([]BucketInfo, error) {
 	var bucketInfos []BucketInfo
-	endpoint := fmt.Sprintf("https://obs.%s.myhuaweicloud.com", region)
-	cli, err := obs.New(o.accessKey, o.secretKey, endpoint)
+	cli, err := o.client(region)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +37,7 @@ func (o obsBucket) List(region string) ([]BucketInfo, error) {
 			Type:     models.Obs,
 			Name:     b.Name,
 			Location: b.Location,
-			Domain:   fmt.Sprintf("%s.obs.%s.myhuaweicloud.com", b.Name, b.Location),
+			Domain:   endpoint.Get(models.Obs, b.Location).BucketDomain(b.Name, b.Location),
 		})
 	}
 	return bucketInfos, nil
@@ -56,3 +56,161 @@ func newObsBucket() BucketOp {
 func init() {
 	RegisterBucket(models.Obs, newObsBucket())
 }
+
+func (o obsBucket) client(region string) (*obs.ObsClient, error) {
+	return obs.New(o.accessKey, o.secretKey, endpoint.Get(models.Obs, region).ServiceURL(region))
+}
+
+// GetConfig implements BucketOp.
+func (o obsBucket) GetConfig(region, name string) (*Config, error) {
+	cli, err := o.client(region)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	cors, err := cli.GetBucketCors(name)
+	if err == nil {
+		for _, r := range cors.CorsRules {
+			c.CORS = append(c.CORS, CORSRule{
+				AllowedOrigins: r.AllowedOrigin,
+				AllowedMethods: r.AllowedMethod,
+				AllowedHeaders: r.AllowedHeader,
+				ExposeHeaders:  r.ExposeHeader,
+				MaxAgeSeconds:  r.MaxAgeSeconds,
+			})
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	lc, err := cli.GetBucketLifecycleConfiguration(name)
+	if err == nil {
+		for _, r := range lc.LifecycleRules {
+			rule := LifecycleRule{
+				ID:                       r.ID,
+				Prefix:                   r.Prefix,
+				Enabled:                  r.Status == obs.RuleStatusEnabled,
+				ExpirationDays:           r.Expiration.Days,
+				NoncurrentExpirationDays: r.NoncurrentVersionExpiration.NoncurrentDays,
+				AbortMultipartDays:       r.AbortIncompleteMultipartUpload.DaysAfterInitiation,
+			}
+			if !r.Expiration.Date.IsZero() {
+				c.unmapped("lifecycle %s: expiration date", r.ID)
+			}
+			for _, tr := range r.Transitions {
+				if tr.Days == 0 {
+					c.unmapped("lifecycle %s: transition date", r.ID)
+					continue
+				}
+				rule.Transitions = append(rule.Transitions, Transition{
+					Days:         tr.Days,
+					StorageClass: object.NeutralStorageClass(models.Obs, string(tr.StorageClass)),
+				})
+			}
+			if len(r.NoncurrentVersionTransitions) > 0 {
+				c.unmapped("lifecycle %s: noncurrent version transitions", r.ID)
+			}
+			c.Lifecycle = append(c.Lifecycle, rule)
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	policy, err := cli.GetBucketPolicy(name)
+	if err == nil && policy.Policy != "" {
+		c.Policy = &Policy{Type: models.Obs, Bucket: name, Document: policy.Policy}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+
+	website, err := cli.GetBucketWebsiteConfiguration(name)
+	if err == nil {
+		c.Website = &Website{IndexDocument: website.IndexDocument.Suffix, ErrorDocument: website.ErrorDocument.Key}
+		if website.RedirectAllRequestsTo.HostName != "" || len(website.RoutingRules) > 0 {
+			c.unmapped("website: redirects and routing rules")
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	enc, err := cli.GetBucketEncryption(name)
+	if err == nil {
+		c.encryption(enc.SSEAlgorithm, enc.KMSMasterKeyID)
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PutConfig implements BucketOp.
+func (o obsBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	cli, err := o.client(region)
+	if err != nil {
+		return nil, err
+	}
+	w := &configWriter{t: models.Obs, name: name}
+	if len(c.CORS) > 0 {
+		input := &obs.SetBucketCorsInput{Bucket: name}
+		for _, r := range c.CORS {
+			input.CorsRules = append(input.CorsRules, obs.CorsRule{
+				AllowedOrigin: r.AllowedOrigins,
+				AllowedMethod: r.AllowedMethods,
+				AllowedHeader: r.AllowedHeaders,
+				ExposeHeader:  r.ExposeHeaders,
+				MaxAgeSeconds: r.MaxAgeSeconds,
+			})
+		}
+		_, err = cli.SetBucketCors(input)
+		w.check("cors", err)
+	}
+
+	if len(c.Lifecycle) > 0 {
+		input := &obs.SetBucketLifecycleConfigurationInput{Bucket: name}
+		for _, r := range c.Lifecycle {
+			rule := obs.LifecycleRule{ID: r.ID, Prefix: r.Prefix, Status: obs.RuleStatusDisabled}
+			if r.Enabled {
+				rule.Status = obs.RuleStatusEnabled
+			}
+			rule.Expiration.Days = r.ExpirationDays
+			for _, tr := range w.transitions(r) {
+				rule.Transitions = append(rule.Transitions, obs.Transition{
+					Days:         tr.Days,
+					StorageClass: obs.StorageClassType(object.NativeStorageClass(models.Obs, tr.StorageClass)),
+				})
+			}
+			rule.NoncurrentVersionExpiration.NoncurrentDays = r.NoncurrentExpirationDays
+			rule.AbortIncompleteMultipartUpload.DaysAfterInitiation = r.AbortMultipartDays
+			input.LifecycleRules = append(input.LifecycleRules, rule)
+		}
+		_, err = cli.SetBucketLifecycleConfiguration(input)
+		w.check("lifecycle", err)
+	}
+
+	if doc, ok := w.policy(c.Policy); ok {
+		_, err = cli.SetBucketPolicy(&obs.SetBucketPolicyInput{Bucket: name, Policy: doc})
+		w.check("policy", err)
+	}
+
+	if ws := c.Website; ws != nil {
+		input := &obs.SetBucketWebsiteConfigurationInput{Bucket: name}
+		input.IndexDocument.Suffix = ws.IndexDocument
+		input.ErrorDocument.Key = ws.ErrorDocument
+		_, err = cli.SetBucketWebsiteConfiguration(input)
+		w.check("website", err)
+	}
+
+	if c.Referer != nil {
+		w.unmapped("referer: not supported by %s, use a bucket policy instead", models.Obs)
+	}
+
+	if e := w.encryption(c.Encryption); e != nil {
+		input := &obs.SetBucketEncryptionInput{Bucket: name}
+		input.SSEAlgorithm = obs.DEFAULT_SSE_KMS_ENCRYPTION
+		if e.Algorithm == SSEAES256 {
+			input.SSEAlgorithm = SSEAES256
+		}
+		_, err = cli.SetBucketEncryption(input)
+		w.check("encryption", err)
+	}
+	return w.report, nil
+}
@@ -1,8 +1,10 @@
 package bucket
 
 import (
-	"fmt"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+	"strings"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
@@ -20,9 +22,7 @@ func (o ossBucket) Create(regino string, name string) error {
 // BucketLister implements BucketOp.
 func (o ossBucket) List(region string) ([]BucketInfo, error) {
 	var bucketInfos []BucketInfo
-	defaultEndpoint := fmt.Sprintf("https://oss-%s.aliyuncs.com", region)
-
-	client, err := oss.New(defaultEndpoint, o.accessKey, o.secretKey)
+	client, err := o.client(region)
 	if err != nil {
 		return nil, err
 	}
@@ -35,11 +35,13 @@ func (o ossBucket) List(region string) ([]BucketInfo, error) {
 		}
 
 		for _, bucket := range lsRes.Buckets {
+			// Location 形如 oss-cn-hangzhou，区域为 cn-hangzhou
+			r := strings.TrimPrefix(bucket.Location, "oss-")
 			bucketInfos = append(bucketInfos, BucketInfo{
 				Type:     models.Oss,
 				Name:     bucket.Name,
 				Location: bucket.Location,
-				Domain:   fmt.Sprintf("%s.%s.aliyuncs.com", bucket.Name, bucket.Location),
+				Domain:   endpoint.Get(models.Oss, r).BucketDomain(bucket.Name, r),
 			})
 		}
 		if lsRes.IsTruncated {
@@ -64,3 +66,176 @@ func newOssBucket() BucketOp {
 func init() {
 	RegisterBucket(models.Oss, newOssBucket())
 }
+
+func (o ossBucket) client(region string) (*oss.Client, error) {
+	return oss.New(endpoint.Get(models.Oss, region).ServiceURL(region), o.accessKey, o.secretKey)
+}
+
+// GetConfig implements BucketOp.
+func (o ossBucket) GetConfig(region, name string) (*Config, error) {
+	client, err := o.client(region)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	cors, err := client.GetBucketCORS(name)
+	if err == nil {
+		for _, r := range cors.CORSRules {
+			c.CORS = append(c.CORS, CORSRule{
+				AllowedOrigins: r.AllowedOrigin,
+				AllowedMethods: r.AllowedMethod,
+				AllowedHeaders: r.AllowedHeader,
+				ExposeHeaders:  r.ExposeHeader,
+				MaxAgeSeconds:  r.MaxAgeSeconds,
+			})
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	lc, err := client.GetBucketLifecycle(name)
+	if err == nil {
+		for _, r := range lc.Rules {
+			rule := LifecycleRule{ID: r.ID, Prefix: r.Prefix, Enabled: r.Status == "Enabled"}
+			if len(r.Tags) > 0 || r.Filter != nil {
+				c.unmapped("lifecycle %s: tag filter", r.ID)
+			}
+			if e := r.Expiration; e != nil {
+				rule.ExpirationDays = e.Days
+				if e.Date != "" || e.CreatedBeforeDate != "" {
+					c.unmapped("lifecycle %s: expiration date", r.ID)
+				}
+			}
+			for _, tr := range r.Transitions {
+				if tr.Days == 0 {
+					c.unmapped("lifecycle %s: transition date", r.ID)
+					continue
+				}
+				rule.Transitions = append(rule.Transitions, Transition{
+					Days:         tr.Days,
+					StorageClass: object.NeutralStorageClass(models.Oss, string(tr.StorageClass)),
+				})
+			}
+			if len(r.NonVersionTransitions) > 0 {
+				c.unmapped("lifecycle %s: noncurrent version transitions", r.ID)
+			}
+			if e := r.NonVersionExpiration; e != nil {
+				rule.NoncurrentExpirationDays = e.NoncurrentDays
+			}
+			if a := r.AbortMultipartUpload; a != nil {
+				rule.AbortMultipartDays = a.Days
+			}
+			c.Lifecycle = append(c.Lifecycle, rule)
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	policy, err := client.GetBucketPolicy(name)
+	if err == nil && policy != "" {
+		c.Policy = &Policy{Type: models.Oss, Bucket: name, Document: policy}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+
+	website, err := client.GetBucketWebsite(name)
+	if err == nil {
+		c.Website = &Website{IndexDocument: website.IndexDocument.Suffix, ErrorDocument: website.ErrorDocument.Key}
+		if len(website.RoutingRules) > 0 {
+			c.unmapped("website: routing rules")
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	referer, err := client.GetBucketReferer(name)
+	if err == nil {
+		// 默认配置为允许空 Referer 且白名单为空，即不限制
+		if len(referer.RefererList) > 0 || !referer.AllowEmptyReferer {
+			c.Referer = &Referer{AllowEmpty: referer.AllowEmptyReferer, Allowed: referer.RefererList}
+		}
+		if referer.RefererBlacklist != nil && len(referer.RefererBlacklist.Referer) > 0 {
+			c.unmapped("referer: blacklist")
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	enc, err := client.GetBucketEncryption(name)
+	if err == nil {
+		c.encryption(enc.SSEDefault.SSEAlgorithm, enc.SSEDefault.KMSMasterKeyID)
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PutConfig implements BucketOp.
+func (o ossBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	client, err := o.client(region)
+	if err != nil {
+		return nil, err
+	}
+	w := &configWriter{t: models.Oss, name: name}
+	if len(c.CORS) > 0 {
+		var rules []oss.CORSRule
+		for _, r := range c.CORS {
+			rules = append(rules, oss.CORSRule{
+				AllowedOrigin: r.AllowedOrigins,
+				AllowedMethod: r.AllowedMethods,
+				AllowedHeader: r.AllowedHeaders,
+				ExposeHeader:  r.ExposeHeaders,
+				MaxAgeSeconds: r.MaxAgeSeconds,
+			})
+		}
+		w.check("cors", client.SetBucketCORS(name, rules))
+	}
+
+	if len(c.Lifecycle) > 0 {
+		var rules []oss.LifecycleRule
+		for _, r := range c.Lifecycle {
+			rule := oss.LifecycleRule{ID: r.ID, Prefix: r.Prefix, Status: "Disabled"}
+			if r.Enabled {
+				rule.Status = "Enabled"
+			}
+			if r.ExpirationDays > 0 {
+				rule.Expiration = &oss.LifecycleExpiration{Days: r.ExpirationDays}
+			}
+			for _, tr := range w.transitions(r) {
+				rule.Transitions = append(rule.Transitions, oss.LifecycleTransition{
+					Days:         tr.Days,
+					StorageClass: oss.StorageClassType(object.NativeStorageClass(models.Oss, tr.StorageClass)),
+				})
+			}
+			if r.NoncurrentExpirationDays > 0 {
+				rule.NonVersionExpiration = &oss.LifecycleVersionExpiration{NoncurrentDays: r.NoncurrentExpirationDays}
+			}
+			if r.AbortMultipartDays > 0 {
+				rule.AbortMultipartUpload = &oss.LifecycleAbortMultipartUpload{Days: r.AbortMultipartDays}
+			}
+			rules = append(rules, rule)
+		}
+		w.check("lifecycle", client.SetBucketLifecycle(name, rules))
+	}
+
+	if doc, ok := w.policy(c.Policy); ok {
+		w.check("policy", client.SetBucketPolicy(name, doc))
+	}
+
+	if ws := c.Website; ws != nil {
+		w.check("website", client.SetBucketWebsite(name, ws.IndexDocument, ws.ErrorDocument))
+	}
+
+	if r := c.Referer; r != nil {
+		w.check("referer", client.SetBucketReferer(name, r.Allowed, r.AllowEmpty))
+	}
+
+	if e := w.encryption(c.Encryption); e != nil {
+		alg := string(oss.AESAlgorithm)
+		if e.Algorithm == SSEKMS {
+			alg = string(oss.KMSAlgorithm)
+		}
+		w.check("encryption", client.SetBucketEncryption(name, oss.ServerEncryptionRule{SSEDefault: oss.SSEDefaultRule{SSEAlgorithm: alg}}))
+	}
+	return w.report, nil
+}
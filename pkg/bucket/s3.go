@@ -3,6 +3,7 @@ package bucket
 import (
 	"fmt"
 	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
 	"obs-sync/pkg/object"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,8 +19,22 @@ type s3Bucket struct {
 }
 
 // BucketCreater implements BucketOp.
-func (s s3Bucket) Create(regino string, name string) error {
-	panic("unimplemented")
+func (s s3Bucket) Create(region string, name string) error {
+	svc, err := s.client(region)
+	if err != nil {
+		return err
+	}
+	return s3CreateBucket(svc, region, name)
+}
+
+// s3CreateBucket 在指定区域创建桶，us-east-1 不需要指定 LocationConstraint
+func s3CreateBucket(svc *s3.S3, region, name string) error {
+	input := &s3.CreateBucketInput{Bucket: &name}
+	if region != "" && region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3.CreateBucketConfiguration{LocationConstraint: aws.String(region)}
+	}
+	_, err := svc.CreateBucket(input)
+	return err
 }
 
 // BucketLister implements BucketOp.
@@ -33,7 +48,7 @@ func (s s3Bucket) List(region string) ([]BucketInfo, error) {
 		Credentials: credentials.NewStaticCredentials(s.accessKey, s.secretKey, ""),
 	}
 	awsConfig.Region = aws.String(region)
-	awsConfig.Endpoint = aws.String(fmt.Sprintf("http://cos.zz-%s.cos.tg.ncmp.unicom.local", region))
+	awsConfig.Endpoint = aws.String(endpoint.Get(models.S3, region).ServiceURL(region))
 	ses, err := session.NewSession(awsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create aws session: %s", err)
@@ -62,7 +77,7 @@ func (s s3Bucket) List(region string) ([]BucketInfo, error) {
 			Type:     models.S3,
 			Name:     *b.Name,
 			Location: *l.LocationConstraint,
-			Domain:   fmt.Sprintf("%s.%s.amazonaws.com", *b.Name, *l.LocationConstraint),
+			Domain:   endpoint.Get(models.S3, *l.LocationConstraint).BucketDomain(*b.Name, *l.LocationConstraint),
 		})
 	}
 	if len(bucketInfos) == 0 {
@@ -84,3 +99,226 @@ func newS3Bucket() BucketOp {
 func init() {
 	RegisterBucket(models.S3, newS3Bucket())
 }
+
+func (s s3Bucket) client(region string) (*s3.S3, error) {
+	awsConfig := &aws.Config{
+		HTTPClient:  object.GetHttpClient(),
+		Credentials: credentials.NewStaticCredentials(s.accessKey, s.secretKey, ""),
+		Region:      aws.String(region),
+		Endpoint:    aws.String(endpoint.Get(models.S3, region).ServiceURL(region)),
+	}
+	ses, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create aws session: %s", err)
+	}
+	return s3.New(ses), nil
+}
+
+// GetConfig implements BucketOp.
+func (s s3Bucket) GetConfig(region, name string) (*Config, error) {
+	svc, err := s.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3GetConfig(svc, models.S3, name)
+}
+
+// PutConfig implements BucketOp.
+func (s s3Bucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	svc, err := s.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3PutConfig(svc, models.S3, name, c), nil
+}
+
+// s3GetConfig 读取 S3 兼容存储的桶配置，s3 与 cuc 共用
+func s3GetConfig(svc *s3.S3, t models.ResourceType, name string) (*Config, error) {
+	c := &Config{}
+	cors, err := svc.GetBucketCors(&s3.GetBucketCorsInput{Bucket: &name})
+	if err == nil {
+		for _, r := range cors.CORSRules {
+			c.CORS = append(c.CORS, CORSRule{
+				AllowedOrigins: aws.StringValueSlice(r.AllowedOrigins),
+				AllowedMethods: aws.StringValueSlice(r.AllowedMethods),
+				AllowedHeaders: aws.StringValueSlice(r.AllowedHeaders),
+				ExposeHeaders:  aws.StringValueSlice(r.ExposeHeaders),
+				MaxAgeSeconds:  int(aws.Int64Value(r.MaxAgeSeconds)),
+			})
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	lc, err := svc.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{Bucket: &name})
+	if err == nil {
+		for _, r := range lc.Rules {
+			rule := LifecycleRule{
+				ID:      aws.StringValue(r.ID),
+				Prefix:  aws.StringValue(r.Prefix),
+				Enabled: aws.StringValue(r.Status) == s3.ExpirationStatusEnabled,
+			}
+			if f := r.Filter; f != nil {
+				if f.Prefix != nil {
+					rule.Prefix = *f.Prefix
+				}
+				if f.Tag != nil || f.And != nil {
+					c.unmapped("lifecycle %s: tag filter", rule.ID)
+				}
+			}
+			if e := r.Expiration; e != nil {
+				rule.ExpirationDays = int(aws.Int64Value(e.Days))
+				if e.Date != nil {
+					c.unmapped("lifecycle %s: expiration date", rule.ID)
+				}
+			}
+			for _, tr := range r.Transitions {
+				if tr.Days == nil {
+					c.unmapped("lifecycle %s: transition date", rule.ID)
+					continue
+				}
+				rule.Transitions = append(rule.Transitions, Transition{
+					Days:         int(*tr.Days),
+					StorageClass: object.NeutralStorageClass(t, aws.StringValue(tr.StorageClass)),
+				})
+			}
+			if len(r.NoncurrentVersionTransitions) > 0 {
+				c.unmapped("lifecycle %s: noncurrent version transitions", rule.ID)
+			}
+			if e := r.NoncurrentVersionExpiration; e != nil {
+				rule.NoncurrentExpirationDays = int(aws.Int64Value(e.NoncurrentDays))
+			}
+			if a := r.AbortIncompleteMultipartUpload; a != nil {
+				rule.AbortMultipartDays = int(aws.Int64Value(a.DaysAfterInitiation))
+			}
+			c.Lifecycle = append(c.Lifecycle, rule)
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	policy, err := svc.GetBucketPolicy(&s3.GetBucketPolicyInput{Bucket: &name})
+	if err == nil && aws.StringValue(policy.Policy) != "" {
+		c.Policy = &Policy{Type: t, Bucket: name, Document: *policy.Policy}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+
+	website, err := svc.GetBucketWebsite(&s3.GetBucketWebsiteInput{Bucket: &name})
+	if err == nil {
+		c.Website = &Website{}
+		if website.IndexDocument != nil {
+			c.Website.IndexDocument = aws.StringValue(website.IndexDocument.Suffix)
+		}
+		if website.ErrorDocument != nil {
+			c.Website.ErrorDocument = aws.StringValue(website.ErrorDocument.Key)
+		}
+		if website.RedirectAllRequestsTo != nil || len(website.RoutingRules) > 0 {
+			c.unmapped("website: redirects and routing rules")
+		}
+	} else if !notConfigured(err) {
+		return nil, err
+	}
+
+	enc, err := svc.GetBucketEncryption(&s3.GetBucketEncryptionInput{Bucket: &name})
+	if err == nil && enc.ServerSideEncryptionConfiguration != nil {
+		for _, r := range enc.ServerSideEncryptionConfiguration.Rules {
+			if d := r.ApplyServerSideEncryptionByDefault; d != nil {
+				c.encryption(aws.StringValue(d.SSEAlgorithm), aws.StringValue(d.KMSMasterKeyID))
+			}
+		}
+	} else if err != nil && !notConfigured(err) {
+		return nil, err
+	}
+	return c, nil
+}
+
+// s3PutConfig 写入 S3 兼容存储的桶配置，s3 与 cuc 共用
+func s3PutConfig(svc *s3.S3, t models.ResourceType, name string, c *Config) []string {
+	w := &configWriter{t: t, name: name}
+	if len(c.CORS) > 0 {
+		cfg := &s3.CORSConfiguration{}
+		for _, r := range c.CORS {
+			rule := &s3.CORSRule{
+				AllowedOrigins: aws.StringSlice(r.AllowedOrigins),
+				AllowedMethods: aws.StringSlice(r.AllowedMethods),
+				AllowedHeaders: aws.StringSlice(r.AllowedHeaders),
+				ExposeHeaders:  aws.StringSlice(r.ExposeHeaders),
+			}
+			if r.MaxAgeSeconds > 0 {
+				rule.MaxAgeSeconds = aws.Int64(int64(r.MaxAgeSeconds))
+			}
+			cfg.CORSRules = append(cfg.CORSRules, rule)
+		}
+		_, err := svc.PutBucketCors(&s3.PutBucketCorsInput{Bucket: &name, CORSConfiguration: cfg})
+		w.check("cors", err)
+	}
+
+	if len(c.Lifecycle) > 0 {
+		cfg := &s3.BucketLifecycleConfiguration{}
+		for _, r := range c.Lifecycle {
+			status := s3.ExpirationStatusDisabled
+			if r.Enabled {
+				status = s3.ExpirationStatusEnabled
+			}
+			rule := &s3.LifecycleRule{
+				ID:     aws.String(r.ID),
+				Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(r.Prefix)},
+				Status: aws.String(status),
+			}
+			if r.ExpirationDays > 0 {
+				rule.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(int64(r.ExpirationDays))}
+			}
+			for _, tr := range w.transitions(r) {
+				rule.Transitions = append(rule.Transitions, &s3.Transition{
+					Days:         aws.Int64(int64(tr.Days)),
+					StorageClass: aws.String(object.NativeStorageClass(t, tr.StorageClass)),
+				})
+			}
+			if r.NoncurrentExpirationDays > 0 {
+				rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(int64(r.NoncurrentExpirationDays))}
+			}
+			if r.AbortMultipartDays > 0 {
+				rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(int64(r.AbortMultipartDays))}
+			}
+			cfg.Rules = append(cfg.Rules, rule)
+		}
+		_, err := svc.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{Bucket: &name, LifecycleConfiguration: cfg})
+		w.check("lifecycle", err)
+	}
+
+	if doc, ok := w.policy(c.Policy); ok {
+		_, err := svc.PutBucketPolicy(&s3.PutBucketPolicyInput{Bucket: &name, Policy: &doc})
+		w.check("policy", err)
+	}
+
+	if ws := c.Website; ws != nil {
+		cfg := &s3.WebsiteConfiguration{IndexDocument: &s3.IndexDocument{Suffix: aws.String(ws.IndexDocument)}}
+		if ws.ErrorDocument != "" {
+			cfg.ErrorDocument = &s3.ErrorDocument{Key: aws.String(ws.ErrorDocument)}
+		}
+		_, err := svc.PutBucketWebsite(&s3.PutBucketWebsiteInput{Bucket: &name, WebsiteConfiguration: cfg})
+		w.check("website", err)
+	}
+
+	if c.Referer != nil {
+		w.unmapped("referer: not supported by %s, use a bucket policy instead", t)
+	}
+
+	if e := w.encryption(c.Encryption); e != nil {
+		alg := s3.ServerSideEncryptionAes256
+		if e.Algorithm == SSEKMS {
+			alg = s3.ServerSideEncryptionAwsKms
+		}
+		_, err := svc.PutBucketEncryption(&s3.PutBucketEncryptionInput{
+			Bucket: &name,
+			ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+				Rules: []*s3.ServerSideEncryptionRule{{
+					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{SSEAlgorithm: aws.String(alg)},
+				}},
+			},
+		})
+		w.check("encryption", err)
+	}
+	return w.report
+}
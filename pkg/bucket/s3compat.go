@@ -0,0 +1,89 @@
+package bucket
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"obs-sync/pkg/object"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// s3CompatBucket MinIO、Ceph RGW 等 S3 兼容存储，region 为 s3compat:// 之后的地址
+type s3CompatBucket struct {
+	accessKey string
+	secretKey string
+}
+
+func (s s3CompatBucket) client(region string) (*s3.S3, *endpoint.Compat, error) {
+	c, err := endpoint.ParseCompat(region)
+	if err != nil {
+		return nil, nil, err
+	}
+	svc, _, err := object.S3CompatService(c, s.accessKey, s.secretKey)
+	return svc, c, err
+}
+
+// Create implements BucketOp.
+func (s s3CompatBucket) Create(region string, name string) error {
+	svc, c, err := s.client(region)
+	if err != nil {
+		return err
+	}
+	return s3CreateBucket(svc, c.Region, name)
+}
+
+// List implements BucketOp.
+func (s s3CompatBucket) List(region string) ([]BucketInfo, error) {
+	svc, c, err := s.client(region)
+	if err != nil {
+		return nil, err
+	}
+	res, err := svc.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, b := range res.Buckets {
+		name := aws.StringValue(b.Name)
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.S3Compat,
+			Name:     name,
+			Location: c.Region,
+			Domain:   c.BucketURL(name),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (s s3CompatBucket) GetConfig(region, name string) (*Config, error) {
+	svc, _, err := s.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3GetConfig(svc, models.S3Compat, name)
+}
+
+// PutConfig implements BucketOp.
+func (s s3CompatBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	svc, _, err := s.client(region)
+	if err != nil {
+		return nil, err
+	}
+	return s3PutConfig(svc, models.S3Compat, name, c), nil
+}
+
+func (s s3CompatBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.accessKey = accessKey
+	s.secretKey = secretKey
+	return s
+}
+
+func init() {
+	RegisterBucket(models.S3Compat, s3CompatBucket{})
+}
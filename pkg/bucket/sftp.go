@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+)
+
+// sftpBucket SFTP 服务器上的目录，region 为 host[:port][/path]
+type sftpBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (s sftpBucket) Create(region string, name string) error {
+	return object.CreateSftpDir(region, s.accessKey, s.secretKey, name)
+}
+
+// List implements BucketOp.
+func (s sftpBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListSftpDirs(region, s.accessKey, s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Sftp,
+			Name:     name,
+			Location: region,
+			Domain:   object.SftpBucketURL(region, name),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (s sftpBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of sftp is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (s sftpBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of sftp is not supported")
+}
+
+func (s sftpBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.accessKey = accessKey
+	s.secretKey = secretKey
+	return s
+}
+
+func init() {
+	RegisterBucket(models.Sftp, sftpBucket{})
+}
@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"obs-sync/models"
+	"obs-sync/pkg/object"
+)
+
+// webdavBucket WebDAV 共享下的目录，region 为 http(s)://host[:port]/path
+type webdavBucket struct {
+	accessKey string
+	secretKey string
+}
+
+// Create implements BucketOp.
+func (s webdavBucket) Create(region string, name string) error {
+	return object.CreateWebdavDir(region, s.accessKey, s.secretKey, name)
+}
+
+// List implements BucketOp.
+func (s webdavBucket) List(region string) ([]BucketInfo, error) {
+	names, err := object.ListWebdavDirs(region, s.accessKey, s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucketInfos []BucketInfo
+	for _, name := range names {
+		bucketInfos = append(bucketInfos, BucketInfo{
+			Type:     models.Webdav,
+			Name:     name,
+			Location: region,
+			Domain:   object.WebdavBucketURL(region, name),
+		})
+	}
+	if len(bucketInfos) == 0 {
+		return nil, fmt.Errorf("can't find any bucket")
+	}
+	return bucketInfos, nil
+}
+
+// GetConfig implements BucketOp.
+func (s webdavBucket) GetConfig(region, name string) (*Config, error) {
+	return nil, errors.New("bucket config of webdav is not supported")
+}
+
+// PutConfig implements BucketOp.
+func (s webdavBucket) PutConfig(region, name string, c *Config) ([]string, error) {
+	return nil, errors.New("bucket config of webdav is not supported")
+}
+
+func (s webdavBucket) SetAuth(accessKey, secretKey string) BucketOp {
+	s.accessKey = accessKey
+	s.secretKey = secretKey
+	return s
+}
+
+func init() {
+	RegisterBucket(models.Webdav, webdavBucket{})
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/url"
 	"obs-sync/models"
+	ep "obs-sync/pkg/endpoint"
 	"obs-sync/pkg/utils"
 	"regexp"
 	"runtime"
@@ -41,9 +42,24 @@ func tryCFR() {
 }
 
 func CreateStorage(info models.UriInfo) (object.ObjectStorage, error) {
+	// chaos+ 类型先按被包装的类型创建存储，再注入故障
+	if inner, domain, params, ok := object.SplitChaos(info.Type, info.BucketDomain); ok {
+		c, err := object.ParseChaos(params)
+		if err != nil {
+			return nil, err
+		}
+		info.Type, info.BucketDomain = inner, domain
+		store, err := CreateStorage(info)
+		if err != nil {
+			return nil, err
+		}
+		return object.WithChaos(store, c), nil
+	}
 	bucketDomain := info.BucketDomain
-	u, _ := url.Parse(bucketDomain)
-	endpoint := u.Path
+	endpoint := bucketDomain
+	if u, err := url.Parse(bucketDomain); err == nil && u.Scheme != "" && u.Host != "" {
+		endpoint = u.Host + u.Path
+	}
 	// 从联通云,s3的domain里提取bucket
 	if info.Type == models.Cuc || info.Type == models.S3 {
 		bucket := strings.Split(bucketDomain, ".")[0]
@@ -53,11 +69,15 @@ func CreateStorage(info models.UriInfo) (object.ObjectStorage, error) {
 
 	isS3PathTypeUrl := isS3PathType(endpoint)
 
-	// 本地文件列表，url列表直接复用domain
-	if info.Type == models.File || info.Type == models.Url {
+	// 本地文件列表，url列表，S3 兼容存储的桶地址，SFTP、WebDAV、HDFS 的目录和内存桶直接复用domain
+	if info.Type == models.File || info.Type == models.Url || info.Type == models.S3Compat ||
+		info.Type == models.Sftp || info.Type == models.Webdav || info.Type == models.Hdfs || info.Type == models.Mem {
 		endpoint = bucketDomain
 	} else if info.Scheme != "" {
 		endpoint = info.Scheme + "://" + endpoint
+	} else if scheme := ep.SchemeOf(info.Type, bucketDomain); scheme != "" {
+		// 按配置的域名模板选择 scheme，如内网域名使用 http
+		endpoint = scheme + "://" + endpoint
 	} else if supportHTTPS(info.Type, endpoint) {
 		endpoint = "https://" + endpoint
 	} else {
@@ -0,0 +1,120 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 访问方式
+const (
+	PathStyle    = "path"    // 桶名在路径中：host/bucket/key
+	VirtualStyle = "virtual" // 桶名在域名中：bucket.host/key
+)
+
+// 签名版本
+const (
+	SignatureV4 = "v4"
+	SignatureV2 = "v2" // 旧版 Ceph RGW 等只支持 V2 签名
+)
+
+const compatDefaultRegion = "us-east-1"
+
+// Compat S3 兼容存储的访问参数，由 s3compat:// 之后的地址解析得到：
+//
+//	[http://|https://]host[:port][?region=us-east-1&style=path|virtual&signature=v4|v2]
+//
+// 默认 https、路径方式和 V4 签名
+type Compat struct {
+	Scheme    string
+	Host      string
+	Region    string
+	Style     string
+	Signature string
+}
+
+// ParseCompat 解析 S3 兼容存储的地址
+func ParseCompat(s string) (*Compat, error) {
+	if s == "" {
+		return nil, fmt.Errorf("s3compat: endpoint is required, like s3compat://minio.local:9000")
+	}
+	raw := s
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("s3compat: invalid endpoint %s: %s", s, err)
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("s3compat: invalid endpoint %s, expected host[:port]", s)
+	}
+	c := &Compat{Scheme: u.Scheme, Host: u.Host}
+	if c.Scheme != "http" && c.Scheme != "https" {
+		return nil, fmt.Errorf("s3compat: unknown scheme %q", c.Scheme)
+	}
+	q := u.Query()
+	for k := range q {
+		switch k {
+		case "region", "style", "signature":
+		default:
+			return nil, fmt.Errorf("s3compat: unknown option %q", k)
+		}
+	}
+	c.Region = q.Get("region")
+	if c.Region == "" {
+		c.Region = compatDefaultRegion
+	}
+	switch c.Style = q.Get("style"); c.Style {
+	case "":
+		c.Style = PathStyle
+	case PathStyle, VirtualStyle:
+	default:
+		return nil, fmt.Errorf("s3compat: unknown style %q, expected path or virtual", c.Style)
+	}
+	switch c.Signature = q.Get("signature"); c.Signature {
+	case "":
+		c.Signature = SignatureV4
+	case SignatureV4, SignatureV2:
+	default:
+		return nil, fmt.Errorf("s3compat: unknown signature %q, expected v4 or v2", c.Signature)
+	}
+	return c, nil
+}
+
+// ParseCompatBucket 解析 BucketURL 生成的桶地址，路径中有桶名时以路径为准
+func ParseCompatBucket(s string) (*Compat, string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("s3compat: invalid bucket url %s: %s", s, err)
+	}
+	bucket := strings.Trim(u.Path, "/")
+	if bucket == "" && u.Query().Get("style") == VirtualStyle {
+		bucket, u.Host, _ = strings.Cut(u.Host, ".")
+	}
+	u.Path = ""
+	c, err := ParseCompat(u.String())
+	if err != nil {
+		return nil, "", err
+	}
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return nil, "", fmt.Errorf("s3compat: invalid bucket url %s", s)
+	}
+	return c, bucket, nil
+}
+
+// ServiceURL 列举、创建桶的地址
+func (c *Compat) ServiceURL() string {
+	return c.Scheme + "://" + c.Host
+}
+
+// BucketURL 桶的访问地址，带上区域、访问方式和签名版本，作为桶域名传给 worker。
+// 桶名带点时即使是虚拟主机方式也放在路径中，否则无法从域名中分出桶名
+func (c *Compat) BucketURL(name string) string {
+	u := url.URL{Scheme: c.Scheme, Host: c.Host, Path: "/" + name}
+	if c.Style == VirtualStyle && !strings.Contains(name, ".") {
+		u.Host, u.Path = name+"."+c.Host, ""
+	}
+	u.RawQuery = url.Values{"region": {c.Region}, "style": {c.Style}, "signature": {c.Signature}}.Encode()
+	return u.String()
+}
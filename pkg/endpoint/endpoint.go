@@ -0,0 +1,185 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"obs-sync/models"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Template 厂商的访问域名模板，{region} 和 {bucket} 替换为区域和桶名，可以带端口
+type Template struct {
+	// Service 列举、创建桶等服务接口的域名，如 obs-{region}.cucloud.cn
+	Service string `json:"service,omitempty"`
+	// Bucket 桶的访问域名，如 {bucket}.obs-{region}.cucloud.cn
+	Bucket string `json:"bucket,omitempty"`
+	// Scheme http 或 https
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// 预置的域名模板
+const (
+	Public     = "public"     // 公网域名
+	Internal   = "internal"   // 内网/VPC 域名，使用 http 避免加解密开销
+	Accelerate = "accelerate" // 全球加速域名
+)
+
+var presets = map[string]map[models.ResourceType]Template{
+	Public: {
+		models.Cos: {"cos.{region}.myqcloud.com", "{bucket}.cos.{region}.myqcloud.com", "https"},
+		models.Cuc: {"obs-{region}.cucloud.cn", "{bucket}.obs-{region}.cucloud.cn", "https"},
+		models.Obs: {"obs.{region}.myhuaweicloud.com", "{bucket}.obs.{region}.myhuaweicloud.com", "https"},
+		models.Oss: {"oss-{region}.aliyuncs.com", "{bucket}.oss-{region}.aliyuncs.com", "https"},
+		models.S3:  {"s3.{region}.amazonaws.com", "{bucket}.s3.{region}.amazonaws.com", "https"},
+		models.Bos: {"{region}.bcebos.com", "{bucket}.{region}.bcebos.com", "https"},
+		// 七牛的桶没有统一的访问域名，桶域名只用于标识桶和区域，下载使用桶绑定的域名
+		models.Kodo: {"uc.qiniuapi.com", "{bucket}.kodo-{region}.qiniucs.com", "https"},
+		// Azure 的区域为存储账号名，容器在路径中
+		models.Azblob: {"{region}.blob.core.windows.net", "{region}.blob.core.windows.net/{bucket}", "https"},
+		// GCS 的区域为项目 ID，桶名可以带点，使用路径方式访问
+		models.Gcs: {"storage.googleapis.com", "storage.googleapis.com/{bucket}", "https"},
+	},
+	Internal: {
+		models.Cos: {"cos-internal.{region}.tencentcos.cn", "{bucket}.cos-internal.{region}.tencentcos.cn", "http"},
+		models.Cuc: {"obs-{region}-internal.cucloud.cn", "{bucket}.obs-{region}-internal.cucloud.cn", "http"},
+		models.Obs: {"obs.{region}.myhuaweicloud.com", "{bucket}.obs.{region}.myhuaweicloud.com", "http"},
+		models.Oss: {"oss-{region}-internal.aliyuncs.com", "{bucket}.oss-{region}-internal.aliyuncs.com", "http"},
+	},
+	Accelerate: {
+		models.Cos: {"", "{bucket}.cos.accelerate.myqcloud.com", "https"},
+		models.Oss: {"", "{bucket}.oss-accelerate.aliyuncs.com", "https"},
+		models.S3:  {"", "{bucket}.s3-accelerate.amazonaws.com", "https"},
+	},
+}
+
+// Config 域名配置文件，Endpoints 的键为厂商类型，或“类型/区域”只对该区域生效
+//
+//	{"preset": "internal", "endpoints": {"oss/cn-hangzhou": {"scheme": "https"}}}
+type Config struct {
+	Preset    string              `json:"preset,omitempty"`
+	Endpoints map[string]Template `json:"endpoints,omitempty"`
+}
+
+var (
+	mu     sync.RWMutex
+	config Config
+)
+
+// Load 读取域名配置文件，path 为空时使用公网域名
+func Load(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var c Config
+	if err = json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("invalid endpoint config %s: %s", path, err)
+	}
+	return Set(c)
+}
+
+// Set 替换当前的域名配置
+func Set(c Config) error {
+	if _, ok := presets[c.Preset]; c.Preset != "" && !ok {
+		return fmt.Errorf("unknown endpoint preset %q", c.Preset)
+	}
+	for k, t := range c.Endpoints {
+		if t.Scheme != "" && t.Scheme != "http" && t.Scheme != "https" {
+			return fmt.Errorf("endpoint %s: unknown scheme %q", k, t.Scheme)
+		}
+	}
+	mu.Lock()
+	config = c
+	mu.Unlock()
+	return nil
+}
+
+// Get 厂商在该区域的域名模板：依次以预置模板、类型配置、区域配置中的非空项覆盖公网域名
+func Get(t models.ResourceType, region string) Template {
+	mu.RLock()
+	defer mu.RUnlock()
+	res := presets[Public][t]
+	if config.Preset != "" {
+		res = res.merge(presets[config.Preset][t])
+	}
+	res = res.merge(config.Endpoints[string(t)])
+	if region != "" {
+		res = res.merge(config.Endpoints[string(t)+"/"+region])
+	}
+	return res
+}
+
+func (t Template) merge(o Template) Template {
+	if o.Service != "" {
+		t.Service = o.Service
+	}
+	if o.Bucket != "" {
+		t.Bucket = o.Bucket
+	}
+	if o.Scheme != "" {
+		t.Scheme = o.Scheme
+	}
+	return t
+}
+
+// ServiceURL 服务接口的地址，如 https://obs-nxyc.cucloud.cn
+func (t Template) ServiceURL(region string) string {
+	return t.scheme() + "://" + strings.ReplaceAll(t.Service, "{region}", region)
+}
+
+// BucketDomain 桶的访问域名，不带 scheme
+func (t Template) BucketDomain(name, region string) string {
+	return strings.NewReplacer("{bucket}", name, "{region}", region).Replace(t.Bucket)
+}
+
+func (t Template) scheme() string {
+	if t.Scheme == "" {
+		return "https"
+	}
+	return t.Scheme
+}
+
+// SchemeOf 按域名匹配到的桶域名模板返回 scheme，未匹配时返回空。模板可以带路径，
+// 如 Azurite 的 127.0.0.1:10000/devstoreaccount1/{bucket}
+func SchemeOf(t models.ResourceType, domain string) string {
+	host := domain
+	mu.RLock()
+	var regions []string
+	for k := range config.Endpoints {
+		if typ, region, ok := strings.Cut(k, "/"); ok && typ == string(t) {
+			regions = append(regions, region)
+		}
+	}
+	mu.RUnlock()
+	// 区域配置优先于类型配置
+	for _, region := range regions {
+		if tpl := Get(t, region); tpl.match(host, region) {
+			return tpl.scheme()
+		}
+	}
+	if tpl := Get(t, ""); tpl.match(host, "") {
+		return tpl.scheme()
+	}
+	return ""
+}
+
+func (t Template) match(host, region string) bool {
+	if t.Bucket == "" {
+		return false
+	}
+	pattern := regexp.QuoteMeta(t.Bucket)
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{bucket}"), `[^/]+`)
+	if region != "" {
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{region}"), regexp.QuoteMeta(region))
+	} else {
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{region}"), `[^./]+`)
+	}
+	ok, _ := regexp.MatchString("^"+pattern+"$", host)
+	return ok
+}
@@ -0,0 +1,83 @@
+package endpoint
+
+import (
+	"obs-sync/models"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	defer Set(Config{})
+
+	tpl := Get(models.Cuc, "nxyc")
+	if got := tpl.ServiceURL("nxyc"); got != "https://obs-nxyc.cucloud.cn" {
+		t.Fatalf("service url: %s", got)
+	}
+	if got := tpl.BucketDomain("b", "nxyc"); got != "b.obs-nxyc.cucloud.cn" {
+		t.Fatalf("bucket domain: %s", got)
+	}
+	tpl = Get(models.S3, "us-west-2")
+	if got := tpl.ServiceURL("us-west-2"); got != "https://s3.us-west-2.amazonaws.com" {
+		t.Fatalf("s3 service url: %s", got)
+	}
+	if got := tpl.BucketDomain("b", "us-west-2"); got != "b.s3.us-west-2.amazonaws.com" || SchemeOf(models.S3, got) != "https" {
+		t.Fatalf("s3 bucket domain: %s", got)
+	}
+
+	err := Set(Config{Preset: Internal, Endpoints: map[string]Template{
+		"oss/cn-beijing": {Bucket: "{bucket}.oss.example.com:8443", Scheme: "https"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Get(models.Cuc, "nxyc").BucketDomain("b", "nxyc"); got != "b.obs-nxyc-internal.cucloud.cn" {
+		t.Fatalf("internal bucket domain: %s", got)
+	}
+	if got := SchemeOf(models.Cuc, "b.obs-nxyc-internal.cucloud.cn"); got != "http" {
+		t.Fatalf("internal scheme: %s", got)
+	}
+	if got := Get(models.Oss, "cn-beijing").BucketDomain("b", "cn-beijing"); got != "b.oss.example.com:8443" {
+		t.Fatalf("region override: %s", got)
+	}
+	if got := SchemeOf(models.Oss, "b.oss.example.com:8443"); got != "https" {
+		t.Fatalf("region scheme: %s", got)
+	}
+	if got := SchemeOf(models.Oss, "b.unknown.com"); got != "" {
+		t.Fatalf("unmatched scheme: %s", got)
+	}
+	if err = Set(Config{Preset: "nope"}); err == nil {
+		t.Fatal("unknown preset accepted")
+	}
+}
+
+func TestCompat(t *testing.T) {
+	c, err := ParseCompat("minio.local:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Scheme != "https" || c.Region != "us-east-1" || c.Style != PathStyle || c.Signature != SignatureV4 {
+		t.Fatalf("defaults: %+v", c)
+	}
+	for _, s := range []string{
+		"http://minio.local:9000?region=cn-1",
+		"https://rgw.local?style=virtual&signature=v2",
+	} {
+		c, err = ParseCompat(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"b", "logs.example.com"} {
+			got, bucket, err := ParseCompatBucket(c.BucketURL(name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bucket != name || *got != *c {
+				t.Fatalf("%s: round trip %+v %s", s, got, bucket)
+			}
+		}
+	}
+	for _, s := range []string{"", "ftp://h", "h/path", "h?style=x", "h?signature=v3", "h?foo=1"} {
+		if _, err = ParseCompat(s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
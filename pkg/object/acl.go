@@ -0,0 +1,174 @@
+package object
+
+import (
+	"fmt"
+	"obs-sync/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 授权给用户组时的 Grantee
+const (
+	AllUsers           = "AllUsers"
+	AuthenticatedUsers = "AuthenticatedUsers"
+)
+
+// 授权的权限
+const (
+	PermRead        = "READ"
+	PermWrite       = "WRITE"
+	PermReadACP     = "READ_ACP"
+	PermWriteACP    = "WRITE_ACP"
+	PermFullControl = "FULL_CONTROL"
+)
+
+// Grant is a permission granted to an account or a group.
+type Grant struct {
+	// Grantee 账号 ID，或者 AllUsers、AuthenticatedUsers 用户组
+	Grantee    string
+	Permission string
+}
+
+// ACL is the grant level access control list of a bucket or an object.
+type ACL struct {
+	Owner  string
+	Grants []Grant
+}
+
+// ACLer is implemented by storages able to read and write the grants of ACLs.
+type ACLer interface {
+	// GetBucketACL returns the ACL of the bucket, it's fetched once and cached.
+	GetBucketACL() (*ACL, error)
+	// GetACL returns the ACL of an object.
+	GetACL(key string) (*ACL, error)
+	// SetACL replaces the grants of an object.
+	SetACL(key string, acl *ACL) error
+}
+
+// CannedACL expands a canned ACL into grants.
+func CannedACL(owner string, canned models.CannedACLType) *ACL {
+	acl := &ACL{Owner: owner, Grants: []Grant{{owner, PermFullControl}}}
+	switch canned {
+	case models.PublicRead:
+		acl.Grants = append(acl.Grants, Grant{AllUsers, PermRead})
+	case models.PublicReadWrite:
+		acl.Grants = append(acl.Grants, Grant{AllUsers, PermRead}, Grant{AllUsers, PermWrite})
+	case models.AuthenticatedRead:
+		acl.Grants = append(acl.Grants, Grant{AuthenticatedUsers, PermRead})
+	}
+	return acl
+}
+
+// Canned compresses the grants into a canned ACL, exact is false when some
+// grants can't be expressed by it.
+func (a *ACL) Canned() (canned models.CannedACLType, exact bool) {
+	canned = models.Private
+	for _, c := range []models.CannedACLType{models.PublicReadWrite, models.PublicRead, models.AuthenticatedRead} {
+		if a.contains(CannedACL(a.Owner, c)) {
+			canned = c
+			break
+		}
+	}
+	return canned, a.Equal(CannedACL(a.Owner, canned))
+}
+
+func (a *ACL) contains(b *ACL) bool {
+	set := make(map[Grant]bool, len(a.Grants))
+	for _, g := range a.Grants {
+		set[g] = true
+	}
+	for _, g := range b.Grants {
+		if g.Grantee != b.Owner && !set[g] {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal 比较两个 ACL 的授权，忽略顺序和重复项
+func (a *ACL) Equal(b *ACL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Owner == b.Owner && a.String() == b.String()
+}
+
+func (a *ACL) String() string {
+	set := make(map[string]bool, len(a.Grants))
+	for _, g := range a.Grants {
+		set[g.Grantee+":"+g.Permission] = true
+	}
+	grants := make([]string, 0, len(set))
+	for g := range set {
+		grants = append(grants, g)
+	}
+	sort.Strings(grants)
+	return "owner=" + a.Owner + " " + strings.Join(grants, ",")
+}
+
+// Translate maps the grantees to the accounts of the dest, whose owner is
+// given. The source owner becomes the dest owner, grants to the other accounts
+// are dropped unless mapped in accounts, and the dropped ones are returned.
+func (a *ACL) Translate(owner string, accounts map[string]string) (*ACL, []string) {
+	res := &ACL{Owner: owner}
+	var dropped []string
+	for _, g := range a.Grants {
+		switch {
+		case g.Grantee == AllUsers || g.Grantee == AuthenticatedUsers:
+		case g.Grantee == a.Owner:
+			g.Grantee = owner
+		case accounts[g.Grantee] != "":
+			g.Grantee = accounts[g.Grantee]
+		default:
+			dropped = append(dropped, fmt.Sprintf("grant %s to %s dropped: account not mapped", g.Permission, g.Grantee))
+			continue
+		}
+		res.Grants = append(res.Grants, g)
+	}
+	return res, dropped
+}
+
+// groupOf 从各厂商用户组的 URI 中识别用户组，如 http://acs.amazonaws.com/groups/global/AllUsers
+func groupOf(uri string) (string, bool) {
+	switch {
+	case strings.HasSuffix(uri, AllUsers), uri == "Everyone":
+		return AllUsers, true
+	case strings.HasSuffix(uri, AuthenticatedUsers):
+		return AuthenticatedUsers, true
+	}
+	return "", false
+}
+
+// aclCache 缓存桶的 ACL，迁移期间桶的 ACL 视为不变，读取失败时下次重新读取
+type aclCache struct {
+	mu  sync.Mutex
+	acl *ACL
+}
+
+func (c *aclCache) get(fetch func() (*ACL, error)) (*ACL, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.acl != nil {
+		return c.acl, nil
+	}
+	acl, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.acl = acl
+	return acl, nil
+}
+
+// objectCanned 兼容 GetObjectAcl：对象与桶的授权相同时返回 Default
+func objectCanned(a ACLer, key string) (models.CannedACLType, error) {
+	acl, err := a.GetACL(key)
+	if err != nil {
+		return "", err
+	}
+	if bucket, err := a.GetBucketACL(); err == nil && bucket.Equal(acl) {
+		return models.Default, nil
+	}
+	canned, _ := acl.Canned()
+	return canned, nil
+}
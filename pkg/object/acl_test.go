@@ -0,0 +1,65 @@
+package object
+
+import (
+	"testing"
+
+	"obs-sync/models"
+)
+
+func TestACLCanned(t *testing.T) {
+	for _, c := range []models.CannedACLType{models.Private, models.PublicRead, models.PublicReadWrite, models.AuthenticatedRead} {
+		if canned, exact := CannedACL("alice", c).Canned(); canned != c || !exact {
+			t.Fatalf("canned %s: %s %v", c, canned, exact)
+		}
+	}
+	// 多出的授权无法用标准 ACL 表示，取能覆盖的最宽的标准 ACL
+	acl := CannedACL("alice", models.PublicRead)
+	acl.Grants = append(acl.Grants, Grant{"bob", PermRead}, Grant{AllUsers, PermRead})
+	if canned, exact := acl.Canned(); canned != models.PublicRead || exact {
+		t.Fatalf("canned with extra grants: %s %v", canned, exact)
+	}
+	acl = &ACL{Owner: "alice", Grants: []Grant{{AllUsers, PermWrite}}}
+	if canned, exact := acl.Canned(); canned != models.Private || exact {
+		t.Fatalf("canned without owner grant: %s %v", canned, exact)
+	}
+
+	// 忽略顺序和重复项
+	a := &ACL{"alice", []Grant{{"alice", PermFullControl}, {AllUsers, PermRead}}}
+	b := &ACL{"alice", []Grant{{AllUsers, PermRead}, {"alice", PermFullControl}, {AllUsers, PermRead}}}
+	if !a.Equal(b) || a.Equal(&ACL{Owner: "bob", Grants: b.Grants}) || a.Equal(nil) || !(*ACL)(nil).Equal(nil) {
+		t.Fatal("equal")
+	}
+}
+
+func TestACLTranslate(t *testing.T) {
+	acl := &ACL{Owner: "alice", Grants: []Grant{
+		{"alice", PermFullControl},
+		{AllUsers, PermRead},
+		{"carol", PermReadACP},
+		{"dave", PermWrite},
+	}}
+	res, dropped := acl.Translate("bob", map[string]string{"carol": "carol2"})
+	want := &ACL{Owner: "bob", Grants: []Grant{{"bob", PermFullControl}, {AllUsers, PermRead}, {"carol2", PermReadACP}}}
+	if !res.Equal(want) {
+		t.Fatalf("translate: %s", res)
+	}
+	if len(dropped) != 1 || dropped[0] != "grant WRITE to dave dropped: account not mapped" {
+		t.Fatalf("dropped: %v", dropped)
+	}
+	if canned, exact := res.Canned(); canned != models.PublicRead || exact {
+		t.Fatalf("canned of translated: %s %v", canned, exact)
+	}
+
+	for uri, want := range map[string]string{
+		"http://acs.amazonaws.com/groups/global/AllUsers":           AllUsers,
+		"http://acs.amazonaws.com/groups/global/AuthenticatedUsers": AuthenticatedUsers,
+		"Everyone": AllUsers,
+	} {
+		if g, ok := groupOf(uri); !ok || g != want {
+			t.Fatalf("group of %s: %s", uri, g)
+		}
+	}
+	if _, ok := groupOf("http://cam.qcloud.com/groups/global/LogDelivery"); ok {
+		t.Fatal("group of LogDelivery")
+	}
+}
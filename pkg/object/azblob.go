@@ -0,0 +1,475 @@
+//go:build !noazblob
+// +build !noazblob
+
+package object
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"obs-sync/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	azblobVersion           = "2020-10-02"
+	azblobChecksumKeyPrefix = "x-ms-meta-"
+)
+
+// azblobService Azure Blob 的 REST 客户端，使用 SharedKey 签名，账号名和密钥即 ak 和 sk
+type azblobService struct {
+	base    string // 账号的服务地址，如 https://acct.blob.core.windows.net 或 Azurite 的 http://127.0.0.1:10000/devstoreaccount1
+	account string
+	key     []byte
+}
+
+type azblobError struct {
+	Status  int
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *azblobError) Error() string {
+	return fmt.Sprintf("azblob: status %d, %s: %s", e.Status, e.Code, strings.TrimSpace(e.Message))
+}
+
+func newAzblobService(service, account, key string) (*azblobService, error) {
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("azblob: invalid account key: %s", err)
+	}
+	if !strings.Contains(service, "://") {
+		service = "https://" + service
+	}
+	return &azblobService{strings.TrimRight(service, "/"), account, k}, nil
+}
+
+// sign SharedKey 签名
+func (s *azblobService) sign(req *http.Request) {
+	h := req.Header
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	toSign := []string{req.Method, h.Get("Content-Encoding"), h.Get("Content-Language"), length,
+		h.Get("Content-MD5"), h.Get("Content-Type"), "", h.Get("If-Modified-Since"), h.Get("If-Match"),
+		h.Get("If-None-Match"), h.Get("If-Unmodified-Since"), h.Get("Range")}
+
+	var names []string
+	for k := range h {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		toSign = append(toSign, k+":"+strings.TrimSpace(h.Get(k)))
+	}
+
+	resource := "/" + s.account + req.URL.EscapedPath()
+	q := req.URL.Query()
+	params := make([]string, 0, len(q))
+	for k := range q {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		vs := q[k]
+		sort.Strings(vs)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(vs, ",")
+	}
+	toSign = append(toSign, resource)
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(strings.Join(toSign, "\n")))
+	h.Set("Authorization", "SharedKey "+s.account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// request 发送请求，path 为服务地址之后的路径，返回非 2xx 时解析错误
+func (s *azblobService) request(method, path string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u := s.base + (&url.URL{Path: path}).EscapedPath()
+	if len(query) > 0 {
+		u += "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azblobVersion)
+	s.sign(req)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer cleanup(resp)
+		e := &azblobError{Status: resp.StatusCode}
+		if data, _ := ioutil.ReadAll(resp.Body); len(data) > 0 && xml.Unmarshal(data, e) != nil {
+			e.Message = string(data)
+		}
+		if e.Code == "" {
+			e.Code = resp.Header.Get("x-ms-error-code")
+		}
+		return nil, e
+	}
+	return resp, nil
+}
+
+func (s *azblobService) call(method, path string, query url.Values, body []byte, header http.Header, out interface{}) error {
+	resp, err := s.request(method, path, query, body, header)
+	if err != nil {
+		return err
+	}
+	defer cleanup(resp)
+	if out != nil {
+		if err = xml.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("azblob: invalid response: %s", err)
+		}
+	}
+	return nil
+}
+
+// ListAzblobContainers 列举账号下的容器，service 为账号的服务地址
+func ListAzblobContainers(service, account, key string) ([]string, error) {
+	s, err := newAzblobService(service, account, key)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	marker := ""
+	for {
+		var res struct {
+			Containers []string `xml:"Containers>Container>Name"`
+			NextMarker string   `xml:"NextMarker"`
+		}
+		q := url.Values{"comp": {"list"}}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		if err = s.call("GET", "/", q, nil, nil, &res); err != nil {
+			return nil, err
+		}
+		names = append(names, res.Containers...)
+		if marker = res.NextMarker; marker == "" {
+			return names, nil
+		}
+	}
+}
+
+// CreateAzblobContainer 创建私有容器，已存在时不报错
+func CreateAzblobContainer(service, account, key, name string) error {
+	s, err := newAzblobService(service, account, key)
+	if err != nil {
+		return err
+	}
+	return s.createContainer(name)
+}
+
+func (s *azblobService) createContainer(name string) error {
+	err := s.call("PUT", "/"+name, url.Values{"restype": {"container"}}, nil, nil, nil)
+	if e, ok := err.(*azblobError); ok && e.Code == "ContainerAlreadyExists" {
+		err = nil
+	}
+	return err
+}
+
+type azblobClient struct {
+	azblobService
+	container    string
+	markers      sync.Map // 上次列举的最后一个 key -> 续页标记
+	uploads      sync.Map // uploadID -> *PutOptions，提交块列表时写入属性
+	checkSumKey  string
+	sumAlgorithm algorithm
+}
+
+func (a *azblobClient) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = azblobChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	a.checkSumKey = meta
+	return nil
+}
+
+func (a *azblobClient) IsSetMd5(flag bool) error {
+	a.sumAlgorithm = checksumCrc32
+	if flag {
+		a.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (a *azblobClient) String() string {
+	return fmt.Sprintf("azblob://%s/%s/", a.account, a.container)
+}
+
+func (a *azblobClient) blob(key string) string {
+	return "/" + a.container + "/" + key
+}
+
+func (a *azblobClient) Create() error {
+	return a.createContainer(a.container)
+}
+
+func (a *azblobClient) Head(key string) (Object, error) {
+	resp, err := a.request("HEAD", a.blob(key), nil, nil, nil)
+	if err != nil {
+		var e *azblobError
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return nil, notExist(key)
+		}
+		return nil, err
+	}
+	defer cleanup(resp)
+	mtime, _ := time.Parse(http.TimeFormat, resp.Header.Get("Last-Modified"))
+	return &objInfo{
+		obj{
+			key,
+			resp.ContentLength,
+			mtime,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Azblob, resp.Header.Get("x-ms-access-tier")),
+		},
+		headerMetadata(resp.Header, azblobChecksumKeyPrefix),
+	}, nil
+}
+
+func (a *azblobClient) GetChecksum(key string) (string, error) {
+	resp, err := a.request("HEAD", a.blob(key), nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup(resp)
+	return resp.Header.Get(a.checkSumKey), nil
+}
+
+func (a *azblobClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if off > 0 || limit > 0 {
+		if limit > 0 {
+			header.Set("x-ms-range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+		} else {
+			header.Set("x-ms-range", fmt.Sprintf("bytes=%d-", off))
+		}
+	}
+	resp, err := a.request("GET", a.blob(key), nil, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// blobHeader 块 blob 的访问层、HTTP 属性和用户元数据
+func (a *azblobClient) blobHeader(o *PutOptions, checksum string) http.Header {
+	h := userMetaHeader(o.Meta, azblobChecksumKeyPrefix)
+	if checksum != "" {
+		h.Set(azblobChecksumKeyPrefix+a.sumAlgorithm.String(), checksum)
+	}
+	if m := o.Meta; m != nil {
+		for k, v := range map[string]string{
+			"x-ms-blob-content-type":        m.ContentType,
+			"x-ms-blob-content-encoding":    m.ContentEncoding,
+			"x-ms-blob-cache-control":       m.CacheControl,
+			"x-ms-blob-content-disposition": m.ContentDisposition,
+		} {
+			if v != "" {
+				h.Set(k, v)
+			}
+		}
+	}
+	if tier := fromStorageClass(models.Azblob, o.StorageClass); tier != "" {
+		h.Set("x-ms-access-tier", tier)
+	}
+	return h
+}
+
+// Put 上传块 blob，Azure 只有容器级别的公开访问，acl 被忽略
+func (a *azblobClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(bytes.NewReader(data), a.sumAlgorithm)
+	}
+	h := a.blobHeader(o, checksum)
+	h.Set("x-ms-blob-type", "BlockBlob")
+	return a.call("PUT", a.blob(key), nil, data, h, nil)
+}
+
+func (a *azblobClient) Delete(key string) error {
+	err := a.call("DELETE", a.blob(key), nil, nil, nil, nil)
+	if e, ok := err.(*azblobError); ok && e.Status == http.StatusNotFound {
+		err = nil
+	}
+	return err
+}
+
+type azblobList struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			LastModified  string `xml:"Last-Modified"`
+			ContentLength int64  `xml:"Content-Length"`
+			AccessTier    string `xml:"AccessTier"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// List Azure 的续页标记不透明，记下每页最后一个 key 对应的标记，未知的 marker 从头列举并跳过
+func (a *azblobClient) List(prefix, marker string, limit int64) ([]Object, error) {
+	token := ""
+	if marker != "" {
+		if t, ok := a.markers.Load(marker); ok {
+			token = t.(string)
+		}
+	}
+	var objs []Object
+	consumed := true // 最后一页的对象全部返回时续页标记才能复用
+	for {
+		q := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix},
+			"maxresults": {strconv.FormatInt(limit, 10)}}
+		if token != "" {
+			q.Set("marker", token)
+		}
+		var res azblobList
+		if err := a.call("GET", "/"+a.container, q, nil, nil, &res); err != nil {
+			return nil, err
+		}
+		for _, b := range res.Blobs {
+			if b.Name <= marker {
+				continue
+			}
+			if int64(len(objs)) >= limit {
+				consumed = false
+				break
+			}
+			t, _ := time.Parse(http.TimeFormat, b.Properties.LastModified)
+			objs = append(objs, &obj{b.Name, b.Properties.ContentLength, t, strings.HasSuffix(b.Name, "/"),
+				toStorageClass(models.Azblob, b.Properties.AccessTier)})
+		}
+		token = res.NextMarker
+		if token == "" || int64(len(objs)) >= limit {
+			break
+		}
+	}
+	if n := len(objs); n > 0 && token != "" && consumed {
+		a.markers.Store(objs[n-1].Key(), token)
+	}
+	return objs, nil
+}
+
+func (a *azblobClient) ListAll(prefix, marker string) (<-chan Object, error) {
+	return nil, notSupported
+}
+
+// CreateMultipartUpload 块 blob 不需要初始化，上传的块在提交块列表前不可见
+func (a *azblobClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	uploadID := hex.EncodeToString(id)
+	a.uploads.Store(uploadID, applyPutOptions(opts))
+	return &MultipartUpload{UploadID: uploadID, MinPartSize: minSize, MaxCount: 50000}, nil
+}
+
+// blockID 同一个 blob 的块 ID 长度必须相同
+func blockID(uploadID string, num int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%05d", uploadID, num)))
+}
+
+func (a *azblobClient) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	id := blockID(uploadID, num)
+	err := a.call("PUT", a.blob(key), url.Values{"comp": {"block"}, "blockid": {id}}, body, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Part{Num: num, Size: len(body), ETag: id}, nil
+}
+
+// AbortUpload 未提交的块一周后自动清理
+func (a *azblobClient) AbortUpload(key string, uploadID string) {
+	a.uploads.Delete(uploadID)
+}
+
+func (a *azblobClient) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	var list struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}
+	for _, p := range parts {
+		list.Latest = append(list.Latest, p.ETag)
+	}
+	body, err := xml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	o := &PutOptions{}
+	if v, ok := a.uploads.Load(uploadID); ok {
+		o = v.(*PutOptions)
+	}
+	h := a.blobHeader(o, o.Checksum)
+	h.Set("Content-Type", "application/xml")
+	if err = a.call("PUT", a.blob(key), url.Values{"comp": {"blocklist"}}, body, h, nil); err != nil {
+		return err
+	}
+	a.uploads.Delete(uploadID)
+	return nil
+}
+
+// ListUploads Azure 没有未完成上传的概念，返回 notSupported
+func (a *azblobClient) ListUploads(marker string) ([]*PendingPart, string, error) {
+	return nil, "", notSupported
+}
+
+// GetObjectAcl Azure 只有容器级别的公开访问
+func (a *azblobClient) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return models.Default, nil
+}
+
+// newAzblob 地址为 https://[ACCOUNT].blob.core.windows.net/[CONTAINER]，
+// 或 Azurite 的 http://127.0.0.1:10000/[ACCOUNT]/[CONTAINER]，ak 为账号名，sk 为账号密钥
+func newAzblob(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	i := strings.LastIndex(endpoint, "/")
+	if i < 0 || strings.HasSuffix(endpoint[:i], ":/") {
+		return nil, fmt.Errorf("invalid endpoint %s: no container", endpoint)
+	}
+	s, err := newAzblobService(endpoint[:i], accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &azblobClient{
+		azblobService: *s,
+		container:     endpoint[i+1:],
+		checkSumKey:   azblobChecksumKeyPrefix + checksumCrc32.String(),
+		sumAlgorithm:  checksumCrc32,
+	}, nil
+}
+
+func init() {
+	Register(models.Azblob, newAzblob)
+}
@@ -0,0 +1,195 @@
+package object
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Azurite 的默认账号
+const (
+	azuriteAccount = "devstoreaccount1"
+	azuriteKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// fakeAzblob 本地的 Azure Blob 替身，按 Azurite 的路径方式访问，校验签名并在内存中保存 blob
+type fakeAzblob struct {
+	t      *testing.T
+	blobs  map[string][]byte
+	tiers  map[string]string
+	blocks map[string][]byte
+}
+
+func (f *fakeAzblob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	clone := httptest.NewRequest(r.Method, r.URL.RequestURI(), nil)
+	clone.ContentLength = r.ContentLength
+	for k, vs := range r.Header {
+		if k != "Authorization" {
+			clone.Header[k] = vs
+		}
+	}
+	s, _ := newAzblobService("", azuriteAccount, azuriteKey)
+	s.sign(clone)
+	if got := r.Header.Get("Authorization"); got != clone.Header.Get("Authorization") {
+		f.t.Errorf("%s %s: bad signature %s", r.Method, r.URL, got)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/"+azuriteAccount+"/bkt/")
+	q := r.URL.Query()
+	switch {
+	case q.Get("restype") == "container" && q.Get("comp") == "list":
+		// 每页最多 maxresults 个，续页标记为下一页的起始序号
+		var keys []string
+		for k := range f.blobs {
+			if strings.HasPrefix(k, q.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		start, _ := strconv.Atoi(q.Get("marker"))
+		n, _ := strconv.Atoi(q.Get("maxresults"))
+		end := start + n
+		next := strconv.Itoa(end)
+		if end >= len(keys) {
+			end, next = len(keys), ""
+		}
+		fmt.Fprint(w, "<EnumerationResults><Blobs>")
+		for _, k := range keys[start:end] {
+			fmt.Fprintf(w, "<Blob><Name>%s</Name><Properties><Last-Modified>Mon, 02 Jan 2006 15:04:05 GMT</Last-Modified>"+
+				"<Content-Length>%d</Content-Length><AccessTier>%s</AccessTier></Properties></Blob>", k, len(f.blobs[k]), f.tiers[k])
+		}
+		fmt.Fprintf(w, "</Blobs><NextMarker>%s</NextMarker></EnumerationResults>", next)
+	case q.Get("comp") == "block":
+		f.blocks[q.Get("blockid")] = body
+	case q.Get("comp") == "blocklist":
+		var list struct{ Latest []string }
+		xml.Unmarshal(body, &list)
+		var data []byte
+		for _, id := range list.Latest {
+			data = append(data, f.blocks[id]...)
+		}
+		f.blobs[key], f.tiers[key] = data, r.Header.Get("x-ms-access-tier")
+	case r.Method == "PUT":
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			f.t.Errorf("put %s: not a block blob", key)
+		}
+		f.blobs[key], f.tiers[key] = body, r.Header.Get("x-ms-access-tier")
+	case r.Method == "DELETE":
+		if _, ok := f.blobs[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, key)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		data, ok := f.blobs[key]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if rng := r.Header.Get("x-ms-range"); rng != "" {
+			r.Header.Set("Range", rng)
+		}
+		w.Header().Set("x-ms-access-tier", f.tiers[key])
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func TestAzblob(t *testing.T) {
+	f := &fakeAzblob{t, map[string][]byte{}, map[string]string{}, map[string][]byte{}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	s, err := CreateStorage(models.Azblob, srv.URL+"/"+azuriteAccount+"/bkt", azuriteAccount, azuriteKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"a/1", "a/2 +中", "a/3", "a/4", "b"}
+	for _, k := range keys {
+		if err = s.Put(k, bytes.NewReader([]byte("hello "+k)), models.Default, WithStorageClass(models.InfrequentAccess)); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+
+	// 续页标记按上一页最后一个 key 复用，未知的 marker 从头跳过
+	var got []string
+	marker := ""
+	for {
+		objs, err := s.List("a/", marker, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objs) == 0 {
+			break
+		}
+		for _, o := range objs {
+			got = append(got, o.Key())
+			if StorageClassOf(o) != models.InfrequentAccess {
+				t.Fatalf("tier of %s: %s", o.Key(), StorageClassOf(o))
+			}
+		}
+		marker = objs[len(objs)-1].Key()
+	}
+	if strings.Join(got, ",") != strings.Join(keys[:4], ",") {
+		t.Fatalf("list: %v", got)
+	}
+	if objs, err := s.List("a/", "a/2 +中", 1); err != nil || len(objs) != 1 || objs[0].Key() != "a/3" {
+		t.Fatalf("list from unknown marker: %v %v", objs, err)
+	}
+
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	if o, err := s.Head("b"); err != nil || o.Size() != int64(len("hello b")) {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+
+	up, err := s.CreateMultipartUpload("big", 5, models.Default, WithStorageClass(models.Archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []*Part
+	for i, p := range []string{"part1-", "part2"} {
+		part, err := s.UploadPart("big", up.UploadID, i+1, []byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if string(f.blobs["big"]) != "part1-part2" || f.tiers["big"] != "Archive" {
+		t.Fatalf("block list: %q %s", f.blobs["big"], f.tiers["big"])
+	}
+
+	if err = s.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("b"); err != nil {
+		t.Fatalf("delete missing: %s", err)
+	}
+}
@@ -0,0 +1,571 @@
+//go:build !nobos
+// +build !nobos
+
+package object
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"obs-sync/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bosChecksumKeyPrefix = "x-bce-meta-"
+
+// bosService 百度云 BOS 的 REST 客户端，使用 bce-auth-v1 签名，桶操作与对象操作共用
+type bosService struct {
+	scheme    string
+	host      string
+	base      string // 路径方式访问时为 /bucket
+	accessKey string
+	secretKey string
+}
+
+type bosError struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *bosError) Error() string {
+	return fmt.Sprintf("bos: status %d, %s: %s", e.Status, e.Code, e.Message)
+}
+
+// bosEncode 按 RFC 3986 编码，只保留非保留字符
+func bosEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || keepSlash && c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func bosQuery(q url.Values) string {
+	var params []string
+	for k, vs := range q {
+		for _, v := range vs {
+			params = append(params, bosEncode(k, false)+"="+bosEncode(v, false))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// bosSign 使用 bce-auth-v1 签名，签名 host、Content-Type、Content-MD5 和所有 x-bce- 头
+func bosSign(req *http.Request, accessKey, secretKey string, now time.Time) {
+	if accessKey == "" {
+		return
+	}
+	timestamp := now.UTC().Format("2006-01-02T15:04:05Z")
+	req.Header.Set("x-bce-date", timestamp)
+	prefix := fmt.Sprintf("bce-auth-v1/%s/%s/1800", accessKey, timestamp)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		k = strings.ToLower(k)
+		if k == "content-type" || k == "content-md5" || strings.HasPrefix(k, "x-bce-") {
+			headers[k] = strings.TrimSpace(vs[0])
+		}
+	}
+	names := make([]string, 0, len(headers))
+	canonical := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		canonical = append(canonical, bosEncode(k, false)+":"+bosEncode(headers[k], false))
+	}
+	sort.Strings(canonical)
+
+	request := req.Method + "\n" + req.URL.EscapedPath() + "\n" + req.URL.RawQuery + "\n" + strings.Join(canonical, "\n")
+	key := hmacSHA256Hex([]byte(secretKey), prefix)
+	sig := hmacSHA256Hex([]byte(key), request)
+	req.Header.Set("Authorization", prefix+"/"+strings.Join(names, ";")+"/"+sig)
+}
+
+func hmacSHA256Hex(key []byte, data string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// request 发送请求，key 为空时访问桶（或服务）本身，返回非 2xx 时解析错误
+func (s *bosService) request(method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	path := s.base + "/" + key
+	if key == "" && s.base != "" {
+		path = s.base
+	}
+	u := &url.URL{Scheme: s.scheme, Host: s.host, Path: path, RawPath: bosEncode(path, true), RawQuery: bosQuery(query)}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	bosSign(req, s.accessKey, s.secretKey, time.Now())
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer cleanup(resp)
+		e := &bosError{Status: resp.StatusCode}
+		if data, _ := ioutil.ReadAll(resp.Body); len(data) > 0 && json.Unmarshal(data, e) != nil {
+			e.Message = string(data)
+		}
+		if e.Code == "" {
+			e.Code = http.StatusText(resp.StatusCode)
+		}
+		return nil, e
+	}
+	return resp, nil
+}
+
+// call 发送请求并解析 JSON 响应，out 为空时丢弃响应
+func (s *bosService) call(method, key string, query url.Values, in interface{}, out interface{}) (http.Header, error) {
+	var body io.Reader
+	var size int64
+	header := http.Header{}
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body, size = bytes.NewReader(data), int64(len(data))
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.request(method, key, query, body, size, header)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup(resp)
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("bos: invalid response: %s", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// BosBucket 列举到的 BOS 桶
+type BosBucket struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+// ListBosBuckets 列举账号下所有区域的桶，endpoint 为服务地址，如 https://bj.bcebos.com
+func ListBosBuckets(endpoint, accessKey, secretKey string) ([]BosBucket, error) {
+	s, err := newBosService(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Buckets []BosBucket `json:"buckets"`
+	}
+	_, err = s.call("GET", "", nil, nil, &res)
+	return res.Buckets, err
+}
+
+// CreateBosBucket 在服务地址所在的区域创建桶
+func CreateBosBucket(endpoint, accessKey, secretKey, name string) error {
+	s, err := newBosService(endpoint, accessKey, secretKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.call("PUT", name, nil, nil, nil)
+	return err
+}
+
+func newBosService(endpoint, accessKey, secretKey string) (*bosService, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	uri, err := url.ParseRequestURI(strings.TrimRight(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %s", endpoint, err)
+	}
+	return &bosService{scheme: uri.Scheme, host: uri.Host, base: uri.Path, accessKey: accessKey, secretKey: secretKey}, nil
+}
+
+type bosClient struct {
+	bosService
+	bucket       string
+	checkSumKey  string
+	sumAlgorithm algorithm
+	bucketACL    aclCache
+}
+
+func (b *bosClient) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = bosChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	b.checkSumKey = meta
+	return nil
+}
+
+func (b *bosClient) IsSetMd5(flag bool) error {
+	b.sumAlgorithm = checksumCrc32
+	if flag {
+		b.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (b *bosClient) String() string {
+	return fmt.Sprintf("bos://%s/", b.bucket)
+}
+
+func (b *bosClient) Create() error {
+	if _, err := b.List("", "", 1); err == nil {
+		return nil
+	}
+	_, err := b.call("PUT", "", nil, nil, nil)
+	if e, ok := err.(*bosError); ok && e.Code == "BucketAlreadyExists" {
+		err = nil
+	}
+	return err
+}
+
+func (b *bosClient) Head(key string) (Object, error) {
+	resp, err := b.request("HEAD", key, nil, nil, 0, nil)
+	if err != nil {
+		var e *bosError
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return nil, notExist(key)
+		}
+		return nil, err
+	}
+	defer cleanup(resp)
+	mtime, _ := time.Parse(http.TimeFormat, resp.Header.Get("Last-Modified"))
+	return &objInfo{
+		obj{
+			key,
+			resp.ContentLength,
+			mtime,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Bos, resp.Header.Get("x-bce-storage-class")),
+		},
+		headerMetadata(resp.Header, bosChecksumKeyPrefix),
+	}, nil
+}
+
+func (b *bosClient) GetChecksum(key string) (string, error) {
+	resp, err := b.request("HEAD", key, nil, nil, 0, nil)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup(resp)
+	return resp.Header.Get(b.checkSumKey), nil
+}
+
+func (b *bosClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if off > 0 || limit > 0 {
+		if limit > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+		} else {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+		}
+	}
+	resp, err := b.request("GET", key, nil, nil, 0, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// putHeader 对象的 ACL、存储类型、HTTP 头和用户元数据
+func (b *bosClient) putHeader(acl models.CannedACLType, o *PutOptions, checksum string) http.Header {
+	h := userMetaHeader(o.Meta, bosChecksumKeyPrefix)
+	if checksum != "" {
+		h.Set(bosChecksumKeyPrefix+b.sumAlgorithm.String(), checksum)
+	}
+	if m := o.Meta; m != nil {
+		for k, v := range map[string]string{
+			"Content-Type":        m.ContentType,
+			"Content-Encoding":    m.ContentEncoding,
+			"Cache-Control":       m.CacheControl,
+			"Content-Disposition": m.ContentDisposition,
+			"Expires":             m.Expires,
+		} {
+			if v != "" {
+				h.Set(k, v)
+			}
+		}
+	}
+	if sc := fromStorageClass(models.Bos, o.StorageClass); sc != "" {
+		h.Set("x-bce-storage-class", sc)
+	}
+	if acl != models.Default && acl != "" {
+		h.Set("x-bce-acl", string(acl))
+	}
+	return h
+}
+
+func (b *bosClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
+	body, ok := in.(io.ReadSeeker)
+	if !ok {
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	size, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(body, b.sumAlgorithm)
+	}
+	resp, err := b.request("PUT", key, nil, body, size, b.putHeader(acl, o, checksum))
+	if err != nil {
+		return err
+	}
+	cleanup(resp)
+	return nil
+}
+
+func (b *bosClient) Delete(key string) error {
+	_, err := b.call("DELETE", key, nil, nil, nil)
+	if e, ok := err.(*bosError); ok && e.Status == http.StatusNotFound {
+		err = nil
+	}
+	return err
+}
+
+func (b *bosClient) List(prefix, marker string, limit int64) ([]Object, error) {
+	q := url.Values{"prefix": {prefix}, "marker": {marker}, "maxKeys": {strconv.FormatInt(limit, 10)}}
+	var res struct {
+		Contents []struct {
+			Key          string `json:"key"`
+			LastModified string `json:"lastModified"`
+			Size         int64  `json:"size"`
+			StorageClass string `json:"storageClass"`
+		} `json:"contents"`
+	}
+	if _, err := b.call("GET", "", q, nil, &res); err != nil {
+		return nil, err
+	}
+	objs := make([]Object, len(res.Contents))
+	for i, o := range res.Contents {
+		t, _ := time.Parse(time.RFC3339, o.LastModified)
+		objs[i] = &obj{o.Key, o.Size, t, strings.HasSuffix(o.Key, "/"), toStorageClass(models.Bos, o.StorageClass)}
+	}
+	return objs, nil
+}
+
+func (b *bosClient) ListAll(prefix, marker string) (<-chan Object, error) {
+	return nil, notSupported
+}
+
+func (b *bosClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
+	resp, err := b.request("POST", key, url.Values{"uploads": {""}}, nil, 0, b.putHeader(acl, o, o.Checksum))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup(resp)
+	var res struct {
+		UploadID string `json:"uploadId"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("bos: invalid response: %s", err)
+	}
+	return &MultipartUpload{UploadID: res.UploadID, MinPartSize: minSize, MaxCount: 10000}, nil
+}
+
+func (b *bosClient) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	q := url.Values{"partNumber": {strconv.Itoa(num)}, "uploadId": {uploadID}}
+	resp, err := b.request("PUT", key, q, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return nil, err
+	}
+	cleanup(resp)
+	return &Part{Num: num, Size: len(body), ETag: strings.Trim(resp.Header.Get("ETag"), `"`)}, nil
+}
+
+func (b *bosClient) AbortUpload(key string, uploadID string) {
+	_, _ = b.call("DELETE", key, url.Values{"uploadId": {uploadID}}, nil, nil)
+}
+
+type bosPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"eTag"`
+}
+
+func (b *bosClient) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	in := struct {
+		Parts []bosPart `json:"parts"`
+	}{}
+	for _, p := range parts {
+		in.Parts = append(in.Parts, bosPart{p.Num, p.ETag})
+	}
+	_, err := b.call("POST", key, url.Values{"uploadId": {uploadID}}, in, nil)
+	return err
+}
+
+func (b *bosClient) ListUploads(marker string) ([]*PendingPart, string, error) {
+	var res struct {
+		Uploads []struct {
+			Key       string `json:"key"`
+			UploadID  string `json:"uploadId"`
+			Initiated string `json:"initiated"`
+		} `json:"uploads"`
+		IsTruncated   bool   `json:"isTruncated"`
+		NextKeyMarker string `json:"nextKeyMarker"`
+	}
+	q := url.Values{"uploads": {""}}
+	if marker != "" {
+		q.Set("keyMarker", marker)
+	}
+	if _, err := b.call("GET", "", q, nil, &res); err != nil {
+		return nil, "", err
+	}
+	parts := make([]*PendingPart, len(res.Uploads))
+	for i, u := range res.Uploads {
+		t, _ := time.Parse(time.RFC3339, u.Initiated)
+		parts[i] = &PendingPart{u.Key, u.UploadID, t}
+	}
+	if !res.IsTruncated {
+		res.NextKeyMarker = ""
+	}
+	return parts, res.NextKeyMarker, nil
+}
+
+// bosACLDoc BOS 的 ACL 以 JSON 表示，Grantee 的 id 为 * 时表示所有用户
+type bosACLDoc struct {
+	Owner             *bosGrantee `json:"owner,omitempty"`
+	AccessControlList []bosGrant  `json:"accessControlList"`
+}
+
+type bosGrantee struct {
+	ID string `json:"id"`
+}
+
+type bosGrant struct {
+	Grantee    []bosGrantee `json:"grantee"`
+	Permission []string     `json:"permission"`
+}
+
+// bosACL 转换为通用 ACL，拥有者始终有完全控制权限
+func bosACL(owner string, doc *bosACLDoc) *ACL {
+	acl := &ACL{Owner: owner, Grants: []Grant{{owner, PermFullControl}}}
+	for _, g := range doc.AccessControlList {
+		for _, grantee := range g.Grantee {
+			id := grantee.ID
+			if id == "*" {
+				id = AllUsers
+			}
+			for _, p := range g.Permission {
+				acl.Grants = append(acl.Grants, Grant{id, p})
+			}
+		}
+	}
+	return acl
+}
+
+func (b *bosClient) GetBucketACL() (*ACL, error) {
+	return b.bucketACL.get(func() (*ACL, error) {
+		var doc bosACLDoc
+		if _, err := b.call("GET", "", url.Values{"acl": {""}}, nil, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Owner == nil {
+			return nil, fmt.Errorf("bos: no owner in the acl of bucket %s", b.bucket)
+		}
+		return bosACL(doc.Owner.ID, &doc), nil
+	})
+}
+
+// GetACL 对象未设置 ACL 时继承桶的 ACL
+func (b *bosClient) GetACL(key string) (*ACL, error) {
+	bucket, err := b.GetBucketACL()
+	if err != nil {
+		return nil, err
+	}
+	var doc bosACLDoc
+	_, err = b.call("GET", key, url.Values{"acl": {""}}, nil, &doc)
+	if e, ok := err.(*bosError); (ok && e.Code == "ObjectAclNotFound") || (err == nil && len(doc.AccessControlList) == 0) {
+		return bucket, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bosACL(bucket.Owner, &doc), nil
+}
+
+// SetACL 写入除拥有者之外的授权
+func (b *bosClient) SetACL(key string, acl *ACL) error {
+	doc := bosACLDoc{AccessControlList: []bosGrant{}}
+	for _, g := range acl.Grants {
+		if g.Grantee == acl.Owner {
+			continue
+		}
+		id := g.Grantee
+		switch id {
+		case AllUsers:
+			id = "*"
+		case AuthenticatedUsers:
+			return fmt.Errorf("bos doesn't support granting %s to %s", g.Permission, id)
+		}
+		doc.AccessControlList = append(doc.AccessControlList, bosGrant{[]bosGrantee{{id}}, []string{g.Permission}})
+	}
+	_, err := b.call("PUT", key, url.Values{"acl": {""}}, doc, nil)
+	return err
+}
+
+func (b *bosClient) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return objectCanned(b, key)
+}
+
+// newBos 桶地址为 [BUCKET].[REGION].bcebos.com，或路径方式的 http://127.0.0.1:8080/[BUCKET]
+func newBos(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	s, err := newBosService(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	var bucket string
+	if s.base != "" {
+		bucket = strings.Trim(s.base, "/")
+	} else {
+		bucket = strings.SplitN(s.host, ".", 2)[0]
+	}
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return nil, fmt.Errorf("invalid endpoint %s: no bucket", endpoint)
+	}
+	return &bosClient{*s, bucket, bosChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}}, nil
+}
+
+func init() {
+	Register(models.Bos, newBos)
+}
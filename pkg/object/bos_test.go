@@ -0,0 +1,204 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBos 本地的 BOS 替身，校验签名并在内存中保存对象
+type fakeBos struct {
+	t       *testing.T
+	objects map[string][]byte
+	headers map[string]http.Header
+	parts   map[string][]byte
+	acls    map[string][]byte
+	uploads map[string]string // uploadID -> key
+}
+
+func (f *fakeBos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 以相同的时间重新签名，校验传输后的路径和参数与签名时一致
+	date, _ := time.Parse("2006-01-02T15:04:05Z", r.Header.Get("x-bce-date"))
+	clone := httptest.NewRequest(r.Method, r.URL.RequestURI(), nil)
+	clone.URL.Host = r.Host
+	for k, vs := range r.Header {
+		if k != "Authorization" {
+			clone.Header[k] = vs
+		}
+	}
+	bosSign(clone, "ak", "sk", date)
+	if got := r.Header.Get("Authorization"); got != clone.Header.Get("Authorization") {
+		f.t.Errorf("%s %s: bad signature %s", r.Method, r.URL, got)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bkt"), "/")
+	q := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case q.Has("acl") && r.Method == "PUT":
+		f.acls[key] = body
+	case q.Has("acl"):
+		if key == "" {
+			w.Write([]byte(`{"owner":{"id":"o1"},"accessControlList":[{"grantee":[{"id":"o1"}],"permission":["FULL_CONTROL"]}]}`))
+		} else if acl, ok := f.acls[key]; ok {
+			w.Write(acl)
+		} else {
+			w.Write([]byte(`{"accessControlList":[]}`))
+		}
+	case q.Has("uploads") && r.Method == "POST":
+		f.headers[key] = r.Header.Clone()
+		id := fmt.Sprint("u", len(f.uploads)+1)
+		f.uploads[id] = key
+		fmt.Fprintf(w, `{"uploadId":%q}`, id)
+	case q.Has("uploads"):
+		var res struct {
+			Uploads []map[string]string `json:"uploads"`
+		}
+		for id, k := range f.uploads {
+			res.Uploads = append(res.Uploads, map[string]string{"key": k, "uploadId": id, "initiated": "2024-01-02T03:04:05Z"})
+		}
+		json.NewEncoder(w).Encode(res)
+	case q.Has("uploadId") && r.Method == "DELETE":
+		delete(f.uploads, q.Get("uploadId"))
+	case q.Has("partNumber"):
+		f.parts[q.Get("partNumber")] = body
+		w.Header().Set("ETag", `"e`+q.Get("partNumber")+`"`)
+	case q.Has("uploadId") && r.Method == "POST":
+		var in struct{ Parts []bosPart }
+		json.Unmarshal(body, &in)
+		var data []byte
+		for _, p := range in.Parts {
+			data = append(data, f.parts[strconv.Itoa(p.PartNumber)]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, q.Get("uploadId"))
+	case key == "" && r.Method == "GET":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("marker") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		if n, _ := strconv.Atoi(q.Get("maxKeys")); n > 0 && len(keys) > n {
+			keys = keys[:n]
+		}
+		var res struct {
+			Contents []map[string]interface{} `json:"contents"`
+		}
+		for _, k := range keys {
+			res.Contents = append(res.Contents, map[string]interface{}{
+				"key": k, "size": len(f.objects[k]), "lastModified": "2024-01-02T03:04:05Z", "storageClass": "STANDARD_IA",
+			})
+		}
+		json.NewEncoder(w).Encode(res)
+	case r.Method == "PUT":
+		f.objects[key] = body
+		f.headers[key] = r.Header.Clone()
+	case r.Method == "DELETE":
+		delete(f.objects, key)
+	case r.Method == "GET" || r.Method == "HEAD":
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NoSuchKey","message":"not found"}`))
+			return
+		}
+		for k, vs := range f.headers[key] {
+			if strings.HasPrefix(strings.ToLower(k), "x-bce-meta-") {
+				w.Header()[k] = vs
+			}
+		}
+		http.ServeContent(w, r, key, time.Now(), bytes.NewReader(data))
+	}
+}
+
+func TestBos(t *testing.T) {
+	f := &fakeBos{t, map[string][]byte{}, map[string]http.Header{}, map[string][]byte{}, map[string][]byte{}, map[string]string{}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	s, err := CreateStorage(models.Bos, srv.URL+"/bkt", "ak", "sk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &Metadata{UserMeta: map[string]string{"owner": "x"}}
+	for _, k := range []string{"a/1", "a/2 +中", "b"} {
+		if err = s.Put(k, bytes.NewReader([]byte("hello "+k)), models.PublicRead, WithMetadata(meta)); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	if h := f.headers["b"]; h.Get("x-bce-acl") != "public-read" || h.Get("x-bce-meta-owner") != "x" || h.Get("x-bce-meta-Crc32c") == "" {
+		t.Fatalf("put headers: %v", h)
+	}
+
+	objs, err := s.List("a/", "a/1", 10)
+	if err != nil || len(objs) != 1 || objs[0].Key() != "a/2 +中" || StorageClassOf(objs[0]) != models.InfrequentAccess {
+		t.Fatalf("list: %v %v", objs, err)
+	}
+
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	o, err := s.Head("b")
+	if err != nil || o.Size() != int64(len("hello b")) || o.(ObjectInfo).Metadata().UserMeta["owner"] != "x" {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if _, err = s.Get("nope", 0, -1); err == nil || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Fatalf("get missing: %v", err)
+	}
+
+	up, err := s.CreateMultipartUpload("big", 5, models.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []*Part
+	for i, p := range []string{"part1-", "part2"} {
+		part, err := s.UploadPart("big", up.UploadID, i+1, []byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if string(f.objects["big"]) != "part1-part2" {
+		t.Fatalf("multipart: %q", f.objects["big"])
+	}
+
+	a := s.(ACLer)
+	if canned, err := s.GetObjectAcl("b"); err != nil || canned != models.Default {
+		t.Fatalf("default acl: %s %v", canned, err)
+	}
+	if err = a.SetACL("b", CannedACL("o1", models.PublicRead)); err != nil {
+		t.Fatal(err)
+	}
+	if canned, err := s.GetObjectAcl("b"); err != nil || canned != models.PublicRead {
+		t.Fatalf("acl: %s %v", canned, err)
+	}
+
+	if err = s.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.objects["b"]; ok {
+		t.Fatal("not deleted")
+	}
+}
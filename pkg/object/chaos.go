@@ -0,0 +1,386 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/url"
+	"obs-sync/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosPrefix 类型前缀，如 chaos+file、chaos+mem，地址中 # 之后为故障注入的参数：
+// chaos+file:///tmp/src/#error=0.1&ops=get,put
+const ChaosPrefix = "chaos+"
+
+// chaos 操作名，用于 ops 参数
+const (
+	chaosGet    = "get"
+	chaosPut    = "put"
+	chaosHead   = "head"
+	chaosDelete = "delete"
+	chaosList   = "list"
+	chaosUpload = "upload" // CreateMultipartUpload、UploadPart、CompleteUpload
+)
+
+// ChaosConfig 故障注入的参数，概率的取值为 0 到 1
+type ChaosConfig struct {
+	// Ops 注入故障的操作，为空时注入所有操作
+	Ops map[string]bool
+	// Prefix 只对以其开头的 key 注入
+	Prefix string
+	// Latency 每次操作前的延迟
+	Latency time.Duration
+	// Error 操作返回错误的概率
+	Error float64
+	// Throttle 操作返回限流错误的概率
+	Throttle float64
+	// Truncate Get 返回的数据被截断、列举中途失败的概率
+	Truncate float64
+	// Slow Get 返回的数据的读取速度，字节每秒，0 为不限制
+	Slow int64
+	// Seed 随机数种子，相同的种子和操作顺序注入相同的故障
+	Seed int64
+}
+
+// ParseChaos 解析 error=0.1&throttle=0.05&truncate=0.1&latency=50ms&slow=65536&ops=get,put&prefix=a/&seed=1
+func ParseChaos(params string) (*ChaosConfig, error) {
+	q, err := url.ParseQuery(params)
+	if err != nil {
+		return nil, fmt.Errorf("chaos: invalid parameters %q: %s", params, err)
+	}
+	c := &ChaosConfig{Prefix: q.Get("prefix"), Seed: time.Now().UnixNano()}
+	for k := range q {
+		v := q.Get(k)
+		switch k {
+		case "prefix":
+		case "ops":
+			c.Ops = make(map[string]bool)
+			for _, op := range strings.Split(v, ",") {
+				switch op {
+				case chaosGet, chaosPut, chaosHead, chaosDelete, chaosList, chaosUpload:
+					c.Ops[op] = true
+				default:
+					return nil, fmt.Errorf("chaos: unknown operation %q", op)
+				}
+			}
+		case "latency":
+			c.Latency, err = time.ParseDuration(v)
+		case "error", "throttle", "truncate":
+			var p float64
+			if p, err = strconv.ParseFloat(v, 64); err == nil && (p < 0 || p > 1) {
+				err = fmt.Errorf("probability out of [0, 1]")
+			}
+			switch k {
+			case "error":
+				c.Error = p
+			case "throttle":
+				c.Throttle = p
+			default:
+				c.Truncate = p
+			}
+		case "slow":
+			c.Slow, err = strconv.ParseInt(v, 10, 64)
+		case "seed":
+			c.Seed, err = strconv.ParseInt(v, 10, 64)
+		default:
+			return nil, fmt.Errorf("chaos: unknown parameter %q", k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos: invalid %s %q: %s", k, v, err)
+		}
+	}
+	return c, nil
+}
+
+// SplitChaos 拆分 chaos+ 类型和带参数的地址，返回被包装的类型、地址和参数，不是 chaos 类型时 ok 为 false
+func SplitChaos(t models.ResourceType, addr string) (inner models.ResourceType, innerAddr, params string, ok bool) {
+	if !strings.HasPrefix(string(t), ChaosPrefix) {
+		return t, addr, "", false
+	}
+	inner = t[len(ChaosPrefix):]
+	innerAddr = addr
+	if i := strings.LastIndex(addr, "#"); i >= 0 {
+		innerAddr, params = addr[:i], addr[i+1:]
+	}
+	return inner, innerAddr, params, true
+}
+
+// ChaosError 注入的错误，Throttled 为模拟的限流响应
+type ChaosError struct {
+	Op        string
+	Key       string
+	Throttled bool
+}
+
+func (e *ChaosError) Error() string {
+	if e.Throttled {
+		return fmt.Sprintf("chaos: %s %s: SlowDown: Please reduce your request rate (status 503)", e.Op, e.Key)
+	}
+	return fmt.Sprintf("chaos: %s %s: injected error (status 500)", e.Op, e.Key)
+}
+
+type withChaos struct {
+	os  ObjectStorage
+	c   *ChaosConfig
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// WithChaos returns an object storage that injects latency, errors, throttling,
+// truncated and slow reads into the operations of os, for resilience tests.
+func WithChaos(os ObjectStorage, c *ChaosConfig) ObjectStorage {
+	return &withChaos{os: os, c: c, rnd: rand.New(rand.NewSource(c.Seed))}
+}
+
+func (w *withChaos) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rnd.Float64() < p
+}
+
+func (w *withChaos) selected(op, key string) bool {
+	return (len(w.c.Ops) == 0 || w.c.Ops[op]) && strings.HasPrefix(key, w.c.Prefix)
+}
+
+// inject 操作前注入延迟和错误
+func (w *withChaos) inject(op, key string) error {
+	if !w.selected(op, key) {
+		return nil
+	}
+	if w.c.Latency > 0 {
+		time.Sleep(w.c.Latency)
+	}
+	if w.chance(w.c.Throttle) {
+		return &ChaosError{op, key, true}
+	}
+	if w.chance(w.c.Error) {
+		return &ChaosError{op, key, false}
+	}
+	return nil
+}
+
+func (w *withChaos) SetCheckSumKey(meta string) error {
+	return w.os.SetCheckSumKey(meta)
+}
+
+func (w *withChaos) IsSetMd5(flag bool) error {
+	return w.os.IsSetMd5(flag)
+}
+
+func (w *withChaos) String() string {
+	return w.os.String()
+}
+
+func (w *withChaos) Create() error {
+	return w.os.Create()
+}
+
+func (w *withChaos) Head(key string) (Object, error) {
+	if err := w.inject(chaosHead, key); err != nil {
+		return nil, err
+	}
+	return w.os.Head(key)
+}
+
+// chaosReader 读取到一半时失败的连接，或者限速的连接
+type chaosReader struct {
+	io.ReadCloser
+	left  int64 // 截断前还能读取的字节数，-1 为不截断
+	slow  int64
+	start time.Time
+	read  int64
+}
+
+func (r *chaosReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if r.left > 0 && int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	if r.slow > 0 && int64(len(p)) > r.slow/10+1 {
+		p = p[:r.slow/10+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if r.left > 0 {
+		r.left -= int64(n)
+	}
+	if r.slow > 0 {
+		r.read += int64(n)
+		if wait := time.Duration(r.read*int64(time.Second)/r.slow) - time.Since(r.start); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return n, err
+}
+
+func (w *withChaos) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	if err := w.inject(chaosGet, key); err != nil {
+		return nil, err
+	}
+	in, err := w.os.Get(key, off, limit)
+	if err != nil || !w.selected(chaosGet, key) {
+		return in, err
+	}
+	r := &chaosReader{ReadCloser: in, left: -1, slow: w.c.Slow, start: time.Now()}
+	if w.chance(w.c.Truncate) {
+		// 截断在数据的前半部分，范围未知时最多读取 1 字节
+		n := limit / 2
+		if limit <= 0 {
+			n = 1
+		}
+		r.left = n
+	}
+	if r.left < 0 && r.slow <= 0 {
+		return in, nil
+	}
+	return r, nil
+}
+
+func (w *withChaos) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	if err := w.inject(chaosPut, key); err != nil {
+		return err
+	}
+	return w.os.Put(key, in, acl, opts...)
+}
+
+func (w *withChaos) Delete(key string) error {
+	if err := w.inject(chaosDelete, key); err != nil {
+		return err
+	}
+	return w.os.Delete(key)
+}
+
+func (w *withChaos) List(prefix, marker string, limit int64) ([]Object, error) {
+	if err := w.inject(chaosList, prefix); err != nil {
+		return nil, err
+	}
+	return w.os.List(prefix, marker, limit)
+}
+
+// ListAll 截断时列举到一半发送 nil，与存储列举失败时的行为相同
+func (w *withChaos) ListAll(prefix, marker string) (<-chan Object, error) {
+	if err := w.inject(chaosList, prefix); err != nil {
+		return nil, err
+	}
+	r, err := w.os.ListAll(prefix, marker)
+	if err != nil || !w.selected(chaosList, prefix) || !w.chance(w.c.Truncate) {
+		return r, err
+	}
+	r2 := make(chan Object, 10240)
+	go func() {
+		defer close(r2)
+		n := 0
+		for o := range r {
+			if n++; n > 1 && w.chance(0.5) {
+				r2 <- nil
+				for range r {
+				}
+				return
+			}
+			r2 <- o
+		}
+		r2 <- nil
+	}()
+	return r2, nil
+}
+
+func (w *withChaos) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	if err := w.inject(chaosUpload, key); err != nil {
+		return nil, err
+	}
+	return w.os.CreateMultipartUpload(key, minSize, acl, opts...)
+}
+
+func (w *withChaos) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	if err := w.inject(chaosUpload, key); err != nil {
+		return nil, err
+	}
+	return w.os.UploadPart(key, uploadID, num, body)
+}
+
+func (w *withChaos) AbortUpload(key string, uploadID string) {
+	w.os.AbortUpload(key, uploadID)
+}
+
+func (w *withChaos) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	if err := w.inject(chaosUpload, key); err != nil {
+		return err
+	}
+	return w.os.CompleteUpload(key, uploadID, parts)
+}
+
+func (w *withChaos) ListUploads(marker string) ([]*PendingPart, string, error) {
+	return w.os.ListUploads(marker)
+}
+
+func (w *withChaos) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return w.os.GetObjectAcl(key)
+}
+
+// GetChecksum 被包装的存储不记录校验值时返回空
+func (w *withChaos) GetChecksum(key string) (string, error) {
+	if c, ok := w.os.(Checksummer); ok {
+		return c.GetChecksum(key)
+	}
+	return "", nil
+}
+
+// GetTags 被包装的存储不支持标签时视为没有标签
+func (w *withChaos) GetTags(key string) (map[string]string, error) {
+	if t, ok := w.os.(Tagger); ok {
+		return t.GetTags(key)
+	}
+	return nil, nil
+}
+
+func (w *withChaos) SetTags(key string, tags map[string]string) error {
+	if t, ok := w.os.(Tagger); ok {
+		return t.SetTags(key, tags)
+	}
+	return notSupported
+}
+
+func (w *withChaos) SetSSE(sse *SSE) error {
+	if e, ok := w.os.(ServerSideEncrypter); ok {
+		return e.SetSSE(sse)
+	}
+	return notSupported
+}
+
+func (w *withChaos) EnableVersioning() error {
+	if e, ok := w.os.(VersioningEnabler); ok {
+		return e.EnableVersioning()
+	}
+	return notSupported
+}
+
+func (w *withChaos) GetBucketACL() (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetBucketACL()
+	}
+	return nil, notSupported
+}
+
+func (w *withChaos) GetACL(key string) (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetACL(key)
+	}
+	return nil, notSupported
+}
+
+func (w *withChaos) SetACL(key string, acl *ACL) error {
+	if a, ok := w.os.(ACLer); ok {
+		return a.SetACL(key, acl)
+	}
+	return notSupported
+}
+
+var _ ObjectStorage = &withChaos{}
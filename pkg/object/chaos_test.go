@@ -0,0 +1,110 @@
+package object
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"obs-sync/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChaos(t *testing.T) {
+	c, err := ParseChaos("error=0.1&throttle=0.2&truncate=1&latency=5ms&slow=1024&ops=get,put&prefix=a/&seed=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Error != 0.1 || c.Throttle != 0.2 || c.Truncate != 1 || c.Latency != 5*time.Millisecond || c.Slow != 1024 ||
+		!c.Ops["get"] || !c.Ops["put"] || c.Ops["head"] || c.Prefix != "a/" || c.Seed != 7 {
+		t.Fatalf("parsed: %+v", c)
+	}
+	for _, bad := range []string{"error=2", "ops=copy", "latency=5", "foo=1"} {
+		if _, err = ParseChaos(bad); err == nil {
+			t.Fatalf("%s should be rejected", bad)
+		}
+	}
+	inner, addr, params, ok := SplitChaos("chaos+file", "/tmp/a#b/#error=1")
+	if !ok || inner != models.File || addr != "/tmp/a#b/" || params != "error=1" {
+		t.Fatalf("split: %s %s %s %v", inner, addr, params, ok)
+	}
+}
+
+func TestChaos(t *testing.T) {
+	bucket := MemBucketURL(t.Name(), "bkt")
+	s, _ := CreateStorage(models.Mem, bucket, "", "")
+	if err := s.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a/1", "a/2", "b"} {
+		if err := s.Put(k, strings.NewReader(strings.Repeat("x", 100)), models.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chaos := func(params string) ObjectStorage {
+		s, err := CreateStorage(ChaosPrefix+models.Mem, bucket+"#"+params, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	s = chaos("error=1&ops=put,head&prefix=a/")
+	var ce *ChaosError
+	if err := s.Put("a/3", strings.NewReader("x"), models.Default); !errors.As(err, &ce) || ce.Throttled {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := s.Head("a/1"); err == nil {
+		t.Fatal("head should fail")
+	}
+	if err := s.Put("b", strings.NewReader("x"), models.Default); err != nil {
+		t.Fatalf("put outside the prefix: %s", err)
+	}
+	if _, err := s.Get("a/1", 0, -1); err != nil {
+		t.Fatalf("get is not selected: %s", err)
+	}
+
+	s = chaos("throttle=1")
+	if _, err := s.Get("b", 0, -1); !errors.As(err, &ce) || !ce.Throttled || !strings.Contains(err.Error(), "SlowDown") {
+		t.Fatalf("throttled get: %v", err)
+	}
+
+	s = chaos("truncate=1&ops=get,list")
+	in, _ := s.Get("a/1", 10, 50)
+	data, err := ioutil.ReadAll(in)
+	if err != io.ErrUnexpectedEOF || len(data) != 25 {
+		t.Fatalf("truncated get: %d bytes, %v", len(data), err)
+	}
+	ch, _ := s.ListAll("", "")
+	var objs []Object
+	for o := range ch {
+		objs = append(objs, o)
+	}
+	if len(objs) == 0 || objs[len(objs)-1] != nil {
+		t.Fatalf("truncated list should end with nil: %v", objs)
+	}
+
+	s = chaos("slow=1000")
+	start := time.Now()
+	in, _ = s.Get("a/2", 0, -1)
+	if data, _ = ioutil.ReadAll(in); len(data) != 100 || time.Since(start) < 80*time.Millisecond {
+		t.Fatalf("slow get: %d bytes in %s", len(data), time.Since(start))
+	}
+
+	// 相同的种子注入相同的故障
+	failures := func() string {
+		s := chaos("error=0.5&seed=42")
+		var res []byte
+		for i := 0; i < 20; i++ {
+			if _, err := s.Head("b"); err != nil {
+				res = append(res, 'x')
+			} else {
+				res = append(res, '.')
+			}
+		}
+		return string(res)
+	}
+	if a, b := failures(), failures(); a != b || !strings.Contains(a, "x") || !strings.Contains(a, ".") {
+		t.Fatalf("not reproducible: %s %s", a, b)
+	}
+}
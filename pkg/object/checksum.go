@@ -19,11 +19,14 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 type algorithm string
@@ -39,6 +42,8 @@ func (a algorithm) String() string {
 
 var crc32c = crc32.MakeTable(crc32.Castagnoli)
 
+// generateChecksum md5 统一为 base64 编码。注意：早期版本对内存中的数据写入的是 md5 的原始字节，
+// 与流式数据的 base64 不一致，parseChecksum 仍能识别这种旧格式
 func generateChecksum(in io.ReadSeeker, algorithmType algorithm) string {
 	switch algorithmType {
 	case checksumCrc32:
@@ -68,7 +73,7 @@ func generateChecksum(in io.ReadSeeker, algorithmType algorithm) string {
 			v := reflect.ValueOf(b)
 			data := v.Elem().Field(0).Bytes()
 			io.WriteString(w, string(data))
-			return string(w.Sum(nil))
+			return base64.StdEncoding.EncodeToString(w.Sum(nil))
 		}
 		crcBuffer := bufPool.Get().(*[]byte)
 		defer bufPool.Put(crcBuffer)
@@ -115,3 +120,114 @@ func verifyChecksum(in io.ReadCloser, checksum string) io.ReadCloser {
 	}
 	return &checksumReader{in, uint32(expected), 0}
 }
+
+func newHash(alg algorithm) hash.Hash {
+	if alg == checksumMd5 {
+		return md5.New()
+	}
+	return crc32.New(crc32c)
+}
+
+// formatChecksum encodes a sum the same way as generateChecksum does.
+func formatChecksum(alg algorithm, sum []byte) string {
+	if alg == checksumMd5 {
+		return base64.StdEncoding.EncodeToString(sum)
+	}
+	if len(sum) != 4 {
+		return ""
+	}
+	return strconv.Itoa(int(uint32(sum[0])<<24 | uint32(sum[1])<<16 | uint32(sum[2])<<8 | uint32(sum[3])))
+}
+
+// parseChecksum decodes a checksum written by us or by other tools: decimal
+// numbers are CRC32C, 32 hex chars, base64 of 16 bytes or the raw 16 bytes
+// written by earlier versions (not printable) are MD5.
+func parseChecksum(v string) (algorithm, []byte, bool) {
+	v = strings.Trim(v, `"`)
+	if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return checksumCrc32, []byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, true
+	}
+	if len(v) == 32 {
+		if sum, err := hex.DecodeString(v); err == nil {
+			return checksumMd5, sum, true
+		}
+	}
+	if sum, err := base64.StdEncoding.DecodeString(v); err == nil && len(sum) == md5.Size {
+		return checksumMd5, sum, true
+	}
+	if len(v) == md5.Size && strings.IndexFunc(v, func(r rune) bool { return r < 0x20 || r >= 0x7f }) >= 0 {
+		return checksumMd5, []byte(v), true
+	}
+	return "", nil, false
+}
+
+// Checksum computes the checksum of the data written to it, in the format
+// that the destination stores as object metadata, and checks it against the
+// checksum of the source object if there is one.
+type Checksum struct {
+	alg      algorithm
+	h        hash.Hash
+	srcAlg   algorithm
+	srcSum   []byte
+	srcHash  hash.Hash
+	expected string
+}
+
+// NewChecksum returns a Checksum using MD5 if useMd5 is set, CRC32C otherwise.
+// expected is the checksum stored with the source object, empty if unknown.
+func NewChecksum(useMd5 bool, expected string) *Checksum {
+	c := &Checksum{alg: checksumCrc32}
+	if useMd5 {
+		c.alg = checksumMd5
+	}
+	c.h = newHash(c.alg)
+	if expected != "" {
+		if alg, sum, ok := parseChecksum(expected); ok {
+			c.srcAlg, c.srcSum, c.expected = alg, sum, expected
+			if alg != c.alg {
+				c.srcHash = newHash(alg)
+			}
+		} else {
+			logger.Warn().Msgf("ignore unrecognized checksum %q", expected)
+		}
+	}
+	return c
+}
+
+func (c *Checksum) Write(p []byte) (int, error) {
+	_, _ = c.h.Write(p)
+	if c.srcHash != nil {
+		_, _ = c.srcHash.Write(p)
+	}
+	return len(p), nil
+}
+
+// Sum returns the checksum of the data written so far.
+func (c *Checksum) Sum() string {
+	return formatChecksum(c.alg, c.h.Sum(nil))
+}
+
+// Expected returns the checksum of the source in the format of Sum, so it can
+// be stored before any data is written. It's empty if the source has no
+// checksum in the same algorithm.
+func (c *Checksum) Expected() string {
+	if c.srcSum == nil || c.srcAlg != c.alg {
+		return ""
+	}
+	return formatChecksum(c.alg, c.srcSum)
+}
+
+// Verify compares the data written so far with the checksum of the source.
+func (c *Checksum) Verify() error {
+	if c.srcSum == nil {
+		return nil
+	}
+	h := c.h
+	if c.srcHash != nil {
+		h = c.srcHash
+	}
+	if sum := h.Sum(nil); !bytes.Equal(sum, c.srcSum) {
+		return fmt.Errorf("verify checksum failed: %s != %s", formatChecksum(c.srcAlg, sum), c.expected)
+	}
+	return nil
+}
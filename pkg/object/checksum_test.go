@@ -0,0 +1,81 @@
+package object
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksum(t *testing.T) {
+	data := []byte("hello world")
+	sum := md5.Sum(data)
+	crc := crc32.Checksum(data, crc32c)
+	cases := []struct {
+		v   string
+		alg algorithm
+	}{
+		{strconv.Itoa(int(crc)), checksumCrc32},
+		{`"` + hex.EncodeToString(sum[:]) + `"`, checksumMd5},
+		{base64.StdEncoding.EncodeToString(sum[:]), checksumMd5},
+		{string(sum[:]), checksumMd5}, // 早期版本写入的原始字节
+	}
+	for _, c := range cases {
+		alg, got, ok := parseChecksum(c.v)
+		if !ok || alg != c.alg {
+			t.Fatalf("parse %q: %s %v", c.v, alg, ok)
+		}
+		if alg == checksumMd5 && !bytes.Equal(got, sum[:]) {
+			t.Fatalf("parse %q: %x", c.v, got)
+		}
+	}
+	for _, v := range []string{"", "not a checksum", "0123456789abcdef", strings.Repeat("z", 32)} {
+		if _, _, ok := parseChecksum(v); ok {
+			t.Fatalf("parsed %q", v)
+		}
+	}
+
+	// formatChecksum 与 generateChecksum 的格式一致
+	for _, alg := range []algorithm{checksumCrc32, checksumMd5} {
+		h := newHash(alg)
+		h.Write(data)
+		if got, want := formatChecksum(alg, h.Sum(nil)), generateChecksum(bytes.NewReader(data), alg); got != want {
+			t.Fatalf("format %s: %s != %s", alg, got, want)
+		}
+		if got, want := formatChecksum(alg, h.Sum(nil)), generateChecksum(strings.NewReader(string(data)), alg); got != want {
+			t.Fatalf("format %s of stream: %s != %s", alg, got, want)
+		}
+	}
+	if formatChecksum(checksumCrc32, []byte{1}) != "" {
+		t.Fatal("format a short crc32c")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	data := []byte("hello world")
+	crc := strconv.Itoa(int(crc32.Checksum(data, crc32c)))
+
+	c := NewChecksum(false, crc)
+	c.Write(data)
+	if c.Sum() != crc || c.Expected() != crc || c.Verify() != nil {
+		t.Fatalf("crc32c: %s %s %v", c.Sum(), c.Expected(), c.Verify())
+	}
+	// 算法不同时仍能校验，但不能提前写入
+	c = NewChecksum(true, crc)
+	c.Write(data)
+	if c.Expected() != "" || c.Verify() != nil {
+		t.Fatalf("md5 against crc32c: %q %v", c.Expected(), c.Verify())
+	}
+	c = NewChecksum(true, crc)
+	c.Write(data[1:])
+	if c.Verify() == nil {
+		t.Fatal("verify wrong data")
+	}
+	if c = NewChecksum(false, "garbage"); c.Expected() != "" || c.Verify() != nil {
+		t.Fatal("unrecognized checksum is not ignored")
+	}
+}
@@ -0,0 +1,316 @@
+package object
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// listKeys 列举 prefix 下 marker 之后的对象，不支持 List 的存储使用 ListAll；
+// 文件类的存储会列出目录，这里忽略目录，只比较对象
+func listKeys(t *testing.T, s ObjectStorage, prefix, marker string, limit int64) string {
+	t.Helper()
+	var objs []Object
+	if res, err := s.List(prefix, marker, limit); err == nil {
+		objs = res
+	} else if IsNotSupported(err) {
+		ch, err := s.ListAll(prefix, marker)
+		if err != nil {
+			t.Fatalf("list all %q after %q: %s", prefix, marker, err)
+		}
+		for o := range ch {
+			if o == nil {
+				t.Fatalf("list all %q after %q failed", prefix, marker)
+			}
+			objs = append(objs, o)
+		}
+	} else {
+		t.Fatalf("list %q after %q: %s", prefix, marker, err)
+	}
+	var keys []string
+	for _, o := range objs {
+		if !o.IsDir() && int64(len(keys)) < limit {
+			keys = append(keys, o.Key())
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// testStorage 所有 ObjectStorage 实现都应满足的行为，s 为已创建的空桶
+func testStorage(t *testing.T, s ObjectStorage) {
+	if _, err := s.Head("x"); !os.IsNotExist(err) {
+		t.Fatalf("head of a missing object should be os.ErrNotExist: %v", err)
+	}
+	if _, err := s.Get("x", 0, -1); err == nil {
+		t.Fatal("get a missing object")
+	}
+	if err := s.Delete("x"); err != nil {
+		t.Fatalf("delete a missing object: %s", err)
+	}
+
+	data := map[string]string{
+		"x":     "hello x",
+		"y+z":   "hello y+z",
+		"y/1":   "hello y/1",
+		"y/2":   "hello y/2",
+		"y/a/3": "hello y/a/3",
+		"y0":    "hello y0",
+		"zero":  "",
+	}
+	for k, v := range data {
+		if err := s.Put(k, strings.NewReader(v), models.Default); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+
+	for _, c := range []struct {
+		prefix, marker string
+		limit          int64
+		want           string
+	}{
+		{"", "", 1000, "x,y+z,y/1,y/2,y/a/3,y0,zero"},
+		{"", "", 2, "x,y+z"},
+		{"", "y/2", 1000, "y/a/3,y0,zero"},
+		{"", "y/10", 1000, "y/2,y/a/3,y0,zero"},
+		{"", "zero", 1000, ""},
+		{"y", "", 1000, "y+z,y/1,y/2,y/a/3,y0"},
+		{"y/", "", 1000, "y/1,y/2,y/a/3"},
+		{"y/", "a", 1000, "y/1,y/2,y/a/3"},
+		{"y/", "y/1", 1000, "y/2,y/a/3"},
+		{"y/", "y0", 1000, ""},
+		{"y/a/", "", 1000, "y/a/3"},
+		{"w", "", 1000, ""},
+	} {
+		if got := listKeys(t, s, c.prefix, c.marker, c.limit); got != c.want {
+			t.Fatalf("list %q after %q (limit %d): %s, want %s", c.prefix, c.marker, c.limit, got, c.want)
+		}
+	}
+
+	for k, v := range data {
+		o, err := s.Head(k)
+		if err != nil || o.Key() != k || o.Size() != int64(len(v)) || o.IsDir() {
+			t.Fatalf("head %s: %+v %v", k, o, err)
+		}
+	}
+
+	get := func(key string, off, limit int64) string {
+		t.Helper()
+		in, err := s.Get(key, off, limit)
+		if err != nil {
+			t.Fatalf("get %s at %d+%d: %s", key, off, limit, err)
+		}
+		defer in.Close()
+		got, err := ioutil.ReadAll(in)
+		if err != nil {
+			t.Fatalf("read %s at %d+%d: %s", key, off, limit, err)
+		}
+		return string(got)
+	}
+	for _, c := range []struct {
+		off, limit int64
+		want       string
+	}{
+		{0, -1, "hello y/1"},
+		{0, 0, "hello y/1"},
+		{6, -1, "y/1"},
+		{6, 2, "y/"},
+		{6, 100, "y/1"},
+		{0, 9, "hello y/1"},
+	} {
+		if got := get("y/1", c.off, c.limit); got != c.want {
+			t.Fatalf("get y/1 at %d+%d: %q, want %q", c.off, c.limit, got, c.want)
+		}
+	}
+	if got := get("zero", 0, -1); got != "" {
+		t.Fatalf("get empty object: %q", got)
+	}
+
+	if err := s.Put("x", strings.NewReader("replaced"), models.Default); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("x", 0, -1); got != "replaced" {
+		t.Fatalf("overwrite: %q", got)
+	}
+	for _, k := range []string{"x", "x", "zero"} {
+		if err := s.Delete(k); err != nil {
+			t.Fatalf("delete %s: %s", k, err)
+		}
+	}
+	if _, err := s.Head("x"); !os.IsNotExist(err) {
+		t.Fatalf("head of a deleted object: %v", err)
+	}
+	if got := listKeys(t, s, "", "", 1000); got != "y+z,y/1,y/2,y/a/3,y0" {
+		t.Fatalf("list after delete: %s", got)
+	}
+
+	testMultipart(t, s)
+}
+
+// testMultipart 分片按编号拼接，未完成的上传可以列举和放弃
+func testMultipart(t *testing.T, s ObjectStorage) {
+	up, err := s.CreateMultipartUpload("big", 5<<20, models.Default)
+	if IsNotSupported(err) {
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	part1 := bytes.Repeat([]byte("1"), 5<<20)
+	p2, err := s.UploadPart("big", up.UploadID, 2, []byte("tail"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := s.UploadPart("big", up.UploadID, 1, part1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不能列举未完成上传的存储不检查列举的结果
+	pending, _, err := s.ListUploads("")
+	listable := !IsNotSupported(err)
+	if listable && (err != nil || len(pending) != 1 || pending[0].Key != "big" || pending[0].UploadID != up.UploadID) {
+		t.Fatalf("list uploads: %v %v", pending, err)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, []*Part{p1, p2}); err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Head("big")
+	if err != nil || o.Size() != int64(len(part1))+4 {
+		t.Fatalf("head multipart object: %+v %v", o, err)
+	}
+	in, err := s.Get("big", int64(len(part1))-2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(got) != "11ta" {
+		t.Fatalf("get across parts: %q", got)
+	}
+
+	up, err = s.CreateMultipartUpload("aborted", 5<<20, models.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.UploadPart("aborted", up.UploadID, 1, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	s.AbortUpload("aborted", up.UploadID)
+	if pending, _, _ = s.ListUploads(""); listable && len(pending) != 0 {
+		t.Fatalf("uploads left: %v", pending)
+	}
+	if _, err = s.Head("aborted"); !os.IsNotExist(err) {
+		t.Fatalf("aborted upload is visible: %v", err)
+	}
+	if err = s.Delete("big"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		s, _ := CreateStorage(models.File, t.TempDir()+"/", "", "")
+		testStorage(t, s)
+	})
+	t.Run("mem", func(t *testing.T) {
+		s, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+		if err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, s)
+	})
+	t.Run("sftp", func(t *testing.T) {
+		addr := startSftpServer(t, t.TempDir())
+		if err := CreateSftpDir(addr, "user", "pass", "bkt"); err != nil {
+			t.Fatal(err)
+		}
+		s, _ := CreateStorage(models.Sftp, SftpBucketURL(addr, "bkt"), "user", "pass")
+		testStorage(t, s)
+	})
+	t.Run("webdav", func(t *testing.T) {
+		srv := httptest.NewServer(&webdav.Handler{FileSystem: webdav.Dir(t.TempDir()), LockSystem: webdav.NewMemLS()})
+		defer srv.Close()
+		if err := CreateWebdavDir(srv.URL, "", "", "bkt"); err != nil {
+			t.Fatal(err)
+		}
+		s, _ := CreateStorage(models.Webdav, WebdavBucketURL(srv.URL, "bkt"), "", "")
+		testStorage(t, s)
+	})
+	t.Run("hdfs", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeWebhdfs{dir: t.TempDir(), owners: map[string]string{}})
+		defer srv.Close()
+		addr := strings.TrimPrefix(srv.URL, "http://")
+		if err := CreateHdfsDir(addr, "hadoop", "bkt"); err != nil {
+			t.Fatal(err)
+		}
+		s, _ := CreateStorage(models.Hdfs, HdfsBucketURL(addr, "bkt"), "hadoop", "")
+		testStorage(t, s)
+	})
+	t.Run("bos", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeBos{t, map[string][]byte{}, map[string]http.Header{}, map[string][]byte{}, map[string][]byte{}, map[string]string{}})
+		defer srv.Close()
+		s, _ := CreateStorage(models.Bos, srv.URL+"/bkt", "ak", "sk")
+		testStorage(t, s)
+	})
+	t.Run("kodo", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeKodo{t, map[string][]byte{}, map[string]map[string]string{}, map[int][]byte{}})
+		defer srv.Close()
+		s, _ := CreateStorage(models.Kodo, srv.URL+"/bkt?region=z0", "ak", "sk")
+		testStorage(t, s)
+	})
+	t.Run("azblob", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeAzblob{t, map[string][]byte{}, map[string]string{}, map[string][]byte{}})
+		defer srv.Close()
+		s, _ := CreateStorage(models.Azblob, srv.URL+"/"+azuriteAccount+"/bkt", azuriteAccount, azuriteKey)
+		testStorage(t, s)
+	})
+	t.Run("gcs", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeGcs{t, nil, map[string][]byte{}, map[string]http.Header{}, map[string][]byte{}, map[string]string{}})
+		defer srv.Close()
+		s, _ := CreateStorage(models.Gcs, srv.URL+"/bkt", "GOOG1ID", "secret")
+		testStorage(t, s)
+	})
+	// 前缀之外的对象和分片上传对包装后的存储不可见
+	t.Run("prefix/file", func(t *testing.T) {
+		s, _ := CreateStorage(models.File, t.TempDir()+"/", "", "")
+		if err := s.Put("p0", strings.NewReader("outside"), models.Default); err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, WithPrefix(s, "p/"))
+	})
+	t.Run("prefix/mem", func(t *testing.T) {
+		s, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+		if err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []string{"a", "p", "p0", "q/y/1"} {
+			if err := s.Put(k, strings.NewReader("outside"), models.Default); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.CreateMultipartUpload("outside", 5<<20, models.Default); err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, WithPrefix(s, "p/"))
+	})
+	// 加密后的存储对调用者表现为普通存储
+	t.Run("encrypt/file", func(t *testing.T) {
+		key, _ := NewEncryptionKey(bytes.Repeat([]byte{1}, 32))
+		s, _ := CreateStorage(models.File, t.TempDir()+"/", "", "")
+		testStorage(t, WithEncryption(s, key))
+	})
+	t.Run("encrypt/mem", func(t *testing.T) {
+		key, _ := NewEncryptionKey(bytes.Repeat([]byte{1}, 32))
+		s, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+		if err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, WithEncryption(s, key))
+	})
+}
@@ -24,20 +24,49 @@ type COS struct {
 	endpoint     string
 	checkSumKey  string
 	sumAlgorithm algorithm
+	bucketACL    aclCache
+	sse          *SSE
 }
 
 func (c *COS) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = cosChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
 	c.checkSumKey = meta
 	return nil
 }
 
 func (c *COS) IsSetMd5(flag bool) error {
+	c.sumAlgorithm = checksumCrc32
 	if flag {
 		c.sumAlgorithm = checksumMd5
 	}
 	return nil
 }
 
+func (c *COS) SetSSE(sse *SSE) error {
+	c.sse = sse
+	return nil
+}
+
+// sseCustomer SSE-C 的算法、密钥和密钥的 MD5，没有使用 SSE-C 时为空
+func (c *COS) sseCustomer() (algorithm, key, keyMD5 string) {
+	if !c.sse.customer() {
+		return "", "", ""
+	}
+	return "AES256", c.sse.customerKey(), c.sse.customerKeyMD5()
+}
+
+// headOptions 读取 SSE-C 加密的对象需要带上密钥
+func (c *COS) headOptions() *cos.ObjectHeadOptions {
+	if !c.sse.customer() {
+		return nil
+	}
+	opt := &cos.ObjectHeadOptions{}
+	opt.XCosSSECustomerAglo, opt.XCosSSECustomerKey, opt.XCosSSECustomerKeyMD5 = c.sseCustomer()
+	return opt
+}
+
 func (c *COS) String() string {
 	return fmt.Sprintf("cos://%s/", strings.Split(c.endpoint, ".")[0])
 }
@@ -51,7 +80,7 @@ func (c *COS) Create() error {
 }
 
 func (c *COS) Head(key string) (Object, error) {
-	resp, err := c.c.Object.Head(ctx, key, nil)
+	resp, err := c.c.Object.Head(ctx, key, c.headOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -67,11 +96,58 @@ func (c *COS) Head(key string) (Object, error) {
 	if val, ok := header["Last-Modified"]; ok {
 		mtime, _ = time.Parse(time.RFC1123, val[0])
 	}
-	return &obj{key, size, mtime, strings.HasSuffix(key, "/")}, nil
+	sc := toStorageClass(models.Cos, header.Get("x-cos-storage-class"))
+	return &objInfo{obj{key, size, mtime, strings.HasSuffix(key, "/"), sc}, headerMetadata(header, cosChecksumKeyPrefix)}, nil
+}
+
+func (c *COS) GetTags(key string) (map[string]string, error) {
+	r, _, err := c.c.Object.GetTagging(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(r.TagSet))
+	for _, t := range r.TagSet {
+		tags[t.Key] = t.Value
+	}
+	return tags, nil
+}
+
+func (c *COS) SetTags(key string, tags map[string]string) error {
+	opt := &cos.ObjectPutTaggingOptions{}
+	for k, v := range tags {
+		opt.TagSet = append(opt.TagSet, cos.ObjectTaggingTag{Key: k, Value: v})
+	}
+	_, err := c.c.Object.PutTagging(ctx, key, opt)
+	return tagErr(err)
+}
+
+func (c *COS) Restore(key string, days int) error {
+	_, err := c.c.Object.PostRestore(ctx, key, &cos.ObjectRestoreOptions{
+		Days: days,
+		Tier: &cos.CASJobParameters{Tier: "Standard"},
+	})
+	return restoreStarted(err)
+}
+
+func (c *COS) Restored(key string) (bool, error) {
+	resp, err := c.c.Object.Head(ctx, key, c.headOptions())
+	if err != nil {
+		return false, err
+	}
+	return restoreDone(resp.Header.Get("x-cos-restore")), nil
+}
+
+func (c *COS) GetChecksum(key string) (string, error) {
+	resp, err := c.c.Object.Head(ctx, key, c.headOptions())
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get(c.checkSumKey), nil
 }
 
 func (c *COS) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	params := &cos.ObjectGetOptions{}
+	params.XCosSSECustomerAglo, params.XCosSSECustomerKey, params.XCosSSECustomerKeyMD5 = c.sseCustomer()
 	if off > 0 || limit > 0 {
 		var r string
 		if limit > 0 {
@@ -91,22 +167,109 @@ func (c *COS) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func (c *COS) Put(key string, in io.Reader, acl models.CannedACLType) error {
-	var options *cos.ObjectPutOptions
-	checkSumMetaKey := cosChecksumKeyPrefix + c.sumAlgorithm.String()
-	if ins, ok := in.(io.ReadSeeker); ok {
-		header := http.Header(map[string][]string{
-			checkSumMetaKey: {generateChecksum(ins, c.sumAlgorithm)},
-		})
-		// cos 默认权限不支持 prw https://cloud.tencent.com/document/product/436/30752#.E6.93.8D.E4.BD.9C-permission
-		if acl == "" || acl == models.PublicReadWrite {
-			acl = models.Default
+func (c *COS) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	resp, _, err := c.c.Bucket.GetObjectVersions(ctx, &cos.BucketGetObjectVersionsOptions{
+		Prefix:          prefix,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionMarker,
+		MaxKeys:         int(limit),
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	vs := make([]Version, 0, len(resp.Version)+len(resp.DeleteMarker))
+	for _, v := range resp.Version {
+		t, _ := time.Parse(time.RFC3339, v.LastModified)
+		vs = append(vs, &version{obj{v.Key, v.Size, t, strings.HasSuffix(v.Key, "/"), toStorageClass(models.Cos, v.StorageClass)}, v.VersionId, false})
+	}
+	for _, v := range resp.DeleteMarker {
+		t, _ := time.Parse(time.RFC3339, v.LastModified)
+		vs = append(vs, &version{obj{v.Key, 0, t, strings.HasSuffix(v.Key, "/"), ""}, v.VersionId, true})
+	}
+	sortVersions(vs)
+	if !resp.IsTruncated {
+		return vs, "", "", nil
+	}
+	return vs, resp.NextKeyMarker, resp.NextVersionIdMarker, nil
+}
+
+func (c *COS) EnableVersioning() error {
+	_, err := c.c.Bucket.PutVersioning(ctx, &cos.BucketPutVersionOptions{Status: "Enabled"})
+	return err
+}
+
+func (c *COS) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	params := &cos.ObjectGetOptions{}
+	params.XCosSSECustomerAglo, params.XCosSSECustomerKey, params.XCosSSECustomerKeyMD5 = c.sseCustomer()
+	if off > 0 || limit > 0 {
+		var r string
+		if limit > 0 {
+			r = fmt.Sprintf("bytes=%d-%d", off, off+limit-1)
+		} else {
+			r = fmt.Sprintf("bytes=%d-", off)
 		}
-		options = &cos.ObjectPutOptions{
-			ACLHeaderOptions:       &cos.ACLHeaderOptions{XCosACL: string(acl)},
-			ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{XCosMetaXXX: &header},
+		params.Range = r
+	}
+	resp, err := c.c.Object.Get(ctx, key, params, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *COS) putHeaderOptions(o *PutOptions, checksum string) *cos.ObjectPutHeaderOptions {
+	options := &cos.ObjectPutHeaderOptions{}
+	header := userMetaHeader(o.Meta, cosChecksumKeyPrefix)
+	if checksum != "" {
+		header.Set(cosChecksumKeyPrefix+c.sumAlgorithm.String(), checksum)
+	}
+	if len(header) > 0 {
+		options.XCosMetaXXX = &header
+	}
+	if o.Meta != nil {
+		options.ContentType = o.Meta.ContentType
+		options.ContentEncoding = o.Meta.ContentEncoding
+		options.CacheControl = o.Meta.CacheControl
+		options.ContentDisposition = o.Meta.ContentDisposition
+		options.Expires = o.Meta.Expires
+	}
+	options.XCosStorageClass = fromStorageClass(models.Cos, o.StorageClass)
+	if len(o.Tags) > 0 {
+		options.XOptionHeader = &http.Header{}
+		options.XOptionHeader.Set("x-cos-tagging", encodeTags(o.Tags))
+	}
+	if c.sse != nil {
+		switch c.sse.Mode {
+		case SSEProvider:
+			options.XCosServerSideEncryption = "AES256"
+		case SSEKMS:
+			options.XCosServerSideEncryption = "cos/kms"
+			if c.sse.KMSKeyID != "" {
+				if options.XOptionHeader == nil {
+					options.XOptionHeader = &http.Header{}
+				}
+				options.XOptionHeader.Set("x-cos-server-side-encryption-cos-kms-key-id", c.sse.KMSKeyID)
+			}
 		}
 	}
+	options.XCosSSECustomerAglo, options.XCosSSECustomerKey, options.XCosSSECustomerKeyMD5 = c.sseCustomer()
+	return options
+}
+
+func (c *COS) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
+	checksum := o.Checksum
+	if ins, ok := in.(io.ReadSeeker); ok && checksum == "" {
+		checksum = generateChecksum(ins, c.sumAlgorithm)
+	}
+	// cos 默认权限不支持 prw https://cloud.tencent.com/document/product/436/30752#.E6.93.8D.E4.BD.9C-permission
+	if acl == "" || acl == models.PublicReadWrite {
+		acl = models.Default
+	}
+	options := &cos.ObjectPutOptions{
+		ACLHeaderOptions:       &cos.ACLHeaderOptions{XCosACL: string(acl)},
+		ObjectPutHeaderOptions: c.putHeaderOptions(o, checksum),
+	}
 	_, err := c.c.Object.Put(ctx, key, in, options)
 	return err
 }
@@ -141,7 +304,7 @@ func (c *COS) List(prefix, marker string, limit int64) ([]Object, error) {
 	for i := 0; i < n; i++ {
 		o := resp.Contents[i]
 		t, _ := time.Parse(time.RFC3339, o.LastModified)
-		objs[i] = &obj{o.Key, int64(o.Size), t, strings.HasSuffix(o.Key, "/")}
+		objs[i] = &obj{o.Key, int64(o.Size), t, strings.HasSuffix(o.Key, "/"), toStorageClass(models.Cos, o.StorageClass)}
 	}
 	return objs, nil
 }
@@ -150,12 +313,13 @@ func (c *COS) ListAll(prefix, marker string) (<-chan Object, error) {
 	return nil, notSupported
 }
 
-func (c *COS) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
-	var options *cos.InitiateMultipartUploadOptions
+func (c *COS) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
+	options := &cos.InitiateMultipartUploadOptions{
+		ObjectPutHeaderOptions: c.putHeaderOptions(o, o.Checksum),
+	}
 	if acl != models.Default && acl != "" {
-		options = &cos.InitiateMultipartUploadOptions{
-			ACLHeaderOptions: &cos.ACLHeaderOptions{XCosACL: string(acl)},
-		}
+		options.ACLHeaderOptions = &cos.ACLHeaderOptions{XCosACL: string(acl)}
 	}
 	resp, _, err := c.c.Object.InitiateMultipartUpload(ctx, key, options)
 	if err != nil {
@@ -165,7 +329,12 @@ func (c *COS) CreateMultipartUpload(key string, minSize int, acl models.CannedAC
 }
 
 func (c *COS) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
-	resp, err := c.c.Object.UploadPart(ctx, key, uploadID, num, bytes.NewReader(body), nil)
+	var opt *cos.ObjectUploadPartOptions
+	if c.sse.customer() {
+		opt = &cos.ObjectUploadPartOptions{}
+		opt.XCosSSECustomerAglo, opt.XCosSSECustomerKey, opt.XCosSSECustomerKeyMD5 = c.sseCustomer()
+	}
+	resp, err := c.c.Object.UploadPart(ctx, key, uploadID, num, bytes.NewReader(body), opt)
 	if err != nil {
 		return nil, err
 	}
@@ -221,9 +390,56 @@ func (c *COS) GetObjectAcl(key string) (models.CannedACLType, error) {
 	}
 }
 
-//func (c *COS) SetObjectAcl(key string, acl CannedACLType) error {
-//	c.c.Object.PutACL(ctx, key, acl)
-//}
+func (c *COS) GetBucketACL() (*ACL, error) {
+	return c.bucketACL.get(func() (*ACL, error) {
+		result, _, err := c.c.Bucket.GetACL(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return cosACL(result), nil
+	})
+}
+
+func (c *COS) GetACL(key string) (*ACL, error) {
+	result, _, err := c.c.Object.GetACL(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return cosACL(result), nil
+}
+
+func (c *COS) SetACL(key string, acl *ACL) error {
+	body := &cos.ACLXml{Owner: &cos.Owner{ID: acl.Owner}}
+	for _, g := range acl.Grants {
+		grantee := &cos.ACLGrantee{Type: "CanonicalUser", ID: g.Grantee}
+		if g.Grantee == AllUsers || g.Grantee == AuthenticatedUsers {
+			grantee = &cos.ACLGrantee{Type: "Group", URI: "http://cam.qcloud.com/groups/global/" + g.Grantee}
+		}
+		body.AccessControlList = append(body.AccessControlList, cos.ACLGrant{Grantee: grantee, Permission: g.Permission})
+	}
+	_, err := c.c.Object.PutACL(ctx, key, &cos.ObjectPutACLOptions{Body: body})
+	return err
+}
+
+func cosACL(x *cos.ACLXml) *ACL {
+	acl := &ACL{}
+	if x.Owner != nil {
+		acl.Owner = x.Owner.ID
+	}
+	for _, g := range x.AccessControlList {
+		if g.Grantee == nil {
+			continue
+		}
+		grantee := g.Grantee.ID
+		if group, ok := groupOf(g.Grantee.URI); ok {
+			grantee = group
+		} else if g.Grantee.URI != "" {
+			grantee = g.Grantee.URI
+		}
+		acl.Grants = append(acl.Grants, Grant{grantee, g.Permission})
+	}
+	return acl
+}
 
 func autoCOSEndpoint(bucketName, accessKey, secretKey string) (string, error) {
 	client := cos.NewClient(nil, &http.Client{
@@ -277,7 +493,7 @@ func newCOS(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
 		},
 	})
 	client.UserAgent = UserAgent
-	return &COS{client, uri.Host, cosChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32}, nil
+	return &COS{client, uri.Host, cosChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}, nil}, nil
 }
 
 func init() {
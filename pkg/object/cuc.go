@@ -16,7 +16,6 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
-	mapset "github.com/deckarep/golang-set"
 )
 
 const cucChecksumKeyPrefix = "x-amz-meta-cuoss-"
@@ -27,20 +26,31 @@ type Cuc struct {
 	ses          *session.Session
 	checkSumKey  string
 	sumAlgorithm algorithm
+	bucketACL    aclCache
+	sse          *SSE
 }
 
 func (c *Cuc) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = cucChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
 	c.checkSumKey = meta
 	return nil
 }
 
 func (c *Cuc) IsSetMd5(flag bool) error {
+	c.sumAlgorithm = checksumCrc32
 	if flag {
 		c.sumAlgorithm = checksumMd5
 	}
 	return nil
 }
 
+func (c *Cuc) SetSSE(sse *SSE) error {
+	c.sse = sse
+	return nil
+}
+
 func (c *Cuc) String() string {
 	return fmt.Sprintf("cuc://%s/", c.bucket)
 }
@@ -61,20 +71,52 @@ func (c *Cuc) Head(key string) (Object, error) {
 		Bucket: &c.bucket,
 		Key:    &key,
 	}
+	param.SSECustomerAlgorithm, param.SSECustomerKey = s3SSECustomer(c.sse)
 	r, err := c.s3.HeadObject(&param)
 	if err != nil {
 		return nil, err
 	}
-	return &obj{
-		key,
-		*r.ContentLength,
-		*r.LastModified,
-		strings.HasSuffix(key, "/"),
+	return &objInfo{
+		obj{
+			key,
+			*r.ContentLength,
+			*r.LastModified,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Cuc, aws.StringValue(r.StorageClass)),
+		},
+		s3Metadata(r),
 	}, nil
 }
 
+func (c *Cuc) GetTags(key string) (map[string]string, error) {
+	return s3GetTags(c.s3, c.bucket, key)
+}
+
+func (c *Cuc) SetTags(key string, tags map[string]string) error {
+	return s3SetTags(c.s3, c.bucket, key, tags)
+}
+
+func (c *Cuc) Restore(key string, days int) error {
+	return s3Restore(c.s3, c.bucket, key, days)
+}
+
+func (c *Cuc) Restored(key string) (bool, error) {
+	return s3Restored(c.s3, c.bucket, key, c.sse)
+}
+
+func (c *Cuc) GetChecksum(key string) (string, error) {
+	param := &s3.HeadObjectInput{Bucket: &c.bucket, Key: &key}
+	param.SSECustomerAlgorithm, param.SSECustomerKey = s3SSECustomer(c.sse)
+	r, err := c.s3.HeadObject(param)
+	if err != nil {
+		return "", err
+	}
+	return s3HeaderValue(r, c.checkSumKey), nil
+}
+
 func (c *Cuc) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	params := &s3.GetObjectInput{Bucket: &c.bucket, Key: &key}
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(c.sse)
 	if off > 0 || limit > 0 {
 		var r string
 		if limit > 0 {
@@ -97,7 +139,20 @@ func (c *Cuc) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func (c *Cuc) Put(key string, in io.Reader, acl models.CannedACLType) error {
+func (c *Cuc) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	return s3ListVersions(c.s3, models.Cuc, c.bucket, prefix, keyMarker, versionMarker, limit)
+}
+
+func (c *Cuc) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	return s3GetVersion(c.s3, c.bucket, key, versionID, off, limit, c.sse)
+}
+
+func (c *Cuc) EnableVersioning() error {
+	return s3EnableVersioning(c.s3, c.bucket)
+}
+
+func (c *Cuc) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
 	var body io.ReadSeeker
 	if b, ok := in.(io.ReadSeeker); ok {
 		body = b
@@ -110,17 +165,33 @@ func (c *Cuc) Put(key string, in io.Reader, acl models.CannedACLType) error {
 	}
 
 	checkSumMetaKey := "cuoss-" + c.sumAlgorithm.String()
-	checksum := generateChecksum(body, c.sumAlgorithm)
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(body, c.sumAlgorithm)
+	}
 	params := &s3.PutObjectInput{
 		Bucket:   &c.bucket,
 		Key:      &key,
 		Body:     body,
-		Metadata: map[string]*string{checkSumMetaKey: &checksum},
+		Metadata: s3UserMeta(o.Meta, checkSumMetaKey, checksum),
+	}
+	if o.Meta != nil {
+		params.ContentType = s3Header(o.Meta.ContentType)
+		params.ContentEncoding = s3Header(o.Meta.ContentEncoding)
+		params.CacheControl = s3Header(o.Meta.CacheControl)
+		params.ContentDisposition = s3Header(o.Meta.ContentDisposition)
+		params.Expires = s3Expires(o.Meta.Expires)
+	}
+	if len(o.Tags) > 0 {
+		params.Tagging = aws.String(encodeTags(o.Tags))
 	}
+	params.StorageClass = s3Header(fromStorageClass(models.Cuc, o.StorageClass))
 	if acl == models.Default {
 		acl = c.getBucketAcl()
 	}
 	params.ACL = aws.String(string(acl))
+	params.ServerSideEncryption, params.SSEKMSKeyId = s3SSE(c.sse)
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(c.sse)
 	_, err := c.s3.PutObject(params)
 	return err
 }
@@ -165,6 +236,7 @@ func (c *Cuc) List(prefix, marker string, limit int64) ([]Object, error) {
 			*o.Size,
 			*o.LastModified,
 			strings.HasSuffix(*o.Key, "/"),
+			toStorageClass(models.Cuc, aws.StringValue(o.StorageClass)),
 		}
 	}
 	return objs, nil
@@ -174,15 +246,27 @@ func (c *Cuc) ListAll(prefix, marker string) (<-chan Object, error) {
 	return nil, notSupported
 }
 
-func (c *Cuc) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
+func (c *Cuc) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
 	params := &s3.CreateMultipartUploadInput{
 		Bucket: &c.bucket,
 		Key:    &key,
 	}
+	params.Metadata = s3UserMeta(o.Meta, "cuoss-"+c.sumAlgorithm.String(), o.Checksum)
+	if o.Meta != nil {
+		params.ContentType = s3Header(o.Meta.ContentType)
+		params.ContentEncoding = s3Header(o.Meta.ContentEncoding)
+		params.CacheControl = s3Header(o.Meta.CacheControl)
+		params.ContentDisposition = s3Header(o.Meta.ContentDisposition)
+		params.Expires = s3Expires(o.Meta.Expires)
+	}
+	params.StorageClass = s3Header(fromStorageClass(models.Cuc, o.StorageClass))
 	if acl == models.Default {
 		acl = c.getBucketAcl()
 	}
 	params.ACL = aws.String(string(acl))
+	params.ServerSideEncryption, params.SSEKMSKeyId = s3SSE(c.sse)
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(c.sse)
 	resp, err := c.s3.CreateMultipartUpload(params)
 	if err != nil {
 		return nil, err
@@ -199,6 +283,7 @@ func (c *Cuc) UploadPart(key string, uploadID string, num int, body []byte) (*Pa
 		Body:       bytes.NewReader(body),
 		PartNumber: &n,
 	}
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(c.sse)
 	resp, err := c.s3.UploadPart(params)
 	if err != nil {
 		return nil, err
@@ -254,79 +339,28 @@ func (c *Cuc) ListUploads(marker string) ([]*PendingPart, string, error) {
 }
 
 func (c *Cuc) getBucketAcl() models.CannedACLType {
-	inputBucket := &s3.GetBucketAclInput{
-		Bucket: &c.bucket,
-	}
-	result, err := c.s3.GetBucketAcl(inputBucket)
+	acl, err := c.GetBucketACL()
 	if err != nil {
 		return ""
 	}
-	allUsersPermissions := mapset.NewSet()
-	authenticatedRead := false
-	for _, value := range result.Grants {
-		if value.Grantee.URI != nil && strings.Contains(*value.Grantee.URI, "AllUsers") {
-			allUsersPermissions.Add(aws.StringValue(value.Permission))
-		}
-		if strings.Contains(aws.StringValue(value.Grantee.URI), "AuthenticatedUsers") && aws.StringValue(value.Permission) == "READ" {
-			authenticatedRead = true
-		}
-	}
-	read := allUsersPermissions.Contains("READ")
-	write := allUsersPermissions.Contains("WRITE")
-	if read && write { // all user read write
-		return models.PublicReadWrite
-	} else if read { // all read
-		return models.PublicRead
-	} else if authenticatedRead {
-		return models.AuthenticatedRead
-	} else { //owner FULL_CONTROL
-		return models.Private
-	}
+	canned, _ := acl.Canned()
+	return canned
 }
 
 func (c *Cuc) GetObjectAcl(key string) (models.CannedACLType, error) {
-	// object acl
-	input := &s3.GetObjectAclInput{
-		Bucket: &c.bucket,
-		Key:    &key,
-	}
-	result, err := c.s3.GetObjectAcl(input)
-	if err != nil || len(result.Grants) == 0 {
-		return "", err
-	}
-	// bucket acl
-	inputBucket := &s3.GetBucketAclInput{
-		Bucket: &c.bucket,
-	}
-	resultBucket, err := c.s3.GetBucketAcl(inputBucket)
-	if err != nil || len(resultBucket.Grants) == 0 {
-		return "", err
-	}
-	if resultBucket.String() == result.String() {
-		return models.Default, nil
-	}
+	return objectCanned(c, key)
+}
 
-	allUsersPermissions := mapset.NewSet()
-	authenticatedRead := false
-	for _, value := range result.Grants {
-		if value.Grantee.URI != nil && strings.Contains(*value.Grantee.URI, "AllUsers") {
-			allUsersPermissions.Add(aws.StringValue(value.Permission))
-		}
-		if strings.Contains(aws.StringValue(value.Grantee.URI), "AuthenticatedUsers") && aws.StringValue(value.Permission) == "READ" {
-			authenticatedRead = true
-		}
-	}
-	read := allUsersPermissions.Contains("READ")
-	write := allUsersPermissions.Contains("WRITE")
-	if read && write { // all user read write
-		return models.PublicReadWrite, nil
-	} else if read { // all read
-		return models.PublicRead, nil
-	} else if authenticatedRead {
-		return models.AuthenticatedRead, nil
-	} else { //owner FULL_CONTROL
-		return models.Private, nil
-	}
+func (c *Cuc) GetBucketACL() (*ACL, error) {
+	return c.bucketACL.get(func() (*ACL, error) { return s3GetBucketACL(c.s3, c.bucket) })
+}
+
+func (c *Cuc) GetACL(key string) (*ACL, error) {
+	return s3GetACL(c.s3, c.bucket, key)
+}
+
+func (c *Cuc) SetACL(key string, acl *ACL) error {
+	return s3SetACL(c.s3, c.bucket, key, acl)
 }
 
 func parseCucRegion(endpoint string) string {
@@ -391,7 +425,7 @@ func newCuc(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
 	}
 
 	ses.Handlers.Build.PushFront(DisableSha256Func)
-	return &Cuc{bucketName, s3.New(ses), ses, cucChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32}, nil
+	return &Cuc{bucketName, s3.New(ses), ses, cucChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}, nil}, nil
 }
 
 func init() {
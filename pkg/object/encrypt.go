@@ -0,0 +1,610 @@
+package object
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"obs-sync/models"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// 加密对象的格式：24 字节的头部（魔数、密钥 ID、随机的基础 nonce），之后是按 encChunk 分帧的
+// AES-GCM 密文，每帧带 16 字节的认证标签。第 i 帧的 nonce 为基础 nonce 的后 8 字节异或 i，
+// 帧的位置由明文偏移量直接算出，所以可以只解密范围读取涉及的帧，分片上传也可以各自加密
+const (
+	encMagic   = "OSE1"
+	encKeyID   = 8
+	encHeader  = len(encMagic) + encKeyID + 12
+	encChunk   = 64 << 10
+	encTag     = 16
+	encFrame   = encChunk + encTag
+	encKeyMeta = "encryption-key-id"
+	// encSizeMeta 明文大小，写入时不知道大小则不记录
+	encSizeMeta = "plaintext-size"
+	// encSumMeta 调用方传入的明文校验值，不能作为密文的校验值记录
+	encSumMeta = "plaintext-checksum"
+)
+
+// EncryptionKey 客户端加密使用的 AES-256 密钥
+type EncryptionKey struct {
+	ID   string // 密钥 SHA-256 的前 8 字节，写入对象的头部和元数据
+	aead cipher.AEAD
+	id   []byte
+}
+
+// NewEncryptionKey 由 32 字节的密钥创建
+func NewEncryptionKey(key []byte) (*EncryptionKey, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &EncryptionKey{ID: hex.EncodeToString(sum[:encKeyID]), aead: aead, id: sum[:encKeyID]}, nil
+}
+
+// readKeyFile 读取 32 字节的密钥，文件内容为原始密钥，或者其 hex、base64 编码
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 32 {
+		text := string(bytes.TrimSpace(data))
+		if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+			data = key
+		} else if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+			data = key
+		} else {
+			return nil, fmt.Errorf("%s: not a 32-byte key in raw, hex or base64", path)
+		}
+	}
+	return data, nil
+}
+
+// LoadEncryptionKey 读取密钥文件，格式见 readKeyFile
+func LoadEncryptionKey(path string) (*EncryptionKey, error) {
+	data, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptionKey(data)
+}
+
+// nonce 第 i 帧的 nonce
+func (k *EncryptionKey) nonce(base []byte, i uint64) []byte {
+	n := append([]byte(nil), base...)
+	binary.BigEndian.PutUint64(n[4:], binary.BigEndian.Uint64(n[4:])^i)
+	return n
+}
+
+// seal 从第 i 帧开始加密 plain，追加到 dst
+func (k *EncryptionKey) seal(dst, plain, base []byte, i uint64) []byte {
+	for len(plain) > 0 {
+		n := len(plain)
+		if n > encChunk {
+			n = encChunk
+		}
+		dst = k.aead.Seal(dst, k.nonce(base, i), plain[:n], k.id)
+		plain, i = plain[n:], i+1
+	}
+	return dst
+}
+
+func (k *EncryptionKey) header(base []byte) []byte {
+	h := make([]byte, 0, encHeader)
+	h = append(h, encMagic...)
+	h = append(h, k.id...)
+	return append(h, base...)
+}
+
+// parseHeader 检查头部，返回基础 nonce
+func (k *EncryptionKey) parseHeader(key string, h []byte) ([]byte, error) {
+	if len(h) != encHeader || string(h[:len(encMagic)]) != encMagic {
+		return nil, fmt.Errorf("%s is not encrypted", key)
+	}
+	if id := h[len(encMagic) : len(encMagic)+encKeyID]; !bytes.Equal(id, k.id) {
+		return nil, fmt.Errorf("%s is encrypted with key %x, not %s", key, id, k.ID)
+	}
+	return h[len(encMagic)+encKeyID:], nil
+}
+
+// plainSize 由密文大小算出明文大小，不是加密对象时原样返回
+func plainSize(size int64) int64 {
+	if size < int64(encHeader) {
+		return size
+	}
+	frames := (size - int64(encHeader) + encFrame - 1) / encFrame
+	return size - int64(encHeader) - frames*encTag
+}
+
+type withEncryption struct {
+	os      ObjectStorage
+	key     *EncryptionKey
+	uploads sync.Map // uploadID -> *encUpload
+}
+
+// encUpload 分片上传的基础 nonce 和分片大小，分片大小是 encChunk 的整数倍，
+// 第 n 个分片从第 (n-1)*partSize/encChunk 帧开始
+type encUpload struct {
+	nonce    []byte
+	partSize int
+}
+
+// WithEncryption returns an object storage that encrypts the objects written
+// to os with AES-GCM and decrypts them when read. Sizes returned by Head and
+// the listing are the plaintext sizes.
+func WithEncryption(os ObjectStorage, key *EncryptionKey) ObjectStorage {
+	return &withEncryption{os: os, key: key}
+}
+
+func (w *withEncryption) SetCheckSumKey(meta string) error {
+	return w.os.SetCheckSumKey(meta)
+}
+
+func (w *withEncryption) IsSetMd5(flag bool) error {
+	return w.os.IsSetMd5(flag)
+}
+
+func (w *withEncryption) String() string {
+	return w.os.String()
+}
+
+func (w *withEncryption) Create() error {
+	return w.os.Create()
+}
+
+// plain 把对象的大小改为明文大小，去掉加密使用的元数据
+func (w *withEncryption) plain(o Object) Object {
+	switch po := o.(type) {
+	case *obj:
+		po.size = plainSize(po.size)
+	case *file:
+		if !po.isDir {
+			po.size = plainSize(po.size)
+		}
+	case *objInfo:
+		po.size = plainSize(po.size)
+		if po.meta != nil && po.meta.UserMeta != nil {
+			meta := *po.meta
+			meta.UserMeta = make(map[string]string, len(po.meta.UserMeta))
+			for k, v := range po.meta.UserMeta {
+				if k != encKeyMeta && k != encSizeMeta && k != encSumMeta {
+					meta.UserMeta[k] = v
+				}
+			}
+			po.meta = &meta
+		}
+	}
+	return o
+}
+
+func (w *withEncryption) Head(key string) (Object, error) {
+	o, err := w.os.Head(key)
+	if err != nil {
+		return nil, err
+	}
+	return w.plain(o), nil
+}
+
+// decReader 逐帧读取并解密，跳过第一帧中 skip 之前的数据，最多返回 left 字节，left 为 -1 时不限制。
+// 密文在帧的边界被截断时每一帧都能通过认证，所以读到结尾时检查解密到的位置与明文大小 size 一致，
+// size 为 -1（写入时不知道大小）时不检查
+type decReader struct {
+	in    io.ReadCloser
+	key   *EncryptionKey
+	nonce []byte
+	i     uint64
+	skip  int
+	left  int64
+	size  int64
+	frame []byte
+	out   []byte
+}
+
+func (r *decReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.left == 0 {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.in, r.frame[:encFrame])
+		if n == 0 && err == io.EOF {
+			if pos := int64(r.i) * encChunk; r.size >= 0 && pos < r.size {
+				return 0, fmt.Errorf("encrypted object is truncated at %d of %d bytes", pos, r.size)
+			}
+			return 0, io.EOF
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if n <= encTag {
+			return 0, errors.New("encrypted object is truncated")
+		}
+		plain, err := r.key.aead.Open(r.frame[:0], r.key.nonce(r.nonce, r.i), r.frame[:n], r.key.id)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt frame %d: %s", r.i, err)
+		}
+		if r.size >= 0 && int64(r.i)*encChunk+int64(len(plain)) > r.size {
+			return 0, fmt.Errorf("encrypted object is larger than %d bytes", r.size)
+		}
+		r.i++
+		if len(plain) < encChunk {
+			r.size = -1 // 最后一帧，之后不会再有数据
+		}
+		if r.skip >= len(plain) {
+			return 0, io.EOF
+		}
+		r.out, r.skip = plain[r.skip:], 0
+		if r.left >= 0 && int64(len(r.out)) > r.left {
+			r.out = r.out[:r.left]
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	if r.left > 0 {
+		r.left -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decReader) Close() error {
+	return r.in.Close()
+}
+
+// Get 只读取范围涉及的帧，不从头读取时单独读取头部
+func (w *withEncryption) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	o, err := w.os.Head(key)
+	if err != nil {
+		return nil, err
+	}
+	return w.decrypt(key, off, limit, sizeMeta(o), func(off, limit int64) (io.ReadCloser, error) {
+		return w.os.Get(key, off, limit)
+	})
+}
+
+// sizeMeta 元数据中记录的明文大小，没有记录时返回 -1
+func sizeMeta(o Object) int64 {
+	if oi, ok := o.(ObjectInfo); ok && oi.Metadata() != nil {
+		if size, err := strconv.ParseInt(oi.Metadata().UserMeta[encSizeMeta], 10, 64); err == nil {
+			return size
+		}
+	}
+	return -1
+}
+
+// decrypt 通过 get 读取密文并解密，size 为元数据中的明文大小
+func (w *withEncryption) decrypt(key string, off, limit, size int64, get func(off, limit int64) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	first := off / encChunk
+	encOff, encLimit := int64(encHeader)+first*encFrame, int64(-1)
+	if limit > 0 {
+		encLimit = ((off+limit-1)/encChunk - first + 1) * encFrame
+	} else {
+		limit = -1
+	}
+	var in io.ReadCloser
+	var err error
+	h := make([]byte, encHeader)
+	if first == 0 {
+		if encLimit > 0 {
+			encLimit += int64(encHeader)
+		}
+		if in, err = get(0, encLimit); err != nil {
+			return nil, err
+		}
+		if _, err = io.ReadFull(in, h); err != nil {
+			in.Close()
+			return nil, fmt.Errorf("read header of %s: %s", key, err)
+		}
+	} else {
+		if in, err = get(0, int64(encHeader)); err != nil {
+			return nil, err
+		}
+		_, err = io.ReadFull(in, h)
+		in.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read header of %s: %s", key, err)
+		}
+		if in, err = get(encOff, encLimit); err != nil {
+			return nil, err
+		}
+	}
+	nonce, err := w.key.parseHeader(key, h)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	return &decReader{in: in, key: w.key, nonce: nonce, i: uint64(first), skip: int(off - first*encChunk),
+		left: limit, size: size, frame: make([]byte, encFrame)}, nil
+}
+
+// encReader 逐帧加密 in，读完后检查明文大小与 size 一致，size 为 -1 时不检查
+type encReader struct {
+	in    io.Reader
+	key   *EncryptionKey
+	nonce []byte
+	i     uint64
+	size  int64
+	read  int64
+	eof   bool
+	plain []byte
+	out   []byte
+}
+
+func (r *encReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.in, r.plain)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+		r.read += int64(n)
+		if r.eof && r.size >= 0 && r.read != r.size {
+			return 0, fmt.Errorf("read %d bytes, expected %d", r.read, r.size)
+		}
+		r.out = r.key.seal(r.out[:0], r.plain[:n], r.nonce, r.i)
+		r.i++
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func randomNonce() []byte {
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return nonce
+}
+
+// putOptions 在元数据中记录密钥 ID 和明文大小，覆盖调用方元数据中的同名项
+// （如从另一个加密的桶复制来的元数据）。调用方的校验值是明文的，记录在 encSumMeta 中，
+// 被包装的存储对密文计算校验值，否则完整读取时会校验失败
+func (w *withEncryption) putOptions(opts []PutOption) ([]PutOption, int64) {
+	o := applyPutOptions(opts)
+	meta := Metadata{}
+	if o.Meta != nil {
+		meta = *o.Meta
+	}
+	meta.UserMeta = map[string]string{}
+	if o.Meta != nil {
+		for k, v := range o.Meta.UserMeta {
+			meta.UserMeta[k] = v
+		}
+	}
+	meta.UserMeta[encKeyMeta] = w.key.ID
+	delete(meta.UserMeta, encSizeMeta)
+	if o.Size >= 0 {
+		meta.UserMeta[encSizeMeta] = strconv.FormatInt(o.Size, 10)
+	}
+	delete(meta.UserMeta, encSumMeta)
+	if o.Checksum != "" {
+		meta.UserMeta[encSumMeta] = o.Checksum
+	}
+	return append(opts, WithMetadata(&meta), WithChecksum("")), o.Size
+}
+
+func (w *withEncryption) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	opts, size := w.putOptions(opts)
+	nonce := randomNonce()
+	r := &encReader{in: in, key: w.key, nonce: nonce, size: size, plain: make([]byte, encChunk),
+		out: w.key.header(nonce)}
+	return w.os.Put(key, r, acl, opts...)
+}
+
+func (w *withEncryption) Delete(key string) error {
+	return w.os.Delete(key)
+}
+
+func (w *withEncryption) List(prefix, marker string, limit int64) ([]Object, error) {
+	objs, err := w.os.List(prefix, marker, limit)
+	for _, o := range objs {
+		w.plain(o)
+	}
+	return objs, err
+}
+
+func (w *withEncryption) ListAll(prefix, marker string) (<-chan Object, error) {
+	r, err := w.os.ListAll(prefix, marker)
+	if err != nil {
+		return nil, err
+	}
+	r2 := make(chan Object, 10240)
+	go func() {
+		defer close(r2)
+		for o := range r {
+			if o != nil {
+				o = w.plain(o)
+			}
+			r2 <- o
+		}
+	}()
+	return r2, nil
+}
+
+// CreateMultipartUpload 分片大小对齐到 encChunk，已知大小时调大分片使分片数不超过 MaxCount，
+// 调用者需要按返回的 MinPartSize 切分
+func (w *withEncryption) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	opts, size := w.putOptions(opts)
+	upload, err := w.os.CreateMultipartUpload(key, minSize, acl, opts...)
+	if err != nil {
+		return nil, err
+	}
+	partSize := upload.MinPartSize
+	if partSize == 0 {
+		partSize = minSize
+	}
+	partSize = (partSize + encChunk - 1) / encChunk * encChunk
+	if upload.MaxCount > 0 && size > int64(partSize)*int64(upload.MaxCount) {
+		partSize = int((size/int64(upload.MaxCount)-1)>>20+1) << 20 // align to MB
+	}
+	w.uploads.Store(upload.UploadID, &encUpload{randomNonce(), partSize})
+	return &MultipartUpload{MinPartSize: partSize, MaxCount: upload.MaxCount, UploadID: upload.UploadID}, nil
+}
+
+func (w *withEncryption) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	v, ok := w.uploads.Load(uploadID)
+	if !ok {
+		return nil, fmt.Errorf("upload %s of %s was not created by this client", uploadID, key)
+	}
+	u := v.(*encUpload)
+	if len(body) > u.partSize {
+		return nil, fmt.Errorf("part %d of %s is larger than the part size %d", num, key, u.partSize)
+	}
+	var data []byte
+	if num == 1 {
+		data = w.key.header(u.nonce)
+	}
+	data = w.key.seal(data, body, u.nonce, uint64(num-1)*uint64(u.partSize/encChunk))
+	return w.os.UploadPart(key, uploadID, num, data)
+}
+
+func (w *withEncryption) AbortUpload(key string, uploadID string) {
+	w.uploads.Delete(uploadID)
+	w.os.AbortUpload(key, uploadID)
+}
+
+func (w *withEncryption) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	err := w.os.CompleteUpload(key, uploadID, parts)
+	if err == nil {
+		w.uploads.Delete(uploadID)
+	}
+	return err
+}
+
+func (w *withEncryption) ListUploads(marker string) ([]*PendingPart, string, error) {
+	return w.os.ListUploads(marker)
+}
+
+func (w *withEncryption) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return w.os.GetObjectAcl(key)
+}
+
+// GetChecksum 校验值为写入时传入的明文校验值，没有传入时返回空
+func (w *withEncryption) GetChecksum(key string) (string, error) {
+	o, err := w.os.Head(key)
+	if err != nil {
+		return "", err
+	}
+	if oi, ok := o.(ObjectInfo); ok && oi.Metadata() != nil {
+		return oi.Metadata().UserMeta[encSumMeta], nil
+	}
+	return "", nil
+}
+
+// GetTags 被包装的存储不支持标签时视为没有标签
+func (w *withEncryption) GetTags(key string) (map[string]string, error) {
+	if t, ok := w.os.(Tagger); ok {
+		return t.GetTags(key)
+	}
+	return nil, nil
+}
+
+func (w *withEncryption) SetTags(key string, tags map[string]string) error {
+	if t, ok := w.os.(Tagger); ok {
+		return t.SetTags(key, tags)
+	}
+	return notSupported
+}
+
+func (w *withEncryption) GetBucketACL() (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetBucketACL()
+	}
+	return nil, notSupported
+}
+
+func (w *withEncryption) GetACL(key string) (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetACL(key)
+	}
+	return nil, notSupported
+}
+
+func (w *withEncryption) SetACL(key string, acl *ACL) error {
+	if a, ok := w.os.(ACLer); ok {
+		return a.SetACL(key, acl)
+	}
+	return notSupported
+}
+
+func (w *withEncryption) Restore(key string, days int) error {
+	if r, ok := w.os.(Restorer); ok {
+		return r.Restore(key, days)
+	}
+	return notSupported
+}
+
+func (w *withEncryption) Restored(key string) (bool, error) {
+	if r, ok := w.os.(Restorer); ok {
+		return r.Restored(key)
+	}
+	return false, notSupported
+}
+
+// ListVersions 版本的大小改为明文大小
+func (w *withEncryption) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	v, ok := w.os.(Versioner)
+	if !ok {
+		return nil, "", "", notSupported
+	}
+	vs, nextKey, nextVersion, err := v.ListVersions(prefix, keyMarker, versionMarker, limit)
+	for _, o := range vs {
+		if p, ok := o.(*version); ok && !p.deleteMarker {
+			p.size = plainSize(p.size)
+		}
+	}
+	return vs, nextKey, nextVersion, err
+}
+
+// GetVersion 解密读取的版本，列举版本时没有元数据，所以不检查明文大小
+func (w *withEncryption) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	v, ok := w.os.(Versioner)
+	if !ok {
+		return nil, notSupported
+	}
+	return w.decrypt(key, off, limit, -1, func(off, limit int64) (io.ReadCloser, error) {
+		return v.GetVersion(key, versionID, off, limit)
+	})
+}
+
+func (w *withEncryption) EnableVersioning() error {
+	if e, ok := w.os.(VersioningEnabler); ok {
+		return e.EnableVersioning()
+	}
+	return notSupported
+}
+
+// WithKeyFile 使用密钥文件加解密 os，path 为空时原样返回
+func WithKeyFile(os ObjectStorage, path string) (ObjectStorage, error) {
+	if path == "" {
+		return os, nil
+	}
+	key, err := LoadEncryptionKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return WithEncryption(os, key), nil
+}
+
+var _ ObjectStorage = &withEncryption{}
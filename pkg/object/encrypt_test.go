@@ -0,0 +1,238 @@
+package object
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEncryptionKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{7}, 32)
+	dir := t.TempDir()
+	want, _ := NewEncryptionKey(raw)
+	for name, data := range map[string]string{
+		"raw":    string(raw),
+		"hex":    hex.EncodeToString(raw) + "\n",
+		"base64": "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(data), 0600)
+		if k, err := LoadEncryptionKey(path); err != nil || k.ID != want.ID {
+			t.Fatalf("load %s key: %v %v", name, k, err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "short"), []byte("secret"), 0600)
+	if _, err := LoadEncryptionKey(filepath.Join(dir, "short")); err == nil {
+		t.Fatal("loaded a short key")
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	key, _ := NewEncryptionKey(bytes.Repeat([]byte{1}, 32))
+	other, _ := NewEncryptionKey(bytes.Repeat([]byte{2}, 32))
+	raw, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+	if err := raw.Create(); err != nil {
+		t.Fatal(err)
+	}
+	s := WithEncryption(raw, key)
+
+	data := make([]byte, 3*encChunk+100)
+	rand.New(rand.NewSource(1)).Read(data)
+	meta := &Metadata{ContentType: "text/plain", UserMeta: map[string]string{"owner": "x"}}
+	if err := s.Put("a", bytes.NewReader(data), models.Default, WithMetadata(meta), WithSize(int64(len(data)))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("b", bytes.NewReader(data), models.Default, WithSize(1)); err == nil {
+		t.Fatal("put with a wrong size")
+	}
+
+	o, err := raw.Head("a")
+	if err != nil || o.Size() != int64(encHeader+len(data)+4*encTag) {
+		t.Fatalf("head encrypted object: %v %v", o, err)
+	}
+	um := o.(ObjectInfo).Metadata().UserMeta
+	if um[encKeyMeta] != key.ID || um[encSizeMeta] != "196708" || um["owner"] != "x" {
+		t.Fatalf("metadata of encrypted object: %v", um)
+	}
+	// 从另一个加密的桶复制来的元数据不能覆盖密钥 ID 和明文大小
+	copied := &Metadata{UserMeta: map[string]string{encKeyMeta: other.ID, encSizeMeta: "1", "owner": "y"}}
+	if err := s.Put("c", strings.NewReader("hello"), models.Default, WithMetadata(copied)); err != nil {
+		t.Fatal(err)
+	}
+	o, _ = raw.Head("c")
+	if um = o.(ObjectInfo).Metadata().UserMeta; um[encKeyMeta] != key.ID || um[encSizeMeta] != "" || um["owner"] != "y" {
+		t.Fatalf("metadata of a copied object: %v", um)
+	}
+	if in, err := s.Get("c", 0, -1); err != nil {
+		t.Fatal(err)
+	} else if got, _ := ioutil.ReadAll(in); string(got) != "hello" {
+		t.Fatalf("get a copied object: %q", got)
+	}
+	raw.Delete("c")
+	// 明文的校验值不作为密文的校验值记录
+	if err := s.Put("d", strings.NewReader("hello"), models.Default, WithChecksum("plain-sum")); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := s.(Checksummer).GetChecksum("d"); err != nil || c != "plain-sum" {
+		t.Fatalf("checksum: %q %v", c, err)
+	}
+	if c, _ := raw.(Checksummer).GetChecksum("d"); c == "plain-sum" {
+		t.Fatal("plaintext checksum is stored as the checksum of the ciphertext")
+	}
+	if o, _ = s.Head("d"); len(o.(ObjectInfo).Metadata().UserMeta) != 0 {
+		t.Fatalf("metadata with a checksum: %v", o.(ObjectInfo).Metadata().UserMeta)
+	}
+	raw.Delete("d")
+	in, _ := raw.Get("a", 0, -1)
+	enc, _ := ioutil.ReadAll(in)
+	in.Close()
+	if bytes.Contains(enc, data[:64]) {
+		t.Fatal("data is not encrypted")
+	}
+
+	o, err = s.Head("a")
+	if err != nil || o.Size() != int64(len(data)) || o.(ObjectInfo).Metadata().ContentType != "text/plain" {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if um = o.(ObjectInfo).Metadata().UserMeta; len(um) != 1 || um["owner"] != "x" {
+		t.Fatalf("metadata: %v", um)
+	}
+	objs, err := s.List("", "", 10)
+	if err != nil || len(objs) != 1 || objs[0].Size() != int64(len(data)) {
+		t.Fatalf("list: %v %v", objs, err)
+	}
+
+	for _, c := range [][2]int64{{0, -1}, {1, 10}, {encChunk - 3, 6}, {encChunk, encChunk}, {2*encChunk + 5, -1}, {encChunk + 1, 3 * encChunk}} {
+		in, err := s.Get("a", c[0], c[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(in)
+		in.Close()
+		want := data[c[0]:]
+		if c[1] > 0 && c[1] < int64(len(want)) {
+			want = want[:c[1]]
+		}
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("get %d+%d: %d bytes, want %d, %v", c[0], c[1], len(got), len(want), err)
+		}
+	}
+
+	if _, err = WithEncryption(raw, other).Get("a", 0, -1); err == nil || !strings.Contains(err.Error(), key.ID) {
+		t.Fatalf("get with another key: %v", err)
+	}
+	if _, err = WithEncryption(raw, other).Get("a", 2*encChunk, 10); err == nil {
+		t.Fatal("ranged get with another key")
+	}
+	raw.Put("plain", strings.NewReader("not encrypted"), models.Default)
+	if _, err = s.Get("plain", 0, -1); err == nil {
+		t.Fatal("get a plain object")
+	}
+	// 在帧的边界截断后每一帧都能通过认证，由明文大小发现
+	o, _ = raw.Head("a")
+	encMeta := WithMetadata(o.(ObjectInfo).Metadata())
+	raw.Put("a", bytes.NewReader(enc[:encHeader+2*encFrame]), models.Default, encMeta)
+	for _, c := range [][2]int64{{0, -1}, {encChunk, -1}, {encChunk, 3 * encChunk}} {
+		in, _ = s.Get("a", c[0], c[1])
+		if _, err = ioutil.ReadAll(in); err == nil || !strings.Contains(err.Error(), "truncated") {
+			t.Fatalf("read a truncated object from %d: %v", c[0], err)
+		}
+	}
+	in, _ = s.Get("a", 0, 2*encChunk)
+	if got, err := ioutil.ReadAll(in); err != nil || !bytes.Equal(got, data[:2*encChunk]) {
+		t.Fatalf("read the frames before the truncation: %v", err)
+	}
+	// 篡改密文后认证失败
+	enc[encHeader+encChunk+encTag] ^= 1
+	raw.Put("a", bytes.NewReader(enc), models.Default, encMeta)
+	in, _ = s.Get("a", 0, -1)
+	if _, err = ioutil.ReadAll(in); err == nil {
+		t.Fatal("read a tampered object")
+	}
+
+	// 分片大小对齐到帧，分片按帧编号加密，与一次写入的格式相同
+	up, err := s.CreateMultipartUpload("big", 100000, models.Default, WithSize(int64(len(data))))
+	if err != nil || up.MinPartSize != 2*encChunk {
+		t.Fatalf("create upload: %+v %v", up, err)
+	}
+	if _, err = s.UploadPart("big", up.UploadID, 1, data); err == nil {
+		t.Fatal("upload a part larger than the part size")
+	}
+	p2, err := s.UploadPart("big", up.UploadID, 2, data[up.MinPartSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := s.UploadPart("big", up.UploadID, 1, data[:up.MinPartSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, []*Part{p1, p2}); err != nil {
+		t.Fatal(err)
+	}
+	if o, err = s.Head("big"); err != nil || o.Size() != int64(len(data)) {
+		t.Fatalf("head multipart object: %v %v", o, err)
+	}
+	in, _ = s.Get("big", encChunk+10, 2*encChunk)
+	got, err := ioutil.ReadAll(in)
+	in.Close()
+	if err != nil || !bytes.Equal(got, data[encChunk+10:3*encChunk+10]) {
+		t.Fatalf("get across parts: %d bytes, %v", len(got), err)
+	}
+}
+
+// memVersions 只有当前版本的多版本存储
+type memVersions struct {
+	ObjectStorage
+}
+
+func (m memVersions) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	objs, err := m.List(prefix, keyMarker, limit)
+	var vs []Version
+	for _, o := range objs {
+		vs = append(vs, &version{obj{o.Key(), o.Size(), o.Mtime(), false, ""}, "v1", false})
+	}
+	return vs, "", "", err
+}
+
+func (m memVersions) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	return m.Get(key, off, limit)
+}
+
+// TestEncryptionVersions 加密的存储转发多版本的接口，读取的版本解密
+func TestEncryptionVersions(t *testing.T) {
+	key, _ := NewEncryptionKey(bytes.Repeat([]byte{1}, 32))
+	raw, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+	raw.Create()
+	s := WithEncryption(memVersions{raw}, key)
+	if err := s.Put("a", strings.NewReader("hello"), models.Default); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := s.(Versioner)
+	if !ok {
+		t.Fatal("not a versioner")
+	}
+	vs, _, _, err := v.ListVersions("", "", "", 10)
+	if err != nil || len(vs) != 1 || vs[0].Size() != 5 {
+		t.Fatalf("list versions: %v %v", vs, err)
+	}
+	in, err := v.GetVersion("a", "v1", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadAll(in); err != nil || string(got) != "ell" {
+		t.Fatalf("get version: %q %v", got, err)
+	}
+	if err = EnableVersioning(s); err == nil {
+		t.Fatal("enabled versioning of a mem bucket")
+	}
+	if _, err = s.(Restorer).Restored("a"); !IsNotSupported(err) {
+		t.Fatalf("restored: %v", err)
+	}
+}
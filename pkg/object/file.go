@@ -32,6 +32,11 @@ type onlyWriter struct {
 	io.Writer
 }
 
+type limitedFile struct {
+	io.Reader
+	io.Closer
+}
+
 func (f *filestore) SetCheckSumKey(meta string) error {
 	return notSupported
 }
@@ -70,6 +75,7 @@ func (f *filestore) Head(key string) (Object, error) {
 		size,
 		fi.ModTime(),
 		fi.IsDir(),
+		"",
 	}, nil
 }
 
@@ -98,18 +104,13 @@ func (f *filestore) Get(key string, off, limit int64) (io.ReadCloser, error) {
 		}
 	}
 	if limit > 0 {
-		defer localFile.Close()
-		buf := make([]byte, limit)
-		if _, err = localFile.Read(buf); err != nil {
-			return nil, err
-		} else {
-			return ioutil.NopCloser(bytes.NewBuffer(buf)), nil
-		}
+		// 不超过文件末尾，读到的数据可能少于 limit
+		return &limitedFile{io.LimitReader(localFile, limit), localFile}, nil
 	}
 	return localFile, nil
 }
 
-func (f *filestore) Put(key string, in io.Reader, _ models.CannedACLType) error {
+func (f *filestore) Put(key string, in io.Reader, _ models.CannedACLType, _ ...PutOption) error {
 	p := f.path(key)
 
 	if strings.HasSuffix(key, dirSuffix) || key == "" && strings.HasSuffix(f.root, dirSuffix) {
@@ -317,6 +318,7 @@ func (f *filestore) ListAll(prefix, marker string) (<-chan Object, error) {
 					info.Size(),
 					info.ModTime(),
 					info.IsDir(),
+					"",
 				},
 				owner,
 				group,
@@ -0,0 +1,595 @@
+//go:build !nogcs
+// +build !nogcs
+
+package object
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"obs-sync/models"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+const (
+	gcsChecksumKeyPrefix = "x-goog-meta-"
+	gcsScope             = "https://www.googleapis.com/auth/devstorage.full_control"
+)
+
+// gcsService GCS 的 XML API 客户端。ak、sk 为 HMAC 密钥时使用 V4 签名，
+// sk 为服务账号的 JSON 密钥文件（或其内容）时使用 OAuth2 令牌，ak 此时被忽略
+type gcsService struct {
+	base    string // 服务地址，如 https://storage.googleapis.com
+	project string // 服务账号所属的项目，列举和创建桶时使用
+	auth    func(req *http.Request, body []byte) error
+}
+
+type gcsError struct {
+	Status  int
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *gcsError) Error() string {
+	return fmt.Sprintf("gcs: status %d, %s: %s", e.Status, e.Code, strings.TrimSpace(e.Message))
+}
+
+// gcsServiceAccount 服务账号的 JSON 密钥
+type gcsServiceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// isGcsServiceAccount sk 为 .json 文件路径或 JSON 内容时视为服务账号密钥
+func isGcsServiceAccount(sk string) bool {
+	return strings.HasSuffix(sk, ".json") || strings.HasPrefix(strings.TrimSpace(sk), "{")
+}
+
+func newGcsService(service, ak, sk string) (*gcsService, error) {
+	if !strings.Contains(service, "://") {
+		service = "https://" + service
+	}
+	s := &gcsService{base: strings.TrimRight(service, "/")}
+	if !isGcsServiceAccount(sk) {
+		// GCS 的 XML API 兼容 AWS 的 V4 签名，区域固定为 auto
+		signer := v4.NewSigner(credentials.NewStaticCredentials(ak, sk, ""))
+		s.auth = func(req *http.Request, body []byte) error {
+			_, err := signer.Sign(req, bytes.NewReader(body), "s3", "auto", time.Now())
+			return err
+		}
+		return s, nil
+	}
+	data := []byte(sk)
+	if !strings.HasPrefix(strings.TrimSpace(sk), "{") {
+		var err error
+		if data, err = os.ReadFile(sk); err != nil {
+			return nil, fmt.Errorf("gcs: read service account key: %s", err)
+		}
+	}
+	var sa gcsServiceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("gcs: invalid service account key: %s", err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" {
+		return nil, fmt.Errorf("gcs: not a service account key")
+	}
+	t, err := newGcsToken(&sa)
+	if err != nil {
+		return nil, err
+	}
+	s.project = sa.ProjectID
+	s.auth = func(req *http.Request, body []byte) error {
+		token, err := t.get()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	return s, nil
+}
+
+// gcsToken 用服务账号私钥签发的 JWT 换取访问令牌，过期前一分钟刷新
+type gcsToken struct {
+	email    string
+	tokenURI string
+	key      *rsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	expire time.Time
+}
+
+func newGcsToken(sa *gcsServiceAccount) (*gcsToken, error) {
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("gcs: invalid private key of %s", sa.ClientEmail)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("gcs: invalid private key of %s: %s", sa.ClientEmail, err)
+		}
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("gcs: private key of %s is not RSA", sa.ClientEmail)
+	}
+	uri := sa.TokenURI
+	if uri == "" {
+		uri = "https://oauth2.googleapis.com/token"
+	}
+	return &gcsToken{email: sa.ClientEmail, tokenURI: uri, key: rsaKey}, nil
+}
+
+// jwt RS256 签名的令牌申请
+func (t *gcsToken) jwt(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   t.email,
+		"scope": gcsScope,
+		"aud":   t.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(nil, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func (t *gcsToken) get() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.token != "" && now.Before(t.expire) {
+		return t.token, nil
+	}
+	assertion, err := t.jwt(now)
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.PostForm(t.tokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer cleanup(resp)
+	data, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcs: fetch token for %s: status %d, %s", t.email, resp.StatusCode, data)
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.Unmarshal(data, &res); err != nil || res.AccessToken == "" {
+		return "", fmt.Errorf("gcs: invalid token response: %s", data)
+	}
+	t.token, t.expire = res.AccessToken, now.Add(time.Duration(res.ExpiresIn)*time.Second-time.Minute)
+	return t.token, nil
+}
+
+// request 发送请求，path 为服务地址之后的路径，返回非 2xx 时解析错误
+func (s *gcsService) request(method, path string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u := s.base + (&url.URL{Path: path}).EscapedPath()
+	if len(query) > 0 {
+		u += "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if err = s.auth(req, body); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer cleanup(resp)
+		e := &gcsError{Status: resp.StatusCode}
+		if data, _ := ioutil.ReadAll(resp.Body); len(data) > 0 && xml.Unmarshal(data, e) != nil {
+			e.Message = string(data)
+		}
+		if e.Code == "" && resp.StatusCode == http.StatusNotFound {
+			e.Code = "NoSuchKey"
+		}
+		return nil, e
+	}
+	return resp, nil
+}
+
+func (s *gcsService) call(method, path string, query url.Values, body []byte, header http.Header, out interface{}) error {
+	resp, err := s.request(method, path, query, body, header)
+	if err != nil {
+		return err
+	}
+	defer cleanup(resp)
+	if out != nil {
+		if err = xml.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("gcs: invalid response: %s", err)
+		}
+	}
+	return nil
+}
+
+// projectHeader 使用 OAuth2 令牌列举和创建桶时需要指定项目，HMAC 密钥本身属于某个项目
+func (s *gcsService) projectHeader(project string) http.Header {
+	h := http.Header{}
+	if project == "" {
+		project = s.project
+	}
+	if project != "" {
+		h.Set("x-goog-project-id", project)
+	}
+	return h
+}
+
+// ListGcsBuckets 列举项目下的桶，service 为服务地址
+func ListGcsBuckets(service, project, ak, sk string) ([]string, error) {
+	s, err := newGcsService(service, ak, sk)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Buckets []string `xml:"Buckets>Bucket>Name"`
+	}
+	if err = s.call("GET", "/", nil, nil, s.projectHeader(project), &res); err != nil {
+		return nil, err
+	}
+	return res.Buckets, nil
+}
+
+// CreateGcsBucket 在项目下创建桶，已存在时不报错
+func CreateGcsBucket(service, project, ak, sk, name string) error {
+	s, err := newGcsService(service, ak, sk)
+	if err != nil {
+		return err
+	}
+	return s.createBucket(project, name)
+}
+
+func (s *gcsService) createBucket(project, name string) error {
+	err := s.call("PUT", "/"+name, nil, nil, s.projectHeader(project), nil)
+	if e, ok := err.(*gcsError); ok && e.Code == "BucketAlreadyOwnedByYou" {
+		err = nil
+	}
+	return err
+}
+
+// gcsHash 解析 x-goog-hash 中的 crc32c 或 md5，均为 base64 编码
+func gcsHash(h http.Header, alg algorithm) string {
+	name := "crc32c="
+	if alg == checksumMd5 {
+		name = "md5="
+	}
+	for _, v := range h.Values("x-goog-hash") {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); !strings.HasPrefix(item, name) {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(item[len(name):])
+			if err != nil {
+				return ""
+			}
+			return formatChecksum(alg, sum)
+		}
+	}
+	return ""
+}
+
+// gcsCrc32c 上传时带上 x-goog-hash，服务端校验不一致时拒绝写入
+func gcsCrc32c(data []byte) string {
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc32.Checksum(data, crc32c))
+	return "crc32c=" + base64.StdEncoding.EncodeToString(sum)
+}
+
+type gcsClient struct {
+	gcsService
+	bucket       string
+	checkSumKey  string
+	sumAlgorithm algorithm
+}
+
+func (g *gcsClient) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = gcsChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	g.checkSumKey = meta
+	return nil
+}
+
+func (g *gcsClient) IsSetMd5(flag bool) error {
+	g.sumAlgorithm = checksumCrc32
+	if flag {
+		g.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (g *gcsClient) String() string {
+	return fmt.Sprintf("gcs://%s/", g.bucket)
+}
+
+func (g *gcsClient) object(key string) string {
+	return "/" + g.bucket + "/" + key
+}
+
+func (g *gcsClient) Create() error {
+	return g.createBucket("", g.bucket)
+}
+
+func (g *gcsClient) Head(key string) (Object, error) {
+	resp, err := g.request("HEAD", g.object(key), nil, nil, nil)
+	if err != nil {
+		var e *gcsError
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return nil, notExist(key)
+		}
+		return nil, err
+	}
+	defer cleanup(resp)
+	mtime, _ := time.Parse(http.TimeFormat, resp.Header.Get("Last-Modified"))
+	return &objInfo{
+		obj{
+			key,
+			resp.ContentLength,
+			mtime,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Gcs, resp.Header.Get("x-goog-storage-class")),
+		},
+		headerMetadata(resp.Header, gcsChecksumKeyPrefix),
+	}, nil
+}
+
+// GetChecksum 优先使用写入时记录的校验值，没有时使用 GCS 自带的 CRC32C（组合对象没有 MD5）
+func (g *gcsClient) GetChecksum(key string) (string, error) {
+	resp, err := g.request("HEAD", g.object(key), nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup(resp)
+	if v := resp.Header.Get(g.checkSumKey); v != "" {
+		return v, nil
+	}
+	return gcsHash(resp.Header, g.sumAlgorithm), nil
+}
+
+func (g *gcsClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if off > 0 || limit > 0 {
+		if limit > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+		} else {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+		}
+	}
+	resp, err := g.request("GET", g.object(key), nil, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	// 读取整个对象时校验 CRC32C，gzip 转码后的内容与校验值不一致
+	if off == 0 && limit == -1 && resp.Header.Get("x-goog-stored-content-encoding") != "gzip" {
+		resp.Body = verifyChecksum(resp.Body, gcsHash(resp.Header, checksumCrc32))
+	}
+	return resp.Body, nil
+}
+
+// objectHeader 对象的存储类型、ACL、HTTP 属性和用户元数据
+func (g *gcsClient) objectHeader(acl models.CannedACLType, o *PutOptions, checksum string) http.Header {
+	h := userMetaHeader(o.Meta, gcsChecksumKeyPrefix)
+	if checksum != "" {
+		h.Set(gcsChecksumKeyPrefix+g.sumAlgorithm.String(), checksum)
+	}
+	if m := o.Meta; m != nil {
+		for k, v := range map[string]string{
+			"Content-Type":        m.ContentType,
+			"Content-Encoding":    m.ContentEncoding,
+			"Cache-Control":       m.CacheControl,
+			"Content-Disposition": m.ContentDisposition,
+		} {
+			if v != "" {
+				h.Set(k, v)
+			}
+		}
+	}
+	if class := fromStorageClass(models.Gcs, o.StorageClass); class != "" {
+		h.Set("x-goog-storage-class", class)
+	}
+	if acl != "" && acl != models.Default {
+		h.Set("x-goog-acl", string(acl))
+	}
+	return h
+}
+
+func (g *gcsClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(bytes.NewReader(data), g.sumAlgorithm)
+	}
+	h := g.objectHeader(acl, o, checksum)
+	h.Set("x-goog-hash", gcsCrc32c(data))
+	return g.call("PUT", g.object(key), nil, data, h, nil)
+}
+
+func (g *gcsClient) Delete(key string) error {
+	err := g.call("DELETE", g.object(key), nil, nil, nil, nil)
+	if e, ok := err.(*gcsError); ok && e.Status == http.StatusNotFound {
+		err = nil
+	}
+	return err
+}
+
+func (g *gcsClient) List(prefix, marker string, limit int64) ([]Object, error) {
+	q := url.Values{"prefix": {prefix}, "max-keys": {strconv.FormatInt(limit, 10)}}
+	if marker != "" {
+		q.Set("marker", marker)
+	}
+	var res struct {
+		Contents []struct {
+			Key          string `xml:"Key"`
+			LastModified string `xml:"LastModified"`
+			Size         int64  `xml:"Size"`
+			StorageClass string `xml:"StorageClass"`
+		} `xml:"Contents"`
+	}
+	if err := g.call("GET", "/"+g.bucket, q, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	objs := make([]Object, 0, len(res.Contents))
+	for _, c := range res.Contents {
+		t, _ := time.Parse(time.RFC3339, c.LastModified)
+		objs = append(objs, &obj{c.Key, c.Size, t, strings.HasSuffix(c.Key, "/"), toStorageClass(models.Gcs, c.StorageClass)})
+	}
+	return objs, nil
+}
+
+func (g *gcsClient) ListAll(prefix, marker string) (<-chan Object, error) {
+	return nil, notSupported
+}
+
+// CreateMultipartUpload 使用 XML API 的分片上传，与组合上传一样可以并发上传分片，但不会留下临时对象
+func (g *gcsClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
+	var res struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err := g.call("POST", g.object(key), url.Values{"uploads": {""}}, nil, g.objectHeader(acl, o, o.Checksum), &res)
+	if err != nil {
+		return nil, err
+	}
+	return &MultipartUpload{UploadID: res.UploadID, MinPartSize: minSize, MaxCount: 10000}, nil
+}
+
+func (g *gcsClient) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	q := url.Values{"partNumber": {strconv.Itoa(num)}, "uploadId": {uploadID}}
+	resp, err := g.request("PUT", g.object(key), q, body, http.Header{"X-Goog-Hash": {gcsCrc32c(body)}})
+	if err != nil {
+		return nil, err
+	}
+	cleanup(resp)
+	return &Part{Num: num, Size: len(body), ETag: resp.Header.Get("ETag")}, nil
+}
+
+func (g *gcsClient) AbortUpload(key string, uploadID string) {
+	_ = g.call("DELETE", g.object(key), url.Values{"uploadId": {uploadID}}, nil, nil, nil)
+}
+
+func (g *gcsClient) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var in struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}
+	for _, p := range parts {
+		in.Parts = append(in.Parts, part{p.Num, p.ETag})
+	}
+	body, err := xml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return g.call("POST", g.object(key), url.Values{"uploadId": {uploadID}}, body, nil, nil)
+}
+
+func (g *gcsClient) ListUploads(marker string) ([]*PendingPart, string, error) {
+	q := url.Values{"uploads": {""}}
+	if marker != "" {
+		q.Set("key-marker", marker)
+	}
+	var res struct {
+		Uploads []struct {
+			Key       string `xml:"Key"`
+			UploadID  string `xml:"UploadId"`
+			Initiated string `xml:"Initiated"`
+		} `xml:"Upload"`
+		NextKeyMarker string `xml:"NextKeyMarker"`
+		IsTruncated   bool   `xml:"IsTruncated"`
+	}
+	if err := g.call("GET", "/"+g.bucket, q, nil, nil, &res); err != nil {
+		return nil, "", err
+	}
+	parts := make([]*PendingPart, 0, len(res.Uploads))
+	for _, u := range res.Uploads {
+		t, _ := time.Parse(time.RFC3339, u.Initiated)
+		parts = append(parts, &PendingPart{u.Key, u.UploadID, t})
+	}
+	next := ""
+	if res.IsTruncated {
+		next = res.NextKeyMarker
+	}
+	return parts, next, nil
+}
+
+// GetObjectAcl 开启统一访问控制的桶没有对象 ACL，按默认处理
+func (g *gcsClient) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return models.Default, nil
+}
+
+// newGcs 地址为 https://storage.googleapis.com/[BUCKET]，或本地模拟服务的 http://127.0.0.1:4443/[BUCKET]
+func newGcs(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	i := strings.LastIndex(endpoint, "/")
+	if i < 0 || strings.HasSuffix(endpoint[:i], ":/") {
+		return nil, fmt.Errorf("invalid endpoint %s: no bucket", endpoint)
+	}
+	s, err := newGcsService(endpoint[:i], accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsClient{
+		gcsService:   *s,
+		bucket:       endpoint[i+1:],
+		checkSumKey:  gcsChecksumKeyPrefix + checksumCrc32.String(),
+		sumAlgorithm: checksumCrc32,
+	}, nil
+}
+
+func init() {
+	Register(models.Gcs, newGcs)
+}
@@ -0,0 +1,286 @@
+package object
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// fakeGcs 本地的 GCS 替身，支持 HMAC 签名和服务账号令牌，在内存中保存对象
+type fakeGcs struct {
+	t       *testing.T
+	key     *rsa.PublicKey
+	objects map[string][]byte
+	headers map[string]http.Header
+	parts   map[string][]byte
+	uploads map[string]string // uploadID -> key
+}
+
+func (f *fakeGcs) auth(r *http.Request, body []byte) bool {
+	got := r.Header.Get("Authorization")
+	if got == "Bearer token-1" {
+		return true
+	}
+	// 以相同的时间重新签名
+	date, _ := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	clone := httptest.NewRequest(r.Method, r.URL.RequestURI(), nil)
+	clone.Host = r.Host
+	clone.URL.Host = r.Host
+	for k, vs := range r.Header {
+		if k != "Authorization" && k != "Content-Length" {
+			clone.Header[k] = vs
+		}
+	}
+	signer := v4.NewSigner(credentials.NewStaticCredentials("GOOG1ID", "secret", ""))
+	signer.Sign(clone, bytes.NewReader(body), "s3", "auto", date)
+	return got == clone.Header.Get("Authorization")
+}
+
+// token 校验服务账号签发的 JWT
+func (f *fakeGcs) token(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if len(parts) != 3 || r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], sig); err != nil {
+		f.t.Errorf("bad jwt: %s", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(`{"access_token":"token-1","expires_in":3600,"token_type":"Bearer"}`))
+}
+
+func (f *fakeGcs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		f.token(w, r)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if !f.auth(r, body) {
+		f.t.Errorf("%s %s: bad authorization %s", r.Method, r.URL, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bkt"), "/")
+	q := r.URL.Query()
+	switch {
+	case r.URL.Path == "/":
+		if r.Header.Get("x-goog-project-id") != "proj" {
+			f.t.Errorf("list buckets without project")
+		}
+		w.Write([]byte(`<ListAllMyBucketsResult><Buckets><Bucket><Name>bkt</Name></Bucket></Buckets></ListAllMyBucketsResult>`))
+	case q.Has("uploads") && r.Method == "POST":
+		f.headers[key] = r.Header.Clone()
+		id := fmt.Sprint("u", len(f.uploads)+1)
+		f.uploads[id] = key
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case q.Has("uploads"):
+		fmt.Fprint(w, "<ListMultipartUploadsResult>")
+		for id, k := range f.uploads {
+			fmt.Fprintf(w, "<Upload><Key>%s</Key><UploadId>%s</UploadId><Initiated>2024-01-02T03:04:05.000Z</Initiated></Upload>", k, id)
+		}
+		fmt.Fprint(w, "</ListMultipartUploadsResult>")
+	case q.Has("uploadId") && r.Method == "DELETE":
+		delete(f.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case q.Has("partNumber"):
+		if r.Header.Get("x-goog-hash") != gcsCrc32c(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.parts[q.Get("partNumber")] = body
+		w.Header().Set("ETag", `"e`+q.Get("partNumber")+`"`)
+	case q.Has("uploadId") && r.Method == "POST":
+		var in struct {
+			Parts []struct{ PartNumber, ETag string } `xml:"Part"`
+		}
+		xml.Unmarshal(body, &in)
+		var data []byte
+		for _, p := range in.Parts {
+			if p.ETag != `"e`+p.PartNumber+`"` {
+				f.t.Errorf("part %s: etag %s", p.PartNumber, p.ETag)
+			}
+			data = append(data, f.parts[p.PartNumber]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, q.Get("uploadId"))
+	case key == "" && r.Method == "GET":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("marker") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		if n, _ := strconv.Atoi(q.Get("max-keys")); n > 0 && len(keys) > n {
+			keys = keys[:n]
+		}
+		fmt.Fprint(w, "<ListBucketResult>")
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><LastModified>2024-01-02T03:04:05.000Z</LastModified><Size>%d</Size>"+
+				"<StorageClass>%s</StorageClass></Contents>", k, len(f.objects[k]), f.headers[k].Get("x-goog-storage-class"))
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == "PUT":
+		if r.Header.Get("x-goog-hash") != gcsCrc32c(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Error><Code>BadDigest</Code></Error>`))
+			return
+		}
+		f.objects[key] = body
+		f.headers[key] = r.Header.Clone()
+	case r.Method == "DELETE":
+		if _, ok := f.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			return
+		}
+		for k, vs := range f.headers[key] {
+			if strings.HasPrefix(strings.ToLower(k), "x-goog-meta-") || k == "X-Goog-Storage-Class" {
+				w.Header()[k] = vs
+			}
+		}
+		// 替身保存的内容被篡改时返回原始的校验值
+		w.Header().Set("x-goog-hash", gcsCrc32c([]byte(strings.TrimSuffix(string(data), "!"))))
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func TestGcs(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeGcs{t, &pk.PublicKey, map[string][]byte{}, map[string]http.Header{}, map[string][]byte{}, map[string]string{}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	s, err := CreateStorage(models.Gcs, srv.URL+"/bkt", "GOOG1ID", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &Metadata{UserMeta: map[string]string{"owner": "x"}}
+	for _, k := range []string{"a/1", "a/2 +中", "b"} {
+		if err = s.Put(k, bytes.NewReader([]byte("hello "+k)), models.PublicRead,
+			WithMetadata(meta), WithStorageClass(models.InfrequentAccess)); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	if h := f.headers["b"]; h.Get("x-goog-acl") != "public-read" || h.Get("x-goog-storage-class") != "NEARLINE" || h.Get("x-goog-meta-Crc32c") == "" {
+		t.Fatalf("put headers: %v", h)
+	}
+	objs, err := s.List("a/", "a/1", 10)
+	if err != nil || len(objs) != 1 || objs[0].Key() != "a/2 +中" || StorageClassOf(objs[0]) != models.InfrequentAccess {
+		t.Fatalf("list: %v %v", objs, err)
+	}
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	o, err := s.Head("b")
+	if err != nil || o.Size() != int64(len("hello b")) || o.(ObjectInfo).Metadata().UserMeta["owner"] != "x" {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+
+	// 没有写入时记录的校验值时使用 GCS 的 CRC32C
+	f.objects["c"] = []byte("hello c")
+	sum, err := s.(Checksummer).GetChecksum("c")
+	if err != nil || sum != generateChecksum(bytes.NewReader([]byte("hello c")), checksumCrc32) {
+		t.Fatalf("checksum: %q %v", sum, err)
+	}
+	f.objects["c"] = []byte("hello c!")
+	in, err = s.Get("c", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ioutil.ReadAll(in); err == nil || !strings.Contains(err.Error(), "verify checksum") {
+		t.Fatalf("corrupted get: %v", err)
+	}
+
+	up, err := s.CreateMultipartUpload("big", 5, models.Default, WithStorageClass(models.Archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []*Part
+	for i, p := range []string{"part1-", "part2"} {
+		part, err := s.UploadPart("big", up.UploadID, i+1, []byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if string(f.objects["big"]) != "part1-part2" || f.headers["big"].Get("x-goog-storage-class") != "ARCHIVE" {
+		t.Fatalf("multipart: %q %v", f.objects["big"], f.headers["big"])
+	}
+
+	if err = s.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("b"); err != nil {
+		t.Fatalf("delete missing: %s", err)
+	}
+
+	// 服务账号密钥
+	der, _ := x509.MarshalPKCS8PrivateKey(pk)
+	sa, _ := json.Marshal(gcsServiceAccount{
+		Type:        "service_account",
+		ProjectID:   "proj",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail: "sync@proj.iam.gserviceaccount.com",
+		TokenURI:    srv.URL + "/token",
+	})
+	buckets, err := ListGcsBuckets(srv.URL, "", "", string(sa))
+	if err != nil || len(buckets) != 1 || buckets[0] != "bkt" {
+		t.Fatalf("list buckets: %v %v", buckets, err)
+	}
+	s, err = CreateStorage(models.Gcs, srv.URL+"/bkt", "", string(sa))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, err = s.Head("a/1"); err != nil || o.Size() != int64(len("hello a/1")) {
+		t.Fatalf("head with service account: %v %v", o, err)
+	}
+}
@@ -0,0 +1,497 @@
+//go:build !nohdfs
+// +build !nohdfs
+
+package object
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"obs-sync/models"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hdfsStore 通过 WebHDFS 访问 HDFS 上的目录，ak 为 simple 认证的用户名，sk 被忽略。
+// 读写请求由 NameNode 重定向到 DataNode，NameNode 优先选择与请求方同一节点的 DataNode，
+// 所以运行在 Hadoop 节点上的 worker 读写本地副本
+type hdfsStore struct {
+	DefaultObjectStorage
+	scheme    string
+	namenodes []string // 高可用时有多个 NameNode，遇到 StandbyException 时切换
+	active    int
+	root      string // 以 / 结尾的绝对路径
+	user      string
+
+	mu       sync.Mutex
+	noBatch  bool // 不支持 LISTSTATUS_BATCH 的旧版本
+	redirect *http.Client
+}
+
+type hdfsError struct {
+	Status    int
+	Exception string `json:"exception"`
+	Message   string `json:"message"`
+}
+
+func (e *hdfsError) Error() string {
+	return fmt.Sprintf("hdfs: status %d, %s: %s", e.Status, e.Exception, e.Message)
+}
+
+func hdfsNotFound(err error) bool {
+	e, ok := err.(*hdfsError)
+	return ok && (e.Status == http.StatusNotFound || e.Exception == "FileNotFoundException")
+}
+
+type hdfsFileStatus struct {
+	PathSuffix       string `json:"pathSuffix"`
+	Type             string `json:"type"`
+	Length           int64  `json:"length"`
+	Owner            string `json:"owner"`
+	Group            string `json:"group"`
+	Permission       string `json:"permission"`
+	ModificationTime int64  `json:"modificationTime"`
+}
+
+// parseHdfsAddr 地址为 [http(s)://]nn1:9870[,nn2:9870][/path]，默认 http
+func parseHdfsAddr(addr string) (scheme string, namenodes []string, root string, err error) {
+	scheme = "http"
+	if i := strings.Index(addr, "://"); i >= 0 {
+		scheme, addr = addr[:i], addr[i+3:]
+	}
+	if scheme != "http" && scheme != "https" {
+		return "", nil, "", fmt.Errorf("hdfs: unknown scheme %q", scheme)
+	}
+	hosts := addr
+	root = "/"
+	if i := strings.Index(addr, "/"); i >= 0 {
+		hosts, root = addr[:i], addr[i:]
+	}
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			namenodes = append(namenodes, h)
+		}
+	}
+	if len(namenodes) == 0 {
+		return "", nil, "", fmt.Errorf("hdfs: invalid address %s, expected namenode:port[/path]", addr)
+	}
+	if !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+	return scheme, namenodes, root, nil
+}
+
+// HdfsBucketURL 目录的访问地址，addr 为 hdfs:// 之后的 namenode:port[/path]
+func HdfsBucketURL(addr, name string) string {
+	scheme, namenodes, root, err := parseHdfsAddr(addr)
+	if err != nil {
+		return ""
+	}
+	return scheme + "://" + strings.Join(namenodes, ",") + root + name + "/"
+}
+
+func (h *hdfsStore) SetCheckSumKey(meta string) error {
+	return notSupported
+}
+
+func (h *hdfsStore) IsSetMd5(flag bool) error {
+	return notSupported
+}
+
+func (h *hdfsStore) String() string {
+	h.mu.Lock()
+	active := h.active
+	h.mu.Unlock()
+	return fmt.Sprintf("hdfs://%s%s", h.namenodes[active], h.root)
+}
+
+func (h *hdfsStore) url(namenode, key string, q url.Values) string {
+	if h.user != "" {
+		q.Set("user.name", h.user)
+	}
+	u := url.URL{Scheme: h.scheme, Host: namenode, Path: "/webhdfs/v1" + h.root + key, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// hdfsCheck 2xx 和不跟随重定向时的 307 为成功
+func hdfsCheck(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusTemporaryRedirect {
+		return nil
+	}
+	defer cleanup(resp)
+	var res struct {
+		RemoteException hdfsError `json:"RemoteException"`
+	}
+	e := &res.RemoteException
+	data, _ := ioutil.ReadAll(resp.Body)
+	if json.Unmarshal(data, &res) != nil {
+		e.Message = string(data)
+	}
+	e.Status = resp.StatusCode
+	return e
+}
+
+// request 向 NameNode 发送请求，遇到备用 NameNode 时依次尝试其他的
+func (h *hdfsStore) request(client *http.Client, method, key string, q url.Values) (*http.Response, error) {
+	h.mu.Lock()
+	active := h.active
+	h.mu.Unlock()
+	var err error
+	for i := 0; i < len(h.namenodes); i++ {
+		n := (active + i) % len(h.namenodes)
+		var req *http.Request
+		if req, err = http.NewRequest(method, h.url(h.namenodes[n], key, q), nil); err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		var resp *http.Response
+		if resp, err = client.Do(req); err != nil {
+			continue
+		}
+		err = hdfsCheck(resp)
+		if e, ok := err.(*hdfsError); ok && e.Exception == "StandbyException" {
+			continue
+		}
+		if i > 0 {
+			h.mu.Lock()
+			h.active = n
+			h.mu.Unlock()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, err
+}
+
+func (h *hdfsStore) call(method, key string, q url.Values, out interface{}) error {
+	resp, err := h.request(httpClient, method, key, q)
+	if err != nil {
+		return err
+	}
+	defer cleanup(resp)
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("hdfs: invalid response of %s: %s", q.Get("op"), err)
+		}
+	}
+	return nil
+}
+
+func (h *hdfsStore) fileInfo(key string, st *hdfsFileStatus) Object {
+	mode, _ := strconv.ParseUint(st.Permission, 8, 32)
+	isDir := st.Type == "DIRECTORY"
+	f := &file{
+		obj{key, st.Length, time.UnixMilli(st.ModificationTime), isDir, ""},
+		st.Owner,
+		st.Group,
+		os.FileMode(mode) & os.ModePerm,
+	}
+	if mode&01000 != 0 {
+		f.mode |= os.ModeSticky
+	}
+	if isDir {
+		f.mode |= os.ModeDir
+		f.size = 0
+		if key != "" && !strings.HasSuffix(key, "/") {
+			f.key += "/"
+		}
+	}
+	return f
+}
+
+func (h *hdfsStore) Create() error {
+	return h.mkdirs("")
+}
+
+func (h *hdfsStore) mkdirs(dir string) error {
+	var res struct{ Boolean bool }
+	if err := h.call("PUT", dir, url.Values{"op": {"MKDIRS"}}, &res); err != nil {
+		return err
+	}
+	if !res.Boolean {
+		return fmt.Errorf("hdfs: mkdirs %s%s failed", h.root, dir)
+	}
+	return nil
+}
+
+func (h *hdfsStore) Head(key string) (Object, error) {
+	var res struct {
+		FileStatus hdfsFileStatus
+	}
+	err := h.call("GET", key, url.Values{"op": {"GETFILESTATUS"}}, &res)
+	if hdfsNotFound(err) {
+		return nil, notExist(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.fileInfo(key, &res.FileStatus), nil
+}
+
+// Get OPEN 被重定向到持有数据块的 DataNode
+func (h *hdfsStore) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	q := url.Values{"op": {"OPEN"}}
+	if off > 0 {
+		q.Set("offset", strconv.FormatInt(off, 10))
+	}
+	if limit > 0 {
+		q.Set("length", strconv.FormatInt(limit, 10))
+	}
+	resp, err := h.request(httpClient, "GET", key, q)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put CREATE 分两步：NameNode 返回 307 和选定的 DataNode 地址，再把数据写到该地址
+func (h *hdfsStore) Put(key string, in io.Reader, _ models.CannedACLType, _ ...PutOption) error {
+	if strings.HasSuffix(key, "/") || key == "" {
+		return h.mkdirs(key)
+	}
+	resp, err := h.request(h.redirect, "PUT", key, url.Values{"op": {"CREATE"}, "overwrite": {"true"}})
+	if err != nil {
+		return err
+	}
+	cleanup(resp)
+	loc := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusTemporaryRedirect || loc == "" {
+		return fmt.Errorf("hdfs: create %s%s: no datanode location, status %d", h.root, key, resp.StatusCode)
+	}
+	dn, err := resp.Request.URL.Parse(loc)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", dn.String(), in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if resp, err = httpClient.Do(req); err != nil {
+		return err
+	}
+	if err = hdfsCheck(resp); err != nil {
+		return err
+	}
+	cleanup(resp)
+	return nil
+}
+
+// Delete 只删除文件和空目录
+func (h *hdfsStore) Delete(key string) error {
+	var res struct{ Boolean bool }
+	err := h.call("DELETE", strings.TrimSuffix(key, "/"), url.Values{"op": {"DELETE"}, "recursive": {"false"}}, &res)
+	if hdfsNotFound(err) {
+		err = nil
+	}
+	return err
+}
+
+// readDir 目录的直接子项，大目录使用 LISTSTATUS_BATCH 分批读取
+func (h *hdfsStore) readDir(dir string) ([]hdfsFileStatus, error) {
+	h.mu.Lock()
+	noBatch := h.noBatch
+	h.mu.Unlock()
+	if noBatch {
+		var res struct {
+			FileStatuses struct{ FileStatus []hdfsFileStatus }
+		}
+		err := h.call("GET", dir, url.Values{"op": {"LISTSTATUS"}}, &res)
+		return res.FileStatuses.FileStatus, err
+	}
+	var all []hdfsFileStatus
+	after := ""
+	for {
+		q := url.Values{"op": {"LISTSTATUS_BATCH"}}
+		if after != "" {
+			q.Set("startAfter", after)
+		}
+		var res struct {
+			DirectoryListing struct {
+				PartialListing struct {
+					FileStatuses struct{ FileStatus []hdfsFileStatus }
+				}
+				RemainingEntries int
+			}
+		}
+		err := h.call("GET", dir, q, &res)
+		var e *hdfsError
+		if errors.As(err, &e) && e.Status == http.StatusBadRequest && after == "" {
+			// Hadoop 2.8 之前没有 LISTSTATUS_BATCH
+			h.mu.Lock()
+			h.noBatch = true
+			h.mu.Unlock()
+			return h.readDir(dir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		part := res.DirectoryListing.PartialListing.FileStatuses.FileStatus
+		all = append(all, part...)
+		if res.DirectoryListing.RemainingEntries == 0 || len(part) == 0 {
+			return all, nil
+		}
+		after = part[len(part)-1].PathSuffix
+	}
+}
+
+// ListAll 按 key 的字典序递归列举，跳过不含 prefix 和 marker 之后 key 的目录
+func (h *hdfsStore) ListAll(prefix, marker string) (<-chan Object, error) {
+	listed := make(chan Object, 10240)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		sts, err := h.readDir(dir)
+		if err != nil {
+			if hdfsNotFound(err) {
+				logger.Warn().Msgf("skip not exist directory: %s%s", h.root, dir)
+				return nil
+			}
+			return err
+		}
+		objs := make([]Object, 0, len(sts))
+		for i := range sts {
+			objs = append(objs, h.fileInfo(dir+sts[i].PathSuffix, &sts[i]))
+		}
+		sort.Slice(objs, func(i, j int) bool { return objs[i].Key() < objs[j].Key() })
+		for _, o := range objs {
+			key := o.Key()
+			if !o.IsDir() {
+				if strings.HasPrefix(key, prefix) && key > marker {
+					listed <- o
+				}
+				continue
+			}
+			if !strings.HasPrefix(key, prefix) && !strings.HasPrefix(prefix, key) {
+				continue
+			}
+			if key < marker && !strings.HasPrefix(marker, key) {
+				continue
+			}
+			if strings.HasPrefix(key, prefix) && key > marker {
+				listed <- o
+			}
+			if err = walk(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	go func() {
+		if err := walk(""); err != nil {
+			logger.Error().Msgf("list %s: %s", h, err)
+			listed <- nil
+		}
+		close(listed)
+	}()
+	return listed, nil
+}
+
+func (h *hdfsStore) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return "", nil
+}
+
+func (h *hdfsStore) Chtimes(key string, mtime time.Time) error {
+	return h.call("PUT", key, url.Values{"op": {"SETTIMES"},
+		"modificationtime": {strconv.FormatInt(mtime.UnixMilli(), 10)}, "accesstime": {"-1"}}, nil)
+}
+
+func (h *hdfsStore) Chmod(key string, mode os.FileMode) error {
+	perm := mode.Perm()
+	if mode&os.ModeSticky != 0 {
+		perm |= 01000
+	}
+	return h.call("PUT", key, url.Values{"op": {"SETPERMISSION"}, "permission": {strconv.FormatUint(uint64(perm), 8)}}, nil)
+}
+
+// Chown HDFS 的用户和组按名字记录，不需要转换为 uid
+func (h *hdfsStore) Chown(key string, owner, group string) error {
+	q := url.Values{"op": {"SETOWNER"}}
+	if owner != "" {
+		q.Set("owner", owner)
+	}
+	if group != "" {
+		q.Set("group", group)
+	}
+	return h.call("PUT", key, q, nil)
+}
+
+// Locations 持有文件第一个数据块的 DataNode 主机名，用于把任务调度到这些节点上的 worker
+func (h *hdfsStore) Locations(key string) ([]string, error) {
+	var res struct {
+		BlockLocations struct {
+			BlockLocation []struct {
+				Hosts []string `json:"hosts"`
+			}
+		}
+	}
+	q := url.Values{"op": {"GETFILEBLOCKLOCATIONS"}, "offset": {"0"}, "length": {"1"}}
+	if err := h.call("GET", key, q, &res); err != nil {
+		return nil, err
+	}
+	if len(res.BlockLocations.BlockLocation) == 0 {
+		return nil, nil
+	}
+	return res.BlockLocations.BlockLocation[0].Hosts, nil
+}
+
+// ListHdfsDirs 列举 addr 下的目录，作为 hdfs 的桶
+func ListHdfsDirs(addr, user string) ([]string, error) {
+	s, err := newHdfs(addr, user, "")
+	if err != nil {
+		return nil, err
+	}
+	sts, err := s.(*hdfsStore).readDir("")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, st := range sts {
+		if st.Type == "DIRECTORY" && !strings.HasPrefix(st.PathSuffix, ".") {
+			names = append(names, st.PathSuffix)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateHdfsDir 在 addr 下创建目录
+func CreateHdfsDir(addr, user, name string) error {
+	s, err := newHdfs(HdfsBucketURL(addr, name), user, "")
+	if err != nil {
+		return err
+	}
+	return s.Create()
+}
+
+// newHdfs 地址为 [http(s)://]namenode:port[,namenode2:port][/path/]，ak 为用户名
+func newHdfs(endpoint, user, _ string) (ObjectStorage, error) {
+	scheme, namenodes, root, err := parseHdfsAddr(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	redirect := *httpClient
+	redirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &hdfsStore{
+		scheme:    scheme,
+		namenodes: namenodes,
+		root:      strings.TrimSuffix(path.Clean(root), "/") + "/",
+		user:      user,
+		redirect:  &redirect,
+	}, nil
+}
+
+func init() {
+	Register(models.Hdfs, newHdfs)
+}
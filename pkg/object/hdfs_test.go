@@ -0,0 +1,268 @@
+package object
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebhdfs 以本地目录模拟 WebHDFS，NameNode 把 OPEN 和 CREATE 重定向到 /datanode 下
+type fakeWebhdfs struct {
+	dir     string
+	noBatch bool
+	mu      sync.Mutex
+	owners  map[string]string
+}
+
+func (f *fakeWebhdfs) fail(w http.ResponseWriter, status int, exception, msg string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"RemoteException": map[string]string{"exception": exception, "message": msg},
+	})
+}
+
+func (f *fakeWebhdfs) status(name string, fi os.FileInfo) map[string]interface{} {
+	typ, perm := "FILE", uint32(fi.Mode().Perm())
+	if fi.IsDir() {
+		typ = "DIRECTORY"
+	}
+	if fi.Mode()&os.ModeSticky != 0 {
+		perm |= 01000
+	}
+	f.mu.Lock()
+	owner := f.owners[fi.Name()]
+	f.mu.Unlock()
+	if owner == "" {
+		owner = "hadoop"
+	}
+	return map[string]interface{}{
+		"pathSuffix": name, "type": typ, "length": fi.Size(), "owner": owner, "group": "supergroup",
+		"permission": strconv.FormatUint(uint64(perm), 8), "modificationTime": fi.ModTime().UnixMilli(),
+	}
+}
+
+func (f *fakeWebhdfs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if p, ok := strings.CutPrefix(r.URL.Path, "/datanode/"); ok {
+		name := filepath.Join(f.dir, p)
+		if r.Method == "PUT" {
+			data, _ := io.ReadAll(r.Body)
+			os.MkdirAll(filepath.Dir(name), 0755)
+			os.WriteFile(name, data, 0644)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		data, _ := os.ReadFile(name)
+		off, _ := strconv.Atoi(q.Get("offset"))
+		data = data[off:]
+		if n, err := strconv.Atoi(q.Get("length")); err == nil && n < len(data) {
+			data = data[:n]
+		}
+		w.Write(data)
+		return
+	}
+	if q.Get("user.name") != "hadoop" {
+		f.fail(w, http.StatusUnauthorized, "SecurityException", "unknown user")
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	name := filepath.Join(f.dir, p)
+	reply := func(v interface{}) { json.NewEncoder(w).Encode(v) }
+	fi, statErr := os.Stat(name)
+	switch q.Get("op") {
+	case "MKDIRS":
+		reply(map[string]bool{"boolean": os.MkdirAll(name, 0755) == nil})
+		return
+	case "CREATE":
+		http.Redirect(w, r, "/datanode"+p, http.StatusTemporaryRedirect)
+		return
+	case "DELETE":
+		err := os.Remove(name)
+		if err != nil && !os.IsNotExist(err) {
+			f.fail(w, http.StatusForbidden, "PathIsNotEmptyDirectoryException", err.Error())
+			return
+		}
+		reply(map[string]bool{"boolean": err == nil})
+		return
+	}
+	if statErr != nil {
+		f.fail(w, http.StatusNotFound, "FileNotFoundException", "File does not exist: "+p)
+		return
+	}
+	switch q.Get("op") {
+	case "GETFILESTATUS":
+		reply(map[string]interface{}{"FileStatus": f.status("", fi)})
+	case "LISTSTATUS", "LISTSTATUS_BATCH":
+		if q.Get("op") == "LISTSTATUS_BATCH" && f.noBatch {
+			f.fail(w, http.StatusBadRequest, "IllegalArgumentException", "Invalid value for webhdfs parameter \"op\"")
+			return
+		}
+		entries, _ := ioutil.ReadDir(name)
+		var sts []map[string]interface{}
+		for _, e := range entries {
+			if e.Name() > q.Get("startAfter") {
+				sts = append(sts, f.status(e.Name(), e))
+			}
+		}
+		if q.Get("op") == "LISTSTATUS" {
+			reply(map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": sts}})
+			return
+		}
+		// 每批最多两项，验证分批读取
+		remaining := 0
+		if len(sts) > 2 {
+			remaining, sts = len(sts)-2, sts[:2]
+		}
+		reply(map[string]interface{}{"DirectoryListing": map[string]interface{}{
+			"partialListing":   map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": sts}},
+			"remainingEntries": remaining,
+		}})
+	case "OPEN":
+		http.Redirect(w, r, "/datanode"+p+"?"+r.URL.RawQuery, http.StatusTemporaryRedirect)
+	case "SETTIMES":
+		ms, _ := strconv.ParseInt(q.Get("modificationtime"), 10, 64)
+		os.Chtimes(name, time.Now(), time.UnixMilli(ms))
+	case "SETPERMISSION":
+		perm, _ := strconv.ParseUint(q.Get("permission"), 8, 32)
+		mode := os.FileMode(perm) & os.ModePerm
+		if perm&01000 != 0 {
+			mode |= os.ModeSticky
+		}
+		os.Chmod(name, mode)
+	case "SETOWNER":
+		f.mu.Lock()
+		f.owners[fi.Name()] = q.Get("owner")
+		f.mu.Unlock()
+	case "GETFILEBLOCKLOCATIONS":
+		reply(map[string]interface{}{"BlockLocations": map[string]interface{}{
+			"BlockLocation": []map[string]interface{}{{"hosts": []string{"dn1.example.com", "dn2.example.com"}}},
+		}})
+	default:
+		f.fail(w, http.StatusBadRequest, "IllegalArgumentException", "unknown op "+q.Get("op"))
+	}
+}
+
+func TestHdfs(t *testing.T) {
+	fake := &fakeWebhdfs{dir: t.TempDir(), owners: map[string]string{}}
+	active := httptest.NewServer(fake)
+	defer active.Close()
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.fail(w, http.StatusForbidden, "StandbyException", "Operation category READ is not supported in state standby")
+	}))
+	defer standby.Close()
+	addr := strings.TrimPrefix(standby.URL, "http://") + "," + strings.TrimPrefix(active.URL, "http://") + "/user/hadoop"
+
+	if err := CreateHdfsDir(addr, "hadoop", "bkt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateHdfsDir(addr, "hadoop", "other"); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ListHdfsDirs(addr, "hadoop")
+	if err != nil || strings.Join(names, ",") != "bkt,other" {
+		t.Fatalf("list dirs: %v %v", names, err)
+	}
+	if _, err = ListHdfsDirs(addr, "nobody"); err == nil {
+		t.Fatal("list with unknown user")
+	}
+
+	s, err := CreateStorage(models.Hdfs, HdfsBucketURL(addr, "bkt"), "hadoop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a/1", "a/2 +中", "a/b/3", "c", "d/"} {
+		if err = s.Put(k, strings.NewReader("hello "+k), models.Default); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(fake.dir, "user", "hadoop", "bkt", "a", "b", "3")); string(data) != "hello a/b/3" {
+		t.Fatalf("put: %q", data)
+	}
+
+	listAll := func(prefix, marker string) string {
+		ch, err := s.ListAll(prefix, marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for o := range ch {
+			if o == nil {
+				t.Fatal("list failed")
+			}
+			keys = append(keys, o.Key())
+		}
+		return strings.Join(keys, ",")
+	}
+	if got := listAll("", ""); got != "a/,a/1,a/2 +中,a/b/,a/b/3,c,d/" {
+		t.Fatalf("list all: %s", got)
+	}
+	if got := listAll("a/", "a/2 +中"); got != "a/b/,a/b/3" {
+		t.Fatalf("list from marker: %s", got)
+	}
+	fake.noBatch = true
+	if got := listAll("", "c"); got != "d/" {
+		t.Fatalf("list without batch: %s", got)
+	}
+
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fs := s.(FileSystem)
+	if err = fs.Chtimes("a/1", mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.Chmod("a/1", 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.Chown("a/1", "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Head("a/1")
+	if err != nil || !o.Mtime().Equal(mtime) || o.(File).Mode().Perm() != 0600 || o.(File).Owner() != "alice" ||
+		o.Size() != int64(len("hello a/1")) {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if o, err = s.Head("d"); err != nil || !o.IsDir() || o.Key() != "d/" {
+		t.Fatalf("head dir: %v %v", o, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+
+	hosts, err := s.(Locator).Locations("a/1")
+	sort.Strings(hosts)
+	if err != nil || strings.Join(hosts, ",") != "dn1.example.com,dn2.example.com" {
+		t.Fatalf("locations: %v %v", hosts, err)
+	}
+
+	if err = s.Delete("a/"); err == nil {
+		t.Fatal("deleted a non-empty directory")
+	}
+	for _, k := range []string{"c", "c", "d/"} {
+		if err = s.Delete(k); err != nil {
+			t.Fatalf("delete %s: %s", k, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(fake.dir, "user", "hadoop", "bkt", "d")); !os.IsNotExist(err) {
+		t.Fatalf("dir not deleted: %v", err)
+	}
+}
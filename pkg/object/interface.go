@@ -13,17 +13,51 @@ type Object interface {
 	IsDir() bool
 }
 
+// ClassObject is an Object knowing its storage class.
+type ClassObject interface {
+	Object
+	StorageClass() models.StorageClass
+}
+
 type obj struct {
 	key   string
 	size  int64
 	mtime time.Time
 	isDir bool
+	sc    models.StorageClass
+}
+
+func (o *obj) Key() string                       { return o.key }
+func (o *obj) Size() int64                       { return o.size }
+func (o *obj) Mtime() time.Time                  { return o.mtime }
+func (o *obj) IsDir() bool                       { return o.isDir }
+func (o *obj) StorageClass() models.StorageClass { return o.sc }
+
+// Metadata holds the HTTP headers and user metadata of an object.
+type Metadata struct {
+	ContentType        string
+	ContentEncoding    string
+	CacheControl       string
+	ContentDisposition string
+	// Expires is a HTTP date as sent in the Expires header.
+	Expires string
+	// UserMeta holds the user metadata without the provider prefix (x-amz-meta- etc.).
+	UserMeta map[string]string
+}
+
+// ObjectInfo is an Object carrying its metadata, returned by Head of the
+// storages that support it. The listing APIs don't return these headers.
+type ObjectInfo interface {
+	Object
+	Metadata() *Metadata
+}
+
+type objInfo struct {
+	obj
+	meta *Metadata
 }
 
-func (o *obj) Key() string      { return o.key }
-func (o *obj) Size() int64      { return o.size }
-func (o *obj) Mtime() time.Time { return o.mtime }
-func (o *obj) IsDir() bool      { return o.isDir }
+func (o *objInfo) Metadata() *Metadata { return o.meta }
 
 type MultipartUpload struct {
 	MinPartSize int
@@ -43,12 +77,75 @@ type PendingPart struct {
 	Created  time.Time
 }
 
+// PutOptions holds the optional attributes of an object written by Put or
+// CreateMultipartUpload.
+type PutOptions struct {
+	// Checksum is stored as object metadata instead of one computed from the data.
+	Checksum string
+	// Meta holds the headers and user metadata of the object.
+	Meta *Metadata
+	// StorageClass of the object, the default of the bucket if empty.
+	StorageClass models.StorageClass
+	// Tags of the object, only written by Put, set them by Tagger after
+	// CompleteUpload for multipart uploads.
+	Tags map[string]string
+	// Size of the data, -1 if unknown.
+	Size int64
+}
+
+type PutOption func(*PutOptions)
+
+// WithChecksum stores a checksum computed by the caller with the object.
+func WithChecksum(checksum string) PutOption {
+	return func(o *PutOptions) {
+		o.Checksum = checksum
+	}
+}
+
+// WithMetadata writes the headers and user metadata of the object.
+func WithMetadata(meta *Metadata) PutOption {
+	return func(o *PutOptions) {
+		o.Meta = meta
+	}
+}
+
+// WithStorageClass writes the object in the given storage class.
+func WithStorageClass(sc models.StorageClass) PutOption {
+	return func(o *PutOptions) {
+		o.StorageClass = sc
+	}
+}
+
+// WithTags writes the object with the given tags.
+func WithTags(tags map[string]string) PutOption {
+	return func(o *PutOptions) {
+		o.Tags = tags
+	}
+}
+
+// WithSize tells the size of the data, wrappers that change the data need it
+// before reading the data.
+func WithSize(size int64) PutOption {
+	return func(o *PutOptions) {
+		o.Size = size
+	}
+}
+
+func applyPutOptions(opts []PutOption) *PutOptions {
+	o := &PutOptions{Size: -1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 // ObjectStorage is the interface for object storage.
 // all of these API should be idempotent.
 type ObjectStorage interface {
-	// SetCheckSumKey use checksum algorithm
+	// SetCheckSumKey sets the header holding the checksum of the source
+	// objects, empty to restore the default.
 	SetCheckSumKey(meta string) error
-	// IsSetMd5 Set checksum algorithm
+	// IsSetMd5 sets the checksum algorithm to MD5, or back to CRC32C.
 	IsSetMd5(flag bool) error
 
 	// String Description of the object storage.
@@ -58,7 +155,7 @@ type ObjectStorage interface {
 	// Get the data for the given object specified by key.
 	Get(key string, off, limit int64) (io.ReadCloser, error)
 	// Put data read from a reader to an object specified by key.
-	Put(key string, in io.Reader, acl models.CannedACLType) error
+	Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error
 	// Delete a object.
 	Delete(key string) error
 
@@ -70,7 +167,7 @@ type ObjectStorage interface {
 	ListAll(prefix, marker string) (<-chan Object, error)
 
 	// CreateMultipartUpload starts to upload a large object part by part.
-	CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error)
+	CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error)
 	// UploadPart upload a part of an object.
 	UploadPart(key string, uploadID string, num int, body []byte) (*Part, error)
 	// AbortUpload abort a multipart upload.
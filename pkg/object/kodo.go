@@ -0,0 +1,560 @@
+//go:build !nokodo
+// +build !nokodo
+
+package object
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"obs-sync/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kodoChecksumKeyPrefix = "x-qn-meta-"
+
+// kodoFileTypes 七牛的存储类型，下标为接口中的 type/fileType
+var kodoFileTypes = []string{"STANDARD", "LINE", "ARCHIVE", "DEEP_ARCHIVE", "ARCHIVE_IR", "INTELLIGENT_TIERING"}
+
+func kodoFileType(t int) string {
+	if t >= 0 && t < len(kodoFileTypes) {
+		return kodoFileTypes[t]
+	}
+	return strconv.Itoa(t)
+}
+
+// kodoHosts 七牛各类接口的地址，公有云按区域区分，如 rs-z0.qiniuapi.com
+type kodoHosts struct {
+	uc, rs, rsf, up string
+}
+
+func kodoRegionHosts(scheme, region string) kodoHosts {
+	return kodoHosts{
+		uc:  scheme + "://uc.qiniuapi.com",
+		rs:  scheme + "://rs-" + region + ".qiniuapi.com",
+		rsf: scheme + "://rsf-" + region + ".qiniuapi.com",
+		up:  scheme + "://up-" + region + ".qiniup.com",
+	}
+}
+
+type kodoError struct {
+	Status  int
+	Message string `json:"error"`
+}
+
+func (e *kodoError) Error() string {
+	return fmt.Sprintf("kodo: status %d: %s", e.Status, e.Message)
+}
+
+// kodoNotFound 七牛以 612 表示对象不存在
+func kodoNotFound(err error) bool {
+	e, ok := err.(*kodoError)
+	return ok && (e.Status == 612 || e.Status == http.StatusNotFound)
+}
+
+type kodoAuth struct {
+	accessKey string
+	secretKey string
+}
+
+func (a kodoAuth) sign(data []byte) string {
+	h := hmac.New(sha1.New, []byte(a.secretKey))
+	h.Write(data)
+	return base64.URLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// qboxToken 管理接口的 QBox 签名，对路径、参数和表单内容签名
+func (a kodoAuth) qboxToken(req *http.Request, body []byte) string {
+	data := req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		data += "?" + req.URL.RawQuery
+	}
+	data += "\n"
+	if req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		data += string(body)
+	}
+	return "QBox " + a.accessKey + ":" + a.sign([]byte(data))
+}
+
+// upToken 上传凭证，scope 为 bucket:key 时允许覆盖同名对象
+func (a kodoAuth) upToken(bucket, key string, fileType int) string {
+	policy := map[string]interface{}{
+		"scope":    bucket + ":" + key,
+		"deadline": time.Now().Add(24 * time.Hour).Unix(),
+	}
+	if fileType > 0 {
+		policy["fileType"] = fileType
+	}
+	data, _ := json.Marshal(policy)
+	encoded := base64.URLEncoding.EncodeToString(data)
+	return a.accessKey + ":" + a.sign([]byte(encoded)) + ":" + encoded
+}
+
+// kodoCall 发送请求，auth 为 Authorization 头，out 不为空时解析 JSON 响应
+func kodoCall(method, uri, auth string, header http.Header, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer cleanup(resp)
+	if resp.StatusCode/100 != 2 {
+		e := &kodoError{Status: resp.StatusCode}
+		if data, _ := ioutil.ReadAll(resp.Body); json.Unmarshal(data, e) != nil || e.Message == "" {
+			e.Message = string(data)
+		}
+		return e
+	}
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("kodo: invalid response: %s", err)
+		}
+	}
+	return nil
+}
+
+// manage 以 QBox 签名调用管理接口
+func (a kodoAuth) manage(method, uri string, out interface{}) error {
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	return kodoCall(method, uri, a.qboxToken(req, nil), header, nil, out)
+}
+
+// ListKodoBuckets 列举区域内的桶，service 为 uc 接口的地址
+func ListKodoBuckets(service, region, accessKey, secretKey string) ([]string, error) {
+	var names []string
+	uri := strings.TrimRight(service, "/") + "/buckets?region=" + url.QueryEscape(region)
+	err := kodoAuth{accessKey, secretKey}.manage("GET", uri, &names)
+	return names, err
+}
+
+// CreateKodoBucket 在区域内创建桶
+func CreateKodoBucket(service, region, accessKey, secretKey, name string) error {
+	uri := strings.TrimRight(service, "/") + "/mkbucketv3/" + url.PathEscape(name) + "/region/" + url.PathEscape(region)
+	return kodoAuth{accessKey, secretKey}.manage("POST", uri, nil)
+}
+
+type kodoClient struct {
+	kodoAuth
+	hosts        kodoHosts
+	bucket       string
+	region       string
+	scheme       string // 下载域名没有指定协议时使用地址的协议
+	domain       string // 下载域名，为空时读取桶绑定的第一个域名
+	domainOnce   sync.Once
+	domainErr    error
+	uploads      sync.Map // uploadID -> *PutOptions，完成上传时写入元数据
+	checkSumKey  string
+	sumAlgorithm algorithm
+}
+
+func (k *kodoClient) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = kodoChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	k.checkSumKey = meta
+	return nil
+}
+
+func (k *kodoClient) IsSetMd5(flag bool) error {
+	k.sumAlgorithm = checksumCrc32
+	if flag {
+		k.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (k *kodoClient) String() string {
+	return fmt.Sprintf("kodo://%s/", k.bucket)
+}
+
+func (k *kodoClient) entry(key string) string {
+	return base64.URLEncoding.EncodeToString([]byte(k.bucket + ":" + key))
+}
+
+func (k *kodoClient) Create() error {
+	if _, err := k.List("", "", 1); err == nil {
+		return nil
+	}
+	return CreateKodoBucket(k.hosts.uc, k.region, k.accessKey, k.secretKey, k.bucket)
+}
+
+type kodoStat struct {
+	Fsize    int64             `json:"fsize"`
+	MimeType string            `json:"mimeType"`
+	PutTime  int64             `json:"putTime"` // 单位为 100 纳秒
+	Type     int               `json:"type"`
+	Meta     map[string]string `json:"x-qn-meta"`
+}
+
+func (k *kodoClient) stat(key string) (*kodoStat, error) {
+	var st kodoStat
+	err := k.manage("GET", k.hosts.rs+"/stat/"+k.entry(key), &st)
+	return &st, err
+}
+
+func (k *kodoClient) Head(key string) (Object, error) {
+	st, err := k.stat(key)
+	if err != nil {
+		if kodoNotFound(err) {
+			return nil, notExist(key)
+		}
+		return nil, err
+	}
+	meta := &Metadata{ContentType: st.MimeType}
+	for name, v := range st.Meta {
+		if isChecksumMeta(name) {
+			continue
+		}
+		if meta.UserMeta == nil {
+			meta.UserMeta = make(map[string]string)
+		}
+		meta.UserMeta[strings.ToLower(name)] = v
+	}
+	return &objInfo{
+		obj{
+			key,
+			st.Fsize,
+			time.Unix(0, st.PutTime*100),
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Kodo, kodoFileType(st.Type)),
+		},
+		meta,
+	}, nil
+}
+
+func (k *kodoClient) GetChecksum(key string) (string, error) {
+	st, err := k.stat(key)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimPrefix(strings.ToLower(k.checkSumKey), kodoChecksumKeyPrefix)
+	for n, v := range st.Meta {
+		if strings.ToLower(n) == name {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// downloadDomain 七牛通过桶绑定的域名下载对象
+func (k *kodoClient) downloadDomain() (string, error) {
+	k.domainOnce.Do(func() {
+		if k.domain != "" {
+			return
+		}
+		var domains []string
+		k.domainErr = k.manage("GET", k.hosts.uc+"/v2/domains?tbl="+url.QueryEscape(k.bucket), &domains)
+		if k.domainErr == nil && len(domains) == 0 {
+			k.domainErr = fmt.Errorf("kodo: no domain bound to bucket %s", k.bucket)
+		}
+		if k.domainErr == nil {
+			k.domain = k.scheme + "://" + domains[0]
+		}
+	})
+	return k.domain, k.domainErr
+}
+
+func (k *kodoClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	domain, err := k.downloadDomain()
+	if err != nil {
+		return nil, err
+	}
+	// 私有下载地址：对带过期时间的地址签名
+	u := domain + (&url.URL{Path: "/" + key}).EscapedPath() + "?e=" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	u += "&token=" + k.accessKey + ":" + k.sign([]byte(u))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if off > 0 || limit > 0 {
+		if limit > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+		} else {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+		}
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer cleanup(resp)
+		data, _ := ioutil.ReadAll(resp.Body)
+		return nil, &kodoError{resp.StatusCode, string(data)}
+	}
+	return resp.Body, nil
+}
+
+// userMeta 用户元数据和校验和，七牛只支持 Content-Type 和 x-qn-meta- 用户元数据
+func (k *kodoClient) userMeta(o *PutOptions, checksum string) map[string]string {
+	meta := make(map[string]string)
+	if o.Meta != nil {
+		for name, v := range o.Meta.UserMeta {
+			meta[kodoChecksumKeyPrefix+name] = v
+		}
+	}
+	if checksum != "" {
+		meta[kodoChecksumKeyPrefix+k.sumAlgorithm.String()] = checksum
+	}
+	return meta
+}
+
+func kodoFileTypeOf(sc models.StorageClass) int {
+	name := fromStorageClass(models.Kodo, sc)
+	for i, n := range kodoFileTypes {
+		if n == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// Put 表单上传，七牛没有对象级的 ACL，acl 被忽略
+func (k *kodoClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(bytes.NewReader(data), k.sumAlgorithm)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("token", k.upToken(k.bucket, key, kodoFileTypeOf(o.StorageClass)))
+	_ = w.WriteField("key", key)
+	for name, v := range k.userMeta(o, checksum) {
+		_ = w.WriteField(name, v)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(key, `"`, `\"`)))
+	h.Set("Content-Type", "application/octet-stream")
+	if o.Meta != nil && o.Meta.ContentType != "" {
+		h.Set("Content-Type", o.Meta.ContentType)
+	}
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return kodoCall("POST", k.hosts.up+"/", "", http.Header{"Content-Type": {w.FormDataContentType()}}, body.Bytes(), nil)
+}
+
+func (k *kodoClient) Delete(key string) error {
+	err := k.manage("POST", k.hosts.rs+"/delete/"+k.entry(key), nil)
+	if kodoNotFound(err) {
+		err = nil
+	}
+	return err
+}
+
+// kodoMarker 七牛的列举标记不透明，由上次列举的最后一个 key 构造
+func kodoMarker(key string) string {
+	if key == "" {
+		return ""
+	}
+	data, _ := json.Marshal(map[string]interface{}{"c": 0, "k": key})
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+func (k *kodoClient) List(prefix, marker string, limit int64) ([]Object, error) {
+	q := url.Values{"bucket": {k.bucket}, "prefix": {prefix}, "limit": {strconv.FormatInt(limit, 10)}}
+	if marker != "" {
+		q.Set("marker", kodoMarker(marker))
+	}
+	var res struct {
+		Items []struct {
+			Key     string `json:"key"`
+			Fsize   int64  `json:"fsize"`
+			PutTime int64  `json:"putTime"`
+			Type    int    `json:"type"`
+		} `json:"items"`
+	}
+	if err := k.manage("GET", k.hosts.rsf+"/list?"+q.Encode(), &res); err != nil {
+		return nil, err
+	}
+	objs := make([]Object, len(res.Items))
+	for i, o := range res.Items {
+		objs[i] = &obj{o.Key, o.Fsize, time.Unix(0, o.PutTime*100), strings.HasSuffix(o.Key, "/"),
+			toStorageClass(models.Kodo, kodoFileType(o.Type))}
+	}
+	return objs, nil
+}
+
+func (k *kodoClient) ListAll(prefix, marker string) (<-chan Object, error) {
+	return nil, notSupported
+}
+
+// uploadURL 分片上传 v2 的地址，对象名为空时用 ~ 表示
+func (k *kodoClient) uploadURL(key string, rest ...string) string {
+	name := "~"
+	if key != "" {
+		name = base64.URLEncoding.EncodeToString([]byte(key))
+	}
+	return k.hosts.up + "/buckets/" + url.PathEscape(k.bucket) + "/objects/" + name + "/uploads" + strings.Join(append([]string{""}, rest...), "/")
+}
+
+func (k *kodoClient) upAuth(key, uploadID string) string {
+	fileType := 0
+	if o, ok := k.uploads.Load(uploadID); ok {
+		fileType = kodoFileTypeOf(o.(*PutOptions).StorageClass)
+	}
+	return "UpToken " + k.upToken(k.bucket, key, fileType)
+}
+
+// CreateMultipartUpload 使用分片上传 v2，元数据在完成上传时写入
+func (k *kodoClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
+	var res struct {
+		UploadID string `json:"uploadId"`
+	}
+	auth := "UpToken " + k.upToken(k.bucket, key, kodoFileTypeOf(o.StorageClass))
+	if err := kodoCall("POST", k.uploadURL(key), auth, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	k.uploads.Store(res.UploadID, o)
+	// 七牛的分片最小 1MB
+	if minSize < 1<<20 {
+		minSize = 1 << 20
+	}
+	return &MultipartUpload{UploadID: res.UploadID, MinPartSize: minSize, MaxCount: 10000}, nil
+}
+
+func (k *kodoClient) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	var res struct {
+		Etag string `json:"etag"`
+	}
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	err := kodoCall("PUT", k.uploadURL(key, uploadID, strconv.Itoa(num)), k.upAuth(key, uploadID), header, body, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &Part{Num: num, Size: len(body), ETag: res.Etag}, nil
+}
+
+func (k *kodoClient) AbortUpload(key string, uploadID string) {
+	_ = kodoCall("DELETE", k.uploadURL(key, uploadID), k.upAuth(key, uploadID), nil, nil, nil)
+	k.uploads.Delete(uploadID)
+}
+
+func (k *kodoClient) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	type kodoPart struct {
+		PartNumber int    `json:"partNumber"`
+		Etag       string `json:"etag"`
+	}
+	in := struct {
+		Parts    []kodoPart        `json:"parts"`
+		MimeType string            `json:"mimeType,omitempty"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+	}{}
+	for _, p := range parts {
+		in.Parts = append(in.Parts, kodoPart{p.Num, p.ETag})
+	}
+	if v, ok := k.uploads.Load(uploadID); ok {
+		o := v.(*PutOptions)
+		if o.Meta != nil {
+			in.MimeType = o.Meta.ContentType
+		}
+		in.Metadata = k.userMeta(o, o.Checksum)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if err = kodoCall("POST", k.uploadURL(key, uploadID), k.upAuth(key, uploadID), header, body, nil); err != nil {
+		return err
+	}
+	k.uploads.Delete(uploadID)
+	return nil
+}
+
+// ListUploads 七牛不支持列举未完成的分片上传，过期后自动清理，返回 notSupported
+func (k *kodoClient) ListUploads(marker string) ([]*PendingPart, string, error) {
+	return nil, "", notSupported
+}
+
+// GetObjectAcl 七牛只有桶级别的公开/私有属性
+func (k *kodoClient) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return models.Default, nil
+}
+
+// newKodo 桶地址为 [BUCKET].kodo-[REGION].qiniucs.com，按区域选择各类接口的地址；
+// 也可以是 http://127.0.0.1:8080/[BUCKET]，所有接口和下载都使用该地址，用于私有部署和测试。
+// 参数 domain 指定下载域名，默认读取桶绑定的第一个域名，没有指定协议时与地址的协议相同
+func newKodo(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	uri, err := url.ParseRequestURI(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %s", endpoint, err)
+	}
+	k := &kodoClient{
+		kodoAuth:     kodoAuth{accessKey, secretKey},
+		scheme:       uri.Scheme,
+		domain:       uri.Query().Get("domain"),
+		checkSumKey:  kodoChecksumKeyPrefix + checksumCrc32.String(),
+		sumAlgorithm: checksumCrc32,
+	}
+	if k.domain != "" && !strings.Contains(k.domain, "://") {
+		k.domain = k.scheme + "://" + k.domain
+	}
+	if path := strings.Trim(uri.Path, "/"); path != "" {
+		base := uri.Scheme + "://" + uri.Host
+		k.bucket, k.region = path, uri.Query().Get("region")
+		k.hosts = kodoHosts{base, base, base, base}
+		if k.domain == "" {
+			k.domain = base + "/" + k.bucket
+		}
+	} else {
+		parts := strings.SplitN(uri.Host, ".", 3)
+		if len(parts) < 3 || !strings.HasPrefix(parts[1], "kodo-") {
+			return nil, fmt.Errorf("invalid endpoint %s, expected [BUCKET].kodo-[REGION].qiniucs.com", endpoint)
+		}
+		k.bucket, k.region = parts[0], strings.TrimPrefix(parts[1], "kodo-")
+		k.hosts = kodoRegionHosts(uri.Scheme, k.region)
+	}
+	if k.bucket == "" || strings.Contains(k.bucket, "/") {
+		return nil, fmt.Errorf("invalid endpoint %s: no bucket", endpoint)
+	}
+	return k, nil
+}
+
+func init() {
+	Register(models.Kodo, newKodo)
+}
@@ -0,0 +1,225 @@
+package object
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeKodo 本地的七牛替身，校验凭证并在内存中保存对象
+type fakeKodo struct {
+	t       *testing.T
+	objects map[string][]byte
+	meta    map[string]map[string]string
+	parts   map[int][]byte
+}
+
+func (f *fakeKodo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := kodoAuth{"ak", "sk"}
+	body, _ := ioutil.ReadAll(r.Body)
+	path := r.URL.Path
+	entryKey := func(entry string) string {
+		data, _ := base64.URLEncoding.DecodeString(entry)
+		return strings.TrimPrefix(string(data), "bkt:")
+	}
+	checkQBox := func() bool {
+		if r.Header.Get("Authorization") != auth.qboxToken(r, body) {
+			f.t.Errorf("%s %s: bad token", r.Method, r.URL)
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	switch {
+	case strings.HasPrefix(path, "/stat/"):
+		if !checkQBox() {
+			return
+		}
+		key := entryKey(strings.TrimPrefix(path, "/stat/"))
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(612)
+			w.Write([]byte(`{"error":"no such file or directory"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"fsize": len(data), "putTime": 17000000000000000, "type": 1, "x-qn-meta": f.meta[key]})
+	case strings.HasPrefix(path, "/delete/"):
+		if checkQBox() {
+			delete(f.objects, entryKey(strings.TrimPrefix(path, "/delete/")))
+		}
+	case path == "/list":
+		if !checkQBox() {
+			return
+		}
+		var marker struct{ K string }
+		if m := r.URL.Query().Get("marker"); m != "" {
+			data, _ := base64.URLEncoding.DecodeString(m)
+			json.Unmarshal(data, &marker)
+		}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > marker.K {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		items := []map[string]interface{}{}
+		for _, k := range keys {
+			items = append(items, map[string]interface{}{"key": k, "fsize": len(f.objects[k]), "type": 2})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case path == "/" && r.Method == "POST":
+		// 表单上传
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			f.t.Fatal(err)
+		}
+		key := r.FormValue("key")
+		if !strings.Contains(r.FormValue("token"), ":") {
+			f.t.Errorf("no upload token")
+		}
+		file, _, _ := r.FormFile("file")
+		f.objects[key], _ = ioutil.ReadAll(file)
+		f.meta[key] = map[string]string{}
+		for k, vs := range r.MultipartForm.Value {
+			if strings.HasPrefix(k, kodoChecksumKeyPrefix) {
+				f.meta[key][strings.TrimPrefix(k, kodoChecksumKeyPrefix)] = vs[0]
+			}
+		}
+	case strings.HasPrefix(path, "/buckets/bkt/objects/"):
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "UpToken ak:") {
+			f.t.Errorf("%s %s: no upload token", r.Method, path)
+		}
+		parts := strings.Split(path, "/")
+		switch {
+		case len(parts) == 6:
+			w.Write([]byte(`{"uploadId":"u1"}`))
+		case len(parts) == 8:
+			n, _ := strconv.Atoi(parts[7])
+			f.parts[n] = body
+			w.Write([]byte(`{"etag":"e` + parts[7] + `"}`))
+		case r.Method == "POST":
+			var in struct {
+				Parts    []struct{ PartNumber int }
+				Metadata map[string]string
+			}
+			json.Unmarshal(body, &in)
+			key := entryKey(parts[4])
+			f.objects[key] = nil
+			for _, p := range in.Parts {
+				f.objects[key] = append(f.objects[key], f.parts[p.PartNumber]...)
+			}
+			f.meta[key] = map[string]string{}
+			for k, v := range in.Metadata {
+				f.meta[key][strings.TrimPrefix(k, kodoChecksumKeyPrefix)] = v
+			}
+		}
+	case strings.HasPrefix(path, "/bkt/"):
+		// 下载域名
+		if !strings.HasPrefix(r.URL.Query().Get("token"), "ak:") {
+			f.t.Errorf("download without token")
+		}
+		data, ok := f.objects[strings.TrimPrefix(path, "/bkt/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	default:
+		f.t.Errorf("unexpected %s %s", r.Method, r.URL)
+	}
+}
+
+func TestKodo(t *testing.T) {
+	f := &fakeKodo{t, map[string][]byte{}, map[string]map[string]string{}, map[int][]byte{}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	s, err := CreateStorage(models.Kodo, srv.URL+"/bkt?region=z0", "ak", "sk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &Metadata{UserMeta: map[string]string{"owner": "x"}}
+	for _, k := range []string{"a/1", "a/2 +中", "b"} {
+		if err = s.Put(k, bytes.NewReader([]byte("hello "+k)), models.Default, WithMetadata(meta)); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	objs, err := s.List("a/", "a/1", 10)
+	if err != nil || len(objs) != 1 || objs[0].Key() != "a/2 +中" || StorageClassOf(objs[0]) != models.Archive {
+		t.Fatalf("list: %v %v", objs, err)
+	}
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	o, err := s.Head("b")
+	if err != nil || o.Size() != int64(len("hello b")) || o.(ObjectInfo).Metadata().UserMeta["owner"] != "x" ||
+		StorageClassOf(o) != models.InfrequentAccess {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if sum, err := s.(Checksummer).GetChecksum("b"); err != nil || sum == "" {
+		t.Fatalf("checksum: %q %v", sum, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+
+	up, err := s.CreateMultipartUpload("big", 5, models.Default, WithChecksum("123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []*Part
+	for i, p := range []string{"part1-", "part2"} {
+		part, err := s.UploadPart("big", up.UploadID, i+1, []byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if string(f.objects["big"]) != "part1-part2" || f.meta["big"]["Crc32c"] != "123" {
+		t.Fatalf("multipart: %q %v", f.objects["big"], f.meta["big"])
+	}
+
+	if err = s.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("b"); err != nil {
+		t.Fatalf("delete missing: %s", err)
+	}
+}
+
+// TestKodoDomain 下载域名没有指定协议时使用地址的协议
+func TestKodoDomain(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"bkt.kodo-z0.qiniucs.com?domain=cdn.example.com":                "https://cdn.example.com",
+		"http://bkt.kodo-z0.qiniucs.com?domain=cdn.example.com":         "http://cdn.example.com",
+		"https://bkt.kodo-z0.qiniucs.com?domain=http://cdn.example.com": "http://cdn.example.com",
+	} {
+		s, err := newKodo(endpoint, "ak", "sk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.(*kodoClient).downloadDomain(); got != want {
+			t.Fatalf("%s: download domain %s, want %s", endpoint, got, want)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package object
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"obs-sync/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memObject 内存中的对象，data 写入后不再修改
+type memObject struct {
+	data     []byte
+	mtime    time.Time
+	sc       models.StorageClass
+	meta     *Metadata
+	checksum string
+	acl      *ACL
+	tags     map[string]string
+}
+
+type memUpload struct {
+	key     string
+	created time.Time
+	opts    *PutOptions
+	acl     models.CannedACLType
+	parts   map[int][]byte
+}
+
+// memBucket 内存中的桶，keys 保持有序以便按字典序列举
+type memBucket struct {
+	sync.Mutex
+	owner   string
+	keys    []string
+	objects map[string]*memObject
+	uploads map[string]*memUpload
+}
+
+// memBuckets 进程内所有的桶，按 命名空间/桶名 索引，同一进程中的服务端和 worker 共享
+var memBuckets = struct {
+	sync.Mutex
+	m map[string]*memBucket
+}{m: make(map[string]*memBucket)}
+
+// memStore 保存在内存中的存储，用于测试和演示，进程退出后数据丢失。
+// ak 作为桶所有者的账号 ID，sk 被忽略
+type memStore struct {
+	name         string
+	owner        string
+	checkSumKey  string
+	sumAlgorithm algorithm
+}
+
+// MemBucketURL 桶的访问地址，namespace 隔离不同测试使用的桶
+func MemBucketURL(namespace, name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(namespace, "mem://"), "/") + "/" + name
+}
+
+// ListMemBuckets 命名空间下已创建的桶
+func ListMemBuckets(namespace string) []string {
+	prefix := MemBucketURL(namespace, "")
+	memBuckets.Lock()
+	defer memBuckets.Unlock()
+	var names []string
+	for name := range memBuckets.m {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name[len(prefix):])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bucket 桶未创建时返回错误
+func (m *memStore) bucket() (*memBucket, error) {
+	memBuckets.Lock()
+	defer memBuckets.Unlock()
+	b, ok := memBuckets.m[m.name]
+	if !ok {
+		return nil, fmt.Errorf("mem: bucket %s does not exist", m.name)
+	}
+	return b, nil
+}
+
+func (m *memStore) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = checksumCrc32.String() // 恢复默认
+	}
+	m.checkSumKey = meta
+	return nil
+}
+
+func (m *memStore) IsSetMd5(flag bool) error {
+	m.sumAlgorithm = checksumCrc32
+	if flag {
+		m.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (m *memStore) String() string {
+	return fmt.Sprintf("mem://%s/", m.name)
+}
+
+func (m *memStore) Create() error {
+	memBuckets.Lock()
+	defer memBuckets.Unlock()
+	if _, ok := memBuckets.m[m.name]; !ok {
+		memBuckets.m[m.name] = &memBucket{
+			owner:   m.owner,
+			objects: make(map[string]*memObject),
+			uploads: make(map[string]*memUpload),
+		}
+	}
+	return nil
+}
+
+func (m *memStore) lookup(key string) (*memObject, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	o, ok := b.objects[key]
+	if !ok {
+		return nil, notExist(key)
+	}
+	return o, nil
+}
+
+func (m *memStore) Head(key string) (Object, error) {
+	o, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return &objInfo{obj{key, int64(len(o.data)), o.mtime, strings.HasSuffix(key, "/"), o.sc}, o.meta}, nil
+}
+
+func (m *memStore) GetChecksum(key string) (string, error) {
+	o, err := m.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return o.checksum, nil
+}
+
+func (m *memStore) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	o, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	data := o.data
+	if off > int64(len(data)) {
+		off = int64(len(data))
+	}
+	data = data[off:]
+	if limit > 0 && limit < int64(len(data)) {
+		data = data[:limit]
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// put 写入对象，替换已有的同名对象
+func (m *memStore) put(key string, data []byte, acl models.CannedACLType, o *PutOptions) error {
+	b, err := m.bucket()
+	if err != nil {
+		return err
+	}
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(bytes.NewReader(data), m.sumAlgorithm)
+	}
+	var tags map[string]string
+	if len(o.Tags) > 0 {
+		tags = make(map[string]string, len(o.Tags))
+		for k, v := range o.Tags {
+			tags[k] = v
+		}
+	}
+	b.Lock()
+	defer b.Unlock()
+	grants := CannedACL(b.owner, acl) // Default 与桶相同，为 private
+	if _, ok := b.objects[key]; !ok {
+		i := sort.SearchStrings(b.keys, key)
+		b.keys = append(b.keys, "")
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = key
+	}
+	b.objects[key] = &memObject{data, time.Now(), o.StorageClass, o.Meta, checksum, grants, tags}
+	return nil
+}
+
+func (m *memStore) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return m.put(key, data, acl, applyPutOptions(opts))
+}
+
+func (m *memStore) Delete(key string) error {
+	b, err := m.bucket()
+	if err != nil {
+		return err
+	}
+	b.Lock()
+	defer b.Unlock()
+	if _, ok := b.objects[key]; !ok {
+		return nil
+	}
+	delete(b.objects, key)
+	i := sort.SearchStrings(b.keys, key)
+	b.keys = append(b.keys[:i], b.keys[i+1:]...)
+	return nil
+}
+
+// List 按字典序返回 prefix 下 marker 之后的对象
+func (m *memStore) List(prefix, marker string, limit int64) ([]Object, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	start := prefix
+	if marker > start {
+		start = marker
+	}
+	var objs []Object
+	for i := sort.SearchStrings(b.keys, start); i < len(b.keys) && int64(len(objs)) < limit; i++ {
+		key := b.keys[i]
+		if key == marker && marker != "" {
+			continue
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		o := b.objects[key]
+		objs = append(objs, &obj{key, int64(len(o.data)), o.mtime, strings.HasSuffix(key, "/"), o.sc})
+	}
+	return objs, nil
+}
+
+func (m *memStore) ListAll(prefix, marker string) (<-chan Object, error) {
+	if _, err := m.bucket(); err != nil {
+		return nil, err
+	}
+	listed := make(chan Object, 1000)
+	go func() {
+		defer close(listed)
+		for {
+			objs, err := m.List(prefix, marker, 1000)
+			if err != nil {
+				logger.Error().Msgf("list %s: %s", m, err)
+				listed <- nil
+				return
+			}
+			for _, o := range objs {
+				listed <- o
+			}
+			if len(objs) < 1000 {
+				return
+			}
+			marker = objs[len(objs)-1].Key()
+		}
+	}()
+	return listed, nil
+}
+
+func (m *memStore) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+	uploadID := hex.EncodeToString(id)
+	b.Lock()
+	b.uploads[uploadID] = &memUpload{key, time.Now(), applyPutOptions(opts), acl, make(map[int][]byte)}
+	b.Unlock()
+	return &MultipartUpload{UploadID: uploadID, MinPartSize: minSize, MaxCount: 10000}, nil
+}
+
+func (m *memStore) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	u, ok := b.uploads[uploadID]
+	if !ok || u.key != key {
+		return nil, fmt.Errorf("mem: no such upload %s of %s", uploadID, key)
+	}
+	u.parts[num] = append([]byte(nil), body...)
+	sum := md5.Sum(body)
+	return &Part{Num: num, Size: len(body), ETag: hex.EncodeToString(sum[:])}, nil
+}
+
+func (m *memStore) AbortUpload(key string, uploadID string) {
+	if b, err := m.bucket(); err == nil {
+		b.Lock()
+		delete(b.uploads, uploadID)
+		b.Unlock()
+	}
+}
+
+// CompleteUpload 按 parts 的顺序拼接分片，分片的 ETag 需要与上传时一致
+func (m *memStore) CompleteUpload(key string, uploadID string, parts []*Part) error {
+	b, err := m.bucket()
+	if err != nil {
+		return err
+	}
+	b.Lock()
+	u, ok := b.uploads[uploadID]
+	if !ok || u.key != key {
+		b.Unlock()
+		return fmt.Errorf("mem: no such upload %s of %s", uploadID, key)
+	}
+	var data []byte
+	for _, p := range parts {
+		body, ok := u.parts[p.Num]
+		sum := md5.Sum(body)
+		if !ok || hex.EncodeToString(sum[:]) != p.ETag {
+			b.Unlock()
+			return fmt.Errorf("mem: invalid part %d of upload %s", p.Num, uploadID)
+		}
+		data = append(data, body...)
+	}
+	delete(b.uploads, uploadID)
+	b.Unlock()
+	return m.put(key, data, u.acl, u.opts)
+}
+
+func (m *memStore) ListUploads(marker string) ([]*PendingPart, string, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, "", err
+	}
+	b.Lock()
+	defer b.Unlock()
+	var parts []*PendingPart
+	for id, u := range b.uploads {
+		if u.key > marker {
+			parts = append(parts, &PendingPart{Key: u.key, UploadID: id, Created: u.created})
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].Key != parts[j].Key {
+			return parts[i].Key < parts[j].Key
+		}
+		return parts[i].UploadID < parts[j].UploadID
+	})
+	return parts, "", nil
+}
+
+func (m *memStore) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return objectCanned(m, key)
+}
+
+// GetBucketACL 内存中的桶总是私有的
+func (m *memStore) GetBucketACL() (*ACL, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	return CannedACL(b.owner, models.Private), nil
+}
+
+func (m *memStore) GetACL(key string) (*ACL, error) {
+	o, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ACL{Owner: o.acl.Owner, Grants: append([]Grant(nil), o.acl.Grants...)}, nil
+}
+
+func (m *memStore) SetACL(key string, acl *ACL) error {
+	b, err := m.bucket()
+	if err != nil {
+		return err
+	}
+	b.Lock()
+	defer b.Unlock()
+	o, ok := b.objects[key]
+	if !ok {
+		return notExist(key)
+	}
+	o.acl = &ACL{Owner: b.owner, Grants: append([]Grant(nil), acl.Grants...)}
+	return nil
+}
+
+func (m *memStore) GetTags(key string) (map[string]string, error) {
+	b, err := m.bucket()
+	if err != nil {
+		return nil, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	o, ok := b.objects[key]
+	if !ok {
+		return nil, notExist(key)
+	}
+	tags := make(map[string]string, len(o.tags))
+	for k, v := range o.tags {
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+func (m *memStore) SetTags(key string, tags map[string]string) error {
+	b, err := m.bucket()
+	if err != nil {
+		return err
+	}
+	b.Lock()
+	defer b.Unlock()
+	o, ok := b.objects[key]
+	if !ok {
+		return notExist(key)
+	}
+	o.tags = make(map[string]string, len(tags))
+	for k, v := range tags {
+		o.tags[k] = v
+	}
+	return nil
+}
+
+// newMem 地址为 [mem://]namespace/bucket，桶需要先 Create
+func newMem(endpoint, accessKey, _ string) (ObjectStorage, error) {
+	name := strings.Trim(strings.TrimPrefix(endpoint, "mem://"), "/")
+	if name == "" {
+		return nil, fmt.Errorf("invalid endpoint %s: no bucket", endpoint)
+	}
+	if accessKey == "" {
+		accessKey = "mem"
+	}
+	return &memStore{name: name, owner: accessKey, checkSumKey: checksumCrc32.String(), sumAlgorithm: checksumCrc32}, nil
+}
+
+func init() {
+	Register(models.Mem, newMem)
+}
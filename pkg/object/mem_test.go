@@ -0,0 +1,128 @@
+package object
+
+import (
+	"io/ioutil"
+	"obs-sync/models"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMem(t *testing.T) {
+	s, err := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "owner", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Put("a", strings.NewReader("a"), models.Default); err == nil {
+		t.Fatal("put before the bucket is created")
+	}
+	if err = s.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if names := ListMemBuckets(t.Name()); strings.Join(names, ",") != "bkt" {
+		t.Fatalf("buckets: %v", names)
+	}
+	for _, k := range []string{"b/2", "a", "b/1", "c/", "b/10", "ba"} {
+		if err = s.Put(k, strings.NewReader("hello "+k), models.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := func(objs []Object) string {
+		var ks []string
+		for _, o := range objs {
+			ks = append(ks, o.Key())
+		}
+		return strings.Join(ks, ",")
+	}
+	for _, c := range []struct {
+		prefix, marker string
+		limit          int64
+		want           string
+	}{
+		{"", "", 100, "a,b/1,b/10,b/2,ba,c/"},
+		{"", "", 2, "a,b/1"},
+		{"", "b/1", 100, "b/10,b/2,ba,c/"},
+		{"", "b/0", 2, "b/1,b/10"},
+		{"b/", "", 100, "b/1,b/10,b/2"},
+		{"b/", "a", 100, "b/1,b/10,b/2"},
+		{"b/", "b/10", 100, "b/2"},
+		{"b/", "b/2", 100, ""},
+		{"", "c/", 100, ""},
+	} {
+		objs, err := s.List(c.prefix, c.marker, c.limit)
+		if err != nil || keys(objs) != c.want {
+			t.Fatalf("list %q after %q: %s %v, want %s", c.prefix, c.marker, keys(objs), err, c.want)
+		}
+	}
+	ch, _ := s.ListAll("b", "b/1")
+	var all []Object
+	for o := range ch {
+		all = append(all, o)
+	}
+	if keys(all) != "b/10,b/2,ba" {
+		t.Fatalf("list all: %s", keys(all))
+	}
+
+	meta := &Metadata{ContentType: "text/plain", UserMeta: map[string]string{"k": "v"}}
+	err = s.Put("a", strings.NewReader("replaced"), models.PublicRead,
+		WithMetadata(meta), WithStorageClass(models.StorageClass("IA")), WithTags(map[string]string{"t": "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Head("a")
+	if err != nil || o.Size() != 8 || o.(ObjectInfo).Metadata().UserMeta["k"] != "v" || StorageClassOf(o) != "IA" {
+		t.Fatalf("head: %+v %v", o, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+	if canned, _ := s.GetObjectAcl("a"); canned != models.PublicRead {
+		t.Fatalf("acl: %s", canned)
+	}
+	if canned, _ := s.GetObjectAcl("ba"); canned != models.Default {
+		t.Fatalf("default acl: %s", canned)
+	}
+	if tags, _ := s.(Tagger).GetTags("a"); tags["t"] != "1" {
+		t.Fatalf("tags: %v", tags)
+	}
+	if sum, _ := s.(Checksummer).GetChecksum("a"); sum != generateChecksum(strings.NewReader("replaced"), checksumCrc32) {
+		t.Fatalf("checksum: %s", sum)
+	}
+	in, _ := s.Get("a", 2, 3)
+	data, _ := ioutil.ReadAll(in)
+	if string(data) != "pla" {
+		t.Fatalf("ranged get: %q", data)
+	}
+
+	up, err := s.CreateMultipartUpload("big", 5, models.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, _ := s.UploadPart("big", up.UploadID, 2, []byte("world"))
+	p1, _ := s.UploadPart("big", up.UploadID, 1, []byte("hello "))
+	if pending, _, _ := s.ListUploads(""); len(pending) != 1 || pending[0].Key != "big" {
+		t.Fatalf("uploads: %v", pending)
+	}
+	if err = s.CompleteUpload("big", up.UploadID, []*Part{p1, {Num: 2, ETag: "bad"}}); err == nil {
+		t.Fatal("complete with a wrong etag")
+	}
+	if err = s.CompleteUpload("big", up.UploadID, []*Part{p1, p2}); err != nil {
+		t.Fatal(err)
+	}
+	in, _ = s.Get("big", 0, -1)
+	if data, _ = ioutil.ReadAll(in); string(data) != "hello world" {
+		t.Fatalf("multipart: %q", data)
+	}
+	if pending, _, _ := s.ListUploads(""); len(pending) != 0 {
+		t.Fatalf("uploads left: %v", pending)
+	}
+
+	for _, k := range []string{"b/10", "b/10"} {
+		if err = s.Delete(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if objs, _ := s.List("b/", "", 100); keys(objs) != "b/1,b/2" {
+		t.Fatalf("after delete: %s", keys(objs))
+	}
+}
@@ -0,0 +1,49 @@
+package object
+
+import (
+	"net/http"
+	"strings"
+)
+
+// headerMetadata extracts the metadata of an object from the response headers,
+// prefix is the prefix of the user metadata, like x-oss-meta-.
+func headerMetadata(h http.Header, prefix string) *Metadata {
+	meta := &Metadata{
+		ContentType:        h.Get("Content-Type"),
+		ContentEncoding:    h.Get("Content-Encoding"),
+		CacheControl:       h.Get("Cache-Control"),
+		ContentDisposition: h.Get("Content-Disposition"),
+		Expires:            h.Get("Expires"),
+	}
+	prefix = strings.ToLower(prefix)
+	for k, v := range h {
+		if len(v) == 0 || !strings.HasPrefix(strings.ToLower(k), prefix) || isChecksumMeta(k[len(prefix):]) {
+			continue
+		}
+		if meta.UserMeta == nil {
+			meta.UserMeta = make(map[string]string)
+		}
+		meta.UserMeta[strings.ToLower(k[len(prefix):])] = v[0]
+	}
+	return meta
+}
+
+// isChecksumMeta 是否为源端存储写入的校验值，如 Crc32c、cuoss-md5，不作为用户元数据复制，
+// 目的端按自己的算法重新写入
+func isChecksumMeta(name string) bool {
+	name = strings.ToLower(name)
+	name = strings.TrimPrefix(name, strings.ToLower(s3ChecksumKeyPrefix))
+	name = strings.TrimPrefix(name, "cuoss-")
+	return name == strings.ToLower(checksumCrc32.String()) || name == checksumMd5.String()
+}
+
+// userMetaHeader returns the user metadata as headers with the given prefix.
+func userMetaHeader(meta *Metadata, prefix string) http.Header {
+	h := make(http.Header)
+	if meta != nil {
+		for k, v := range meta.UserMeta {
+			h.Set(prefix+k, v)
+		}
+	}
+	return h
+}
@@ -0,0 +1,55 @@
+package object
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestHeaderMetadata(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "text/plain")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Oss-Meta-Owner", "x")
+	h.Set("x-oss-meta-Crc32c", "123")
+	h.Set("x-oss-meta-md5", "abc")
+	h.Set("X-Oss-Request-Id", "1")
+	meta := headerMetadata(h, "x-oss-meta-")
+	if meta.ContentType != "text/plain" || meta.CacheControl != "no-cache" {
+		t.Fatalf("headers: %+v", meta)
+	}
+	if len(meta.UserMeta) != 1 || meta.UserMeta["owner"] != "x" {
+		t.Fatalf("user metadata: %v", meta.UserMeta)
+	}
+
+	h = userMetaHeader(meta, "x-cos-meta-")
+	if len(h) != 1 || h.Get("x-cos-meta-owner") != "x" {
+		t.Fatalf("user metadata headers: %v", h)
+	}
+	if h = userMetaHeader(nil, "x-cos-meta-"); len(h) != 0 {
+		t.Fatalf("headers of nil metadata: %v", h)
+	}
+}
+
+func TestS3Metadata(t *testing.T) {
+	meta := s3Metadata(&s3.HeadObjectOutput{
+		ContentType: aws.String("image/png"),
+		Metadata: map[string]*string{
+			"Owner":                aws.String("x"),
+			"X-Amz-Content-Crc32c": aws.String("123"),
+			"Cuoss-Md5":            aws.String("abc"),
+		},
+	})
+	if meta.ContentType != "image/png" || len(meta.UserMeta) != 1 || meta.UserMeta["Owner"] != "x" {
+		t.Fatalf("metadata: %+v", meta)
+	}
+	m := s3UserMeta(meta, "X-Amz-Content-md5", "sum")
+	if len(m) != 2 || aws.StringValue(m["X-Amz-Content-md5"]) != "sum" {
+		t.Fatalf("user metadata with checksum: %v", m)
+	}
+	if s3UserMeta(nil, "X-Amz-Content-md5", "") != nil {
+		t.Fatal("empty user metadata")
+	}
+}
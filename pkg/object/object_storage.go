@@ -10,6 +10,11 @@ import (
 
 	"obs-sync/models"
 	"obs-sync/pkg/utils"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
 var ctx = context.Background()
@@ -29,6 +34,21 @@ type SupportSymlink interface {
 	Readlink(name string) (string, error)
 }
 
+// Checksummer is implemented by storages that can tell the checksum stored in
+// the metadata of an object, under the key set by SetCheckSumKey.
+type Checksummer interface {
+	// GetChecksum returns the checksum of the object, or "" if it has none.
+	GetChecksum(key string) (string, error)
+}
+
+// Locator is implemented by storages that know which hosts hold the data of
+// an object, such as the datanodes of HDFS, so that tasks can be scheduled to
+// the workers running on those hosts.
+type Locator interface {
+	// Locations returns the host names holding the first block of the object.
+	Locations(key string) ([]string, error)
+}
+
 type File interface {
 	Object
 	Owner() string
@@ -53,6 +73,13 @@ func MarshalObject(o Object) map[string]interface{} {
 	m["size"] = o.Size()
 	m["mtime"] = o.Mtime().UnixNano()
 	m["isdir"] = o.IsDir()
+	if sc := StorageClassOf(o); sc != "" {
+		m["storageClass"] = string(sc)
+	}
+	if v, ok := o.(Version); ok {
+		m["versionId"] = v.VersionID()
+		m["deleteMarker"] = v.IsDeleteMarker()
+	}
 	if f, ok := o.(File); ok {
 		m["mode"] = f.Mode()
 		m["owner"] = f.Owner()
@@ -63,7 +90,14 @@ func MarshalObject(o Object) map[string]interface{} {
 
 func UnmarshalObject(m map[string]interface{}) Object {
 	mtime := time.Unix(0, m["mtime"].(int64))
-	o := obj{m["key"].(string), m["size"].(int64), mtime, m["isdir"].(bool)}
+	o := obj{m["key"].(string), m["size"].(int64), mtime, m["isdir"].(bool), ""}
+	if sc, ok := m["storageClass"].(string); ok {
+		o.sc = models.StorageClass(sc)
+	}
+	if id, ok := m["versionId"].(string); ok && id != "" {
+		dm, _ := m["deleteMarker"].(bool)
+		return &version{o, id, dm}
+	}
 	if _, ok := m["mode"]; ok {
 		f := file{o, m["owner"].(string), m["group"].(string), os.FileMode(m["mode"].(float64))}
 		return &f
@@ -79,6 +113,48 @@ type FileSystem interface {
 
 var notSupported = errors.New("not supported")
 
+// notExist 对象不存在时 Head 返回的错误，调用方统一用 os.IsNotExist 判断
+func notExist(key string) error {
+	return &os.PathError{Op: "head", Path: key, Err: os.ErrNotExist}
+}
+
+// errorCode 厂商 SDK 或接口返回的错误码，如 InvalidObjectState，不是厂商的错误时为空
+func errorCode(err error) string {
+	var (
+		ae  awserr.Error
+		ce  *cos.ErrorResponse
+		oe  oss.ServiceError
+		he  obs.ObsError
+		aze *azblobError
+		be  *bosError
+		ge  *gcsError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &ae):
+		return ae.Code()
+	case errors.As(err, &ce):
+		return ce.Code
+	case errors.As(err, &oe):
+		return oe.Code
+	case errors.As(err, &he):
+		return he.Code
+	case errors.As(err, &aze):
+		return aze.Code
+	case errors.As(err, &be):
+		return be.Code
+	case errors.As(err, &ge):
+		return ge.Code
+	}
+	return ""
+}
+
+// IsNotSupported 存储不支持该操作，如 WithPrefix 包装的存储未实现对应的可选接口
+func IsNotSupported(err error) bool {
+	return err == notSupported
+}
+
 type DefaultObjectStorage struct{}
 
 func (s DefaultObjectStorage) Create() error {
@@ -89,7 +165,7 @@ func (s DefaultObjectStorage) Head(key string) (Object, error) {
 	return nil, notSupported
 }
 
-func (s DefaultObjectStorage) CreateMultipartUpload(key string, minSize int, _ models.CannedACLType) (*MultipartUpload, error) {
+func (s DefaultObjectStorage) CreateMultipartUpload(key string, minSize int, _ models.CannedACLType, _ ...PutOption) (*MultipartUpload, error) {
 	return nil, notSupported
 }
 
@@ -128,6 +204,17 @@ func Register(name models.ResourceType, register Creator) {
 }
 
 func CreateStorage(name models.ResourceType, endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	if inner, addr, params, ok := SplitChaos(name, endpoint); ok {
+		c, err := ParseChaos(params)
+		if err != nil {
+			return nil, err
+		}
+		s, err := CreateStorage(inner, addr, accessKey, secretKey)
+		if err != nil {
+			return nil, err
+		}
+		return WithChaos(s, c), nil
+	}
 	f, ok := storages[name]
 	if ok {
 		return f(endpoint, accessKey, secretKey)
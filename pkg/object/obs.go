@@ -15,8 +15,6 @@ import (
 	"os"
 	"strings"
 
-	mapset "github.com/deckarep/golang-set"
-
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	"golang.org/x/net/http/httpproxy"
 
@@ -25,18 +23,61 @@ import (
 
 const obsDefaultRegion = "cn-north-1"
 
+const obsChecksumKeyPrefix = "x-obs-meta-"
+
 type obsClient struct {
-	bucket string
-	region string
-	c      *obs.ObsClient
+	bucket       string
+	region       string
+	c            *obs.ObsClient
+	checkSumKey  string
+	sumAlgorithm algorithm
+	bucketACL    aclCache
+	sse          *SSE
 }
 
 func (o *obsClient) SetCheckSumKey(meta string) error {
-	return notSupported
+	if meta == "" {
+		meta = obsChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	o.checkSumKey = meta
+	return nil
 }
 
 func (o *obsClient) IsSetMd5(flag bool) error {
-	return notSupported
+	o.sumAlgorithm = checksumCrc32
+	if flag {
+		o.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (o *obsClient) SetSSE(sse *SSE) error {
+	o.sse = sse
+	return nil
+}
+
+// sseHeader 写入对象时的加密请求头，KMS 的加密方式由 SDK 按协议填写
+func (o *obsClient) sseHeader() obs.ISseHeader {
+	if o.sse.customer() {
+		return o.sseCHeader()
+	}
+	if o.sse != nil {
+		switch o.sse.Mode {
+		case SSEProvider:
+			return obs.SseKmsHeader{Encryption: "AES256"}
+		case SSEKMS:
+			return obs.SseKmsHeader{Key: o.sse.KMSKeyID}
+		}
+	}
+	return nil
+}
+
+// sseCHeader 读取 SSE-C 加密的对象和上传分片时需要带上密钥
+func (o *obsClient) sseCHeader() obs.ISseHeader {
+	if !o.sse.customer() {
+		return nil
+	}
+	return obs.SseCHeader{Encryption: obs.DEFAULT_SSE_C_ENCRYPTION, Key: o.sse.customerKey(), KeyMD5: o.sse.customerKeyMD5()}
 }
 
 func (o *obsClient) String() string {
@@ -56,25 +97,88 @@ func (o *obsClient) Create() error {
 
 func (o *obsClient) Head(key string) (Object, error) {
 	params := &obs.GetObjectMetadataInput{
-		Bucket: o.bucket,
-		Key:    key,
+		Bucket:    o.bucket,
+		Key:       key,
+		SseHeader: o.sseCHeader(),
 	}
 	r, err := o.c.GetObjectMetadata(params)
 	if err != nil {
 		return nil, err
 	}
-	return &obj{
-		key,
-		r.ContentLength,
-		r.LastModified,
-		strings.HasSuffix(key, "/"),
+	meta := &Metadata{
+		ContentType:        r.ContentType,
+		ContentEncoding:    r.ContentEncoding,
+		CacheControl:       r.CacheControl,
+		ContentDisposition: r.ContentDisposition,
+		Expires:            r.HttpExpires,
+	}
+	for k, v := range r.Metadata {
+		if isChecksumMeta(k) {
+			continue
+		}
+		if meta.UserMeta == nil {
+			meta.UserMeta = make(map[string]string)
+		}
+		meta.UserMeta[k] = v
+	}
+	return &objInfo{
+		obj{
+			key,
+			r.ContentLength,
+			r.LastModified,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Obs, string(r.StorageClass)),
+		},
+		meta,
 	}, nil
 }
 
+// obsMetaValue SDK 返回的 Metadata 已去掉 x-obs-meta- 前缀
+func obsMetaValue(r *obs.GetObjectMetadataOutput, header string) string {
+	name := strings.ToLower(header)
+	if name == "etag" {
+		return r.ETag
+	}
+	name = strings.TrimPrefix(name, obsChecksumKeyPrefix)
+	for k, v := range r.Metadata {
+		if strings.ToLower(k) == name {
+			return v
+		}
+	}
+	return ""
+}
+
+func (o *obsClient) Restore(key string, days int) error {
+	_, err := o.c.RestoreObject(&obs.RestoreObjectInput{
+		Bucket: o.bucket,
+		Key:    key,
+		Days:   days,
+		Tier:   obs.RestoreTierStandard,
+	})
+	return restoreStarted(err)
+}
+
+func (o *obsClient) Restored(key string) (bool, error) {
+	r, err := o.c.GetObjectMetadata(&obs.GetObjectMetadataInput{Bucket: o.bucket, Key: key, SseHeader: o.sseCHeader()})
+	if err != nil {
+		return false, err
+	}
+	return restoreDone(r.Restore), nil
+}
+
+func (o *obsClient) GetChecksum(key string) (string, error) {
+	r, err := o.c.GetObjectMetadata(&obs.GetObjectMetadataInput{Bucket: o.bucket, Key: key, SseHeader: o.sseCHeader()})
+	if err != nil {
+		return "", err
+	}
+	return obsMetaValue(r, o.checkSumKey), nil
+}
+
 func (o *obsClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	params := &obs.GetObjectInput{}
 	params.Bucket = o.bucket
 	params.Key = key
+	params.SseHeader = o.sseCHeader()
 	params.RangeStart = off
 	if limit > 0 {
 		params.RangeEnd = off + limit - 1
@@ -86,7 +190,90 @@ func (o *obsClient) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func (o *obsClient) Put(key string, in io.Reader, acl models.CannedACLType) error {
+func (o *obsClient) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	input := &obs.ListVersionsInput{
+		Bucket:          o.bucket,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionMarker,
+	}
+	input.Prefix = prefix
+	input.MaxKeys = int(limit)
+	resp, err := o.c.ListVersions(input)
+	if err != nil {
+		return nil, "", "", err
+	}
+	vs := make([]Version, 0, len(resp.Versions)+len(resp.DeleteMarkers))
+	for _, v := range resp.Versions {
+		vs = append(vs, &version{obj{v.Key, v.Size, v.LastModified, strings.HasSuffix(v.Key, "/"), toStorageClass(models.Obs, string(v.StorageClass))}, v.VersionId, false})
+	}
+	for _, v := range resp.DeleteMarkers {
+		vs = append(vs, &version{obj{v.Key, 0, v.LastModified, strings.HasSuffix(v.Key, "/"), ""}, v.VersionId, true})
+	}
+	sortVersions(vs)
+	if !resp.IsTruncated {
+		return vs, "", "", nil
+	}
+	return vs, resp.NextKeyMarker, resp.NextVersionIdMarker, nil
+}
+
+func (o *obsClient) EnableVersioning() error {
+	_, err := o.c.SetBucketVersioning(&obs.SetBucketVersioningInput{
+		Bucket:                        o.bucket,
+		BucketVersioningConfiguration: obs.BucketVersioningConfiguration{Status: obs.VersioningStatusEnabled},
+	})
+	return err
+}
+
+func (o *obsClient) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	params := &obs.GetObjectInput{}
+	params.Bucket = o.bucket
+	params.Key = key
+	params.VersionId = versionID
+	params.SseHeader = o.sseCHeader()
+	params.RangeStart = off
+	if limit > 0 {
+		params.RangeEnd = off + limit - 1
+	}
+	resp, err := o.c.GetObject(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// obsMetadata 用户元数据及校验值，SDK 会自动加上 x-obs-meta- 前缀
+func (o *obsClient) obsMetadata(meta *Metadata, checksum string) map[string]string {
+	m := make(map[string]string)
+	if meta != nil {
+		for k, v := range meta.UserMeta {
+			m[k] = v
+		}
+	}
+	if checksum != "" {
+		m[o.sumAlgorithm.String()] = checksum
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func obsHttpHeader(meta *Metadata) obs.HttpHeader {
+	if meta == nil {
+		return obs.HttpHeader{}
+	}
+	return obs.HttpHeader{
+		CacheControl:       meta.CacheControl,
+		ContentDisposition: meta.ContentDisposition,
+		ContentEncoding:    meta.ContentEncoding,
+		ContentType:        meta.ContentType,
+		HttpExpires:        meta.Expires,
+	}
+}
+
+func (o *obsClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	po := applyPutOptions(opts)
+	checksum := po.Checksum
 	var body io.ReadSeeker
 	var vlen int64
 	var sum []byte
@@ -105,6 +292,9 @@ func (o *obsClient) Put(key string, in io.Reader, acl models.CannedACLType) erro
 		}
 		sum = h.Sum(nil)
 		body = b
+		if checksum == "" && o.sumAlgorithm != checksumMd5 {
+			checksum = generateChecksum(b, o.sumAlgorithm)
+		}
 	} else {
 		data, err := ioutil.ReadAll(in)
 		if err != nil {
@@ -114,9 +304,19 @@ func (o *obsClient) Put(key string, in io.Reader, acl models.CannedACLType) erro
 		s := md5.Sum(data)
 		sum = s[:]
 		body = bytes.NewReader(data)
+		if checksum == "" && o.sumAlgorithm != checksumMd5 {
+			checksum = generateChecksum(body, o.sumAlgorithm)
+		}
+	}
+	if checksum == "" && o.sumAlgorithm == checksumMd5 {
+		checksum = base64.StdEncoding.EncodeToString(sum)
 	}
-	mimeType := utils.GuessMimeType(key)
 	params := &obs.PutObjectInput{}
+	params.HttpHeader = obsHttpHeader(po.Meta)
+	params.StorageClass = obs.StorageClassType(fromStorageClass(models.Obs, po.StorageClass))
+	if params.ContentType == "" {
+		params.ContentType = utils.GuessMimeType(key)
+	}
 	if acl != "" && acl != models.Default {
 		params.ACL = obs.AclType(acl)
 	}
@@ -125,7 +325,8 @@ func (o *obsClient) Put(key string, in io.Reader, acl models.CannedACLType) erro
 	params.Body = body
 	params.ContentLength = vlen
 	params.ContentMD5 = base64.StdEncoding.EncodeToString(sum[:])
-	params.ContentType = mimeType
+	params.Metadata = o.obsMetadata(po.Meta, checksum)
+	params.SseHeader = o.sseHeader()
 	_, err := o.c.PutObject(params)
 	return err
 }
@@ -163,7 +364,7 @@ func (o *obsClient) List(prefix, marker string, limit int64) ([]Object, error) {
 	objs := make([]Object, n)
 	for i := 0; i < n; i++ {
 		o := resp.Contents[i]
-		objs[i] = &obj{o.Key, o.Size, o.LastModified, strings.HasSuffix(o.Key, "/")}
+		objs[i] = &obj{o.Key, o.Size, o.LastModified, strings.HasSuffix(o.Key, "/"), toStorageClass(models.Obs, string(o.StorageClass))}
 	}
 	return objs, nil
 }
@@ -172,13 +373,18 @@ func (o *obsClient) ListAll(prefix, marker string) (<-chan Object, error) {
 	return nil, notSupported
 }
 
-func (o *obsClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
+func (o *obsClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	po := applyPutOptions(opts)
 	params := &obs.InitiateMultipartUploadInput{}
+	params.HttpHeader = obsHttpHeader(po.Meta)
+	params.StorageClass = obs.StorageClassType(fromStorageClass(models.Obs, po.StorageClass))
+	params.Metadata = o.obsMetadata(po.Meta, po.Checksum)
 	if acl != "" && acl != models.Default {
 		params.ACL = obs.AclType(acl)
 	}
 	params.Bucket = o.bucket
 	params.Key = key
+	params.SseHeader = o.sseHeader()
 	resp, err := o.c.InitiateMultipartUpload(params)
 	if err != nil {
 		return nil, err
@@ -196,6 +402,7 @@ func (o *obsClient) UploadPart(key string, uploadID string, num int, body []byte
 	params.PartSize = int64(len(body))
 	sum := md5.Sum(body)
 	params.ContentMD5 = base64.StdEncoding.EncodeToString(sum[:])
+	params.SseHeader = o.sseCHeader()
 	resp, err := o.c.UploadPart(params)
 	if err != nil {
 		return nil, err
@@ -245,45 +452,56 @@ func (o *obsClient) ListUploads(marker string) ([]*PendingPart, string, error) {
 }
 
 func (o *obsClient) GetObjectAcl(key string) (models.CannedACLType, error) {
-	// object acl
-	input := &obs.GetObjectAclInput{
-		Bucket: o.bucket,
-		Key:    key,
-	}
-	result, err := o.c.GetObjectAcl(input)
-	if err != nil || len(result.Grants) == 0 {
-		return "", err
-	}
-	// bucket acl
-	resultBucket, err := o.c.GetBucketAcl(o.bucket)
-	if err != nil || len(resultBucket.Grants) == 0 {
-		return "", err
-	}
-	if fmt.Sprintf("%v", resultBucket.Grants) == fmt.Sprintf("%v", result.Grants) {
-		return models.Default, nil
-	}
+	return objectCanned(o, key)
+}
 
-	allUsersPermissions := mapset.NewSet()
-	authenticatedRead := false
-	for _, value := range result.Grants {
-		if value.Grantee.URI == obs.GroupAllUsers {
-			allUsersPermissions.Add(value.Permission)
+func (o *obsClient) GetBucketACL() (*ACL, error) {
+	return o.bucketACL.get(func() (*ACL, error) {
+		result, err := o.c.GetBucketAcl(o.bucket)
+		if err != nil {
+			return nil, err
 		}
-		if value.Grantee.URI == obs.GroupAuthenticatedUsers && value.Permission == obs.PermissionRead {
-			authenticatedRead = true
+		return obsACL(result.AccessControlPolicy), nil
+	})
+}
+
+func (o *obsClient) GetACL(key string) (*ACL, error) {
+	result, err := o.c.GetObjectAcl(&obs.GetObjectAclInput{Bucket: o.bucket, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return obsACL(result.AccessControlPolicy), nil
+}
+
+func (o *obsClient) SetACL(key string, acl *ACL) error {
+	input := &obs.SetObjectAclInput{Bucket: o.bucket, Key: key}
+	input.Owner.ID = acl.Owner
+	for _, g := range acl.Grants {
+		grant := obs.Grant{Permission: obs.PermissionType(g.Permission)}
+		switch g.Grantee {
+		case AllUsers, AuthenticatedUsers:
+			grant.Grantee = obs.Grantee{Type: obs.GranteeGroup, URI: obs.GroupUriType(g.Grantee)}
+		default:
+			grant.Grantee = obs.Grantee{Type: obs.GranteeUser, ID: g.Grantee}
 		}
+		input.Grants = append(input.Grants, grant)
 	}
-	read := allUsersPermissions.Contains("READ")
-	write := allUsersPermissions.Contains("WRITE")
-	if read && write { // all user read write
-		return models.PublicReadWrite, nil
-	} else if read { // all read
-		return models.PublicRead, nil
-	} else if authenticatedRead {
-		return models.AuthenticatedRead, nil
-	} else { //owner FULL_CONTROL
-		return models.Private, nil
+	_, err := o.c.SetObjectAcl(input)
+	return err
+}
+
+func obsACL(p obs.AccessControlPolicy) *ACL {
+	acl := &ACL{Owner: p.Owner.ID}
+	for _, g := range p.Grants {
+		grantee := g.Grantee.ID
+		if group, ok := groupOf(string(g.Grantee.URI)); ok {
+			grantee = group
+		} else if g.Grantee.URI != "" {
+			grantee = string(g.Grantee.URI)
+		}
+		acl.Grants = append(acl.Grants, Grant{grantee, string(g.Permission)})
 	}
+	return acl
 }
 
 func autoOBSEndpoint(bucketName, accessKey, secretKey string) (string, error) {
@@ -362,7 +580,7 @@ func newOBS(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("fail to initialize OBS: %q", err)
 	}
-	return &obsClient{bucketName, region, c}, nil
+	return &obsClient{bucketName, region, c, obsChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}, nil}, nil
 }
 
 func init() {
@@ -31,20 +31,35 @@ type ossClient struct {
 	bucket       *oss.Bucket
 	checkSumKey  string
 	sumAlgorithm algorithm
+	bucketACL    aclCache
+	sse          *SSE
 }
 
 func (o *ossClient) SetCheckSumKey(meta string) error {
+	if meta == "" {
+		meta = oss.HTTPHeaderOssMetaPrefix + checksumCrc32.String() // 恢复默认
+	}
 	o.checkSumKey = meta
 	return nil
 }
 
 func (o *ossClient) IsSetMd5(flag bool) error {
+	o.sumAlgorithm = checksumCrc32
 	if flag {
 		o.sumAlgorithm = checksumMd5
 	}
 	return nil
 }
 
+// SetSSE OSS 不支持用户提供的密钥（SSE-C），由服务端管理密钥时读取不需要额外的请求头
+func (o *ossClient) SetSSE(sse *SSE) error {
+	if sse.customer() {
+		return fmt.Errorf("%s does not support customer-provided encryption keys", o)
+	}
+	o.sse = sse
+	return nil
+}
+
 func (o *ossClient) String() string {
 	return fmt.Sprintf("oss://%s/", o.bucket.BucketName)
 }
@@ -70,7 +85,7 @@ func (o *ossClient) checkError(err error) error {
 }
 
 func (o *ossClient) Head(key string) (Object, error) {
-	r, err := o.bucket.GetObjectMeta(key)
+	r, err := o.bucket.GetObjectDetailedMeta(key)
 	if o.checkError(err) != nil {
 		return nil, err
 	}
@@ -85,11 +100,15 @@ func (o *ossClient) Head(key string) (Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &obj{
-		key,
-		size,
-		mtime,
-		strings.HasSuffix(key, "/"),
+	return &objInfo{
+		obj{
+			key,
+			size,
+			mtime,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.Oss, r.Get(oss.HTTPHeaderOssStorageClass)),
+		},
+		headerMetadata(r, oss.HTTPHeaderOssMetaPrefix),
 	}, nil
 }
 
@@ -113,26 +132,167 @@ func (o *ossClient) Get(key string, off, limit int64) (resp io.ReadCloser, err e
 	return
 }
 
-func (o *ossClient) Put(key string, in io.Reader, acl models.CannedACLType) error {
-	checkSumMetaKey := oss.HTTPHeaderOssMetaPrefix + o.sumAlgorithm.String()
-	if ins, ok := in.(io.ReadSeeker); ok {
-		option := oss.Meta(checkSumMetaKey, generateChecksum(ins, o.sumAlgorithm))
-		return o.checkError(o.bucket.PutObject(key, in, option))
-	}
-	var ossAcl oss.Option
-	if acl != "" && acl != models.AuthenticatedRead {
-		switch acl {
-		case models.Default, "":
-			ossAcl = oss.ACL(oss.ACLDefault)
-		case models.Private:
-			ossAcl = oss.ACL(oss.ACLPrivate)
-		case models.PublicRead:
-			ossAcl = oss.ACL(oss.ACLPublicRead)
-		case models.PublicReadWrite:
-			ossAcl = oss.ACL(oss.ACLPublicReadWrite)
+func (o *ossClient) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	options := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(int(limit))}
+	if keyMarker != "" {
+		options = append(options, oss.KeyMarker(keyMarker))
+	}
+	if versionMarker != "" {
+		options = append(options, oss.VersionIdMarker(versionMarker))
+	}
+	resp, err := o.bucket.ListObjectVersions(options...)
+	if err != nil {
+		return nil, "", "", err
+	}
+	vs := make([]Version, 0, len(resp.ObjectVersions)+len(resp.ObjectDeleteMarkers))
+	for _, v := range resp.ObjectVersions {
+		vs = append(vs, &version{obj{v.Key, v.Size, v.LastModified, strings.HasSuffix(v.Key, "/"), toStorageClass(models.Oss, v.StorageClass)}, v.VersionId, false})
+	}
+	for _, v := range resp.ObjectDeleteMarkers {
+		vs = append(vs, &version{obj{v.Key, 0, v.LastModified, strings.HasSuffix(v.Key, "/"), ""}, v.VersionId, true})
+	}
+	sortVersions(vs)
+	if !resp.IsTruncated {
+		return vs, "", "", nil
+	}
+	return vs, resp.NextKeyMarker, resp.NextVersionIdMarker, nil
+}
+
+func (o *ossClient) EnableVersioning() error {
+	return o.checkError(o.client.SetBucketVersioning(o.bucket.BucketName, oss.VersioningConfig{Status: string(oss.VersionEnabled)}))
+}
+
+func (o *ossClient) GetVersion(key, versionID string, off, limit int64) (resp io.ReadCloser, err error) {
+	options := []oss.Option{oss.VersionId(versionID)}
+	if off > 0 || limit > 0 {
+		var r string
+		if limit > 0 {
+			r = fmt.Sprintf("%d-%d", off, off+limit-1)
+		} else {
+			r = fmt.Sprintf("%d-", off)
 		}
+		options = append(options, oss.NormalizedRange(r), oss.RangeBehavior("standard"))
 	}
-	return o.checkError(o.bucket.PutObject(key, in, ossAcl))
+	resp, err = o.bucket.GetObject(key, options...)
+	err = o.checkError(err)
+	return
+}
+
+func ossACL(acl models.CannedACLType) oss.Option {
+	switch acl {
+	case models.Default:
+		return oss.ACL(oss.ACLDefault)
+	case models.Private:
+		return oss.ACL(oss.ACLPrivate)
+	case models.PublicRead:
+		return oss.ACL(oss.ACLPublicRead)
+	case models.PublicReadWrite:
+		return oss.ACL(oss.ACLPublicReadWrite)
+	}
+	return nil
+}
+
+func (o *ossClient) putOptions(acl models.CannedACLType, po *PutOptions, checksum string) []oss.Option {
+	var options []oss.Option
+	if sc := fromStorageClass(models.Oss, po.StorageClass); sc != "" {
+		options = append(options, oss.ObjectStorageClass(oss.StorageClassType(sc)))
+	}
+	if len(po.Tags) > 0 {
+		options = append(options, oss.SetTagging(ossTagging(po.Tags)))
+	}
+	if meta := po.Meta; meta != nil {
+		if meta.ContentType != "" {
+			options = append(options, oss.ContentType(meta.ContentType))
+		}
+		if meta.ContentEncoding != "" {
+			options = append(options, oss.ContentEncoding(meta.ContentEncoding))
+		}
+		if meta.CacheControl != "" {
+			options = append(options, oss.CacheControl(meta.CacheControl))
+		}
+		if meta.ContentDisposition != "" {
+			options = append(options, oss.ContentDisposition(meta.ContentDisposition))
+		}
+		if t, err := http.ParseTime(meta.Expires); err == nil {
+			options = append(options, oss.Expires(t))
+		}
+		for k, v := range meta.UserMeta {
+			options = append(options, oss.Meta(k, v))
+		}
+	}
+	if checksum != "" {
+		// oss.Meta 会自动加上 x-oss-meta- 前缀
+		options = append(options, oss.Meta(o.sumAlgorithm.String(), checksum))
+	}
+	if ossAcl := ossACL(acl); ossAcl != nil {
+		options = append(options, ossAcl)
+	}
+	if o.sse != nil {
+		switch o.sse.Mode {
+		case SSEProvider:
+			options = append(options, oss.ServerSideEncryption("AES256"))
+		case SSEKMS:
+			options = append(options, oss.ServerSideEncryption("KMS"))
+			if o.sse.KMSKeyID != "" {
+				options = append(options, oss.ServerSideEncryptionKeyID(o.sse.KMSKeyID))
+			}
+		}
+	}
+	return options
+}
+
+func (o *ossClient) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	po := applyPutOptions(opts)
+	checksum := po.Checksum
+	if ins, ok := in.(io.ReadSeeker); ok && checksum == "" {
+		checksum = generateChecksum(ins, o.sumAlgorithm)
+	}
+	return o.checkError(o.bucket.PutObject(key, in, o.putOptions(acl, po, checksum)...))
+}
+
+func (o *ossClient) GetTags(key string) (map[string]string, error) {
+	r, err := o.bucket.GetObjectTagging(key)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(r.Tags))
+	for _, t := range r.Tags {
+		tags[t.Key] = t.Value
+	}
+	return tags, nil
+}
+
+func ossTagging(tags map[string]string) oss.Tagging {
+	var tagging oss.Tagging
+	for k, v := range tags {
+		tagging.Tags = append(tagging.Tags, oss.Tag{Key: k, Value: v})
+	}
+	return tagging
+}
+
+func (o *ossClient) SetTags(key string, tags map[string]string) error {
+	return tagErr(o.bucket.PutObjectTagging(key, ossTagging(tags)))
+}
+
+func (o *ossClient) Restore(key string, days int) error {
+	err := o.bucket.RestoreObjectDetail(key, oss.RestoreConfiguration{Days: int32(days)})
+	return restoreStarted(o.checkError(err))
+}
+
+func (o *ossClient) Restored(key string) (bool, error) {
+	r, err := o.bucket.GetObjectDetailedMeta(key)
+	if o.checkError(err) != nil {
+		return false, err
+	}
+	return restoreDone(r.Get("X-Oss-Restore")), nil
+}
+
+func (o *ossClient) GetChecksum(key string) (string, error) {
+	r, err := o.bucket.GetObjectMeta(key)
+	if o.checkError(err) != nil {
+		return "", err
+	}
+	return r.Get(o.checkSumKey), nil
 }
 
 func (o *ossClient) Copy(dst, src string) error {
@@ -157,7 +317,7 @@ func (o *ossClient) List(prefix, marker string, limit int64) ([]Object, error) {
 	objs := make([]Object, n)
 	for i := 0; i < n; i++ {
 		o := result.Objects[i]
-		objs[i] = &obj{o.Key, o.Size, o.LastModified, strings.HasSuffix(o.Key, "/")}
+		objs[i] = &obj{o.Key, o.Size, o.LastModified, strings.HasSuffix(o.Key, "/"), toStorageClass(models.Oss, o.StorageClass)}
 	}
 	return objs, nil
 }
@@ -166,21 +326,10 @@ func (o *ossClient) ListAll(prefix, marker string) (<-chan Object, error) {
 	return nil, notSupported
 }
 
-func (o *ossClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
-	var ossAcl oss.Option
-	if acl != "" && acl != models.AuthenticatedRead {
-		switch acl {
-		case models.Default, "":
-			ossAcl = oss.ACL(oss.ACLDefault)
-		case models.Private:
-			ossAcl = oss.ACL(oss.ACLPrivate)
-		case models.PublicRead:
-			ossAcl = oss.ACL(oss.ACLPublicRead)
-		case models.PublicReadWrite:
-			ossAcl = oss.ACL(oss.ACLPublicReadWrite)
-		}
-	}
-	r, err := o.bucket.InitiateMultipartUpload(key, ossAcl)
+func (o *ossClient) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	po := applyPutOptions(opts)
+	options := o.putOptions(acl, po, po.Checksum)
+	r, err := o.bucket.InitiateMultipartUpload(key, options...)
 	if o.checkError(err) != nil {
 		return nil, err
 	}
@@ -254,6 +403,38 @@ func (o *ossClient) GetObjectAcl(key string) (models.CannedACLType, error) {
 	}
 }
 
+// GetBucketACL OSS 只支持标准 ACL，展开为对应的授权
+func (o *ossClient) GetBucketACL() (*ACL, error) {
+	return o.bucketACL.get(func() (*ACL, error) {
+		result, err := o.client.GetBucketACL(o.bucket.BucketName)
+		if err != nil {
+			return nil, err
+		}
+		return CannedACL(result.Owner.ID, models.CannedACLType(result.ACL)), nil
+	})
+}
+
+// GetACL 对象 ACL 为 default 时继承桶的 ACL
+func (o *ossClient) GetACL(key string) (*ACL, error) {
+	result, err := o.bucket.GetObjectACL(key)
+	if err != nil {
+		return nil, err
+	}
+	if result.ACL == string(oss.ACLDefault) {
+		return o.GetBucketACL()
+	}
+	return CannedACL(result.Owner.ID, models.CannedACLType(result.ACL)), nil
+}
+
+// SetACL 只能写入可以用标准 ACL 表示的授权
+func (o *ossClient) SetACL(key string, acl *ACL) error {
+	canned, exact := acl.Canned()
+	if !exact {
+		return fmt.Errorf("oss supports canned acl only, can't grant %s", acl)
+	}
+	return o.bucket.SetObjectACL(key, oss.ACLType(canned))
+}
+
 type stsCred struct {
 	AccessKeyId     string
 	AccessKeySecret string
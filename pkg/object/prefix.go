@@ -5,6 +5,7 @@ import (
 	"io"
 	"obs-sync/models"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -58,6 +59,8 @@ func (w *withPrefix) Head(key string) (Object, error) {
 		po.key = po.key[len(w.prefix):]
 	case *file:
 		po.key = po.key[len(w.prefix):]
+	case *objInfo:
+		po.key = po.key[len(w.prefix):]
 	}
 	return o, nil
 }
@@ -66,8 +69,99 @@ func (w *withPrefix) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return w.os.Get(w.prefix+key, off, limit)
 }
 
-func (w *withPrefix) Put(key string, in io.Reader, acl models.CannedACLType) error {
-	return w.os.Put(w.prefix+key, in, acl)
+func (w *withPrefix) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	return w.os.Put(w.prefix+key, in, acl, opts...)
+}
+
+func (w *withPrefix) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	v, ok := w.os.(Versioner)
+	if !ok {
+		return nil, "", "", notSupported
+	}
+	if keyMarker != "" {
+		keyMarker = w.prefix + keyMarker
+	}
+	vs, nextKey, nextVersion, err := v.ListVersions(w.prefix+prefix, keyMarker, versionMarker, limit)
+	ln := len(w.prefix)
+	for _, o := range vs {
+		if p, ok := o.(*version); ok {
+			p.key = p.key[ln:]
+		}
+	}
+	if nextKey != "" {
+		nextKey = nextKey[ln:]
+	}
+	return vs, nextKey, nextVersion, err
+}
+
+func (w *withPrefix) EnableVersioning() error {
+	if e, ok := w.os.(VersioningEnabler); ok {
+		return e.EnableVersioning()
+	}
+	return notSupported
+}
+
+func (w *withPrefix) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	if v, ok := w.os.(Versioner); ok {
+		return v.GetVersion(w.prefix+key, versionID, off, limit)
+	}
+	return nil, notSupported
+}
+
+func (w *withPrefix) GetTags(key string) (map[string]string, error) {
+	if t, ok := w.os.(Tagger); ok {
+		return t.GetTags(w.prefix + key)
+	}
+	return nil, notSupported
+}
+
+func (w *withPrefix) SetTags(key string, tags map[string]string) error {
+	if t, ok := w.os.(Tagger); ok {
+		return t.SetTags(w.prefix+key, tags)
+	}
+	return notSupported
+}
+
+func (w *withPrefix) GetBucketACL() (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetBucketACL()
+	}
+	return nil, notSupported
+}
+
+func (w *withPrefix) GetACL(key string) (*ACL, error) {
+	if a, ok := w.os.(ACLer); ok {
+		return a.GetACL(w.prefix + key)
+	}
+	return nil, notSupported
+}
+
+func (w *withPrefix) SetACL(key string, acl *ACL) error {
+	if a, ok := w.os.(ACLer); ok {
+		return a.SetACL(w.prefix+key, acl)
+	}
+	return notSupported
+}
+
+func (w *withPrefix) Restore(key string, days int) error {
+	if r, ok := w.os.(Restorer); ok {
+		return r.Restore(w.prefix+key, days)
+	}
+	return notSupported
+}
+
+func (w *withPrefix) Restored(key string) (bool, error) {
+	if r, ok := w.os.(Restorer); ok {
+		return r.Restored(w.prefix + key)
+	}
+	return false, notSupported
+}
+
+func (w *withPrefix) GetChecksum(key string) (string, error) {
+	if c, ok := w.os.(Checksummer); ok {
+		return c.GetChecksum(w.prefix + key)
+	}
+	return "", notSupported
 }
 
 func (w *withPrefix) Delete(key string) error {
@@ -86,6 +180,10 @@ func (w *withPrefix) List(prefix, marker string, limit int64) ([]Object, error)
 			p.key = p.key[ln:]
 		case *file:
 			p.key = p.key[ln:]
+		case *objInfo:
+			p.key = p.key[ln:]
+		case *version:
+			p.key = p.key[ln:]
 		}
 	}
 	return objs, err
@@ -109,6 +207,10 @@ func (w *withPrefix) ListAll(prefix, marker string) (<-chan Object, error) {
 					p.key = p.key[ln:]
 				case *file:
 					p.key = p.key[ln:]
+				case *objInfo:
+					p.key = p.key[ln:]
+				case *version:
+					p.key = p.key[ln:]
 				}
 			}
 			r2 <- o
@@ -139,8 +241,8 @@ func (w *withPrefix) Chtimes(key string, mtime time.Time) error {
 	return notSupported
 }
 
-func (w *withPrefix) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
-	return w.os.CreateMultipartUpload(w.prefix+key, minSize, acl)
+func (w *withPrefix) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	return w.os.CreateMultipartUpload(w.prefix+key, minSize, acl, opts...)
 }
 
 func (w *withPrefix) UploadPart(key string, uploadID string, num int, body []byte) (*Part, error) {
@@ -156,11 +258,22 @@ func (w *withPrefix) CompleteUpload(key string, uploadID string, parts []*Part)
 }
 
 func (w *withPrefix) ListUploads(marker string) ([]*PendingPart, string, error) {
+	if marker != "" {
+		marker = w.prefix + marker
+	}
 	parts, nextMarker, err := w.os.ListUploads(marker)
+	// 忽略前缀之外的上传
+	var res []*PendingPart
 	for _, part := range parts {
-		part.Key = part.Key[len(w.prefix):]
+		if strings.HasPrefix(part.Key, w.prefix) {
+			part.Key = part.Key[len(w.prefix):]
+			res = append(res, part)
+		}
+	}
+	if strings.HasPrefix(nextMarker, w.prefix) {
+		nextMarker = nextMarker[len(w.prefix):]
 	}
-	return parts, nextMarker, err
+	return res, nextMarker, err
 }
 
 func (w *withPrefix) GetObjectAcl(key string) (models.CannedACLType, error) {
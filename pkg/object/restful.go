@@ -167,6 +167,7 @@ func (r *RestfulStorage) Head(key string) (Object, error) {
 		resp.ContentLength,
 		mtime,
 		strings.HasSuffix(key, "/"),
+		"",
 	}, nil
 }
 
@@ -189,7 +190,7 @@ func (r *RestfulStorage) Get(key string, off, limit int64) (io.ReadCloser, error
 	return resp.Body, nil
 }
 
-func (r *RestfulStorage) Put(key string, body io.Reader, acl models.CannedACLType) error {
+func (r *RestfulStorage) Put(key string, body io.Reader, acl models.CannedACLType, _ ...PutOption) error {
 	resp, err := r.request("PUT", key, body, nil)
 	if err != nil {
 		return err
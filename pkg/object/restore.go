@@ -0,0 +1,37 @@
+package object
+
+import "strings"
+
+// Restorer is implemented by storages keeping objects in archive storage
+// classes, which must be restored before they can be read.
+type Restorer interface {
+	// Restore starts to restore an archived object, the restored copy is kept for days.
+	Restore(key string, days int) error
+	// Restored tells whether an archived object can be read now.
+	Restored(key string) (bool, error)
+}
+
+// IsArchived tells whether err is returned by reading an archived object
+// which is not restored: all providers supporting Restorer answer with the
+// error code InvalidObjectState, Azure with BlobArchived.
+func IsArchived(err error) bool {
+	switch errorCode(err) {
+	case "InvalidObjectState", "BlobArchived":
+		return true
+	}
+	return false
+}
+
+// restoreStarted ignores the error of restoring an object twice.
+func restoreStarted(err error) error {
+	if errorCode(err) == "RestoreAlreadyInProgress" {
+		return nil
+	}
+	return err
+}
+
+// restoreDone parses the restore header returned by Head, like
+// ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT".
+func restoreDone(v string) bool {
+	return strings.Contains(strings.ReplaceAll(v, " ", ""), `ongoing-request="false"`)
+}
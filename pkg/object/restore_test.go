@@ -0,0 +1,54 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+func TestRestoreDone(t *testing.T) {
+	for v, want := range map[string]bool{
+		"":                       false,
+		`ongoing-request="true"`: false,
+		`ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"`: true,
+		`ongoing-request = "false"`: true,
+		`ongoing-request="false"`:   true,
+	} {
+		if restoreDone(v) != want {
+			t.Fatalf("restore done %q: %v", v, !want)
+		}
+	}
+}
+
+func TestIsArchived(t *testing.T) {
+	for _, err := range []error{
+		awserr.New("InvalidObjectState", "The operation is not valid for the object's storage class", nil),
+		&cos.ErrorResponse{Code: "InvalidObjectState"},
+		oss.ServiceError{Code: "InvalidObjectState"},
+		obs.ObsError{Code: "InvalidObjectState"},
+		&azblobError{Status: 409, Code: "BlobArchived"},
+		fmt.Errorf("multipart: %w", fmt.Errorf("part 1: %w", awserr.New("InvalidObjectState", "", nil))),
+	} {
+		if !IsArchived(err) {
+			t.Fatalf("not archived: %v", err)
+		}
+	}
+	for _, err := range []error{
+		nil,
+		errors.New("InvalidObjectState"),
+		awserr.New("AccessDenied", "InvalidObjectState", nil),
+		&ChaosError{Op: "get", Key: "k"},
+	} {
+		if IsArchived(err) {
+			t.Fatalf("archived: %v", err)
+		}
+	}
+	if restoreStarted(oss.ServiceError{Code: "RestoreAlreadyInProgress"}) != nil || restoreStarted(errors.New("x")) == nil {
+		t.Fatal("restore started")
+	}
+}
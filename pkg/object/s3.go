@@ -8,12 +8,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"obs-sync/models"
 	"os"
 	"strings"
-
-	mapset "github.com/deckarep/golang-set"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -43,14 +43,29 @@ type s3client struct {
 	ses          *session.Session
 	checkSumKey  string
 	sumAlgorithm algorithm
+	bucketACL    aclCache
+	sse          *SSE
 }
 
 func (s *s3client) SetCheckSumKey(meta string) error {
-	return notSupported
+	if meta == "" {
+		meta = s3ChecksumKeyPrefix + checksumCrc32.String() // 恢复默认
+	}
+	s.checkSumKey = meta
+	return nil
 }
 
 func (s *s3client) IsSetMd5(flag bool) error {
-	return notSupported
+	s.sumAlgorithm = checksumCrc32
+	if flag {
+		s.sumAlgorithm = checksumMd5
+	}
+	return nil
+}
+
+func (s *s3client) SetSSE(sse *SSE) error {
+	s.sse = sse
+	return nil
 }
 
 func (s *s3client) String() string {
@@ -78,20 +93,189 @@ func (s *s3client) Head(key string) (Object, error) {
 		Bucket: &s.bucket,
 		Key:    &key,
 	}
+	param.SSECustomerAlgorithm, param.SSECustomerKey = s3SSECustomer(s.sse)
 	r, err := s.s3.HeadObject(&param)
 	if err != nil {
 		return nil, err
 	}
-	return &obj{
-		key,
-		*r.ContentLength,
-		*r.LastModified,
-		strings.HasSuffix(key, "/"),
+	return &objInfo{
+		obj{
+			key,
+			*r.ContentLength,
+			*r.LastModified,
+			strings.HasSuffix(key, "/"),
+			toStorageClass(models.S3, aws.StringValue(r.StorageClass)),
+		},
+		s3Metadata(r),
 	}, nil
 }
 
+// s3HeaderValue looks up a header of a HeadObject response, where the user
+// metadata comes without the x-amz-meta- prefix.
+func s3HeaderValue(r *s3.HeadObjectOutput, header string) string {
+	name := strings.ToLower(header)
+	if name == "etag" {
+		return aws.StringValue(r.ETag)
+	}
+	name = strings.TrimPrefix(name, "x-amz-meta-")
+	for k, v := range r.Metadata {
+		if strings.ToLower(k) == name {
+			return aws.StringValue(v)
+		}
+	}
+	return ""
+}
+
+// s3Metadata returns the headers and user metadata of a HeadObject response.
+func s3Metadata(r *s3.HeadObjectOutput) *Metadata {
+	meta := &Metadata{
+		ContentType:        aws.StringValue(r.ContentType),
+		ContentEncoding:    aws.StringValue(r.ContentEncoding),
+		CacheControl:       aws.StringValue(r.CacheControl),
+		ContentDisposition: aws.StringValue(r.ContentDisposition),
+		Expires:            aws.StringValue(r.Expires),
+	}
+	if len(r.Metadata) > 0 {
+		meta.UserMeta = make(map[string]string, len(r.Metadata))
+		for k, v := range r.Metadata {
+			if !isChecksumMeta(k) {
+				meta.UserMeta[k] = aws.StringValue(v)
+			}
+		}
+	}
+	return meta
+}
+
+// s3UserMeta merges the user metadata with the checksum of the object.
+func s3UserMeta(meta *Metadata, checksumKey, checksum string) map[string]*string {
+	m := make(map[string]*string)
+	if meta != nil {
+		for k, v := range meta.UserMeta {
+			m[k] = aws.String(v)
+		}
+	}
+	if checksum != "" {
+		m[checksumKey] = aws.String(checksum)
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// s3Header returns nil for empty headers, so they are not sent.
+func s3Header(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// s3SSE 写入对象时的加密方式和 KMS 密钥
+func s3SSE(sse *SSE) (mode, kmsKeyID *string) {
+	if sse == nil {
+		return nil, nil
+	}
+	switch sse.Mode {
+	case SSEProvider:
+		return aws.String(s3.ServerSideEncryptionAes256), nil
+	case SSEKMS:
+		return aws.String(s3.ServerSideEncryptionAwsKms), s3Header(sse.KMSKeyID)
+	}
+	return nil, nil
+}
+
+// s3SSECustomer SSE-C 的算法和密钥，SDK 负责编码和计算 MD5，只能通过 HTTPS 发送
+func s3SSECustomer(sse *SSE) (algorithm, key *string) {
+	if !sse.customer() {
+		return nil, nil
+	}
+	return aws.String(s3.ServerSideEncryptionAes256), aws.String(string(sse.CustomerKey))
+}
+
+func s3Expires(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func s3Restore(svc *s3.S3, bucket, key string, days int) error {
+	_, err := svc.RestoreObject(&s3.RestoreObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+		RestoreRequest: &s3.RestoreRequest{
+			Days:                 aws.Int64(int64(days)),
+			GlacierJobParameters: &s3.GlacierJobParameters{Tier: aws.String(s3.TierStandard)},
+		},
+	})
+	return restoreStarted(err)
+}
+
+func s3Restored(svc *s3.S3, bucket, key string, sse *SSE) (bool, error) {
+	param := &s3.HeadObjectInput{Bucket: &bucket, Key: &key}
+	param.SSECustomerAlgorithm, param.SSECustomerKey = s3SSECustomer(sse)
+	r, err := svc.HeadObject(param)
+	if err != nil {
+		return false, err
+	}
+	return restoreDone(aws.StringValue(r.Restore)), nil
+}
+
+func s3GetTags(svc *s3.S3, bucket, key string) (map[string]string, error) {
+	r, err := svc.GetObjectTagging(&s3.GetObjectTaggingInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(r.TagSet))
+	for _, t := range r.TagSet {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+func s3SetTags(svc *s3.S3, bucket, key string, tags map[string]string) error {
+	tagging := &s3.Tagging{}
+	for k, v := range tags {
+		tagging.TagSet = append(tagging.TagSet, &s3.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	_, err := svc.PutObjectTagging(&s3.PutObjectTaggingInput{Bucket: &bucket, Key: &key, Tagging: tagging})
+	return tagErr(err)
+}
+
+func (s *s3client) GetTags(key string) (map[string]string, error) {
+	return s3GetTags(s.s3, s.bucket, key)
+}
+
+func (s *s3client) SetTags(key string, tags map[string]string) error {
+	return s3SetTags(s.s3, s.bucket, key, tags)
+}
+
+func (s *s3client) Restore(key string, days int) error {
+	return s3Restore(s.s3, s.bucket, key, days)
+}
+
+func (s *s3client) Restored(key string) (bool, error) {
+	return s3Restored(s.s3, s.bucket, key, s.sse)
+}
+
+func (s *s3client) GetChecksum(key string) (string, error) {
+	param := &s3.HeadObjectInput{Bucket: &s.bucket, Key: &key}
+	param.SSECustomerAlgorithm, param.SSECustomerKey = s3SSECustomer(s.sse)
+	r, err := s.s3.HeadObject(param)
+	if err != nil {
+		return "", err
+	}
+	return s3HeaderValue(r, s.checkSumKey), nil
+}
+
 func (s *s3client) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	params := &s3.GetObjectInput{Bucket: &s.bucket, Key: &key}
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(s.sse)
 	if off > 0 || limit > 0 {
 		var r string
 		if limit > 0 {
@@ -106,7 +290,7 @@ func (s *s3client) Get(key string, off, limit int64) (io.ReadCloser, error) {
 		return nil, err
 	}
 	if off == 0 && limit == -1 {
-		cs := resp.Metadata[checksumCrc32.String()]
+		cs := resp.Metadata[s3ChecksumKeyPrefix+checksumCrc32.String()]
 		if cs != nil {
 			resp.Body = verifyChecksum(resp.Body, *cs)
 		}
@@ -114,7 +298,80 @@ func (s *s3client) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func (s *s3client) Put(key string, in io.Reader, acl models.CannedACLType) error {
+func s3ListVersions(svc *s3.S3, t models.ResourceType, bucket, prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	param := &s3.ListObjectVersionsInput{
+		Bucket:  &bucket,
+		Prefix:  &prefix,
+		MaxKeys: &limit,
+	}
+	if keyMarker != "" {
+		param.KeyMarker = &keyMarker
+	}
+	if versionMarker != "" {
+		param.VersionIdMarker = &versionMarker
+	}
+	resp, err := svc.ListObjectVersions(param)
+	if err != nil {
+		return nil, "", "", err
+	}
+	vs := make([]Version, 0, len(resp.Versions)+len(resp.DeleteMarkers))
+	for _, o := range resp.Versions {
+		key := aws.StringValue(o.Key)
+		vs = append(vs, &version{obj{key, aws.Int64Value(o.Size), aws.TimeValue(o.LastModified), strings.HasSuffix(key, "/"),
+			toStorageClass(t, aws.StringValue(o.StorageClass))}, aws.StringValue(o.VersionId), false})
+	}
+	for _, o := range resp.DeleteMarkers {
+		key := aws.StringValue(o.Key)
+		vs = append(vs, &version{obj{key, 0, aws.TimeValue(o.LastModified), strings.HasSuffix(key, "/"), ""}, aws.StringValue(o.VersionId), true})
+	}
+	sortVersions(vs)
+	if !aws.BoolValue(resp.IsTruncated) {
+		return vs, "", "", nil
+	}
+	return vs, aws.StringValue(resp.NextKeyMarker), aws.StringValue(resp.NextVersionIdMarker), nil
+}
+
+func s3EnableVersioning(svc *s3.S3, bucket string) error {
+	_, err := svc.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket:                  &bucket,
+		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(s3.BucketVersioningStatusEnabled)},
+	})
+	return err
+}
+
+func s3GetVersion(svc *s3.S3, bucket, key, versionID string, off, limit int64, sse *SSE) (io.ReadCloser, error) {
+	params := &s3.GetObjectInput{Bucket: &bucket, Key: &key, VersionId: &versionID}
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(sse)
+	if off > 0 || limit > 0 {
+		var r string
+		if limit > 0 {
+			r = fmt.Sprintf("bytes=%d-%d", off, off+limit-1)
+		} else {
+			r = fmt.Sprintf("bytes=%d-", off)
+		}
+		params.Range = &r
+	}
+	resp, err := svc.GetObject(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3client) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]Version, string, string, error) {
+	return s3ListVersions(s.s3, models.S3, s.bucket, prefix, keyMarker, versionMarker, limit)
+}
+
+func (s *s3client) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	return s3GetVersion(s.s3, s.bucket, key, versionID, off, limit, s.sse)
+}
+
+func (s *s3client) EnableVersioning() error {
+	return s3EnableVersioning(s.s3, s.bucket)
+}
+
+func (s *s3client) Put(key string, in io.Reader, acl models.CannedACLType, opts ...PutOption) error {
+	o := applyPutOptions(opts)
 	var body io.ReadSeeker
 	if b, ok := in.(io.ReadSeeker); ok {
 		body = b
@@ -126,18 +383,34 @@ func (s *s3client) Put(key string, in io.Reader, acl models.CannedACLType) error
 		body = bytes.NewReader(data)
 	}
 	checkSumMetaKey := s3ChecksumKeyPrefix + s.sumAlgorithm.String()
-	checksum := generateChecksum(body, s.sumAlgorithm)
+	checksum := o.Checksum
+	if checksum == "" {
+		checksum = generateChecksum(body, s.sumAlgorithm)
+	}
 
 	params := &s3.PutObjectInput{
 		Bucket:   &s.bucket,
 		Key:      &key,
 		Body:     body,
-		Metadata: map[string]*string{checkSumMetaKey: &checksum},
+		Metadata: s3UserMeta(o.Meta, checkSumMetaKey, checksum),
+	}
+	if o.Meta != nil {
+		params.ContentType = s3Header(o.Meta.ContentType)
+		params.ContentEncoding = s3Header(o.Meta.ContentEncoding)
+		params.CacheControl = s3Header(o.Meta.CacheControl)
+		params.ContentDisposition = s3Header(o.Meta.ContentDisposition)
+		params.Expires = s3Expires(o.Meta.Expires)
 	}
+	if len(o.Tags) > 0 {
+		params.Tagging = aws.String(encodeTags(o.Tags))
+	}
+	params.StorageClass = s3Header(fromStorageClass(models.S3, o.StorageClass))
 	if acl == models.Default {
 		acl = s.getBucketAcl()
 	}
 	params.ACL = aws.String(string(acl))
+	params.ServerSideEncryption, params.SSEKMSKeyId = s3SSE(s.sse)
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(s.sse)
 	_, err := s.s3.PutObject(params)
 	return err
 }
@@ -182,6 +455,7 @@ func (s *s3client) List(prefix, marker string, limit int64) ([]Object, error) {
 			*o.Size,
 			*o.LastModified,
 			strings.HasSuffix(*o.Key, "/"),
+			toStorageClass(models.S3, aws.StringValue(o.StorageClass)),
 		}
 	}
 	return objs, nil
@@ -191,15 +465,27 @@ func (s *s3client) ListAll(prefix, marker string) (<-chan Object, error) {
 	return nil, notSupported
 }
 
-func (s *s3client) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType) (*MultipartUpload, error) {
+func (s *s3client) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...PutOption) (*MultipartUpload, error) {
+	o := applyPutOptions(opts)
 	params := &s3.CreateMultipartUploadInput{
 		Bucket: &s.bucket,
 		Key:    &key,
 	}
+	params.Metadata = s3UserMeta(o.Meta, s3ChecksumKeyPrefix+s.sumAlgorithm.String(), o.Checksum)
+	if o.Meta != nil {
+		params.ContentType = s3Header(o.Meta.ContentType)
+		params.ContentEncoding = s3Header(o.Meta.ContentEncoding)
+		params.CacheControl = s3Header(o.Meta.CacheControl)
+		params.ContentDisposition = s3Header(o.Meta.ContentDisposition)
+		params.Expires = s3Expires(o.Meta.Expires)
+	}
+	params.StorageClass = s3Header(fromStorageClass(models.S3, o.StorageClass))
 	if acl == models.Default {
 		acl = s.getBucketAcl()
 	}
 	params.ACL = aws.String(string(acl))
+	params.ServerSideEncryption, params.SSEKMSKeyId = s3SSE(s.sse)
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(s.sse)
 	resp, err := s.s3.CreateMultipartUpload(params)
 	if err != nil {
 		return nil, err
@@ -216,6 +502,7 @@ func (s *s3client) UploadPart(key string, uploadID string, num int, body []byte)
 		Body:       bytes.NewReader(body),
 		PartNumber: &n,
 	}
+	params.SSECustomerAlgorithm, params.SSECustomerKey = s3SSECustomer(s.sse)
 	resp, err := s.s3.UploadPart(params)
 	if err != nil {
 		return nil, err
@@ -271,79 +558,91 @@ func (s *s3client) ListUploads(marker string) ([]*PendingPart, string, error) {
 }
 
 func (s *s3client) getBucketAcl() models.CannedACLType {
-	inputBucket := &s3.GetBucketAclInput{
-		Bucket: &s.bucket,
-	}
-	result, err := s.s3.GetBucketAcl(inputBucket)
+	acl, err := s.GetBucketACL()
 	if err != nil {
 		return ""
 	}
-	allUsersPermissions := mapset.NewSet()
-	authenticatedRead := false
-	for _, value := range result.Grants {
-		if value.Grantee.URI != nil && strings.Contains(*value.Grantee.URI, "AllUsers") {
-			allUsersPermissions.Add(aws.StringValue(value.Permission))
-		}
-		if strings.Contains(aws.StringValue(value.Grantee.URI), "AuthenticatedUsers") && aws.StringValue(value.Permission) == "READ" {
-			authenticatedRead = true
-		}
-	}
-	read := allUsersPermissions.Contains("READ")
-	write := allUsersPermissions.Contains("WRITE")
-	if read && write { // all user read write
-		return models.PublicReadWrite
-	} else if read { // all read
-		return models.PublicRead
-	} else if authenticatedRead {
-		return models.AuthenticatedRead
-	} else { //owner FULL_CONTROL
-		return models.Private
-	}
+	canned, _ := acl.Canned()
+	return canned
 }
 
 func (s *s3client) GetObjectAcl(key string) (models.CannedACLType, error) {
-	// object acl
-	input := &s3.GetObjectAclInput{
-		Bucket: &s.bucket,
-		Key:    &key,
-	}
-	result, err := s.s3.GetObjectAcl(input)
-	if err != nil || len(result.Grants) == 0 {
-		return "", err
-	}
-	// bucket acl
-	inputBucket := &s3.GetBucketAclInput{
-		Bucket: &s.bucket,
-	}
-	resultBucket, err := s.s3.GetBucketAcl(inputBucket)
-	if err != nil || len(resultBucket.Grants) == 0 {
-		return "", err
+	return objectCanned(s, key)
+}
+
+func (s *s3client) GetBucketACL() (*ACL, error) {
+	return s.bucketACL.get(func() (*ACL, error) { return s3GetBucketACL(s.s3, s.bucket) })
+}
+
+func (s *s3client) GetACL(key string) (*ACL, error) {
+	return s3GetACL(s.s3, s.bucket, key)
+}
+
+func (s *s3client) SetACL(key string, acl *ACL) error {
+	return s3SetACL(s.s3, s.bucket, key, acl)
+}
+
+// s3ACL 转换为授权级别的 ACL，按邮箱授权时以邮箱作为账号，无法识别的用户组保留 URI
+func s3ACL(owner *s3.Owner, grants []*s3.Grant) *ACL {
+	acl := &ACL{}
+	if owner != nil {
+		acl.Owner = aws.StringValue(owner.ID)
 	}
-	if resultBucket.String() == result.String() {
-		return models.Default, nil
+	for _, g := range grants {
+		if g.Grantee == nil {
+			continue
+		}
+		grantee := aws.StringValue(g.Grantee.ID)
+		if group, ok := groupOf(aws.StringValue(g.Grantee.URI)); ok {
+			grantee = group
+		} else if g.Grantee.URI != nil {
+			grantee = *g.Grantee.URI
+		} else if grantee == "" {
+			grantee = aws.StringValue(g.Grantee.EmailAddress)
+		}
+		acl.Grants = append(acl.Grants, Grant{grantee, aws.StringValue(g.Permission)})
 	}
+	return acl
+}
 
-	allUsersPermissions := mapset.NewSet()
-	authenticatedRead := false
-	for _, value := range result.Grants {
-		if value.Grantee.URI != nil && strings.Contains(*value.Grantee.URI, "AllUsers") {
-			allUsersPermissions.Add(aws.StringValue(value.Permission))
-		}
-		if strings.Contains(aws.StringValue(value.Grantee.URI), "AuthenticatedUsers") && aws.StringValue(value.Permission) == "READ" {
-			authenticatedRead = true
+func s3Policy(acl *ACL) *s3.AccessControlPolicy {
+	policy := &s3.AccessControlPolicy{Owner: &s3.Owner{ID: aws.String(acl.Owner)}}
+	for _, g := range acl.Grants {
+		grantee := &s3.Grantee{}
+		switch g.Grantee {
+		case AllUsers, AuthenticatedUsers:
+			grantee.SetType(s3.TypeGroup).SetURI("http://acs.amazonaws.com/groups/global/" + g.Grantee)
+		default:
+			grantee.SetType(s3.TypeCanonicalUser).SetID(g.Grantee)
 		}
+		policy.Grants = append(policy.Grants, &s3.Grant{Grantee: grantee, Permission: aws.String(g.Permission)})
+	}
+	return policy
+}
+
+func s3GetBucketACL(svc *s3.S3, bucket string) (*ACL, error) {
+	result, err := svc.GetBucketAcl(&s3.GetBucketAclInput{Bucket: &bucket})
+	if err != nil {
+		return nil, err
 	}
-	read := allUsersPermissions.Contains("READ")
-	write := allUsersPermissions.Contains("WRITE")
-	if read && write { // all user read write
-		return models.PublicReadWrite, nil
-	} else if read { // all read
-		return models.PublicRead, nil
-	} else if authenticatedRead {
-		return models.AuthenticatedRead, nil
-	} else { //owner FULL_CONTROL
-		return models.Private, nil
+	return s3ACL(result.Owner, result.Grants), nil
+}
+
+func s3GetACL(svc *s3.S3, bucket, key string) (*ACL, error) {
+	result, err := svc.GetObjectAcl(&s3.GetObjectAclInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return nil, err
 	}
+	return s3ACL(result.Owner, result.Grants), nil
+}
+
+func s3SetACL(svc *s3.S3, bucket, key string, acl *ACL) error {
+	_, err := svc.PutObjectAcl(&s3.PutObjectAclInput{
+		Bucket:              &bucket,
+		Key:                 &key,
+		AccessControlPolicy: s3Policy(acl),
+	})
+	return err
 }
 
 func autoS3Region(bucketName, accessKey, secretKey string) (string, error) {
@@ -487,7 +786,7 @@ func newS3(endpoint, accessKey, secretKey string) (ObjectStorage, error) {
 		return nil, fmt.Errorf("Fail to create aws session: %s", err)
 	}
 	ses.Handlers.Build.PushFront(DisableSha256Func)
-	return &s3client{bucketName, s3.New(ses), ses, checksumCrc32.String(), checksumCrc32}, nil
+	return &s3client{bucketName, s3.New(ses), ses, s3ChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}, nil}, nil
 }
 
 func init() {
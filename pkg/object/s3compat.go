@@ -0,0 +1,149 @@
+//go:build !nos3
+// +build !nos3
+
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"obs-sync/models"
+	"obs-sync/pkg/endpoint"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3CompatService 按地址参数创建 S3 兼容存储的客户端，桶操作与对象操作共用
+func S3CompatService(c *endpoint.Compat, accessKey, secretKey string) (*s3.S3, *session.Session, error) {
+	awsConfig := &aws.Config{
+		Region:           aws.String(c.Region),
+		Endpoint:         aws.String(c.ServiceURL()),
+		DisableSSL:       aws.Bool(c.Scheme == "http"),
+		S3ForcePathStyle: aws.Bool(c.Style == endpoint.PathStyle),
+		HTTPClient:       httpClient,
+	}
+	if accessKey != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(accessKey, secretKey, "")
+	}
+	ses, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to create aws session: %s", err)
+	}
+	ses.Handlers.Build.PushFront(DisableSha256Func)
+	svc := s3.New(ses)
+	if c.Signature == endpoint.SignatureV2 {
+		svc.Handlers.Sign.Swap(v4.SignRequestHandler.Name, request.NamedHandler{
+			Name: v4.SignRequestHandler.Name,
+			Fn:   func(r *request.Request) { signV2(r, c.Host) },
+		})
+	}
+	return svc, ses, nil
+}
+
+func newS3Compat(endpointURL, accessKey, secretKey string) (ObjectStorage, error) {
+	c, bucket, err := endpoint.ParseCompatBucket(endpointURL)
+	if err != nil {
+		return nil, err
+	}
+	svc, ses, err := S3CompatService(c, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &s3client{bucket, svc, ses, s3ChecksumKeyPrefix + checksumCrc32.String(), checksumCrc32, aclCache{}, nil}, nil
+}
+
+// s3V2SubResources 参与 V2 签名的子资源
+var s3V2SubResources = map[string]bool{
+	"acl": true, "cors": true, "delete": true, "lifecycle": true, "location": true, "logging": true,
+	"notification": true, "partNumber": true, "policy": true, "requestPayment": true, "tagging": true,
+	"torrent": true, "uploadId": true, "uploads": true, "versionId": true, "versioning": true,
+	"versions": true, "website": true, "encryption": true,
+	"response-cache-control": true, "response-content-disposition": true, "response-content-encoding": true,
+	"response-content-language": true, "response-content-type": true, "response-expires": true,
+}
+
+// signV2 以 AWS 签名 V2 替换 V4 签名，host 为服务地址，用于从虚拟主机方式的域名中取出桶名
+func signV2(r *request.Request, host string) {
+	if r.Config.Credentials == nil || r.Config.Credentials == credentials.AnonymousCredentials {
+		return
+	}
+	v, err := r.Config.Credentials.GetWithContext(r.Context())
+	if err != nil {
+		r.Error = err
+		return
+	}
+	req := r.HTTPRequest
+	req.Header.Del("X-Amz-Date")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	if v.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", v.SessionToken)
+	}
+	mac := hmac.New(sha1.New, []byte(v.SecretAccessKey))
+	mac.Write([]byte(v2StringToSign(req, host)))
+	req.Header.Set("Authorization", "AWS "+v.AccessKeyID+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func v2StringToSign(req *http.Request, host string) string {
+	var b strings.Builder
+	b.WriteString(req.Method + "\n")
+	b.WriteString(req.Header.Get("Content-MD5") + "\n")
+	b.WriteString(req.Header.Get("Content-Type") + "\n")
+	b.WriteString(req.Header.Get("Date") + "\n")
+
+	var amz []string
+	for k := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-amz-") {
+			amz = append(amz, k)
+		}
+	}
+	sort.Strings(amz)
+	for _, k := range amz {
+		// 不修改请求头中的值
+		values := append([]string{}, req.Header.Values(k)...)
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		b.WriteString(k + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	// 虚拟主机方式下资源路径需补上桶名
+	if bucket, ok := strings.CutSuffix(req.URL.Host, "."+host); ok {
+		b.WriteString("/" + bucket)
+	}
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path)
+
+	var subs []string
+	for k, vs := range req.URL.Query() {
+		if !s3V2SubResources[k] {
+			continue
+		}
+		if len(vs) == 0 || vs[0] == "" {
+			subs = append(subs, k)
+		} else {
+			subs = append(subs, k+"="+vs[0])
+		}
+	}
+	if len(subs) > 0 {
+		sort.Strings(subs)
+		b.WriteString("?" + strings.Join(subs, "&"))
+	}
+	return b.String()
+}
+
+func init() {
+	Register(models.S3Compat, newS3Compat)
+}
@@ -0,0 +1,58 @@
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+// TestV2StringToSign 使用 AWS 文档 Signing and authenticating REST requests 中签名 V2 的示例
+func TestV2StringToSign(t *testing.T) {
+	const secret = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	for _, c := range []struct {
+		method, url, date, contentType string
+		want, signature                string
+	}{
+		{http.MethodGet, "http://johnsmith.s3.amazonaws.com/photos/puppy.jpg", "Tue, 27 Mar 2007 19:36:42 +0000", "",
+			"GET\n\n\nTue, 27 Mar 2007 19:36:42 +0000\n/johnsmith/photos/puppy.jpg", "bWq2s1WEIj+Ydj0vQ697zp+IXMU="},
+		{http.MethodPut, "http://johnsmith.s3.amazonaws.com/photos/puppy.jpg", "Tue, 27 Mar 2007 21:15:45 +0000", "image/jpeg",
+			"PUT\n\nimage/jpeg\nTue, 27 Mar 2007 21:15:45 +0000\n/johnsmith/photos/puppy.jpg", "MyyxeRY7whkBe+bq8fHCL/2kKUg="},
+		// 列举参数不是子资源
+		{http.MethodGet, "http://johnsmith.s3.amazonaws.com/?prefix=photos&max-keys=50&marker=puppy", "Tue, 27 Mar 2007 19:42:41 +0000", "",
+			"GET\n\n\nTue, 27 Mar 2007 19:42:41 +0000\n/johnsmith/", "htDYFYduRNen8P9ZfE/s9SuKy0U="},
+		{http.MethodGet, "http://johnsmith.s3.amazonaws.com/?acl", "Tue, 27 Mar 2007 19:44:46 +0000", "",
+			"GET\n\n\nTue, 27 Mar 2007 19:44:46 +0000\n/johnsmith/?acl", "c2WLPFtWHVgbEmeEG93a4cG37dM="},
+	} {
+		req, _ := http.NewRequest(c.method, c.url, nil)
+		req.Header.Set("Date", c.date)
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		got := v2StringToSign(req, "s3.amazonaws.com")
+		if got != c.want {
+			t.Fatalf("%s %s: string to sign %q, expected %q", c.method, c.url, got, c.want)
+		}
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write([]byte(got))
+		if sig := base64.StdEncoding.EncodeToString(mac.Sum(nil)); sig != c.signature {
+			t.Fatalf("%s %s: signature %s, expected %s", c.method, c.url, sig, c.signature)
+		}
+	}
+
+	// 路径方式、x-amz- 请求头和多个子资源
+	req, _ := http.NewRequest(http.MethodPut, "http://minio.local:9000/bkt/a%20b?uploadId=u1&partNumber=2&foo=1", nil)
+	req.Header.Set("Content-MD5", "md5")
+	req.Header.Set("Date", "d")
+	req.Header.Add("X-Amz-Meta-Tag", " a ")
+	req.Header.Add("X-Amz-Meta-Tag", "b")
+	req.Header.Set("X-Amz-Acl", "private")
+	want := "PUT\nmd5\n\nd\nx-amz-acl:private\nx-amz-meta-tag:a,b\n/bkt/a%20b?partNumber=2&uploadId=u1"
+	if got := v2StringToSign(req, "minio.local:9000"); got != want {
+		t.Fatalf("path style: %q, expected %q", got, want)
+	}
+	if v := req.Header.Values("X-Amz-Meta-Tag"); v[0] != " a " {
+		t.Fatalf("request header modified: %q", v)
+	}
+}
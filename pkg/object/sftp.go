@@ -0,0 +1,443 @@
+//go:build !nosftp
+// +build !nosftp
+
+package object
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"obs-sync/models"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpStore SFTP 服务器上的目录，key 为相对 root 的路径。ak 为用户名，
+// sk 为密码或私钥文件的路径
+type sftpStore struct {
+	DefaultObjectStorage
+	host   string // host:port
+	root   string // 以 / 结尾，为空时相对登录目录
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *sftp.Client
+}
+
+// sftpAddr 解析 host[:port][/path]，可以带 sftp:// 前缀，默认端口 22。
+// 没有 path 或 path 以 /~/ 开头时相对登录目录
+func sftpAddr(addr string) (host, root string) {
+	addr = strings.TrimPrefix(addr, "sftp://")
+	host, root = addr, ""
+	if i := strings.Index(addr, "/"); i >= 0 {
+		host, root = addr[:i], addr[i:]
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	if root == "/~" || strings.HasPrefix(root, "/~/") {
+		root = strings.TrimPrefix(root[2:], dirSuffix)
+	}
+	if root != "" && !strings.HasSuffix(root, dirSuffix) {
+		root += dirSuffix
+	}
+	return host, root
+}
+
+// SftpBucketURL 目录的访问地址，addr 为 sftp:// 之后的 host[:port][/path]
+func SftpBucketURL(addr, name string) string {
+	host, root := sftpAddr(addr)
+	if !strings.HasPrefix(root, dirSuffix) {
+		root = "/~/" + root
+	}
+	return host + root + name + dirSuffix
+}
+
+// sftpHostKey 使用 ~/.ssh/known_hosts 校验主机密钥，未记录的主机只告警
+func sftpHostKey() ssh.HostKeyCallback {
+	home, _ := os.UserHomeDir()
+	check, err := knownhosts.New(path.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		check = nil
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if check == nil {
+			return nil
+		}
+		err := check(hostname, remote, key)
+		var ke *knownhosts.KeyError
+		if errors.As(err, &ke) && len(ke.Want) == 0 {
+			logger.Warn().Msgf("host key of %s is not in known_hosts", hostname)
+			return nil
+		}
+		return err
+	}
+}
+
+func sftpConfig(user, secret string) *ssh.ClientConfig {
+	var auth []ssh.AuthMethod
+	if data, err := os.ReadFile(secret); err == nil {
+		if signer, err := ssh.ParsePrivateKey(data); err == nil {
+			auth = append(auth, ssh.PublicKeys(signer))
+		}
+	}
+	if len(auth) == 0 {
+		auth = append(auth, ssh.Password(secret), ssh.KeyboardInteractive(
+			func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = secret
+				}
+				return answers, nil
+			}))
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: sftpHostKey(),
+		Timeout:         30 * time.Second,
+	}
+}
+
+// getClient 复用同一个连接，连接断开后重新建立
+func (f *sftpStore) getClient() (*sftp.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.client != nil {
+		return f.client, nil
+	}
+	conn, err := ssh.Dial("tcp", f.host, f.config)
+	if err != nil {
+		return nil, fmt.Errorf("sftp: connect %s: %s", f.host, err)
+	}
+	c, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sftp: start session on %s: %s", f.host, err)
+	}
+	go func() {
+		_ = conn.Wait()
+		f.mu.Lock()
+		if f.client == c {
+			f.client = nil
+		}
+		f.mu.Unlock()
+		c.Close()
+	}()
+	f.client = c
+	return c, nil
+}
+
+func (f *sftpStore) SetCheckSumKey(meta string) error {
+	return notSupported
+}
+
+func (f *sftpStore) IsSetMd5(flag bool) error {
+	return notSupported
+}
+
+func (f *sftpStore) String() string {
+	return fmt.Sprintf("sftp://%s@%s%s", f.config.User, f.host, f.root)
+}
+
+func (f *sftpStore) path(key string) string {
+	if f.root == "" && key == "" {
+		return "."
+	}
+	return f.root + key
+}
+
+func (f *sftpStore) Create() error {
+	if f.root == "" {
+		return nil
+	}
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	return c.MkdirAll(f.root)
+}
+
+func (f *sftpStore) fileInfo(key string, fi os.FileInfo) Object {
+	owner, group := getOwnerGroup(fi)
+	o := &file{obj{key, fi.Size(), fi.ModTime(), fi.IsDir(), ""}, owner, group, fi.Mode()}
+	if fi.IsDir() {
+		o.size = 0
+		if key != "" && !strings.HasSuffix(key, dirSuffix) {
+			o.key += dirSuffix
+		}
+	}
+	return o
+}
+
+func (f *sftpStore) Head(key string) (Object, error) {
+	c, err := f.getClient()
+	if err != nil {
+		return nil, err
+	}
+	fi, err := c.Stat(f.path(key))
+	if err != nil {
+		return nil, err
+	}
+	return f.fileInfo(key, fi), nil
+}
+
+type sftpReader struct {
+	io.Reader
+	io.Closer
+}
+
+func (f *sftpStore) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	c, err := f.getClient()
+	if err != nil {
+		return nil, err
+	}
+	ff, err := c.Open(f.path(key))
+	if err != nil {
+		return nil, err
+	}
+	fi, err := ff.Stat()
+	if err != nil {
+		ff.Close()
+		return nil, err
+	}
+	if fi.IsDir() {
+		ff.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if off > 0 {
+		if _, err = ff.Seek(off, io.SeekStart); err != nil {
+			ff.Close()
+			return nil, err
+		}
+	}
+	if limit > 0 {
+		return &sftpReader{io.LimitReader(ff, limit), ff}, nil
+	}
+	return ff, nil
+}
+
+// Put 先写入同目录下的临时文件再改名，读取方不会看到写了一半的文件
+func (f *sftpStore) Put(key string, in io.Reader, _ models.CannedACLType, _ ...PutOption) error {
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	p := f.path(key)
+	if strings.HasSuffix(key, dirSuffix) || key == "" {
+		return c.MkdirAll(p)
+	}
+	tmp := path.Join(path.Dir(p), "."+path.Base(p)+".tmp"+strconv.Itoa(rand.Int()))
+	ff, err := c.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil && os.IsNotExist(err) {
+		if err = c.MkdirAll(path.Dir(p)); err != nil {
+			return err
+		}
+		ff, err = c.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = c.Remove(tmp)
+		}
+	}()
+
+	buf := bufPool.Get().(*[]byte)
+	defer bufPool.Put(buf)
+	if _, err = io.CopyBuffer(ff, in, *buf); err != nil {
+		_ = ff.Close()
+		return err
+	}
+	if err = ff.Close(); err != nil {
+		return err
+	}
+	// 不支持 posix-rename 扩展的服务器不能覆盖已有文件
+	if err = c.PosixRename(tmp, p); err != nil {
+		_ = c.Remove(p)
+		err = c.Rename(tmp, p)
+	}
+	return err
+}
+
+func (f *sftpStore) Delete(key string) error {
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	err = c.Remove(strings.TrimSuffix(f.path(key), dirSuffix))
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
+
+// readDirSorted 按名字排序，目录名带 / 后缀，与本地文件的列举顺序一致
+func (f *sftpStore) readDirSorted(c *sftp.Client, dir string) ([]os.FileInfo, error) {
+	fis, err := c.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := fis[:0]
+	for _, fi := range fis {
+		if fi.Mode()&os.ModeSymlink != 0 {
+			// 跟随软链接
+			st, err := c.Stat(path.Join(dir, fi.Name()))
+			if err != nil {
+				logger.Warn().Msgf("skip broken symlink %s", path.Join(dir, fi.Name()))
+				continue
+			}
+			fi = &mInfo{fi.Name(), st}
+		}
+		if fi.IsDir() {
+			fi = &mInfo{fi.Name() + dirSuffix, fi}
+		}
+		res = append(res, fi)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name() < res[j].Name() })
+	return res, nil
+}
+
+// ListAll 按 key 的字典序递归列举，跳过不含 prefix 和 marker 之后 key 的目录
+func (f *sftpStore) ListAll(prefix, marker string) (<-chan Object, error) {
+	c, err := f.getClient()
+	if err != nil {
+		return nil, err
+	}
+	listed := make(chan Object, 10240)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		fis, err := f.readDirSorted(c, f.path(dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				logger.Warn().Msgf("skip not exist directory: %s", f.path(dir))
+				return nil
+			}
+			return err
+		}
+		for _, fi := range fis {
+			key := dir + fi.Name()
+			if !fi.IsDir() {
+				if strings.HasPrefix(key, prefix) && key > marker {
+					listed <- f.fileInfo(key, fi)
+				}
+				continue
+			}
+			if !strings.HasPrefix(key, prefix) && !strings.HasPrefix(prefix, key) {
+				continue
+			}
+			if key < marker && !strings.HasPrefix(marker, key) {
+				continue
+			}
+			if strings.HasPrefix(key, prefix) && key > marker {
+				listed <- f.fileInfo(key, fi)
+			}
+			if err = walk(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	go func() {
+		if err := walk(""); err != nil {
+			logger.Error().Msgf("list %s: %s", f, err)
+			listed <- nil
+		}
+		close(listed)
+	}()
+	return listed, nil
+}
+
+func (f *sftpStore) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return "", nil
+}
+
+func (f *sftpStore) Chtimes(key string, mtime time.Time) error {
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Chtimes(f.path(key), mtime, mtime)
+}
+
+func (f *sftpStore) Chmod(key string, mode os.FileMode) error {
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Chmod(f.path(key), mode)
+}
+
+// Chown 按本机的用户名和组名查找 uid、gid
+func (f *sftpStore) Chown(key string, owner, group string) error {
+	c, err := f.getClient()
+	if err != nil {
+		return err
+	}
+	return c.Chown(f.path(key), lookupUser(owner), lookupGroup(group))
+}
+
+// ListSftpDirs 列举 addr 下的目录，作为 sftp 的桶
+func ListSftpDirs(addr, user, secret string) ([]string, error) {
+	s, err := newSftp(addr, user, secret)
+	if err != nil {
+		return nil, err
+	}
+	f := s.(*sftpStore)
+	c, err := f.getClient()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	fis, err := f.readDirSorted(c, f.path(""))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fi := range fis {
+		if fi.IsDir() && !strings.HasPrefix(fi.Name(), ".") {
+			names = append(names, strings.TrimSuffix(fi.Name(), dirSuffix))
+		}
+	}
+	return names, nil
+}
+
+// CreateSftpDir 在 addr 下创建目录
+func CreateSftpDir(addr, user, secret, name string) error {
+	s, err := newSftp(SftpBucketURL(addr, name), user, secret)
+	if err != nil {
+		return err
+	}
+	c, err := s.(*sftpStore).getClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return s.Create()
+}
+
+// newSftp 地址为 [sftp://]host[:port][/path/]，相对登录目录时为 host[:port]/~/path/，
+// ak 为用户名，sk 为密码或私钥文件
+func newSftp(endpoint, user, secret string) (ObjectStorage, error) {
+	host, root := sftpAddr(endpoint)
+	if strings.HasPrefix(host, ":") {
+		return nil, fmt.Errorf("invalid sftp endpoint %s: no host", endpoint)
+	}
+	return &sftpStore{host: host, root: root, config: sftpConfig(user, secret)}, nil
+}
+
+func init() {
+	Register(models.Sftp, newSftp)
+}
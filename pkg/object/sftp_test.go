@@ -0,0 +1,180 @@
+package object
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"net"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSftpServer 本地的 SSH 服务，只提供 sftp 子系统，工作目录为 dir
+func startSftpServer(t *testing.T, dir string) string {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "user" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					ch, reqs, err := nc.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range reqs {
+							req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+						}
+					}()
+					server, err := sftp.NewServer(ch, sftp.WithServerWorkingDirectory(dir))
+					if err != nil {
+						return
+					}
+					go func() {
+						_ = server.Serve()
+						server.Close()
+					}()
+				}
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestSftp(t *testing.T) {
+	dir := t.TempDir()
+	addr := startSftpServer(t, dir)
+	if err := os.MkdirAll(filepath.Join(dir, "bkt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateSftpDir(addr, "user", "pass", "other"); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ListSftpDirs(addr, "user", "pass")
+	if err != nil || strings.Join(names, ",") != "bkt,other" {
+		t.Fatalf("list dirs: %v %v", names, err)
+	}
+	if _, err = ListSftpDirs(addr, "user", "wrong"); err == nil {
+		t.Fatal("login with wrong password")
+	}
+
+	s, err := CreateStorage(models.Sftp, SftpBucketURL(addr, "bkt"), "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a/1", "a/2 +中", "a/b/3", "c", "d/"} {
+		if err = s.Put(k, strings.NewReader("hello "+k), models.Default); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	if err = s.Put("c", strings.NewReader("replaced"), models.Default); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "bkt", "c")); string(data) != "replaced" {
+		t.Fatalf("overwrite: %q", data)
+	}
+	tmps, _ := filepath.Glob(filepath.Join(dir, "bkt", "a", ".*.tmp*"))
+	if len(tmps) != 0 {
+		t.Fatalf("temp files left: %v", tmps)
+	}
+
+	listAll := func(prefix, marker string) string {
+		ch, err := s.ListAll(prefix, marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for o := range ch {
+			if o == nil {
+				t.Fatal("list failed")
+			}
+			keys = append(keys, o.Key())
+		}
+		return strings.Join(keys, ",")
+	}
+	if got := listAll("", ""); got != "a/,a/1,a/2 +中,a/b/,a/b/3,c,d/" {
+		t.Fatalf("list all: %s", got)
+	}
+	if got := listAll("a/", "a/2 +中"); got != "a/b/,a/b/3" {
+		t.Fatalf("list from marker: %s", got)
+	}
+
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	in, _ = s.Get("a/1", 2, -1)
+	data, _ = io.ReadAll(in)
+	in.Close()
+	if string(data) != "llo a/1" {
+		t.Fatalf("get from offset: %q", data)
+	}
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fs := s.(FileSystem)
+	if err = fs.Chtimes("a/1", mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.Chmod("a/1", 0600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Head("a/1")
+	if err != nil || !o.Mtime().Equal(mtime) || o.(File).Mode().Perm() != 0600 || o.Size() != int64(len("hello a/1")) {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if o, err = s.Head("d"); err != nil || !o.IsDir() || o.Key() != "d/" {
+		t.Fatalf("head dir: %v %v", o, err)
+	}
+
+	if err = s.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("c"); err != nil {
+		t.Fatalf("delete missing: %s", err)
+	}
+	if err = s.Delete("d/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "bkt", "d")); !os.IsNotExist(err) {
+		t.Fatalf("dir not deleted: %v", err)
+	}
+}
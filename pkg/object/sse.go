@@ -0,0 +1,91 @@
+package object
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+)
+
+// 服务端加密的方式，见 SSE.Mode
+const (
+	// SSEProvider 使用服务商管理的密钥，如 S3 的 SSE-S3、OBS 的 SSE-OBS
+	SSEProvider = "provider"
+	// SSEKMS 使用 KMS 管理的密钥，KMSKeyID 为空时使用账号的默认密钥
+	SSEKMS = "kms"
+)
+
+// SSE holds the server-side encryption of the objects of a storage.
+type SSE struct {
+	// Mode is SSEProvider or SSEKMS to encrypt the objects written, empty to
+	// use the default of the bucket.
+	Mode string
+	// KMSKeyID is the KMS key used by SSEKMS.
+	KMSKeyID string
+	// CustomerKey is the 32-byte SSE-C key, sent when writing and reading
+	// objects, exclusive with Mode.
+	CustomerKey []byte
+}
+
+// ServerSideEncrypter is implemented by storages supporting server-side encryption.
+type ServerSideEncrypter interface {
+	// SetSSE sets the encryption of the objects written and the customer key
+	// to read the objects, nil to clear it.
+	SetSSE(sse *SSE) error
+}
+
+// ParseSSE 由任务参数生成 SSE，customerKeyFile 为 SSE-C 的密钥文件，格式见 readKeyFile，
+// 参数都为空时返回 nil
+func ParseSSE(mode, kmsKeyID, customerKeyFile string) (*SSE, error) {
+	if mode == "" && kmsKeyID == "" && customerKeyFile == "" {
+		return nil, nil
+	}
+	sse := &SSE{Mode: mode, KMSKeyID: kmsKeyID}
+	switch mode {
+	case "", SSEProvider, SSEKMS:
+	default:
+		return nil, fmt.Errorf("unknown server-side encryption %q, expected %s or %s", mode, SSEProvider, SSEKMS)
+	}
+	if kmsKeyID != "" && mode != SSEKMS {
+		return nil, fmt.Errorf("kms key %s is only used by server-side encryption %s", kmsKeyID, SSEKMS)
+	}
+	if customerKeyFile != "" {
+		if mode != "" {
+			return nil, fmt.Errorf("server-side encryption %s can not be used with a customer-provided key", mode)
+		}
+		key, err := readKeyFile(customerKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		sse.CustomerKey = key
+	}
+	return sse, nil
+}
+
+// SetSSE 设置存储的服务端加密，sse 为 nil 时清除之前的设置，不支持服务端加密的存储忽略 nil
+func SetSSE(s ObjectStorage, sse *SSE) error {
+	if e, ok := s.(ServerSideEncrypter); ok {
+		if err := e.SetSSE(sse); !IsNotSupported(err) {
+			return err
+		}
+	}
+	if sse == nil {
+		return nil
+	}
+	return fmt.Errorf("%s does not support server-side encryption", s)
+}
+
+// customer 是否使用 SSE-C
+func (s *SSE) customer() bool {
+	return s != nil && len(s.CustomerKey) > 0
+}
+
+// customerKey SSE-C 请求头中 base64 编码的密钥
+func (s *SSE) customerKey() string {
+	return base64.StdEncoding.EncodeToString(s.CustomerKey)
+}
+
+// customerKeyMD5 SSE-C 请求头中 base64 编码的密钥 MD5
+func (s *SSE) customerKeyMD5() string {
+	sum := md5.Sum(s.CustomerKey)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,134 @@
+package object
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseSSE(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(keyFile, bytes.Repeat([]byte{7}, 32), 0600)
+	if sse, err := ParseSSE("", "", ""); sse != nil || err != nil {
+		t.Fatalf("parse empty: %v %v", sse, err)
+	}
+	if sse, err := ParseSSE(SSEKMS, "key-1", ""); err != nil || sse.Mode != SSEKMS || sse.KMSKeyID != "key-1" || sse.customer() {
+		t.Fatalf("parse kms: %+v %v", sse, err)
+	}
+	if sse, err := ParseSSE("", "", keyFile); err != nil || !sse.customer() || sse.customerKeyMD5() == "" {
+		t.Fatalf("parse customer key: %+v %v", sse, err)
+	}
+	for _, c := range [][3]string{{"aes", "", ""}, {SSEProvider, "key-1", ""}, {SSEProvider, "", keyFile}, {"", "", keyFile + ".missing"}} {
+		if _, err := ParseSSE(c[0], c[1], c[2]); err == nil {
+			t.Fatalf("parse %v", c)
+		}
+	}
+
+	s, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+	if err := SetSSE(s, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetSSE(s, &SSE{Mode: SSEProvider}); err == nil {
+		t.Fatal("set sse of a storage without server-side encryption")
+	}
+}
+
+// fakeS3SSE 记录每个请求的加密请求头
+type fakeS3SSE struct {
+	sync.Mutex
+	headers map[string]http.Header
+}
+
+func (f *fakeS3SSE) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	op := r.Method
+	q := r.URL.Query()
+	if _, ok := q["uploads"]; ok {
+		op = "CreateMultipartUpload"
+	} else if q.Get("partNumber") != "" {
+		op = "UploadPart"
+	}
+	h := http.Header{}
+	for k, vs := range r.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-server-side-encryption") {
+			h[k] = vs
+		}
+	}
+	f.Lock()
+	f.headers[op] = h
+	f.Unlock()
+	switch op {
+	case "CreateMultipartUpload":
+		w.Write([]byte(`<InitiateMultipartUploadResult><Bucket>bkt</Bucket><Key>k</Key><UploadId>u1</UploadId></InitiateMultipartUploadResult>`))
+	case http.MethodGet:
+		w.Write([]byte("hello"))
+	default:
+		w.Header().Set("ETag", `"etag"`)
+	}
+}
+
+func TestS3SSE(t *testing.T) {
+	f := &fakeS3SSE{headers: map[string]http.Header{}}
+	srv := httptest.NewTLSServer(f)
+	defer srv.Close()
+	// SDK 只通过 HTTPS 发送 SSE-C 密钥，使用测试服务的证书
+	t.Setenv("AWS_CA_BUNDLE", "")
+	saved := httpClient
+	httpClient = srv.Client()
+	defer func() { httpClient = saved }()
+
+	s, err := CreateStorage(models.S3, srv.URL+"/bkt", "ak", "sk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = SetSSE(s, &SSE{Mode: SSEKMS, KMSKeyID: "key-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Put("k", strings.NewReader("hello"), models.Default); err != nil {
+		t.Fatal(err)
+	}
+	if h := f.headers[http.MethodPut]; h.Get("X-Amz-Server-Side-Encryption") != "aws:kms" || h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id") != "key-1" {
+		t.Fatalf("put headers: %v", h)
+	}
+	if _, err = s.CreateMultipartUpload("k", 5<<20, models.Default); err != nil {
+		t.Fatal(err)
+	}
+	if h := f.headers["CreateMultipartUpload"]; h.Get("X-Amz-Server-Side-Encryption") != "aws:kms" {
+		t.Fatalf("create upload headers: %v", h)
+	}
+
+	sse := &SSE{CustomerKey: bytes.Repeat([]byte{7}, 32)}
+	if err = SetSSE(s, sse); err != nil {
+		t.Fatal(err)
+	}
+	in, err := s.Get("k", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Close()
+	if _, err = s.UploadPart("k", "u1", 1, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{http.MethodGet, "UploadPart"} {
+		h := f.headers[op]
+		if h.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") != "AES256" ||
+			h.Get("X-Amz-Server-Side-Encryption-Customer-Key") != sse.customerKey() ||
+			h.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5") != sse.customerKeyMD5() {
+			t.Fatalf("%s headers: %v", op, h)
+		}
+	}
+
+	// nil 清除设置
+	if err = SetSSE(s, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.Put("k", strings.NewReader("hello"), models.Default)
+	if h := f.headers[http.MethodPut]; len(h) != 0 {
+		t.Fatalf("put headers after clearing: %v", h)
+	}
+}
@@ -0,0 +1,176 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"obs-sync/models"
+)
+
+// storageClasses 各厂商的存储类型取值，同一类型有多个取值时第一个用于写入
+var storageClasses = map[models.ResourceType]map[models.StorageClass][]string{
+	models.S3: {
+		models.Standard:           {"STANDARD", "REDUCED_REDUNDANCY"},
+		models.InfrequentAccess:   {"STANDARD_IA", "ONEZONE_IA", "GLACIER_IR"},
+		models.Archive:            {"GLACIER"},
+		models.DeepArchive:        {"DEEP_ARCHIVE"},
+		models.IntelligentTiering: {"INTELLIGENT_TIERING"},
+	},
+	models.S3Compat: {
+		models.Standard:         {"STANDARD", "REDUCED_REDUNDANCY"},
+		models.InfrequentAccess: {"STANDARD_IA"},
+		models.Archive:          {"GLACIER"},
+	},
+	models.Cuc: {
+		models.Standard:         {"STANDARD"},
+		models.InfrequentAccess: {"STANDARD_IA"},
+		models.Archive:          {"GLACIER"},
+	},
+	models.Oss: {
+		models.Standard:         {"Standard"},
+		models.InfrequentAccess: {"IA"},
+		models.Archive:          {"Archive"},
+		models.DeepArchive:      {"ColdArchive", "DeepColdArchive"},
+	},
+	models.Cos: {
+		models.Standard:           {"STANDARD", "MAZ_STANDARD"},
+		models.InfrequentAccess:   {"STANDARD_IA", "MAZ_STANDARD_IA"},
+		models.Archive:            {"ARCHIVE"},
+		models.DeepArchive:        {"DEEP_ARCHIVE"},
+		models.IntelligentTiering: {"INTELLIGENT_TIERING", "MAZ_INTELLIGENT_TIERING"},
+	},
+	models.Bos: {
+		models.Standard:         {"STANDARD", "MAZ_STANDARD"},
+		models.InfrequentAccess: {"STANDARD_IA", "MAZ_STANDARD_IA", "COLD"},
+		models.Archive:          {"ARCHIVE"},
+	},
+	models.Kodo: {
+		models.Standard:           {"STANDARD"},
+		models.InfrequentAccess:   {"LINE", "ARCHIVE_IR"},
+		models.Archive:            {"ARCHIVE"},
+		models.DeepArchive:        {"DEEP_ARCHIVE"},
+		models.IntelligentTiering: {"INTELLIGENT_TIERING"},
+	},
+	models.Azblob: {
+		models.Standard:         {"Hot"},
+		models.InfrequentAccess: {"Cool", "Cold"},
+		models.Archive:          {"Archive"},
+	},
+	models.Gcs: {
+		models.Standard:         {"STANDARD", "MULTI_REGIONAL", "REGIONAL", "DURABLE_REDUCED_AVAILABILITY"},
+		models.InfrequentAccess: {"NEARLINE", "COLDLINE"},
+		models.Archive:          {"ARCHIVE"},
+	},
+	models.Obs: {
+		models.Standard:         {"STANDARD"},
+		models.InfrequentAccess: {"WARM", "STANDARD_IA"},
+		models.Archive:          {"COLD", "GLACIER"},
+		models.DeepArchive:      {"DEEP_ARCHIVE"},
+	},
+}
+
+// storageClassFallback 厂商不支持时依次尝试的类型，只退到更热的类型并从最接近的开始：
+// 深度归档 → 归档 → 低频 → 标准。更冷的类型需要先取回才能读取，不能代替，标准类型所有厂商都支持
+var storageClassFallback = map[models.StorageClass][]models.StorageClass{
+	models.InfrequentAccess:   {models.Standard},
+	models.Archive:            {models.InfrequentAccess, models.Standard},
+	models.DeepArchive:        {models.Archive, models.InfrequentAccess, models.Standard},
+	models.IntelligentTiering: {models.Standard},
+}
+
+// classWarned 已经提示过的存储类型，每种只提示一次，见 ResetStorageClassWarnings
+var classWarned sync.Map
+
+func warnStorageClassOnce(key, format string, args ...interface{}) {
+	if _, loaded := classWarned.LoadOrStore(key, true); !loaded {
+		logger.Warn().Msgf(format, args...)
+	}
+}
+
+// ResetStorageClassWarnings 开始新任务时调用，使每个任务都提示一次不支持的存储类型
+func ResetStorageClassWarnings() {
+	classWarned.Range(func(k, _ interface{}) bool {
+		classWarned.Delete(k)
+		return true
+	})
+}
+
+// toStorageClass 厂商的存储类型转换为通用类型，未知的类型返回空
+func toStorageClass(t models.ResourceType, class string) models.StorageClass {
+	if class == "" {
+		return models.Standard
+	}
+	for sc, names := range storageClasses[t] {
+		for _, name := range names {
+			if strings.EqualFold(name, class) {
+				return sc
+			}
+		}
+	}
+	warnStorageClassOnce("unknown/"+string(t)+"/"+class, "unknown storage class %q of %s", class, t)
+	return ""
+}
+
+// fromStorageClass 通用类型转换为厂商的存储类型，不支持时使用最接近的类型，见 storageClassFallback
+func fromStorageClass(t models.ResourceType, sc models.StorageClass) string {
+	if names := storageClasses[t][sc]; len(names) > 0 {
+		return names[0]
+	}
+	if sc == "" {
+		return ""
+	}
+	for _, f := range storageClassFallback[sc] {
+		if names := storageClasses[t][f]; len(names) > 0 {
+			warnStorageClassOnce("from/"+string(t)+"/"+string(sc), "storage class %s is not supported by %s, use %s instead", sc, t, names[0])
+			return names[0]
+		}
+	}
+	warnStorageClassOnce("from/"+string(t)+"/"+string(sc), "storage class %s is not supported by %s, use the default of bucket", sc, t)
+	return ""
+}
+
+// ParseStorageClass accepts the provider neutral names as well as the names
+// used by any provider, like GLACIER or ColdArchive.
+func ParseStorageClass(v string) (models.StorageClass, error) {
+	for _, sc := range []models.StorageClass{models.Standard, models.InfrequentAccess, models.Archive,
+		models.DeepArchive, models.IntelligentTiering} {
+		if strings.EqualFold(string(sc), v) {
+			return sc, nil
+		}
+	}
+	for _, classes := range storageClasses {
+		for sc, names := range classes {
+			for _, name := range names {
+				if strings.EqualFold(name, v) {
+					return sc, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown storage class %q", v)
+}
+
+// StorageClassOf returns the storage class of a listed object, empty if the
+// storage has no storage class.
+func StorageClassOf(o Object) models.StorageClass {
+	if c, ok := o.(ClassObject); ok {
+		return c.StorageClass()
+	}
+	return ""
+}
+
+// NativeStorageClass returns the name of the storage class used by the
+// provider, empty if it's not supported.
+func NativeStorageClass(t models.ResourceType, sc models.StorageClass) string {
+	if names := storageClasses[t][sc]; len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// NeutralStorageClass maps the storage class name of the provider to the
+// provider neutral one, empty if unknown.
+func NeutralStorageClass(t models.ResourceType, class string) models.StorageClass {
+	return toStorageClass(t, class)
+}
@@ -0,0 +1,72 @@
+package object
+
+import (
+	"testing"
+
+	"obs-sync/models"
+)
+
+func TestStorageClass(t *testing.T) {
+	// 每个厂商的每个取值都能转换回来，写入使用第一个取值
+	for typ, classes := range storageClasses {
+		for sc, names := range classes {
+			for _, name := range names {
+				if got := toStorageClass(typ, name); got != sc {
+					t.Fatalf("%s %s: %s != %s", typ, name, got, sc)
+				}
+				if got, err := ParseStorageClass(name); err != nil || got == "" {
+					t.Fatalf("parse %s: %s %v", name, got, err)
+				}
+			}
+			if got := fromStorageClass(typ, sc); got != names[0] {
+				t.Fatalf("%s %s: %s", typ, sc, got)
+			}
+		}
+		if classes[models.Standard] == nil || classes[models.InfrequentAccess] == nil {
+			t.Fatalf("%s has no standard or IA class", typ)
+		}
+	}
+	if toStorageClass(models.S3, "") != models.Standard || toStorageClass(models.S3, "UNKNOWN") != "" {
+		t.Fatal("empty or unknown class")
+	}
+	if sc, err := ParseStorageClass("coldarchive"); err != nil || sc != models.DeepArchive {
+		t.Fatalf("parse coldarchive: %s %v", sc, err)
+	}
+	if _, err := ParseStorageClass("hot-ish"); err == nil {
+		t.Fatal("parse an unknown class")
+	}
+
+	// 不支持的类型使用最接近的类型
+	for _, c := range []struct {
+		typ  models.ResourceType
+		sc   models.StorageClass
+		want string
+	}{
+		{models.Cuc, models.DeepArchive, "GLACIER"},
+		{models.Cuc, models.IntelligentTiering, "STANDARD"},
+		{models.Gcs, models.DeepArchive, "ARCHIVE"},
+		{models.Obs, models.IntelligentTiering, "STANDARD"},
+		{models.S3, "", ""},
+	} {
+		if got := fromStorageClass(c.typ, c.sc); got != c.want {
+			t.Fatalf("%s %s: %s, want %s", c.typ, c.sc, got, c.want)
+		}
+	}
+	// 只退到更热的类型，智能分层不在冷热顺序中
+	warmth := map[models.StorageClass]int{models.Standard: 0, models.InfrequentAccess: 1, models.Archive: 2, models.DeepArchive: 3}
+	for sc, fallback := range storageClassFallback {
+		if _, ok := warmth[sc]; !ok {
+			continue
+		}
+		for i, f := range fallback {
+			if warmth[f] >= warmth[sc] || i > 0 && warmth[f] >= warmth[fallback[i-1]] {
+				t.Fatalf("fallback of %s: %v", sc, fallback)
+			}
+		}
+	}
+	ResetStorageClassWarnings()
+	classWarned.Range(func(k, _ interface{}) bool {
+		t.Fatalf("warning %s is not reset", k)
+		return false
+	})
+}
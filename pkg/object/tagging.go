@@ -0,0 +1,129 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Tagger is implemented by storages supporting object tagging.
+type Tagger interface {
+	// GetTags returns the tags of an object.
+	GetTags(key string) (map[string]string, error)
+	// SetTags replaces the tags of an object.
+	SetTags(key string, tags map[string]string) error
+}
+
+// encodeTags encodes the tags as the x-amz-tagging header, like k1=v1&k2=v2.
+func encodeTags(tags map[string]string) string {
+	v := url.Values{}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.Set(k, tags[k])
+	}
+	return v.Encode()
+}
+
+// S3 及兼容厂商（OSS、COS）共同的标签限制
+const (
+	maxTags        = 10
+	maxTagKeyLen   = 128
+	maxTagValueLen = 256
+)
+
+// validTagRune 标签只能包含字母、数字、空格和 + - = . _ : / @
+func validTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || strings.ContainsRune("+-=._:/@", r)
+}
+
+// CheckTags checks the tags against the limits shared by the providers and
+// returns the tags that can be written, along with the reasons of the tags
+// dropped. Beyond the first 10 valid tags in the order of keys are dropped.
+func CheckTags(tags map[string]string) (map[string]string, []string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var (
+		valid   = make(map[string]string, len(tags))
+		dropped []string
+	)
+	for _, k := range keys {
+		v := tags[k]
+		switch {
+		case k == "" || len([]rune(k)) > maxTagKeyLen:
+			dropped = append(dropped, fmt.Sprintf("tag %q: key longer than %d", k, maxTagKeyLen))
+		case len([]rune(v)) > maxTagValueLen:
+			dropped = append(dropped, fmt.Sprintf("tag %q: value longer than %d", k, maxTagValueLen))
+		case strings.HasPrefix(strings.ToLower(k), "aws:"):
+			dropped = append(dropped, fmt.Sprintf("tag %q: reserved prefix", k))
+		case strings.IndexFunc(k+v, func(r rune) bool { return !validTagRune(r) }) >= 0:
+			dropped = append(dropped, fmt.Sprintf("tag %q: invalid characters", k))
+		case len(valid) == maxTags:
+			dropped = append(dropped, fmt.Sprintf("tag %q: more than %d tags", k, maxTags))
+		default:
+			valid[k] = v
+		}
+	}
+	return valid, dropped
+}
+
+// tagError 标签请求返回的错误，用于区分写入对象和写入标签时的 BadRequest
+type tagError struct {
+	err error
+}
+
+func (e *tagError) Error() string {
+	return "tagging: " + e.err.Error()
+}
+
+func (e *tagError) Unwrap() error {
+	return e.err
+}
+
+func tagErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &tagError{err}
+}
+
+// IsInvalidTag reports whether the storage rejected the tags of an object:
+// the error code InvalidTag, or BadRequest answered to a tagging request.
+// Other errors, like AccessDenied or throttling, are worth retrying.
+func IsInvalidTag(err error) bool {
+	switch errorCode(err) {
+	case "InvalidTag":
+		return true
+	case "BadRequest":
+		var te *tagError
+		return errors.As(err, &te)
+	}
+	return false
+}
+
+// CanTag tells whether tags can be written to s, the wrappers implement
+// Tagger whether the storage they wrap does or not.
+func CanTag(s ObjectStorage) bool {
+	for {
+		switch w := s.(type) {
+		case *withPrefix:
+			s = w.os
+		case *withChaos:
+			s = w.os
+		case *withEncryption:
+			s = w.os
+		default:
+			_, ok := s.(Tagger)
+			return ok
+		}
+	}
+}
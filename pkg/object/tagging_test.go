@@ -0,0 +1,76 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/tencentyun/cos-go-sdk-v5"
+
+	"obs-sync/models"
+)
+
+func TestEncodeTags(t *testing.T) {
+	if v := encodeTags(map[string]string{"b": "2 3", "a": "1&", "c": ""}); v != "a=1%26&b=2+3&c=" {
+		t.Fatalf("encode tags: %s", v)
+	}
+	if v := encodeTags(nil); v != "" {
+		t.Fatalf("encode no tags: %s", v)
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	tags := map[string]string{
+		"project":                "obs-sync",
+		"path":                   "/a/b:c@d+e=f_g.h",
+		"中文":                     "值",
+		"bad|key":                "x",
+		"aws:createdBy":          "x",
+		strings.Repeat("k", 129): "x",
+		"long":                   strings.Repeat("v", 257),
+	}
+	valid, dropped := CheckTags(tags)
+	if len(valid) != 3 || valid["path"] == "" || valid["中文"] != "值" || len(dropped) != 4 {
+		t.Fatalf("check tags: %v %v", valid, dropped)
+	}
+
+	tags = map[string]string{}
+	for i := 0; i < 12; i++ {
+		tags[fmt.Sprintf("k%02d", i)] = "v"
+	}
+	valid, dropped = CheckTags(tags)
+	if len(valid) != maxTags || valid["k09"] == "" || len(dropped) != 2 || !strings.Contains(dropped[0], "k10") {
+		t.Fatalf("check too many tags: %v %v", valid, dropped)
+	}
+}
+
+func TestIsInvalidTag(t *testing.T) {
+	for err, want := range map[error]bool{
+		awserr.New("InvalidTag", "The TagValue you have provided is invalid", nil):     true,
+		tagErr(&cos.ErrorResponse{Code: "BadRequest"}):                                 true,
+		&cos.ErrorResponse{Code: "BadRequest"}:                                         false,
+		tagErr(awserr.New("AccessDenied", "Tagging is not allowed", nil)):              false,
+		tagErr(awserr.New("SlowDown", "Please reduce your request rate", nil)):         false,
+		fmt.Errorf("put: Tagging %w", awserr.New("RequestError", "send request", nil)): false,
+	} {
+		if IsInvalidTag(err) != want {
+			t.Fatalf("invalid tag %v: %v", err, !want)
+		}
+	}
+	if tagErr(nil) != nil {
+		t.Fatal("tag error of nil")
+	}
+}
+
+func TestCanTag(t *testing.T) {
+	mem, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+	file, _ := CreateStorage(models.File, t.TempDir()+"/", "", "")
+	chaos := &ChaosConfig{}
+	if !CanTag(mem) || !CanTag(WithChaos(WithPrefix(mem, "p/"), chaos)) {
+		t.Fatal("mem can tag")
+	}
+	if CanTag(file) || CanTag(WithChaos(file, chaos)) {
+		t.Fatal("file can not tag")
+	}
+}
@@ -45,7 +45,7 @@ func (u *urlStorage) Get(key string, off, limit int64) (io.ReadCloser, error) {
 	return ioutil.NopCloser(v.Body), nil
 }
 
-func (u *urlStorage) Put(key string, in io.Reader, _ models.CannedACLType) error {
+func (u *urlStorage) Put(key string, in io.Reader, _ models.CannedACLType, _ ...PutOption) error {
 	//dont support
 	return nil
 }
@@ -102,7 +102,7 @@ func (u *urlStorage) ListAll(prefix, marker string) (<-chan Object, error) {
 	return listed, nil
 }
 
-func (u *urlStorage) CreateMultipartUpload(key string, minSize int, aclType models.CannedACLType) (*MultipartUpload, error) {
+func (u *urlStorage) CreateMultipartUpload(key string, minSize int, aclType models.CannedACLType, _ ...PutOption) (*MultipartUpload, error) {
 	return nil, notSupported
 }
 
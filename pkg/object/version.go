@@ -0,0 +1,84 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Version is a version or a delete marker of an object in a versioned bucket.
+type Version interface {
+	Object
+	VersionID() string
+	IsDeleteMarker() bool
+}
+
+type version struct {
+	obj
+	id           string
+	deleteMarker bool
+}
+
+func (v *version) VersionID() string    { return v.id }
+func (v *version) IsDeleteMarker() bool { return v.deleteMarker }
+
+// Versioner is implemented by storages supporting versioned buckets.
+type Versioner interface {
+	// ListVersions lists the versions and delete markers after the markers,
+	// ordered by key and then newest first. The next markers are empty when
+	// there is nothing more.
+	ListVersions(prefix, keyMarker, versionMarker string, limit int64) (vs []Version, nextKey, nextVersion string, err error)
+	// GetVersion reads a version of an object.
+	GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error)
+}
+
+// VersioningEnabler is implemented by storages able to turn on versioning of
+// the bucket, so the versions replayed are kept instead of overwritten.
+type VersioningEnabler interface {
+	EnableVersioning() error
+}
+
+// EnableVersioning 开启存储所在桶的多版本，不支持时返回错误
+func EnableVersioning(s ObjectStorage) error {
+	if e, ok := s.(VersioningEnabler); ok {
+		if err := e.EnableVersioning(); !IsNotSupported(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s does not support versioning", s)
+}
+
+// sortVersions merges the versions and delete markers of a listed page.
+func sortVersions(vs []Version) {
+	sort.SliceStable(vs, func(i, j int) bool {
+		if vs[i].Key() != vs[j].Key() {
+			return vs[i].Key() < vs[j].Key()
+		}
+		return vs[i].Mtime().After(vs[j].Mtime())
+	})
+}
+
+type withVersion struct {
+	ObjectStorage
+	v  Versioner
+	id string
+}
+
+// WithVersion returns a storage reading the given version of the objects
+// instead of the current one. The optional interfaces like Tagger and
+// Checksummer are hidden since they tell about the current version only.
+func WithVersion(store ObjectStorage, versionID string) ObjectStorage {
+	if v, ok := store.(Versioner); ok && versionID != "" {
+		return &withVersion{store, v, versionID}
+	}
+	return store
+}
+
+func (w *withVersion) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	return w.v.GetVersion(key, w.id, off, limit)
+}
+
+// Head is not supported since it tells about the current version only.
+func (w *withVersion) Head(key string) (Object, error) {
+	return nil, notSupported
+}
@@ -0,0 +1,39 @@
+package object
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"obs-sync/models"
+)
+
+func TestSortVersions(t *testing.T) {
+	now := time.Now()
+	v := func(key, id string, age int, dm bool) Version {
+		return &version{obj{key, 1, now.Add(-time.Duration(age) * time.Minute), false, ""}, id, dm}
+	}
+	// 分别列举的版本和删除标记合并后按 key 排序，同一对象从新到旧
+	vs := []Version{v("b", "b1", 3, false), v("a", "a1", 5, false), v("a", "a3", 1, false), v("b", "b2", 2, true), v("a", "a2", 3, true)}
+	sortVersions(vs)
+	var ids []string
+	for _, ver := range vs {
+		ids = append(ids, ver.VersionID())
+	}
+	if got := strings.Join(ids, ","); got != "a3,a2,a1,b2,b1" {
+		t.Fatalf("sorted versions: %s", got)
+	}
+}
+
+func TestEnableVersioning(t *testing.T) {
+	mem, _ := CreateStorage(models.Mem, MemBucketURL(t.Name(), "bkt"), "", "")
+	if err := EnableVersioning(mem); err == nil {
+		t.Fatal("enable versioning of mem")
+	}
+	if err := EnableVersioning(WithChaos(mem, &ChaosConfig{})); err == nil || !strings.Contains(err.Error(), "does not support versioning") {
+		t.Fatalf("enable versioning through chaos: %v", err)
+	}
+	if s := WithVersion(mem, "v1"); s != mem {
+		t.Fatal("mem has no versions")
+	}
+}
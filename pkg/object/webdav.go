@@ -0,0 +1,424 @@
+//go:build !nowebdav
+// +build !nowebdav
+
+package object
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"obs-sync/models"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const davPropfind = `<?xml version="1.0" encoding="utf-8"?>` +
+	`<D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/></D:prop></D:propfind>`
+
+// webdavStore WebDAV 共享下的目录，如 Nextcloud 的 https://host/remote.php/dav/files/USER/，
+// ak、sk 为 Basic 认证的用户名和密码
+type webdavStore struct {
+	DefaultObjectStorage
+	base     *url.URL // 以 / 结尾
+	user     string
+	password string
+	dirs     sync.Map // 已创建的目录
+}
+
+// davObj 带 ETag 的对象，ETag 由服务端生成，内容变化时改变
+type davObj struct {
+	obj
+	etag string
+}
+
+func (o *davObj) ETag() string { return o.etag }
+
+type davError struct {
+	Method string
+	Path   string
+	Status int
+}
+
+func (e *davError) Error() string {
+	return fmt.Sprintf("webdav: %s %s: %d %s", e.Method, e.Path, e.Status, http.StatusText(e.Status))
+}
+
+func davNotFound(err error) bool {
+	e, ok := err.(*davError)
+	return ok && e.Status == http.StatusNotFound
+}
+
+// parseWebdavURL 地址为 http(s)://host[:port]/path，默认 https
+func parseWebdavURL(addr string) (*url.URL, error) {
+	if !strings.Contains(addr, "://") {
+		addr = "https://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("webdav: invalid endpoint %s: %s", addr, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("webdav: invalid endpoint %s, expected http(s)://host[:port]/path", addr)
+	}
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	return u, nil
+}
+
+// WebdavBucketURL 目录的访问地址，addr 为 webdav:// 之后的 http(s)://host[:port]/path
+func WebdavBucketURL(addr, name string) string {
+	u, err := parseWebdavURL(addr)
+	if err != nil {
+		return ""
+	}
+	u.Path += name + "/"
+	return u.String()
+}
+
+func (w *webdavStore) SetCheckSumKey(meta string) error {
+	return notSupported
+}
+
+func (w *webdavStore) IsSetMd5(flag bool) error {
+	return notSupported
+}
+
+func (w *webdavStore) String() string {
+	return "webdav://" + w.base.Host + w.base.Path
+}
+
+func (w *webdavStore) url(key string) string {
+	u := *w.base
+	u.Path += key
+	return u.String()
+}
+
+// request 发送请求，返回非 2xx 时的错误
+func (w *webdavStore) request(method, key string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url(key), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		cleanup(resp)
+		return nil, &davError{method, w.base.Path + key, resp.StatusCode}
+	}
+	return resp, nil
+}
+
+type davResponse struct {
+	Href     string `xml:"DAV: href"`
+	Propstat []struct {
+		Prop struct {
+			ResourceType struct {
+				Collection *struct{} `xml:"DAV: collection"`
+			} `xml:"DAV: resourcetype"`
+			ContentLength int64  `xml:"DAV: getcontentlength"`
+			LastModified  string `xml:"DAV: getlastmodified"`
+			ETag          string `xml:"DAV: getetag"`
+		} `xml:"DAV: prop"`
+		Status string `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+}
+
+// propfind 列举 key 自身（depth 0）或其直接子项（depth 1），返回的 key 相对 base，目录以 / 结尾
+func (w *webdavStore) propfind(key string, depth string) ([]*davObj, error) {
+	h := http.Header{"Depth": {depth}, "Content-Type": {"application/xml; charset=utf-8"}}
+	resp, err := w.request("PROPFIND", key, h, strings.NewReader(davPropfind))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup(resp)
+	var ms struct {
+		Responses []davResponse `xml:"DAV: response"`
+	}
+	if err = xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("webdav: invalid PROPFIND response of %s: %s", key, err)
+	}
+	objs := make([]*davObj, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil || !strings.HasPrefix(href.Path+"/", w.base.Path) {
+			return nil, fmt.Errorf("webdav: unexpected href %q in PROPFIND response of %s", r.Href, key)
+		}
+		k := strings.TrimPrefix(href.Path, w.base.Path)
+		if href.Path+"/" == w.base.Path {
+			k = ""
+		}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			p := ps.Prop
+			isDir := p.ResourceType.Collection != nil
+			if isDir && k != "" && !strings.HasSuffix(k, "/") {
+				k += "/"
+			}
+			mtime, _ := http.ParseTime(p.LastModified)
+			o := &davObj{obj{k, p.ContentLength, mtime, isDir, ""}, strings.Trim(p.ETag, `"`)}
+			if isDir {
+				o.size = 0
+			}
+			objs = append(objs, o)
+			break
+		}
+	}
+	return objs, nil
+}
+
+func (w *webdavStore) Head(key string) (Object, error) {
+	objs, err := w.propfind(key, "0")
+	if davNotFound(err) || err == nil && len(objs) == 0 {
+		return nil, notExist(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	o := objs[0]
+	o.key = key
+	if o.isDir && key != "" && !strings.HasSuffix(key, "/") {
+		o.key += "/"
+	}
+	return o, nil
+}
+
+type davReader struct {
+	io.Reader
+	io.Closer
+}
+
+func (w *webdavStore) Get(key string, off, limit int64) (io.ReadCloser, error) {
+	h := http.Header{}
+	if off > 0 || limit > 0 {
+		if limit > 0 {
+			h.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+		} else {
+			h.Set("Range", fmt.Sprintf("bytes=%d-", off))
+		}
+	}
+	resp, err := w.request("GET", key, h, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPartialContent || off == 0 && limit <= 0 {
+		return resp.Body, nil
+	}
+	// 服务端忽略了 Range 时自行跳过
+	if off > 0 {
+		if _, err = io.CopyN(ioutil.Discard, resp.Body, off); err != nil {
+			cleanup(resp)
+			return nil, err
+		}
+	}
+	if limit > 0 {
+		return &davReader{io.LimitReader(resp.Body, limit), resp.Body}, nil
+	}
+	return resp.Body, nil
+}
+
+// mkdirAll 依次创建 dir 及其上级目录，已存在的目录返回 405
+func (w *webdavStore) mkdirAll(dir string) error {
+	if dir == "" || dir == "." || dir == "/" {
+		return nil
+	}
+	dir = strings.TrimSuffix(dir, "/")
+	if _, ok := w.dirs.Load(dir); ok {
+		return nil
+	}
+	if err := w.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	resp, err := w.request("MKCOL", dir+"/", nil, nil)
+	if err == nil {
+		cleanup(resp)
+	} else if e, ok := err.(*davError); ok && e.Status == http.StatusMethodNotAllowed {
+		err = nil
+	}
+	if err == nil {
+		w.dirs.Store(dir, true)
+	}
+	return err
+}
+
+func (w *webdavStore) Create() error {
+	resp, err := w.request("MKCOL", "", nil, nil)
+	if err == nil {
+		cleanup(resp)
+	} else if e, ok := err.(*davError); ok && e.Status == http.StatusMethodNotAllowed {
+		err = nil
+	}
+	return err
+}
+
+// Put 写入前创建上级目录，WebDAV 不会自动创建
+func (w *webdavStore) Put(key string, in io.Reader, _ models.CannedACLType, opts ...PutOption) error {
+	if strings.HasSuffix(key, "/") || key == "" {
+		return w.mkdirAll(key)
+	}
+	if err := w.mkdirAll(path.Dir(key)); err != nil {
+		return err
+	}
+	h := http.Header{}
+	if m := applyPutOptions(opts).Meta; m != nil && m.ContentType != "" {
+		h.Set("Content-Type", m.ContentType)
+	}
+	resp, err := w.request("PUT", key, h, in)
+	if err != nil {
+		return err
+	}
+	cleanup(resp)
+	return nil
+}
+
+// Delete WebDAV 删除目录时会删除其下所有内容，只删除空目录
+func (w *webdavStore) Delete(key string) error {
+	if strings.HasSuffix(key, "/") {
+		children, err := w.propfind(key, "1")
+		if davNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(children) > 1 {
+			return fmt.Errorf("webdav: directory %s is not empty", key)
+		}
+		w.dirs.Delete(strings.TrimSuffix(key, "/"))
+	}
+	resp, err := w.request("DELETE", key, nil, nil)
+	if davNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cleanup(resp)
+	return nil
+}
+
+// readDirSorted 目录的直接子项，按 key 排序
+func (w *webdavStore) readDirSorted(dir string) ([]*davObj, error) {
+	objs, err := w.propfind(dir, "1")
+	if err != nil {
+		return nil, err
+	}
+	res := objs[:0]
+	for _, o := range objs {
+		if o.key != dir && strings.HasPrefix(o.key, dir) {
+			res = append(res, o)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].key < res[j].key })
+	return res, nil
+}
+
+// ListAll 逐层 PROPFIND（很多服务端禁用了 Depth: infinity），按 key 的字典序列举，
+// 跳过不含 prefix 和 marker 之后 key 的目录
+func (w *webdavStore) ListAll(prefix, marker string) (<-chan Object, error) {
+	listed := make(chan Object, 10240)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		objs, err := w.readDirSorted(dir)
+		if err != nil {
+			if davNotFound(err) {
+				logger.Warn().Msgf("skip not exist directory: %s", dir)
+				return nil
+			}
+			return err
+		}
+		for _, o := range objs {
+			key := o.key
+			if !o.isDir {
+				if strings.HasPrefix(key, prefix) && key > marker {
+					listed <- o
+				}
+				continue
+			}
+			if !strings.HasPrefix(key, prefix) && !strings.HasPrefix(prefix, key) {
+				continue
+			}
+			if key < marker && !strings.HasPrefix(marker, key) {
+				continue
+			}
+			if strings.HasPrefix(key, prefix) && key > marker {
+				listed <- o
+			}
+			if err = walk(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	go func() {
+		if err := walk(""); err != nil {
+			logger.Error().Msgf("list %s: %s", w, err)
+			listed <- nil
+		}
+		close(listed)
+	}()
+	return listed, nil
+}
+
+func (w *webdavStore) GetObjectAcl(key string) (models.CannedACLType, error) {
+	return "", nil
+}
+
+// ListWebdavDirs 列举 addr 下的目录，作为 webdav 的桶
+func ListWebdavDirs(addr, user, password string) ([]string, error) {
+	s, err := newWebdav(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := s.(*webdavStore).readDirSorted("")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, o := range objs {
+		if o.isDir && !strings.HasPrefix(o.key, ".") {
+			names = append(names, strings.TrimSuffix(o.key, "/"))
+		}
+	}
+	return names, nil
+}
+
+// CreateWebdavDir 在 addr 下创建目录
+func CreateWebdavDir(addr, user, password, name string) error {
+	s, err := newWebdav(WebdavBucketURL(addr, name), user, password)
+	if err != nil {
+		return err
+	}
+	return s.Create()
+}
+
+// newWebdav 地址为 http(s)://host[:port]/path/，ak、sk 为用户名和密码，均为空时不认证
+func newWebdav(endpoint, user, password string) (ObjectStorage, error) {
+	u, err := parseWebdavURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &webdavStore{base: u, user: user, password: password}, nil
+}
+
+func init() {
+	Register(models.Webdav, newWebdav)
+}
@@ -0,0 +1,118 @@
+package object
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"obs-sync/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+func TestWebdav(t *testing.T) {
+	dir := t.TempDir()
+	dav := &webdav.Handler{Prefix: "/dav", FileSystem: webdav.Dir(dir), LockSystem: webdav.NewMemLS()}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	if err := CreateWebdavDir(srv.URL+"/dav", "user", "pass", "bkt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "other"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ListWebdavDirs(srv.URL+"/dav", "user", "pass")
+	if err != nil || strings.Join(names, ",") != "bkt,other" {
+		t.Fatalf("list dirs: %v %v", names, err)
+	}
+	if _, err = ListWebdavDirs(srv.URL+"/dav", "user", "wrong"); err == nil {
+		t.Fatal("list with wrong password")
+	}
+
+	s, err := CreateStorage(models.Webdav, WebdavBucketURL(srv.URL+"/dav", "bkt"), "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a/1", "a/2 +中", "a/b/3", "c", "d/"} {
+		if err = s.Put(k, strings.NewReader("hello "+k), models.Default); err != nil {
+			t.Fatalf("put %s: %s", k, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "bkt", "a", "b", "3")); string(data) != "hello a/b/3" {
+		t.Fatalf("put: %q", data)
+	}
+
+	listAll := func(prefix, marker string) []Object {
+		ch, err := s.ListAll(prefix, marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var objs []Object
+		for o := range ch {
+			if o == nil {
+				t.Fatal("list failed")
+			}
+			objs = append(objs, o)
+		}
+		return objs
+	}
+	keys := func(objs []Object) string {
+		var ks []string
+		for _, o := range objs {
+			ks = append(ks, o.Key())
+		}
+		return strings.Join(ks, ",")
+	}
+	all := listAll("", "")
+	if got := keys(all); got != "a/,a/1,a/2 +中,a/b/,a/b/3,c,d/" {
+		t.Fatalf("list all: %s", got)
+	}
+	if o := all[1]; o.Size() != int64(len("hello a/1")) || o.Mtime().IsZero() || o.(*davObj).ETag() == "" {
+		t.Fatalf("listed object: %+v", o)
+	}
+	if got := keys(listAll("a/", "a/2 +中")); got != "a/b/,a/b/3" {
+		t.Fatalf("list from marker: %s", got)
+	}
+
+	in, err := s.Get("a/2 +中", 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(in)
+	in.Close()
+	if string(data) != "a/2" {
+		t.Fatalf("ranged get: %q", data)
+	}
+	o, err := s.Head("c")
+	if err != nil || o.Size() != int64(len("hello c")) || o.IsDir() {
+		t.Fatalf("head: %v %v", o, err)
+	}
+	if o, err = s.Head("d"); err != nil || !o.IsDir() || o.Key() != "d/" {
+		t.Fatalf("head dir: %v %v", o, err)
+	}
+	if _, err = s.Head("nope"); !os.IsNotExist(err) {
+		t.Fatalf("head missing: %v", err)
+	}
+
+	if err = s.Delete("a/"); err == nil {
+		t.Fatal("deleted a non-empty directory")
+	}
+	for _, k := range []string{"c", "c", "d/"} {
+		if err = s.Delete(k); err != nil {
+			t.Fatalf("delete %s: %s", k, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "bkt", "d")); !os.IsNotExist(err) {
+		t.Fatalf("dir not deleted: %v", err)
+	}
+}
@@ -26,6 +26,7 @@ const (
 var (
 	maxBlock    = int64(defaultPartSize * 2)
 	maxPartSize = defaultPartSize
+	retryDelay  = time.Second // 第 i 次重试前等待 i*i*retryDelay
 	l           *log.Logger
 )
 
@@ -39,6 +40,32 @@ var bufPool = sync.Pool{
 type Consumer struct {
 	concurrent chan int          //原子限制器，防止重复复制
 	limiter    *ratelimit.Bucket // 限速开关
+	checksum   bool              // 复制时计算校验值并写入目的端元数据
+	useMd5     bool              // 校验值使用 md5，默认 crc32c
+	class      string            // 目的端存储类型，preserve 沿用源端
+	tags       bool              // 复制对象标签
+	aclMode    string            // 目的端对象的 ACL，见 models.ACLPreserve
+	accounts   map[string]string // 源端账号 ID 到目的端账号 ID 的映射
+}
+
+// Warning 对象已复制成功，但部分属性（如标签）未能写入目的端
+type Warning struct {
+	msg string
+}
+
+func (w *Warning) Error() string {
+	return w.msg
+}
+
+// join 合并同一对象的多条警告
+func (w *Warning) join(o *Warning) *Warning {
+	if w == nil || o == nil || w.msg == o.msg {
+		if w == nil {
+			return o
+		}
+		return w
+	}
+	return &Warning{w.msg + "; " + o.msg}
 }
 
 func NewConsumer(mylog *log.Logger, threads int) *Consumer {
@@ -56,10 +83,10 @@ func NewConsumer(mylog *log.Logger, threads int) *Consumer {
 func try(n int, f func() error) (err error) {
 	for i := 0; i < n; i++ {
 		err = f()
-		if err == nil {
+		if err == nil || object.IsArchived(err) || object.IsInvalidTag(err) { // 归档对象需要先取回，标签被拒绝时重试无意义
 			return
 		}
-		time.Sleep(time.Second * time.Duration(i*i))
+		time.Sleep(retryDelay * time.Duration(i*i))
 	}
 	return
 }
@@ -76,39 +103,359 @@ func (c *Consumer) OpenLimiter(limit int) {
 	}
 }
 
-func (c *Consumer) Work(src, dst object.ObjectStorage, obj object.Object, acl models.CannedACLType) error {
+// OpenChecksum 开启端到端校验，边读源端数据边计算校验值，不需要再读一遍；
+// 源端元数据中有校验值时与之比对，不一致则复制失败
+func (c *Consumer) OpenChecksum(useMd5 bool) {
+	c.checksum = true
+	c.useMd5 = useMd5
+}
+
+func (c *Consumer) newChecksum(expected string) *object.Checksum {
+	if !c.checksum {
+		return nil
+	}
+	return object.NewChecksum(c.useMd5, expected)
+}
+
+// sourceChecksum 源端元数据中记录的校验值，未开启校验或源端不支持时为空
+func (c *Consumer) sourceChecksum(src object.ObjectStorage, key string) string {
+	if !c.checksum {
+		return ""
+	}
+	cs, ok := src.(object.Checksummer)
+	if !ok {
+		return ""
+	}
+	v, err := cs.GetChecksum(key)
+	if err != nil {
+		l.Warn().Msgf("get checksum of %s: %s", key, err)
+		return ""
+	}
+	return v
+}
+
+// SetStorageClass 设置目的端对象的存储类型，preserve 表示沿用源端对象的类型
+func (c *Consumer) SetStorageClass(class string) {
+	c.class = class
+}
+
+// OpenTagging 复制对象标签，目的端拒绝的标签以 Warning 返回，不影响对象本身的复制
+func (c *Consumer) OpenTagging() {
+	c.tags = true
+}
+
+// sourceTags 源端对象的标签，超出限制的标签提前去掉；目的端不支持标签或有标签被去掉时返回 Warning
+func (c *Consumer) sourceTags(src, dst object.ObjectStorage, key string) (map[string]string, *Warning) {
+	if !c.tags {
+		return nil, nil
+	}
+	t, ok := src.(object.Tagger)
+	if !ok {
+		return nil, nil
+	}
+	tags, err := t.GetTags(key)
+	if err != nil {
+		l.Warn().Msgf("get tags of %s: %s", key, err)
+		return nil, &Warning{fmt.Sprintf("get tags: %s", err)}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if !object.CanTag(dst) {
+		return nil, &Warning{fmt.Sprintf("%d tags dropped, %s does not support tagging", len(tags), dst)}
+	}
+	tags, dropped := object.CheckTags(tags)
+	var warn *Warning
+	if len(dropped) > 0 {
+		warn = &Warning{strings.Join(dropped, "; ")}
+	}
+	if len(tags) == 0 {
+		return nil, warn
+	}
+	return tags, warn
+}
+
+// SetACL 设置目的端对象 ACL 的模式：preserve（默认）沿用源端对象的 ACL，bucket 沿用目的端桶的 ACL，
+// 其他值为强制使用的标准 ACL；accounts 将授权给源端账号的权限转给目的端账号
+func (c *Consumer) SetACL(mode string, accounts map[string]string) {
+	c.aclMode = mode
+	c.accounts = accounts
+}
+
+// objectACL 决定目的端对象的 ACL：与源端桶 ACL 相同的对象以 Default 写入，沿用目的端桶的 ACL；
+// 其他对象能用标准 ACL 表示时在写入时指定，否则以 private 写入后再设置授权；无法复制的授权以 Warning 返回
+func (c *Consumer) objectACL(src, dst object.ObjectStorage, key string) (models.CannedACLType, *object.ACL, *Warning) {
+	switch c.aclMode {
+	case "", models.ACLPreserve:
+	case models.ACLBucket:
+		// 不把源端桶的 ACL 写到每个对象上，否则公共读的桶中的私有对象也会变为公共读
+		return models.Default, nil, nil
+	default:
+		return models.CannedACLType(c.aclMode), nil, nil
+	}
+	s, ok := src.(object.ACLer)
+	if !ok {
+		return models.Default, nil, nil
+	}
+	acl, err := s.GetACL(key)
+	if object.IsNotSupported(err) {
+		return models.Default, nil, nil
+	} else if err != nil {
+		l.Warn().Msgf("get acl of %s: %s", key, err)
+		return models.Default, nil, &Warning{fmt.Sprintf("get acl: %s", err)}
+	}
+	if bucket, err := s.GetBucketACL(); err == nil && bucket.Equal(acl) {
+		return models.Default, nil, nil
+	}
+
+	var owner *object.ACL
+	if d, ok := dst.(object.ACLer); ok {
+		if owner, err = d.GetBucketACL(); err != nil && !object.IsNotSupported(err) {
+			l.Warn().Msgf("get bucket acl of %s: %s", dst, err)
+		}
+	}
+	if owner == nil {
+		// 目的端无法读写授权，只能按标准 ACL 写入
+		canned, exact := acl.Canned()
+		if !exact {
+			return canned, nil, &Warning{fmt.Sprintf("acl %s written as %s, %s does not support grants", acl, canned, dst)}
+		}
+		return canned, nil, nil
+	}
+
+	translated, dropped := acl.Translate(owner.Owner, c.accounts)
+	var warn *Warning
+	if len(dropped) > 0 {
+		warn = &Warning{strings.Join(dropped, "; ")}
+	}
+	if canned, exact := translated.Canned(); exact {
+		return canned, nil, warn
+	}
+	return models.Private, translated, warn
+}
+
+// putOptions 对象来自源端 Head 时带有元数据，写入目的端
+func (c *Consumer) putOptions(obj object.Object) []object.PutOption {
+	opts := []object.PutOption{object.WithSize(obj.Size())}
+	if info, ok := obj.(object.ObjectInfo); ok && info.Metadata() != nil {
+		opts = append(opts, object.WithMetadata(info.Metadata()))
+	}
+	switch c.class {
+	case "":
+	case models.PreserveStorageClass:
+		if sc := object.StorageClassOf(obj); sc != "" {
+			opts = append(opts, object.WithStorageClass(sc))
+		}
+	default:
+		opts = append(opts, object.WithStorageClass(models.StorageClass(c.class)))
+	}
+	return opts
+}
+
+// Work 复制单个对象；对象已复制但标签或 ACL 未能写入时返回 *Warning
+func (c *Consumer) Work(src, dst object.ObjectStorage, obj object.Object) error {
+	canned, grants, warn := c.objectACL(src, dst, obj.Key())
+	err := c.work(src, dst, obj, canned, grants)
+	if err == nil && warn != nil {
+		return warn
+	}
+	var w *Warning
+	if errors.As(err, &w) && warn != nil {
+		return warn.join(w)
+	}
+	return err
+}
+
+func (c *Consumer) work(src, dst object.ObjectStorage, obj object.Object, acl models.CannedACLType, grants *object.ACL) error {
 	var err error
+	dstKey := obj.Key()
+	if strings.HasPrefix(src.String(), "url://") {
+		keyArray := strings.Fields(obj.Key())
+		if len(keyArray) != 2 {
+			return errors.New("url object key not valid")
+		}
+		dstKey = keyArray[1]
+	}
+	expected := c.sourceChecksum(src, obj.Key())
+	tags, warn := c.sourceTags(src, dst, obj.Key())
+	copySingle := func() error {
+		err := try(3, func() error { return c.doCopySingle(src, dst, obj, acl, expected, tags) })
+		if tags != nil && object.IsInvalidTag(err) {
+			// 目的端拒绝了标签，不带标签重新上传
+			warn = &Warning{fmt.Sprintf("tags rejected by %s: %s", dst, err)}
+			err = try(3, func() error { return c.doCopySingle(src, dst, obj, acl, expected, nil) })
+		}
+		return err
+	}
 	if obj.Size() < maxBlock {
-		err = try(3, func() error { return c.doCopySingle(src, dst, obj, acl) })
+		err = copySingle()
 	} else {
 		var upload *object.MultipartUpload
-		var dstKey string
-		if strings.HasPrefix(src.String(), "url://") {
-			keyArray := strings.Fields(obj.Key())
-			if len(keyArray) != 2 {
-				return errors.New("url object key not valid")
-			}
-			dstKey = keyArray[1]
-		} else {
-			dstKey = obj.Key()
+		chk := c.newChecksum(expected)
+		opts := c.putOptions(obj)
+		// 分片上传只能在开始时写入元数据，所以只有源端校验值可用时才写入
+		if chk != nil && chk.Expected() != "" {
+			opts = append(opts, object.WithChecksum(chk.Expected()))
 		}
-		if upload, err = dst.CreateMultipartUpload(dstKey, defaultPartSize, acl); err == nil {
-			err = c.doCopyMultiple(src, dst, obj, upload)
+		if upload, err = dst.CreateMultipartUpload(dstKey, defaultPartSize, acl, opts...); err == nil {
+			err = c.doCopyMultiple(src, dst, obj, upload, chk)
+			if err == nil && chk != nil && chk.Expected() == "" {
+				// 校验值在上传完成后才得到，无法再写入元数据，记录在报告中
+				warn = &Warning{fmt.Sprintf("checksum %s not stored, %s only sets metadata when a multipart upload starts", chk.Sum(), dst)}
+			}
+			// 分片上传完成后再写入标签
+			if err == nil && tags != nil {
+				if e := try(3, func() error { return dst.(object.Tagger).SetTags(dstKey, tags) }); e != nil {
+					warn = warn.join(&Warning{fmt.Sprintf("set tags: %s", e)})
+				}
+			}
 		} else { // fallback
-			err = try(3, func() error { return c.doCopySingle(src, dst, obj, acl) })
+			err = copySingle()
+		}
+	}
+	// 无法用标准 ACL 表示的授权在对象写入后设置
+	if err == nil && grants != nil {
+		if e := try(3, func() error { return dst.(object.ACLer).SetACL(dstKey, grants) }); e != nil {
+			warn = warn.join(&Warning{fmt.Sprintf("set acl: %s", e)})
 		}
 	}
+	if err == nil && warn != nil {
+		return warn
+	}
 	return err
 }
 
-func (c *Consumer) doCopySingle(src, dst object.ObjectStorage, obj object.Object, acl models.CannedACLType) error {
+// Replay 按写入顺序依次复制对象的各个版本，删除标记通过删除目的端对象重现，
+// 目的端开启多版本时会生成对应的删除标记。目的端已有的版本（上次复制的）跳过，
+// 只重放之后的版本。各版本使用对象当前的 ACL；
+// 读取指定版本时没有标签和源端校验值，开启时以 Warning 说明
+func (c *Consumer) Replay(src, dst object.ObjectStorage, versions []object.Version) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	n, err := replayed(dst, versions)
+	if err != nil {
+		return err
+	}
+	if versions = versions[n:]; len(versions) == 0 {
+		return nil
+	}
+	canned, grants, warn := c.objectACL(src, dst, versions[0].Key())
+	if _, ok := src.(object.Tagger); ok && c.tags {
+		warn = warn.join(&Warning{"tags of the versions not copied"})
+	}
+	if _, ok := src.(object.Checksummer); ok && c.checksum {
+		warn = warn.join(&Warning{"versions not verified against the source checksum"})
+	}
+	for _, v := range versions {
+		var err error
+		if v.IsDeleteMarker() {
+			if _, ok := dst.(object.Versioner); !ok {
+				warn = warn.join(&Warning{fmt.Sprintf("delete marker %s skipped, %s does not support versioning", v.VersionID(), dst)})
+				continue
+			}
+			err = try(3, func() error { return dst.Delete(v.Key()) })
+		} else {
+			err = c.work(object.WithVersion(src, v.VersionID()), dst, v, canned, grants)
+			var w *Warning
+			if errors.As(err, &w) {
+				warn, err = warn.join(w), nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("version %s: %w", v.VersionID(), err)
+		}
+	}
+	if warn != nil {
+		return warn
+	}
+	return nil
+}
+
+// replayed 返回目的端已经重放过的版本数：目的端对象的版本（从旧到新）与源端的前若干个版本
+// 一一对应，即删除标记的位置相同、版本的大小相同，且写入时间不早于源端的版本。
+// 目的端不支持多版本或者版本不对应时返回 0，全部重放
+func replayed(dst object.ObjectStorage, versions []object.Version) (int, error) {
+	v, ok := dst.(object.Versioner)
+	if !ok {
+		return 0, nil
+	}
+	key := versions[0].Key()
+	var dvs []object.Version
+	var keyMarker, versionMarker string
+	for {
+		var vs []object.Version
+		err := try(3, func() (err error) {
+			vs, keyMarker, versionMarker, err = v.ListVersions(key, keyMarker, versionMarker, 1000)
+			return
+		})
+		if err != nil {
+			return 0, fmt.Errorf("list versions of %s in %s: %w", key, dst, err)
+		}
+		for _, dv := range vs {
+			if dv.Key() == key {
+				dvs = append(dvs, dv)
+			}
+		}
+		if len(dvs) > len(versions) || keyMarker == "" && versionMarker == "" || len(vs) == 0 || vs[len(vs)-1].Key() != key {
+			break
+		}
+	}
+	if len(dvs) > len(versions) {
+		return 0, nil
+	}
+	for i, j := 0, len(dvs)-1; j >= 0; i, j = i+1, j-1 {
+		sv, dv := versions[i], dvs[j]
+		if sv.IsDeleteMarker() != dv.IsDeleteMarker() || !sv.IsDeleteMarker() && sv.Size() != dv.Size() ||
+			dv.Mtime().Unix() < sv.Mtime().Unix() {
+			return 0, nil
+		}
+	}
+	return len(dvs), nil
+}
+
+// stage 将数据暂存到磁盘，同时计算校验值
+func stage(in io.Reader, chk *object.Checksum) (*os.File, error) {
+	f, err := ioutil.TempFile("", "rep")
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(f.Name()) // will be deleted after Close()
+	buf := bufPool.Get().(*[]byte)
+	defer bufPool.Put(buf)
+	if _, err = io.CopyBuffer(struct{ io.Writer }{f}, io.TeeReader(in, chk), *buf); err == nil {
+		_, err = f.Seek(0, 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (c *Consumer) doCopySingle(src, dst object.ObjectStorage, obj object.Object, acl models.CannedACLType, expected string, tags map[string]string) error {
+	chk := c.newChecksum(expected)
+	opts := c.putOptions(obj)
+	if tags != nil {
+		opts = append(opts, object.WithTags(tags))
+	}
 	if obj.Size() > maxBlock || !(strings.HasPrefix(src.String(), "file://") || strings.HasPrefix(src.String(), "url://")) {
 		var err error
 		var in io.Reader
 		downer := newParallelDownloader(src, obj.Key(), obj.Size(), int64(maxPartSize), c.concurrent, c.limiter)
 		defer downer.Close()
-		if strings.HasPrefix(dst.String(), "file://") {
+		if strings.HasPrefix(dst.String(), "file://") && chk == nil {
 			in = downer
+		} else if chk != nil {
+			// 校验通过后才写入目的端
+			var f *os.File
+			if f, err = stage(downer, chk); err == nil {
+				defer f.Close()
+				if err = chk.Verify(); err == nil {
+					opts = append(opts, object.WithChecksum(chk.Sum()))
+					in = f
+				}
+			}
 		} else {
 			var f *os.File
 			// download the object into disk
@@ -126,7 +473,7 @@ func (c *Consumer) doCopySingle(src, dst object.ObjectStorage, obj object.Object
 			}
 		}
 		if err == nil {
-			err = dst.Put(obj.Key(), in, acl)
+			err = dst.Put(obj.Key(), in, acl, opts...)
 		}
 		if err != nil {
 			if _, e := src.Head(obj.Key()); os.IsNotExist(e) {
@@ -159,17 +506,32 @@ SINGLE:
 		}
 	}
 	defer in.Close()
+	var body io.Reader = in
+	if chk != nil {
+		f, err := stage(in, chk)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err = chk.Verify(); err != nil {
+			return err
+		}
+		opts = append(opts, object.WithChecksum(chk.Sum()))
+		body = f
+	}
 	if strings.HasPrefix(src.String(), "url://") {
 		keyArray := strings.Fields(obj.Key())
 		if len(keyArray) != 2 {
 			return errors.New("url object key not valid")
 		}
-		return dst.Put(keyArray[1], in, acl)
+		return dst.Put(keyArray[1], body, acl, opts...)
 	}
-	return dst.Put(obj.Key(), in, acl)
+	return dst.Put(obj.Key(), body, acl, opts...)
 }
 
-func (c *Consumer) doCopyMultiple(src, dst object.ObjectStorage, obj object.Object, upload *object.MultipartUpload) error {
+// doCopyMultiple 并发复制各分片；chk 不为空时按顺序读取源端数据以计算整体校验值，
+// 分片仍并发上传，校验不通过则放弃本次上传
+func (c *Consumer) doCopyMultiple(src, dst object.ObjectStorage, obj object.Object, upload *object.MultipartUpload, chk *object.Checksum) error {
 	var objKey string
 	if strings.HasPrefix(src.String(), "url://") {
 		keyArray := strings.Fields(obj.Key())
@@ -193,62 +555,116 @@ func (c *Consumer) doCopyMultiple(src, dst object.ObjectStorage, obj object.Obje
 	abort := make(chan struct{})
 	parts := make([]*object.Part, n)
 	errs := make(chan error, n)
-	for i := 0; i < n; i++ {
-		go func(num int) {
-			sz := partSize
-			if num == n-1 {
-				sz = obj.Size() - int64(num)*partSize
+
+	var (
+		downer  *parallelDownloader
+		uploads chan int // 限制已读取、等待上传的分片数量
+	)
+	if chk != nil {
+		downer = newParallelDownloader(src, obj.Key(), obj.Size(), partSize, c.concurrent, c.limiter)
+		defer downer.Close()
+		uploads = make(chan int, cap(c.concurrent))
+	}
+
+	var err error
+	started, done := 0, 0
+	for started < n && err == nil {
+		num := started
+		sz := partSize
+		if num == n-1 {
+			sz = obj.Size() - int64(num)*partSize
+		}
+		var data []byte
+		if downer != nil {
+			select {
+			case uploads <- 1:
+			case err = <-errs:
+				done++
+				if err != nil {
+					continue
+				}
+				uploads <- 1
 			}
-			if c.limiter != nil {
-				c.limiter.Wait(sz)
+			data = make([]byte, sz)
+			if _, err = io.ReadFull(downer, data); err != nil {
+				<-uploads
+				err = fmt.Errorf("part %d: %w", num, err)
+				continue
 			}
-			select {
-			case <-abort:
-				errs <- fmt.Errorf("aborted")
-				return
-			case c.concurrent <- 1:
-				defer func() {
-					<-c.concurrent
-				}()
+			_, _ = chk.Write(data)
+		}
+		go func(num int, data []byte) {
+			if data != nil {
+				defer func() { <-uploads }()
+				select {
+				case <-abort:
+					errs <- fmt.Errorf("aborted")
+					return
+				default:
+				}
+			} else {
+				if c.limiter != nil {
+					c.limiter.Wait(sz)
+				}
+				select {
+				case <-abort:
+					errs <- fmt.Errorf("aborted")
+					return
+				case c.concurrent <- 1:
+					defer func() {
+						<-c.concurrent
+					}()
+				}
 			}
 
-			data := make([]byte, sz)
 			if err := try(3, func() error {
-				in, err := src.Get(obj.Key(), int64(num)*partSize, sz)
-				if err != nil {
-					return err
-				}
-				defer in.Close()
-				if _, err = io.ReadFull(in, data); err != nil {
-					return err
+				if data == nil {
+					buf := make([]byte, sz)
+					in, err := src.Get(obj.Key(), int64(num)*partSize, sz)
+					if err != nil {
+						return err
+					}
+					defer in.Close()
+					if _, err = io.ReadFull(in, buf); err != nil {
+						return err
+					}
+					data = buf
 				}
 				// PartNumber starts from 1
+				var err error
 				parts[num], err = dst.UploadPart(objKey, upload.UploadID, num+1, data)
 				return err
 			}); err == nil {
-				errs <- nil
 				l.Info().Msgf("Copied data of %s part %d", obj.Key(), num)
+				errs <- nil
 			} else {
-				errs <- fmt.Errorf("part %d: %s", num, err)
 				l.Error().Err(err).Msgf("Copy data of %s part %d failed", obj.Key(), num)
+				errs <- fmt.Errorf("part %d: %w", num, err)
 			}
-			return
-		}(i)
+		}(num, data)
+		started++
 	}
 
-	var err error
-	for i := 0; i < n; i++ {
-		if err = <-errs; err != nil {
-			close(abort)
-			break
+	for err == nil && done < started {
+		err = <-errs
+		done++
+	}
+	if err != nil {
+		close(abort)
+		// 等待已开始的分片结束，避免放弃上传后仍有分片写入
+		for ; done < started; done++ {
+			<-errs
 		}
 	}
+	if err == nil && chk != nil {
+		err = chk.Verify()
+	}
 	if err == nil {
 		err = try(3, func() error { return dst.CompleteUpload(objKey, upload.UploadID, parts) })
 	}
 	if err != nil {
 		dst.AbortUpload(objKey, upload.UploadID)
-		return fmt.Errorf("multipart: %s", err)
+		return fmt.Errorf("multipart: %w", err)
 	}
 	return nil
 }
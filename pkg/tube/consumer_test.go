@@ -0,0 +1,459 @@
+package tube
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"obs-sync/infra/log"
+	"obs-sync/models"
+	"obs-sync/pkg/cloudstorage"
+	"obs-sync/pkg/object"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumerMem(t *testing.T) {
+	// 缩小分块大小，让小对象也走并发下载和分片上传
+	defer func(b int64, p int) { maxBlock, maxPartSize = b, p }(maxBlock, maxPartSize)
+	maxBlock, maxPartSize = 4<<10, 1<<10
+
+	newBucket := func(name, owner string) object.ObjectStorage {
+		s, err := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), name), owner, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	src, dst := newBucket("src", "alice"), newBucket("dst", "bob")
+
+	small := bytes.Repeat([]byte("0123456789"), 300)
+	big := bytes.Repeat([]byte("abcdefghij"), 1000)
+	meta := &object.Metadata{ContentType: "text/plain", UserMeta: map[string]string{"k": "v"}}
+	if err := src.Put("small", bytes.NewReader(small), models.PublicRead,
+		object.WithMetadata(meta), object.WithTags(map[string]string{"t": "1"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Put("big", bytes.NewReader(big), models.Default); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConsumer(log.DefaultLogger(), 4)
+	c.OpenChecksum(false)
+	c.OpenTagging()
+	c.SetACL(models.ACLPreserve, nil)
+	for _, key := range []string{"small", "big"} {
+		o, err := src.Head(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.Work(src, dst, o); err != nil {
+			t.Fatalf("copy %s: %s", key, err)
+		}
+	}
+
+	for key, want := range map[string][]byte{"small": small, "big": big} {
+		in, err := dst.Get(key, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(in)
+		if !bytes.Equal(data, want) {
+			t.Fatalf("data of %s: %d bytes", key, len(data))
+		}
+		srcSum, _ := src.(object.Checksummer).GetChecksum(key)
+		dstSum, _ := dst.(object.Checksummer).GetChecksum(key)
+		if dstSum != srcSum {
+			t.Fatalf("checksum of %s: %s != %s", key, dstSum, srcSum)
+		}
+	}
+	o, _ := dst.Head("small")
+	if info := o.(object.ObjectInfo).Metadata(); info == nil || info.UserMeta["k"] != "v" {
+		t.Fatalf("metadata: %+v", info)
+	}
+	if tags, _ := dst.(object.Tagger).GetTags("small"); tags["t"] != "1" {
+		t.Fatalf("tags: %v", tags)
+	}
+	acl, _ := dst.(object.ACLer).GetACL("small")
+	if canned, exact := acl.Canned(); canned != models.PublicRead || !exact || acl.Owner != "bob" {
+		t.Fatalf("acl: %s", acl)
+	}
+}
+
+// TestConsumerEncryption 复制到加密的目的端，再从加密的一端解密复制回来
+func TestConsumerEncryption(t *testing.T) {
+	defer func(b int64, p int) { maxBlock, maxPartSize = b, p }(maxBlock, maxPartSize)
+	maxBlock, maxPartSize = 4<<10, 1<<10
+
+	key, _ := object.NewEncryptionKey(bytes.Repeat([]byte{1}, 32))
+	newBucket := func(name string) object.ObjectStorage {
+		s, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), name), "", "")
+		if err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	src, raw, back := newBucket("src"), newBucket("dst"), newBucket("back")
+	dst := object.WithEncryption(raw, key)
+
+	data := map[string][]byte{
+		"small": bytes.Repeat([]byte("0123456789"), 300),
+		"big":   bytes.Repeat([]byte("abcdefghij"), 20000),
+	}
+	for k, v := range data {
+		if err := src.Put(k, bytes.NewReader(v), models.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewConsumer(log.DefaultLogger(), 4)
+	c.OpenChecksum(false)
+	for _, pair := range [][2]object.ObjectStorage{{src, dst}, {dst, back}} {
+		for k := range data {
+			o, err := pair[0].Head(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = c.Work(pair[0], pair[1], o); err != nil {
+				t.Fatalf("copy %s from %s: %s", k, pair[0], err)
+			}
+		}
+	}
+	for k, v := range data {
+		o, _ := raw.Head(k)
+		if o.Size() <= int64(len(v)) {
+			t.Fatalf("%s is not encrypted: %d bytes", k, o.Size())
+		}
+		in, err := back.Get(k, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := ioutil.ReadAll(in)
+		if !bytes.Equal(got, v) {
+			t.Fatalf("data of %s: %d bytes", k, len(got))
+		}
+	}
+}
+
+// TestConsumerChecksum 分片上传时源端没有同算法的校验值，校验通过但无法写入，以 Warning 报告
+func TestConsumerChecksum(t *testing.T) {
+	defer func(b int64, p int) { maxBlock, maxPartSize = b, p }(maxBlock, maxPartSize)
+	maxBlock, maxPartSize = 4<<10, 1<<10
+
+	src, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), "src"), "", "")
+	dst, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), "dst"), "", "")
+	src.Create()
+	dst.Create()
+	small := bytes.Repeat([]byte("0123456789"), 300)
+	big := bytes.Repeat([]byte("abcdefghij"), 1000)
+	src.Put("small", bytes.NewReader(small), models.Default)
+	src.Put("big", bytes.NewReader(big), models.Default)
+
+	c := NewConsumer(log.DefaultLogger(), 4)
+	c.OpenChecksum(true) // 源端是 crc32c
+	o, _ := src.Head("small")
+	if err := c.Work(src, dst, o); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(small)
+	if v, _ := dst.(object.Checksummer).GetChecksum("small"); v != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("checksum of small: %s", v)
+	}
+
+	o, _ = src.Head("big")
+	err := c.Work(src, dst, o)
+	var w *Warning
+	if !errors.As(err, &w) || !strings.Contains(w.Error(), "not stored") {
+		t.Fatalf("copy big: %v", err)
+	}
+	if o, err = dst.Head("big"); err != nil || o.Size() != int64(len(big)) {
+		t.Fatalf("head big: %v %v", o, err)
+	}
+}
+
+// TestConsumerTags 超出限制的标签在上传前去掉，以 Warning 报告，其余标签照常写入
+func TestConsumerTags(t *testing.T) {
+	src, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), "src"), "", "")
+	dst, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), "dst"), "", "")
+	src.Create()
+	dst.Create()
+	src.Put("a", strings.NewReader("hello"), models.Default, object.WithTags(map[string]string{"ok": "1", "bad|key": "2"}))
+
+	c := NewConsumer(log.DefaultLogger(), 4)
+	c.OpenTagging()
+	o, _ := src.Head("a")
+	err := c.Work(src, dst, o)
+	var w *Warning
+	if !errors.As(err, &w) || !strings.Contains(w.Error(), "bad|key") {
+		t.Fatalf("copy a: %v", err)
+	}
+	if tags, _ := dst.(object.Tagger).GetTags("a"); len(tags) != 1 || tags["ok"] != "1" {
+		t.Fatalf("tags: %v", tags)
+	}
+
+	// 目的端不支持标签
+	file, _ := object.CreateStorage(models.File, t.TempDir()+"/", "", "")
+	err = c.Work(src, object.WithChaos(file, &object.ChaosConfig{}), o)
+	if !errors.As(err, &w) || !strings.Contains(w.Error(), "does not support tagging") {
+		t.Fatalf("copy a to file: %v", err)
+	}
+}
+
+// counted 记录写入和分片上传的次数，包括失败的调用
+type counted struct {
+	object.ObjectStorage
+	puts, uploads int32
+}
+
+func (c *counted) Put(key string, in io.Reader, acl models.CannedACLType, opts ...object.PutOption) error {
+	atomic.AddInt32(&c.puts, 1)
+	return c.ObjectStorage.Put(key, in, acl, opts...)
+}
+
+func (c *counted) CreateMultipartUpload(key string, minSize int, acl models.CannedACLType, opts ...object.PutOption) (*object.MultipartUpload, error) {
+	atomic.AddInt32(&c.uploads, 1)
+	return c.ObjectStorage.CreateMultipartUpload(key, minSize, acl, opts...)
+}
+
+// TestConsumerChaos 在 chaos+mem 上复制：偶发的错误重试后成功，持续的错误报告为失败，
+// 分片上传失败时放弃已创建的上传
+func TestConsumerChaos(t *testing.T) {
+	defer func(b int64, p int, d time.Duration) { maxBlock, maxPartSize, retryDelay = b, p, d }(maxBlock, maxPartSize, retryDelay)
+	maxBlock, maxPartSize, retryDelay = 4<<10, 1<<10, time.Millisecond
+
+	newBucket := func(name, chaos string) object.ObjectStorage {
+		info := models.UriInfo{Type: models.Mem, BucketDomain: object.MemBucketURL(t.Name(), name)}
+		if chaos != "" {
+			info.Type = models.ResourceType(object.ChaosPrefix + string(models.Mem))
+			info.BucketDomain += "#" + chaos
+		}
+		s, err := cloudstorage.CreateStorage(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	src := newBucket("src", "")
+	var keys []string
+	for i := 0; i < 8; i++ {
+		keys = append(keys, fmt.Sprint("small", i))
+		src.Put(keys[i], strings.NewReader(strings.Repeat(keys[i], 100)), models.Default)
+	}
+	big := bytes.Repeat([]byte("abcdefghij"), 1000)
+	src.Put("big", bytes.NewReader(big), models.Default)
+	c := NewConsumer(log.DefaultLogger(), 4)
+
+	// 部分写入被限流，重试后全部成功
+	dst := &counted{ObjectStorage: newBucket("dst", "throttle=0.3&ops=put&seed=3")}
+	for _, key := range keys {
+		o, _ := src.Head(key)
+		if err := c.Work(src, dst, o); err != nil {
+			t.Fatalf("copy %s: %v", key, err)
+		}
+		in, err := dst.Get(key, 0, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(in)
+		if string(data) != strings.Repeat(key, 100) {
+			t.Fatalf("data of %s: %q", key, data)
+		}
+	}
+	if dst.puts <= int32(len(keys)) {
+		t.Fatalf("%d puts for %d objects, no retries", dst.puts, len(keys))
+	}
+
+	// 每次写入都失败
+	failing := newBucket("failing", "error=1&ops=put")
+	o, _ := src.Head(keys[0])
+	var injected *object.ChaosError
+	if err := c.Work(src, failing, o); !errors.As(err, &injected) || injected.Throttled {
+		t.Fatalf("copy to a failing bucket: %v", err)
+	}
+	if _, err := failing.Head(keys[0]); err == nil {
+		t.Fatal("object written to a failing bucket")
+	}
+
+	// 源端读取总是中断，分片上传失败后放弃
+	truncated := newBucket("src", "truncate=1&ops=get")
+	dst = &counted{ObjectStorage: newBucket("dst-big", "")}
+	o, _ = src.Head("big")
+	if err := c.Work(truncated, dst, o); err == nil || !strings.Contains(err.Error(), "multipart") {
+		t.Fatalf("copy big from a truncating source: %v", err)
+	}
+	if dst.uploads != 1 {
+		t.Fatalf("%d uploads created", dst.uploads)
+	}
+	if pending, _, err := dst.ListUploads(""); err != nil || len(pending) != 0 {
+		t.Fatalf("pending uploads: %v %v", pending, err)
+	}
+	if _, err := dst.Head("big"); err == nil {
+		t.Fatal("big written from a truncating source")
+	}
+}
+
+// publicBucket 源端桶为公共读
+type publicBucket struct {
+	object.ObjectStorage
+}
+
+func (p publicBucket) GetBucketACL() (*object.ACL, error) {
+	return object.CannedACL("alice", models.PublicRead), nil
+}
+
+func (p publicBucket) GetACL(key string) (*object.ACL, error) {
+	return p.ObjectStorage.(object.ACLer).GetACL(key)
+}
+
+func (p publicBucket) SetACL(key string, acl *object.ACL) error {
+	return p.ObjectStorage.(object.ACLer).SetACL(key, acl)
+}
+
+// TestConsumerACL 公共读的桶中的私有对象复制后仍为私有
+func TestConsumerACL(t *testing.T) {
+	newBucket := func(name, owner string) object.ObjectStorage {
+		s, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), name), owner, "")
+		s.Create()
+		return s
+	}
+	src := publicBucket{newBucket("src", "alice")}
+	src.Put("private", strings.NewReader("p"), models.Private)
+	src.Put("public", strings.NewReader("p"), models.PublicRead)
+
+	for _, mode := range []string{"", models.ACLPreserve, models.ACLBucket} {
+		dst := newBucket("dst-"+mode, "bob")
+		c := NewConsumer(log.DefaultLogger(), 4)
+		c.SetACL(mode, nil)
+		for _, key := range []string{"private", "public"} {
+			o, _ := src.Head(key)
+			if err := c.Work(src, dst, o); err != nil {
+				t.Fatalf("%q: copy %s: %v", mode, key, err)
+			}
+		}
+		acl, _ := dst.(object.ACLer).GetACL("private")
+		if canned, _ := acl.Canned(); canned != models.Private {
+			t.Fatalf("%q: acl of the private object: %s", mode, acl)
+		}
+	}
+}
+
+// versioned 记录每次写入和删除的多版本存储，版本的数据保存在内存中
+type versioned struct {
+	object.ObjectStorage
+	sync.Mutex
+	vs   []object.Version // 从旧到新
+	data map[string][]byte
+}
+
+func newVersioned(s object.ObjectStorage) *versioned {
+	return &versioned{ObjectStorage: s, data: map[string][]byte{}}
+}
+
+func (v *versioned) add(key string, data []byte, deleteMarker bool) {
+	v.Lock()
+	defer v.Unlock()
+	id := fmt.Sprint("v", len(v.vs)+1)
+	v.vs = append(v.vs, object.UnmarshalObject(map[string]interface{}{
+		"key": key, "size": int64(len(data)), "mtime": time.Now().UnixNano(), "isdir": false,
+		"versionId": id, "deleteMarker": deleteMarker,
+	}).(object.Version))
+	v.data[id] = data
+}
+
+func (v *versioned) Put(key string, in io.Reader, acl models.CannedACLType, opts ...object.PutOption) error {
+	data, err := ioutil.ReadAll(in)
+	if err == nil {
+		err = v.ObjectStorage.Put(key, bytes.NewReader(data), acl, opts...)
+	}
+	if err == nil {
+		v.add(key, data, false)
+	}
+	return err
+}
+
+func (v *versioned) Delete(key string) error {
+	v.add(key, nil, true)
+	return v.ObjectStorage.Delete(key)
+}
+
+func (v *versioned) ListVersions(prefix, keyMarker, versionMarker string, limit int64) ([]object.Version, string, string, error) {
+	v.Lock()
+	defer v.Unlock()
+	var vs []object.Version
+	for i := len(v.vs) - 1; i >= 0; i-- {
+		if strings.HasPrefix(v.vs[i].Key(), prefix) {
+			vs = append(vs, v.vs[i])
+		}
+	}
+	sort.SliceStable(vs, func(i, j int) bool { return vs[i].Key() < vs[j].Key() })
+	return vs, "", "", nil
+}
+
+func (v *versioned) GetVersion(key, versionID string, off, limit int64) (io.ReadCloser, error) {
+	v.Lock()
+	defer v.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(v.data[versionID])), nil
+}
+
+// TestConsumerReplay 再次重放时跳过目的端已有的版本，只重放源端新增的版本
+func TestConsumerReplay(t *testing.T) {
+	newBucket := func(name string) *versioned {
+		s, _ := object.CreateStorage(models.Mem, object.MemBucketURL(t.Name(), name), "", "")
+		s.Create()
+		return newVersioned(s)
+	}
+	src, dst := newBucket("src"), newBucket("dst")
+	src.Put("a", strings.NewReader("1"), models.Default)
+	src.Put("a", strings.NewReader("22"), models.Default)
+	src.Delete("a")
+	src.Put("ab", strings.NewReader("x"), models.Default)
+	versionsOf := func(s *versioned, key string) []object.Version {
+		vs, _, _, _ := s.ListVersions(key, "", "", 100)
+		var res []object.Version
+		for i := len(vs) - 1; i >= 0; i-- {
+			if vs[i].Key() == key {
+				res = append(res, vs[i])
+			}
+		}
+		return res
+	}
+
+	c := NewConsumer(log.DefaultLogger(), 4)
+	for i := 0; i < 2; i++ {
+		for _, key := range []string{"a", "ab"} {
+			if err := c.Replay(src, dst, versionsOf(src, key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := len(dst.vs); n != 4 {
+			t.Fatalf("run %d: %d versions replayed", i+1, n)
+		}
+	}
+	src.Put("a", strings.NewReader("333"), models.Default)
+	if err := c.Replay(src, dst, versionsOf(src, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if vs := versionsOf(dst, "a"); len(vs) != 4 || vs[3].Size() != 3 {
+		t.Fatalf("versions after a new version: %v", vs)
+	}
+	// 目的端的版本与源端不对应时全部重放
+	dst.Put("ab", strings.NewReader("changed"), models.Default)
+	if err := c.Replay(src, dst, versionsOf(src, "ab")); err != nil {
+		t.Fatal(err)
+	}
+	if vs := versionsOf(dst, "ab"); len(vs) != 3 {
+		t.Fatalf("versions of a changed object: %v", vs)
+	}
+}
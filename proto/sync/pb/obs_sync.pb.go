@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.0
+// 	protoc-gen-go v1.33.0
 // 	protoc        v3.19.4
 // source: obs_sync.proto
 
@@ -27,6 +27,7 @@ type DataRequest struct {
 	unknownFields protoimpl.UnknownFields
 
 	Sign string `protobuf:"bytes,1,opt,name=sign,proto3" json:"sign,omitempty"`
+	Host string `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
 }
 
 func (x *DataRequest) Reset() {
@@ -68,6 +69,13 @@ func (x *DataRequest) GetSign() string {
 	return ""
 }
 
+func (x *DataRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
 type UriInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -152,10 +160,13 @@ type Object struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Size  int64  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
-	Mtime int64  `protobuf:"varint,3,opt,name=mtime,proto3" json:"mtime,omitempty"`
-	IsDir bool   `protobuf:"varint,4,opt,name=isDir,proto3" json:"isDir,omitempty"`
+	Key          string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Size         int64  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Mtime        int64  `protobuf:"varint,3,opt,name=mtime,proto3" json:"mtime,omitempty"`
+	IsDir        bool   `protobuf:"varint,4,opt,name=isDir,proto3" json:"isDir,omitempty"`
+	StorageClass string `protobuf:"bytes,5,opt,name=storageClass,proto3" json:"storageClass,omitempty"`
+	// 多版本复制时按写入顺序排列的版本
+	Versions []*Version `protobuf:"bytes,6,rep,name=versions,proto3" json:"versions,omitempty"`
 }
 
 func (x *Object) Reset() {
@@ -218,21 +229,282 @@ func (x *Object) GetIsDir() bool {
 	return false
 }
 
+func (x *Object) GetStorageClass() string {
+	if x != nil {
+		return x.StorageClass
+	}
+	return ""
+}
+
+func (x *Object) GetVersions() []*Version {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type Version struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VersionId    string `protobuf:"bytes,1,opt,name=versionId,proto3" json:"versionId,omitempty"`
+	Size         int64  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Mtime        int64  `protobuf:"varint,3,opt,name=mtime,proto3" json:"mtime,omitempty"`
+	DeleteMarker bool   `protobuf:"varint,4,opt,name=deleteMarker,proto3" json:"deleteMarker,omitempty"`
+	StorageClass string `protobuf:"bytes,5,opt,name=storageClass,proto3" json:"storageClass,omitempty"`
+}
+
+func (x *Version) Reset() {
+	*x = Version{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Version) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Version) ProtoMessage() {}
+
+func (x *Version) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Version.ProtoReflect.Descriptor instead.
+func (*Version) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Version) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *Version) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Version) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
+
+func (x *Version) GetDeleteMarker() bool {
+	if x != nil {
+		return x.DeleteMarker
+	}
+	return false
+}
+
+func (x *Version) GetStorageClass() string {
+	if x != nil {
+		return x.StorageClass
+	}
+	return ""
+}
+
+type TaskConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SetObjectMetaMD5      bool              `protobuf:"varint,1,opt,name=setObjectMetaMD5,proto3" json:"setObjectMetaMD5,omitempty"`
+	SrcMD5Header          string            `protobuf:"bytes,2,opt,name=srcMD5Header,proto3" json:"srcMD5Header,omitempty"`
+	PreserveMetadata      bool              `protobuf:"varint,3,opt,name=preserveMetadata,proto3" json:"preserveMetadata,omitempty"`
+	StorageClass          string            `protobuf:"bytes,4,opt,name=storageClass,proto3" json:"storageClass,omitempty"`
+	RestoreDays           int32             `protobuf:"varint,5,opt,name=restoreDays,proto3" json:"restoreDays,omitempty"`
+	CopyTags              bool              `protobuf:"varint,6,opt,name=copyTags,proto3" json:"copyTags,omitempty"`
+	VersionMode           string            `protobuf:"bytes,7,opt,name=versionMode,proto3" json:"versionMode,omitempty"`
+	VersionsAsOf          int64             `protobuf:"varint,8,opt,name=versionsAsOf,proto3" json:"versionsAsOf,omitempty"`
+	AclMode               string            `protobuf:"bytes,9,opt,name=aclMode,proto3" json:"aclMode,omitempty"`
+	AclAccounts           map[string]string `protobuf:"bytes,10,rep,name=aclAccounts,proto3" json:"aclAccounts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	EncryptKeyFile        string            `protobuf:"bytes,11,opt,name=encryptKeyFile,proto3" json:"encryptKeyFile,omitempty"`
+	DecryptKeyFile        string            `protobuf:"bytes,12,opt,name=decryptKeyFile,proto3" json:"decryptKeyFile,omitempty"`
+	Sse                   string            `protobuf:"bytes,13,opt,name=sse,proto3" json:"sse,omitempty"`
+	SseKmsKeyId           string            `protobuf:"bytes,14,opt,name=sseKmsKeyId,proto3" json:"sseKmsKeyId,omitempty"`
+	SseCustomerKeyFile    string            `protobuf:"bytes,15,opt,name=sseCustomerKeyFile,proto3" json:"sseCustomerKeyFile,omitempty"`
+	SrcSseCustomerKeyFile string            `protobuf:"bytes,16,opt,name=srcSseCustomerKeyFile,proto3" json:"srcSseCustomerKeyFile,omitempty"`
+}
+
+func (x *TaskConfig) Reset() {
+	*x = TaskConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaskConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskConfig) ProtoMessage() {}
+
+func (x *TaskConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskConfig.ProtoReflect.Descriptor instead.
+func (*TaskConfig) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TaskConfig) GetSetObjectMetaMD5() bool {
+	if x != nil {
+		return x.SetObjectMetaMD5
+	}
+	return false
+}
+
+func (x *TaskConfig) GetSrcMD5Header() string {
+	if x != nil {
+		return x.SrcMD5Header
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetPreserveMetadata() bool {
+	if x != nil {
+		return x.PreserveMetadata
+	}
+	return false
+}
+
+func (x *TaskConfig) GetStorageClass() string {
+	if x != nil {
+		return x.StorageClass
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetRestoreDays() int32 {
+	if x != nil {
+		return x.RestoreDays
+	}
+	return 0
+}
+
+func (x *TaskConfig) GetCopyTags() bool {
+	if x != nil {
+		return x.CopyTags
+	}
+	return false
+}
+
+func (x *TaskConfig) GetVersionMode() string {
+	if x != nil {
+		return x.VersionMode
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetVersionsAsOf() int64 {
+	if x != nil {
+		return x.VersionsAsOf
+	}
+	return 0
+}
+
+func (x *TaskConfig) GetAclMode() string {
+	if x != nil {
+		return x.AclMode
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetAclAccounts() map[string]string {
+	if x != nil {
+		return x.AclAccounts
+	}
+	return nil
+}
+
+func (x *TaskConfig) GetEncryptKeyFile() string {
+	if x != nil {
+		return x.EncryptKeyFile
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetDecryptKeyFile() string {
+	if x != nil {
+		return x.DecryptKeyFile
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetSse() string {
+	if x != nil {
+		return x.Sse
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetSseKmsKeyId() string {
+	if x != nil {
+		return x.SseKmsKeyId
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetSseCustomerKeyFile() string {
+	if x != nil {
+		return x.SseCustomerKeyFile
+	}
+	return ""
+}
+
+func (x *TaskConfig) GetSrcSseCustomerKeyFile() string {
+	if x != nil {
+		return x.SrcSseCustomerKeyFile
+	}
+	return ""
+}
+
 type TaskInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	BucketName string    `protobuf:"bytes,1,opt,name=bucketName,proto3" json:"bucketName,omitempty"`
-	SrcUri     *UriInfo  `protobuf:"bytes,2,opt,name=srcUri,proto3" json:"srcUri,omitempty"`
-	DestUri    *UriInfo  `protobuf:"bytes,3,opt,name=destUri,proto3" json:"destUri,omitempty"`
-	Objects    []*Object `protobuf:"bytes,4,rep,name=objects,proto3" json:"objects,omitempty"`
+	BucketName string      `protobuf:"bytes,1,opt,name=bucketName,proto3" json:"bucketName,omitempty"`
+	SrcUri     *UriInfo    `protobuf:"bytes,2,opt,name=srcUri,proto3" json:"srcUri,omitempty"`
+	DestUri    *UriInfo    `protobuf:"bytes,3,opt,name=destUri,proto3" json:"destUri,omitempty"`
+	Objects    []*Object   `protobuf:"bytes,4,rep,name=objects,proto3" json:"objects,omitempty"`
+	Config     *TaskConfig `protobuf:"bytes,5,opt,name=config,proto3" json:"config,omitempty"`
 }
 
 func (x *TaskInfo) Reset() {
 	*x = TaskInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[3]
+		mi := &file_obs_sync_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -245,7 +517,7 @@ func (x *TaskInfo) String() string {
 func (*TaskInfo) ProtoMessage() {}
 
 func (x *TaskInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[3]
+	mi := &file_obs_sync_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -258,7 +530,7 @@ func (x *TaskInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TaskInfo.ProtoReflect.Descriptor instead.
 func (*TaskInfo) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{3}
+	return file_obs_sync_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TaskInfo) GetBucketName() string {
@@ -289,6 +561,13 @@ func (x *TaskInfo) GetObjects() []*Object {
 	return nil
 }
 
+func (x *TaskInfo) GetConfig() *TaskConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
 type DataResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -300,7 +579,7 @@ type DataResponse struct {
 func (x *DataResponse) Reset() {
 	*x = DataResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[4]
+		mi := &file_obs_sync_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -313,7 +592,7 @@ func (x *DataResponse) String() string {
 func (*DataResponse) ProtoMessage() {}
 
 func (x *DataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[4]
+	mi := &file_obs_sync_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -326,7 +605,7 @@ func (x *DataResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DataResponse.ProtoReflect.Descriptor instead.
 func (*DataResponse) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{4}
+	return file_obs_sync_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DataResponse) GetTask() *TaskInfo {
@@ -347,12 +626,16 @@ type Result struct {
 	Success    []string `protobuf:"bytes,3,rep,name=success,proto3" json:"success,omitempty"`
 	Failed     []string `protobuf:"bytes,4,rep,name=failed,proto3" json:"failed,omitempty"`
 	DeadlSize  int64    `protobuf:"varint,5,opt,name=deadlSize,proto3" json:"deadlSize,omitempty"`
+	// 归档对象已发起取回，由服务端等待取回完成后重新下发
+	Restoring *TaskInfo `protobuf:"bytes,6,opt,name=restoring,proto3" json:"restoring,omitempty"`
+	// 已复制成功但部分属性（如标签）未能写入目的端的对象
+	Warnings []string `protobuf:"bytes,7,rep,name=warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (x *Result) Reset() {
 	*x = Result{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[5]
+		mi := &file_obs_sync_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -365,7 +648,7 @@ func (x *Result) String() string {
 func (*Result) ProtoMessage() {}
 
 func (x *Result) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[5]
+	mi := &file_obs_sync_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -378,7 +661,7 @@ func (x *Result) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Result.ProtoReflect.Descriptor instead.
 func (*Result) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{5}
+	return file_obs_sync_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Result) GetBucketName() string {
@@ -416,6 +699,20 @@ func (x *Result) GetDeadlSize() int64 {
 	return 0
 }
 
+func (x *Result) GetRestoring() *TaskInfo {
+	if x != nil {
+		return x.Restoring
+	}
+	return nil
+}
+
+func (x *Result) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
 type Replay struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -427,7 +724,7 @@ type Replay struct {
 func (x *Replay) Reset() {
 	*x = Replay{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[6]
+		mi := &file_obs_sync_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -440,7 +737,7 @@ func (x *Replay) String() string {
 func (*Replay) ProtoMessage() {}
 
 func (x *Replay) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[6]
+	mi := &file_obs_sync_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -453,7 +750,7 @@ func (x *Replay) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Replay.ProtoReflect.Descriptor instead.
 func (*Replay) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{6}
+	return file_obs_sync_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Replay) GetStatus() string {
@@ -463,7 +760,7 @@ func (x *Replay) GetStatus() string {
 	return ""
 }
 
-//HasMore
+// HasMore
 type Empty struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -473,7 +770,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[7]
+		mi := &file_obs_sync_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -486,7 +783,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[7]
+	mi := &file_obs_sync_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -499,7 +796,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{7}
+	return file_obs_sync_proto_rawDescGZIP(), []int{9}
 }
 
 type HasMoreReplay struct {
@@ -513,7 +810,7 @@ type HasMoreReplay struct {
 func (x *HasMoreReplay) Reset() {
 	*x = HasMoreReplay{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[8]
+		mi := &file_obs_sync_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -526,7 +823,7 @@ func (x *HasMoreReplay) String() string {
 func (*HasMoreReplay) ProtoMessage() {}
 
 func (x *HasMoreReplay) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[8]
+	mi := &file_obs_sync_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -539,45 +836,430 @@ func (x *HasMoreReplay) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HasMoreReplay.ProtoReflect.Descriptor instead.
 func (*HasMoreReplay) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{8}
+	return file_obs_sync_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HasMoreReplay) GetHas() bool {
+	if x != nil {
+		return x.Has
+	}
+	return false
+}
+
+// Sync
+type Auth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Region    string `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	AccessKey string `protobuf:"bytes,3,opt,name=accessKey,proto3" json:"accessKey,omitempty"`
+	SecretKey string `protobuf:"bytes,4,opt,name=secretKey,proto3" json:"secretKey,omitempty"`
+}
+
+func (x *Auth) Reset() {
+	*x = Auth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Auth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Auth) ProtoMessage() {}
+
+func (x *Auth) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Auth.ProtoReflect.Descriptor instead.
+func (*Auth) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Auth) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Auth) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Auth) GetAccessKey() string {
+	if x != nil {
+		return x.AccessKey
+	}
+	return ""
+}
+
+func (x *Auth) GetSecretKey() string {
+	if x != nil {
+		return x.SecretKey
+	}
+	return ""
+}
+
+type SyncInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Src    *Auth       `protobuf:"bytes,1,opt,name=src,proto3" json:"src,omitempty"`
+	Dest   *Auth       `protobuf:"bytes,2,opt,name=dest,proto3" json:"dest,omitempty"`
+	Config *TaskConfig `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	// 按审核过的计划执行，使用计划中的桶而不重新匹配
+	Plan    []*PlanBucket  `protobuf:"bytes,4,rep,name=plan,proto3" json:"plan,omitempty"`
+	Mapping *BucketMapping `protobuf:"bytes,5,opt,name=mapping,proto3" json:"mapping,omitempty"`
+	// 选择迁移的桶名或通配符，为空则全部迁移
+	Include []string `protobuf:"bytes,6,rep,name=include,proto3" json:"include,omitempty"`
+	Exclude []string `protobuf:"bytes,7,rep,name=exclude,proto3" json:"exclude,omitempty"`
+}
+
+func (x *SyncInfo) Reset() {
+	*x = SyncInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncInfo) ProtoMessage() {}
+
+func (x *SyncInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncInfo.ProtoReflect.Descriptor instead.
+func (*SyncInfo) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SyncInfo) GetSrc() *Auth {
+	if x != nil {
+		return x.Src
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetDest() *Auth {
+	if x != nil {
+		return x.Dest
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetConfig() *TaskConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetPlan() []*PlanBucket {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetMapping() *BucketMapping {
+	if x != nil {
+		return x.Mapping
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+// 源端桶名到目的端桶名的映射，pairs 优先，否则依次去掉前后缀、正则替换、添加前后缀
+type BucketMapping struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pairs       map[string]string `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	StripPrefix string            `protobuf:"bytes,2,opt,name=stripPrefix,proto3" json:"stripPrefix,omitempty"`
+	StripSuffix string            `protobuf:"bytes,3,opt,name=stripSuffix,proto3" json:"stripSuffix,omitempty"`
+	Regex       string            `protobuf:"bytes,4,opt,name=regex,proto3" json:"regex,omitempty"`
+	Replace     string            `protobuf:"bytes,5,opt,name=replace,proto3" json:"replace,omitempty"`
+	AddPrefix   string            `protobuf:"bytes,6,opt,name=addPrefix,proto3" json:"addPrefix,omitempty"`
+	AddSuffix   string            `protobuf:"bytes,7,opt,name=addSuffix,proto3" json:"addSuffix,omitempty"`
+}
+
+func (x *BucketMapping) Reset() {
+	*x = BucketMapping{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BucketMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BucketMapping) ProtoMessage() {}
+
+func (x *BucketMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BucketMapping.ProtoReflect.Descriptor instead.
+func (*BucketMapping) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BucketMapping) GetPairs() map[string]string {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *BucketMapping) GetStripPrefix() string {
+	if x != nil {
+		return x.StripPrefix
+	}
+	return ""
+}
+
+func (x *BucketMapping) GetStripSuffix() string {
+	if x != nil {
+		return x.StripSuffix
+	}
+	return ""
+}
+
+func (x *BucketMapping) GetRegex() string {
+	if x != nil {
+		return x.Regex
+	}
+	return ""
+}
+
+func (x *BucketMapping) GetReplace() string {
+	if x != nil {
+		return x.Replace
+	}
+	return ""
+}
+
+func (x *BucketMapping) GetAddPrefix() string {
+	if x != nil {
+		return x.AddPrefix
+	}
+	return ""
+}
+
+func (x *BucketMapping) GetAddSuffix() string {
+	if x != nil {
+		return x.AddSuffix
+	}
+	return ""
+}
+
+type SyncReplay struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  string            `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Buckets []*SyncReplay_Row `protobuf:"bytes,2,rep,name=Buckets,proto3" json:"Buckets,omitempty"`
+}
+
+func (x *SyncReplay) Reset() {
+	*x = SyncReplay{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncReplay) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncReplay) ProtoMessage() {}
+
+func (x *SyncReplay) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncReplay.ProtoReflect.Descriptor instead.
+func (*SyncReplay) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SyncReplay) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SyncReplay) GetBuckets() []*SyncReplay_Row {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+// Plan
+type PlanCount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Size  int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+}
+
+func (x *PlanCount) Reset() {
+	*x = PlanCount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_obs_sync_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlanCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanCount) ProtoMessage() {}
+
+func (x *PlanCount) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanCount.ProtoReflect.Descriptor instead.
+func (*PlanCount) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PlanCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
 }
 
-func (x *HasMoreReplay) GetHas() bool {
+func (x *PlanCount) GetSize() int64 {
 	if x != nil {
-		return x.Has
+		return x.Size
 	}
-	return false
+	return 0
 }
 
-//Sync
-type Auth struct {
+type PlanBucket struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type      string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
-	Region    string `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
-	AccessKey string `protobuf:"bytes,3,opt,name=accessKey,proto3" json:"accessKey,omitempty"`
-	SecretKey string `protobuf:"bytes,4,opt,name=secretKey,proto3" json:"secretKey,omitempty"`
-}
-
-func (x *Auth) Reset() {
-	*x = Auth{}
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	SrcBucket   string `protobuf:"bytes,2,opt,name=srcBucket,proto3" json:"srcBucket,omitempty"`
+	Orientation int32  `protobuf:"varint,3,opt,name=orientation,proto3" json:"orientation,omitempty"`
+	DestBucket  string `protobuf:"bytes,4,opt,name=destBucket,proto3" json:"destBucket,omitempty"`
+	// 复制方向上的目的桶不存在，执行时会创建
+	Create bool       `protobuf:"varint,5,opt,name=create,proto3" json:"create,omitempty"`
+	Copy   *PlanCount `protobuf:"bytes,6,opt,name=copy,proto3" json:"copy,omitempty"`
+	Skip   *PlanCount `protobuf:"bytes,7,opt,name=skip,proto3" json:"skip,omitempty"`
+	// 只在目的端存在的对象，执行时不删除，保留在目的端
+	Extra *PlanCount `protobuf:"bytes,8,opt,name=extra,proto3" json:"extra,omitempty"`
+	// 两端都有但大小不同的对象，执行时保留目的端
+	Conflict *PlanCount `protobuf:"bytes,9,opt,name=conflict,proto3" json:"conflict,omitempty"`
+	Error    string     `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	DestName string     `protobuf:"bytes,11,opt,name=destName,proto3" json:"destName,omitempty"`
+}
+
+func (x *PlanBucket) Reset() {
+	*x = PlanBucket{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[9]
+		mi := &file_obs_sync_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Auth) String() string {
+func (x *PlanBucket) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Auth) ProtoMessage() {}
+func (*PlanBucket) ProtoMessage() {}
 
-func (x *Auth) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[9]
+func (x *PlanBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -588,120 +1270,113 @@ func (x *Auth) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Auth.ProtoReflect.Descriptor instead.
-func (*Auth) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use PlanBucket.ProtoReflect.Descriptor instead.
+func (*PlanBucket) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *Auth) GetType() string {
+func (x *PlanBucket) GetName() string {
 	if x != nil {
-		return x.Type
+		return x.Name
 	}
 	return ""
 }
 
-func (x *Auth) GetRegion() string {
+func (x *PlanBucket) GetSrcBucket() string {
 	if x != nil {
-		return x.Region
+		return x.SrcBucket
 	}
 	return ""
 }
 
-func (x *Auth) GetAccessKey() string {
+func (x *PlanBucket) GetOrientation() int32 {
 	if x != nil {
-		return x.AccessKey
+		return x.Orientation
 	}
-	return ""
+	return 0
 }
 
-func (x *Auth) GetSecretKey() string {
+func (x *PlanBucket) GetDestBucket() string {
 	if x != nil {
-		return x.SecretKey
+		return x.DestBucket
 	}
 	return ""
 }
 
-type SyncInfo struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Src  *Auth `protobuf:"bytes,1,opt,name=src,proto3" json:"src,omitempty"`
-	Dest *Auth `protobuf:"bytes,2,opt,name=dest,proto3" json:"dest,omitempty"`
+func (x *PlanBucket) GetCreate() bool {
+	if x != nil {
+		return x.Create
+	}
+	return false
 }
 
-func (x *SyncInfo) Reset() {
-	*x = SyncInfo{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[10]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *PlanBucket) GetCopy() *PlanCount {
+	if x != nil {
+		return x.Copy
 	}
+	return nil
 }
 
-func (x *SyncInfo) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *PlanBucket) GetSkip() *PlanCount {
+	if x != nil {
+		return x.Skip
+	}
+	return nil
 }
 
-func (*SyncInfo) ProtoMessage() {}
-
-func (x *SyncInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[10]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *PlanBucket) GetExtra() *PlanCount {
+	if x != nil {
+		return x.Extra
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use SyncInfo.ProtoReflect.Descriptor instead.
-func (*SyncInfo) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{10}
+func (x *PlanBucket) GetConflict() *PlanCount {
+	if x != nil {
+		return x.Conflict
+	}
+	return nil
 }
 
-func (x *SyncInfo) GetSrc() *Auth {
+func (x *PlanBucket) GetError() string {
 	if x != nil {
-		return x.Src
+		return x.Error
 	}
-	return nil
+	return ""
 }
 
-func (x *SyncInfo) GetDest() *Auth {
+func (x *PlanBucket) GetDestName() string {
 	if x != nil {
-		return x.Dest
+		return x.DestName
 	}
-	return nil
+	return ""
 }
 
-type SyncReplay struct {
+type PlanReplay struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  string            `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Buckets []*SyncReplay_Row `protobuf:"bytes,2,rep,name=Buckets,proto3" json:"Buckets,omitempty"`
+	Buckets []*PlanBucket `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
 }
 
-func (x *SyncReplay) Reset() {
-	*x = SyncReplay{}
+func (x *PlanReplay) Reset() {
+	*x = PlanReplay{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[11]
+		mi := &file_obs_sync_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SyncReplay) String() string {
+func (x *PlanReplay) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SyncReplay) ProtoMessage() {}
+func (*PlanReplay) ProtoMessage() {}
 
-func (x *SyncReplay) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[11]
+func (x *PlanReplay) ProtoReflect() protoreflect.Message {
+	mi := &file_obs_sync_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -712,26 +1387,18 @@ func (x *SyncReplay) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SyncReplay.ProtoReflect.Descriptor instead.
-func (*SyncReplay) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{11}
-}
-
-func (x *SyncReplay) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
+// Deprecated: Use PlanReplay.ProtoReflect.Descriptor instead.
+func (*PlanReplay) Descriptor() ([]byte, []int) {
+	return file_obs_sync_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *SyncReplay) GetBuckets() []*SyncReplay_Row {
+func (x *PlanReplay) GetBuckets() []*PlanBucket {
 	if x != nil {
 		return x.Buckets
 	}
 	return nil
 }
 
-//
 type Value struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -743,12 +1410,13 @@ type Value struct {
 	Failed     int64 `protobuf:"varint,4,opt,name=Failed,proto3" json:"Failed,omitempty"`
 	Size       int64 `protobuf:"varint,5,opt,name=Size,proto3" json:"Size,omitempty"`
 	FinishFlag bool  `protobuf:"varint,6,opt,name=FinishFlag,proto3" json:"FinishFlag,omitempty"`
+	Restoring  int64 `protobuf:"varint,7,opt,name=Restoring,proto3" json:"Restoring,omitempty"`
 }
 
 func (x *Value) Reset() {
 	*x = Value{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[12]
+		mi := &file_obs_sync_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -761,7 +1429,7 @@ func (x *Value) String() string {
 func (*Value) ProtoMessage() {}
 
 func (x *Value) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[12]
+	mi := &file_obs_sync_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -774,7 +1442,7 @@ func (x *Value) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Value.ProtoReflect.Descriptor instead.
 func (*Value) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{12}
+	return file_obs_sync_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *Value) GetScanned() int64 {
@@ -819,6 +1487,13 @@ func (x *Value) GetFinishFlag() bool {
 	return false
 }
 
+func (x *Value) GetRestoring() int64 {
+	if x != nil {
+		return x.Restoring
+	}
+	return 0
+}
+
 type Status struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -830,7 +1505,7 @@ type Status struct {
 func (x *Status) Reset() {
 	*x = Status{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[13]
+		mi := &file_obs_sync_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -843,7 +1518,7 @@ func (x *Status) String() string {
 func (*Status) ProtoMessage() {}
 
 func (x *Status) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[13]
+	mi := &file_obs_sync_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -856,7 +1531,7 @@ func (x *Status) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Status.ProtoReflect.Descriptor instead.
 func (*Status) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{13}
+	return file_obs_sync_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *Status) GetValue() *Value {
@@ -878,7 +1553,7 @@ type StopResult struct {
 func (x *StopResult) Reset() {
 	*x = StopResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[14]
+		mi := &file_obs_sync_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -891,7 +1566,7 @@ func (x *StopResult) String() string {
 func (*StopResult) ProtoMessage() {}
 
 func (x *StopResult) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[14]
+	mi := &file_obs_sync_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,7 +1579,7 @@ func (x *StopResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopResult.ProtoReflect.Descriptor instead.
 func (*StopResult) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{14}
+	return file_obs_sync_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *StopResult) GetTaskName() string {
@@ -934,7 +1609,7 @@ type TaskStatus struct {
 func (x *TaskStatus) Reset() {
 	*x = TaskStatus{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[15]
+		mi := &file_obs_sync_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -947,7 +1622,7 @@ func (x *TaskStatus) String() string {
 func (*TaskStatus) ProtoMessage() {}
 
 func (x *TaskStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[15]
+	mi := &file_obs_sync_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -960,7 +1635,7 @@ func (x *TaskStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TaskStatus.ProtoReflect.Descriptor instead.
 func (*TaskStatus) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{15}
+	return file_obs_sync_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *TaskStatus) GetBucket() string {
@@ -995,7 +1670,7 @@ type StatReplay struct {
 func (x *StatReplay) Reset() {
 	*x = StatReplay{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[16]
+		mi := &file_obs_sync_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1008,7 +1683,7 @@ func (x *StatReplay) String() string {
 func (*StatReplay) ProtoMessage() {}
 
 func (x *StatReplay) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[16]
+	mi := &file_obs_sync_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1021,7 +1696,7 @@ func (x *StatReplay) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatReplay.ProtoReflect.Descriptor instead.
 func (*StatReplay) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{16}
+	return file_obs_sync_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *StatReplay) GetTaskStatus() []*TaskStatus {
@@ -1036,17 +1711,22 @@ type BucketSummary struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Scan    int64  `protobuf:"varint,2,opt,name=scan,proto3" json:"scan,omitempty"`
-	Success int64  `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	Fail    int64  `protobuf:"varint,4,opt,name=fail,proto3" json:"fail,omitempty"`
-	Finish  bool   `protobuf:"varint,5,opt,name=finish,proto3" json:"finish,omitempty"`
+	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Scan      int64  `protobuf:"varint,2,opt,name=scan,proto3" json:"scan,omitempty"`
+	Success   int64  `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	Fail      int64  `protobuf:"varint,4,opt,name=fail,proto3" json:"fail,omitempty"`
+	Finish    bool   `protobuf:"varint,5,opt,name=finish,proto3" json:"finish,omitempty"`
+	Restoring int64  `protobuf:"varint,6,opt,name=restoring,proto3" json:"restoring,omitempty"`
+	// 未能迁移的桶配置
+	ConfigReport []string `protobuf:"bytes,7,rep,name=configReport,proto3" json:"configReport,omitempty"`
+	// 未选中，不迁移
+	Excluded bool `protobuf:"varint,8,opt,name=excluded,proto3" json:"excluded,omitempty"`
 }
 
 func (x *BucketSummary) Reset() {
 	*x = BucketSummary{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[17]
+		mi := &file_obs_sync_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1059,7 +1739,7 @@ func (x *BucketSummary) String() string {
 func (*BucketSummary) ProtoMessage() {}
 
 func (x *BucketSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[17]
+	mi := &file_obs_sync_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1072,7 +1752,7 @@ func (x *BucketSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BucketSummary.ProtoReflect.Descriptor instead.
 func (*BucketSummary) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{17}
+	return file_obs_sync_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *BucketSummary) GetName() string {
@@ -1110,6 +1790,27 @@ func (x *BucketSummary) GetFinish() bool {
 	return false
 }
 
+func (x *BucketSummary) GetRestoring() int64 {
+	if x != nil {
+		return x.Restoring
+	}
+	return 0
+}
+
+func (x *BucketSummary) GetConfigReport() []string {
+	if x != nil {
+		return x.ConfigReport
+	}
+	return nil
+}
+
+func (x *BucketSummary) GetExcluded() bool {
+	if x != nil {
+		return x.Excluded
+	}
+	return false
+}
+
 type StatResult struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1122,7 +1823,7 @@ type StatResult struct {
 func (x *StatResult) Reset() {
 	*x = StatResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[18]
+		mi := &file_obs_sync_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1135,7 +1836,7 @@ func (x *StatResult) String() string {
 func (*StatResult) ProtoMessage() {}
 
 func (x *StatResult) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[18]
+	mi := &file_obs_sync_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1148,7 +1849,7 @@ func (x *StatResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatResult.ProtoReflect.Descriptor instead.
 func (*StatResult) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{18}
+	return file_obs_sync_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *StatResult) GetValue() *Value {
@@ -1176,7 +1877,7 @@ type SyncReplay_Row struct {
 func (x *SyncReplay_Row) Reset() {
 	*x = SyncReplay_Row{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_obs_sync_proto_msgTypes[19]
+		mi := &file_obs_sync_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1189,7 +1890,7 @@ func (x *SyncReplay_Row) String() string {
 func (*SyncReplay_Row) ProtoMessage() {}
 
 func (x *SyncReplay_Row) ProtoReflect() protoreflect.Message {
-	mi := &file_obs_sync_proto_msgTypes[19]
+	mi := &file_obs_sync_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1202,7 +1903,7 @@ func (x *SyncReplay_Row) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncReplay_Row.ProtoReflect.Descriptor instead.
 func (*SyncReplay_Row) Descriptor() ([]byte, []int) {
-	return file_obs_sync_proto_rawDescGZIP(), []int{11, 0}
+	return file_obs_sync_proto_rawDescGZIP(), []int{14, 0}
 }
 
 func (x *SyncReplay_Row) GetCells() []string {
@@ -1216,47 +1917,114 @@ var File_obs_sync_proto protoreflect.FileDescriptor
 
 var file_obs_sync_proto_rawDesc = []byte{
 	0x0a, 0x0e, 0x6f, 0x62, 0x73, 0x5f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x12, 0x04, 0x73, 0x79, 0x6e, 0x63, 0x22, 0x21, 0x0a, 0x0b, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65,
+	0x12, 0x04, 0x73, 0x79, 0x6e, 0x63, 0x22, 0x35, 0x0a, 0x0b, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65,
 	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x67, 0x6e, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x67, 0x6e, 0x22, 0x95, 0x01, 0x0a, 0x07, 0x55, 0x72,
-	0x69, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x65, 0x12, 0x22, 0x0a, 0x0c, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x44, 0x6f, 0x6d, 0x61, 0x69,
-	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x44,
-	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4b,
-	0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73,
-	0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4b, 0x65, 0x79,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4b, 0x65,
-	0x79, 0x22, 0x5a, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a,
-	0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a,
-	0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x05, 0x6d, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x73, 0x44, 0x69, 0x72,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x69, 0x73, 0x44, 0x69, 0x72, 0x22, 0xa2, 0x01,
-	0x0a, 0x08, 0x54, 0x61, 0x73, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x75,
-	0x63, 0x6b, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
-	0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x72,
-	0x63, 0x55, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x79, 0x6e,
-	0x63, 0x2e, 0x55, 0x72, 0x69, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x73, 0x72, 0x63, 0x55, 0x72,
-	0x69, 0x12, 0x27, 0x0a, 0x07, 0x64, 0x65, 0x73, 0x74, 0x55, 0x72, 0x69, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x55, 0x72, 0x69, 0x49, 0x6e, 0x66,
-	0x6f, 0x52, 0x07, 0x64, 0x65, 0x73, 0x74, 0x55, 0x72, 0x69, 0x12, 0x26, 0x0a, 0x07, 0x6f, 0x62,
-	0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x73, 0x79,
-	0x6e, 0x63, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63,
-	0x74, 0x73, 0x22, 0x32, 0x0a, 0x0c, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x22, 0x0a, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x49, 0x6e, 0x66, 0x6f,
-	0x52, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x22, 0x90, 0x01, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x4e, 0x61, 0x6d,
-	0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x50, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x50, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63,
-	0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63,
-	0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64,
-	0x65, 0x61, 0x64, 0x6c, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
-	0x64, 0x65, 0x61, 0x64, 0x6c, 0x53, 0x69, 0x7a, 0x65, 0x22, 0x20, 0x0a, 0x06, 0x52, 0x65, 0x70,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x67, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x22, 0x95, 0x01,
+	0x0a, 0x07, 0x55, 0x72, 0x69, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x44,
+	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x42, 0x75, 0x63,
+	0x6b, 0x65, 0x74, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x4b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x4b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x63, 0x72,
+	0x65, 0x74, 0x4b, 0x65, 0x79, 0x22, 0xa9, 0x01, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x69, 0x73, 0x44, 0x69, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x69, 0x73, 0x44,
+	0x69, 0x72, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x43, 0x6c, 0x61,
+	0x73, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x29, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x22, 0x99, 0x01, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a,
+	0x09, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6d, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x6d, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d,
+	0x61, 0x72, 0x6b, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x64, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x22, 0xb9, 0x05,
+	0x0a, 0x0a, 0x54, 0x61, 0x73, 0x6b, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x2a, 0x0a, 0x10,
+	0x73, 0x65, 0x74, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x4d, 0x44, 0x35,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x73, 0x65, 0x74, 0x4f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x4d, 0x65, 0x74, 0x61, 0x4d, 0x44, 0x35, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x72, 0x63, 0x4d,
+	0x44, 0x35, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x73, 0x72, 0x63, 0x4d, 0x44, 0x35, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x2a, 0x0a, 0x10,
+	0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x74, 0x6f, 0x72,
+	0x61, 0x67, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x20, 0x0a, 0x0b,
+	0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x44, 0x61, 0x79, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x44, 0x61, 0x79, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x63, 0x6f, 0x70, 0x79, 0x54, 0x61, 0x67, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x63, 0x6f, 0x70, 0x79, 0x54, 0x61, 0x67, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x22, 0x0a, 0x0c,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x41, 0x73, 0x4f, 0x66, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0c, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x41, 0x73, 0x4f, 0x66,
+	0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x6c, 0x4d, 0x6f, 0x64, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x61, 0x63, 0x6c, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x43, 0x0a, 0x0b, 0x61, 0x63,
+	0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x21, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2e, 0x41, 0x63, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0b, 0x61, 0x63, 0x6c, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x12,
+	0x26, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c,
+	0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x64, 0x65, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x64, 0x65, 0x63, 0x72, 0x79, 0x70, 0x74, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x73, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x73,
+	0x65, 0x12, 0x20, 0x0a, 0x0b, 0x73, 0x73, 0x65, 0x4b, 0x6d, 0x73, 0x4b, 0x65, 0x79, 0x49, 0x64,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x73, 0x65, 0x4b, 0x6d, 0x73, 0x4b, 0x65,
+	0x79, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x12, 0x73, 0x73, 0x65, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d,
+	0x65, 0x72, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x12, 0x73, 0x73, 0x65, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x46,
+	0x69, 0x6c, 0x65, 0x12, 0x34, 0x0a, 0x15, 0x73, 0x72, 0x63, 0x53, 0x73, 0x65, 0x43, 0x75, 0x73,
+	0x74, 0x6f, 0x6d, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x15, 0x73, 0x72, 0x63, 0x53, 0x73, 0x65, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d,
+	0x65, 0x72, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x1a, 0x3e, 0x0a, 0x10, 0x41, 0x63, 0x6c,
+	0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcc, 0x01, 0x0a, 0x08, 0x54, 0x61,
+	0x73, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x62, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x72, 0x63, 0x55, 0x72, 0x69,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x55, 0x72,
+	0x69, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x73, 0x72, 0x63, 0x55, 0x72, 0x69, 0x12, 0x27, 0x0a,
+	0x07, 0x64, 0x65, 0x73, 0x74, 0x55, 0x72, 0x69, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d,
+	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x55, 0x72, 0x69, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x64,
+	0x65, 0x73, 0x74, 0x55, 0x72, 0x69, 0x12, 0x26, 0x0a, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x4f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x28,
+	0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x32, 0x0a, 0x0c, 0x44, 0x61, 0x74, 0x61,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x04, 0x74, 0x61, 0x73, 0x6b,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61,
+	0x73, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x22, 0xda, 0x01, 0x0a,
+	0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x62, 0x75, 0x63,
+	0x6b, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x6f, 0x72, 0x6b, 0x49,
+	0x50, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x50, 0x12,
+	0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69,
+	0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65,
+	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x2c, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x09, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a,
+	0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x20, 0x0a, 0x06, 0x52, 0x65, 0x70,
 	0x6c, 0x61, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x07, 0x0a, 0x05, 0x45,
 	0x6d, 0x70, 0x74, 0x79, 0x22, 0x21, 0x0a, 0x0d, 0x48, 0x61, 0x73, 0x4d, 0x6f, 0x72, 0x65, 0x52,
@@ -1268,29 +2036,91 @@ var file_obs_sync_proto_rawDesc = []byte{
 	0x63, 0x63, 0x65, 0x73, 0x73, 0x4b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
 	0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x63,
 	0x72, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65,
-	0x63, 0x72, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x22, 0x48, 0x0a, 0x08, 0x53, 0x79, 0x6e, 0x63, 0x49,
-	0x6e, 0x66, 0x6f, 0x12, 0x1c, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0a, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x03, 0x73, 0x72,
-	0x63, 0x12, 0x1e, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0a, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x64, 0x65, 0x73,
-	0x74, 0x22, 0x71, 0x0a, 0x0a, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x12,
-	0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2e, 0x0a, 0x07, 0x42, 0x75, 0x63, 0x6b, 0x65,
-	0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
-	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x2e, 0x52, 0x6f, 0x77, 0x52, 0x07,
-	0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x1a, 0x1b, 0x0a, 0x03, 0x52, 0x6f, 0x77, 0x12, 0x14,
-	0x0a, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x63,
-	0x65, 0x6c, 0x6c, 0x73, 0x22, 0x9f, 0x01, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x18,
-	0x0a, 0x07, 0x53, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x07, 0x53, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x6b, 0x69, 0x70,
-	0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x53, 0x6b, 0x69, 0x70, 0x70,
-	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x43, 0x6f, 0x70, 0x69, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x06, 0x43, 0x6f, 0x70, 0x69, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x46, 0x61,
-	0x69, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x46, 0x61, 0x69, 0x6c,
-	0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x04, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68,
-	0x46, 0x6c, 0x61, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x46, 0x69, 0x6e, 0x69,
-	0x73, 0x68, 0x46, 0x6c, 0x61, 0x67, 0x22, 0x2b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x63, 0x72, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x22, 0xfb, 0x01, 0x0a, 0x08, 0x53, 0x79, 0x6e, 0x63,
+	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1c, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0a, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x03, 0x73,
+	0x72, 0x63, 0x12, 0x1e, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x64, 0x65,
+	0x73, 0x74, 0x12, 0x28, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x24, 0x0a, 0x04,
+	0x70, 0x6c, 0x61, 0x6e, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x73, 0x79, 0x6e,
+	0x63, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x04, 0x70, 0x6c,
+	0x61, 0x6e, 0x12, 0x2d, 0x0a, 0x07, 0x6d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x42, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x07, 0x6d, 0x61, 0x70, 0x70, 0x69, 0x6e,
+	0x67, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x18, 0x06, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65,
+	0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x65, 0x78,
+	0x63, 0x6c, 0x75, 0x64, 0x65, 0x22, 0xaf, 0x02, 0x0a, 0x0d, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74,
+	0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x34, 0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x42, 0x75,
+	0x63, 0x6b, 0x65, 0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x61, 0x69, 0x72,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x12, 0x20, 0x0a,
+	0x0b, 0x73, 0x74, 0x72, 0x69, 0x70, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x73, 0x74, 0x72, 0x69, 0x70, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12,
+	0x20, 0x0a, 0x0b, 0x73, 0x74, 0x72, 0x69, 0x70, 0x53, 0x75, 0x66, 0x66, 0x69, 0x78, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x74, 0x72, 0x69, 0x70, 0x53, 0x75, 0x66, 0x66, 0x69,
+	0x78, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x72, 0x65, 0x67, 0x65, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x61,
+	0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12,
+	0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x53, 0x75, 0x66, 0x66, 0x69, 0x78, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x53, 0x75, 0x66, 0x66, 0x69, 0x78, 0x1a, 0x38, 0x0a,
+	0x0a, 0x50, 0x61, 0x69, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x71, 0x0a, 0x0a, 0x53, 0x79, 0x6e, 0x63, 0x52,
+	0x65, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2e, 0x0a,
+	0x07, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79,
+	0x2e, 0x52, 0x6f, 0x77, 0x52, 0x07, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x1a, 0x1b, 0x0a,
+	0x03, 0x52, 0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x22, 0x35, 0x0a, 0x09, 0x50, 0x6c,
+	0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a,
+	0x65, 0x22, 0xe8, 0x02, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x6e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x72, 0x63, 0x42, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x72, 0x63, 0x42, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x6f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x65, 0x73, 0x74, 0x42, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x73, 0x74, 0x42, 0x75,
+	0x63, 0x6b, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x23, 0x0a, 0x04,
+	0x63, 0x6f, 0x70, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x79, 0x6e,
+	0x63, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x04, 0x63, 0x6f, 0x70,
+	0x79, 0x12, 0x23, 0x0a, 0x04, 0x73, 0x6b, 0x69, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0f, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x52, 0x04, 0x73, 0x6b, 0x69, 0x70, 0x12, 0x25, 0x0a, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x50, 0x6c, 0x61,
+	0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x12, 0x2b, 0x0a,
+	0x08, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0f, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x52, 0x08, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x38, 0x0a, 0x0a,
+	0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x2a, 0x0a, 0x07, 0x62, 0x75,
+	0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x73, 0x79,
+	0x6e, 0x63, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x07, 0x62,
+	0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x22, 0xbd, 0x01, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x53, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x53, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x6b,
+	0x69, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x53, 0x6b, 0x69,
+	0x70, 0x70, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x43, 0x6f, 0x70, 0x69, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x43, 0x6f, 0x70, 0x69, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06,
+	0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x46, 0x61,
+	0x69, 0x6c, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x46, 0x69, 0x6e, 0x69,
+	0x73, 0x68, 0x46, 0x6c, 0x61, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x46, 0x69,
+	0x6e, 0x69, 0x73, 0x68, 0x46, 0x6c, 0x61, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x52, 0x65, 0x73, 0x74,
+	0x6f, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x52, 0x65, 0x73,
+	0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x22, 0x2b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
 	0x12, 0x21, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
 	0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61,
 	0x6c, 0x75, 0x65, 0x22, 0x40, 0x0a, 0x0a, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c,
@@ -1306,44 +2136,52 @@ var file_obs_sync_proto_rawDesc = []byte{
 	0x0a, 0x53, 0x74, 0x61, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x12, 0x30, 0x0a, 0x0a, 0x74,
 	0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
 	0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x52, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x7d, 0x0a,
-	0x0d, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x12,
-	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x63, 0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x04, 0x73, 0x63, 0x61, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
-	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
-	0x12, 0x12, 0x0a, 0x04, 0x66, 0x61, 0x69, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
-	0x66, 0x61, 0x69, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x18, 0x05,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x22, 0x6a, 0x0a, 0x0a,
-	0x53, 0x74, 0x61, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x21, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63,
-	0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x39, 0x0a,
-	0x0d, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x02,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x42, 0x75, 0x63, 0x6b,
-	0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x0d, 0x62, 0x75, 0x63, 0x6b, 0x65,
-	0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x32, 0xc3, 0x02, 0x0a, 0x04, 0x50, 0x69, 0x70,
-	0x65, 0x12, 0x39, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12,
-	0x11, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x29, 0x0a, 0x09,
-	0x50, 0x75, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63,
-	0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x52,
-	0x65, 0x70, 0x6c, 0x61, 0x79, 0x22, 0x00, 0x12, 0x2d, 0x0a, 0x07, 0x48, 0x61, 0x73, 0x4d, 0x6f,
-	0x72, 0x65, 0x12, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
-	0x13, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x48, 0x61, 0x73, 0x4d, 0x6f, 0x72, 0x65, 0x52, 0x65,
-	0x70, 0x6c, 0x61, 0x79, 0x22, 0x00, 0x12, 0x2a, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x0e,
-	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x10,
-	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79,
-	0x22, 0x00, 0x12, 0x26, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x0b, 0x2e, 0x73, 0x79,
-	0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x30, 0x01, 0x12, 0x27, 0x0a, 0x04, 0x53, 0x74,
-	0x6f, 0x70, 0x12, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
-	0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x22, 0x00, 0x12, 0x29, 0x0a, 0x04, 0x53, 0x74, 0x61, 0x74, 0x12, 0x0b, 0x2e, 0x73, 0x79,
-	0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
-	0x53, 0x74, 0x61, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x09,
-	0x5a, 0x07, 0x73, 0x79, 0x6e, 0x63, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x73, 0x52, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0xdb, 0x01,
+	0x0a, 0x0d, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x63, 0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x73, 0x63, 0x61, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x61, 0x69, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x04, 0x66, 0x61, 0x69, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x12, 0x1c, 0x0a,
+	0x09, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x22, 0x0a, 0x0c, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x22, 0x6a, 0x0a, 0x0a, 0x53,
+	0x74, 0x61, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x21, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x39, 0x0a, 0x0d,
+	0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x42, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x0d, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74,
+	0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x32, 0xef, 0x02, 0x0a, 0x04, 0x50, 0x69, 0x70, 0x65,
+	0x12, 0x39, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x11,
+	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x12, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x29, 0x0a, 0x09, 0x50,
+	0x75, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x52, 0x65,
+	0x70, 0x6c, 0x61, 0x79, 0x22, 0x00, 0x12, 0x2d, 0x0a, 0x07, 0x48, 0x61, 0x73, 0x4d, 0x6f, 0x72,
+	0x65, 0x12, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x13,
+	0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x48, 0x61, 0x73, 0x4d, 0x6f, 0x72, 0x65, 0x52, 0x65, 0x70,
+	0x6c, 0x61, 0x79, 0x22, 0x00, 0x12, 0x2a, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x0e, 0x2e,
+	0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x10, 0x2e,
+	0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x22,
+	0x00, 0x12, 0x2a, 0x0a, 0x04, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63,
+	0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63,
+	0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x22, 0x00, 0x12, 0x26, 0x0a,
+	0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x1a, 0x0c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x22, 0x00, 0x30, 0x01, 0x12, 0x27, 0x0a, 0x04, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x0b, 0x2e,
+	0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x10, 0x2e, 0x73, 0x79, 0x6e,
+	0x63, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x29,
+	0x0a, 0x04, 0x53, 0x74, 0x61, 0x74, 0x12, 0x0b, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x1a, 0x10, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x09, 0x5a, 0x07, 0x73, 0x79, 0x6e,
+	0x63, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1358,60 +2196,83 @@ func file_obs_sync_proto_rawDescGZIP() []byte {
 	return file_obs_sync_proto_rawDescData
 }
 
-var file_obs_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_obs_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_obs_sync_proto_goTypes = []interface{}{
 	(*DataRequest)(nil),    // 0: sync.DataRequest
 	(*UriInfo)(nil),        // 1: sync.UriInfo
 	(*Object)(nil),         // 2: sync.Object
-	(*TaskInfo)(nil),       // 3: sync.TaskInfo
-	(*DataResponse)(nil),   // 4: sync.DataResponse
-	(*Result)(nil),         // 5: sync.Result
-	(*Replay)(nil),         // 6: sync.Replay
-	(*Empty)(nil),          // 7: sync.Empty
-	(*HasMoreReplay)(nil),  // 8: sync.HasMoreReplay
-	(*Auth)(nil),           // 9: sync.Auth
-	(*SyncInfo)(nil),       // 10: sync.SyncInfo
-	(*SyncReplay)(nil),     // 11: sync.SyncReplay
-	(*Value)(nil),          // 12: sync.Value
-	(*Status)(nil),         // 13: sync.Status
-	(*StopResult)(nil),     // 14: sync.StopResult
-	(*TaskStatus)(nil),     // 15: sync.TaskStatus
-	(*StatReplay)(nil),     // 16: sync.StatReplay
-	(*BucketSummary)(nil),  // 17: sync.BucketSummary
-	(*StatResult)(nil),     // 18: sync.StatResult
-	(*SyncReplay_Row)(nil), // 19: sync.SyncReplay.Row
+	(*Version)(nil),        // 3: sync.Version
+	(*TaskConfig)(nil),     // 4: sync.TaskConfig
+	(*TaskInfo)(nil),       // 5: sync.TaskInfo
+	(*DataResponse)(nil),   // 6: sync.DataResponse
+	(*Result)(nil),         // 7: sync.Result
+	(*Replay)(nil),         // 8: sync.Replay
+	(*Empty)(nil),          // 9: sync.Empty
+	(*HasMoreReplay)(nil),  // 10: sync.HasMoreReplay
+	(*Auth)(nil),           // 11: sync.Auth
+	(*SyncInfo)(nil),       // 12: sync.SyncInfo
+	(*BucketMapping)(nil),  // 13: sync.BucketMapping
+	(*SyncReplay)(nil),     // 14: sync.SyncReplay
+	(*PlanCount)(nil),      // 15: sync.PlanCount
+	(*PlanBucket)(nil),     // 16: sync.PlanBucket
+	(*PlanReplay)(nil),     // 17: sync.PlanReplay
+	(*Value)(nil),          // 18: sync.Value
+	(*Status)(nil),         // 19: sync.Status
+	(*StopResult)(nil),     // 20: sync.StopResult
+	(*TaskStatus)(nil),     // 21: sync.TaskStatus
+	(*StatReplay)(nil),     // 22: sync.StatReplay
+	(*BucketSummary)(nil),  // 23: sync.BucketSummary
+	(*StatResult)(nil),     // 24: sync.StatResult
+	nil,                    // 25: sync.TaskConfig.AclAccountsEntry
+	nil,                    // 26: sync.BucketMapping.PairsEntry
+	(*SyncReplay_Row)(nil), // 27: sync.SyncReplay.Row
 }
 var file_obs_sync_proto_depIdxs = []int32{
-	1,  // 0: sync.TaskInfo.srcUri:type_name -> sync.UriInfo
-	1,  // 1: sync.TaskInfo.destUri:type_name -> sync.UriInfo
-	2,  // 2: sync.TaskInfo.objects:type_name -> sync.Object
-	3,  // 3: sync.DataResponse.task:type_name -> sync.TaskInfo
-	9,  // 4: sync.SyncInfo.src:type_name -> sync.Auth
-	9,  // 5: sync.SyncInfo.dest:type_name -> sync.Auth
-	19, // 6: sync.SyncReplay.Buckets:type_name -> sync.SyncReplay.Row
-	12, // 7: sync.Status.value:type_name -> sync.Value
-	15, // 8: sync.StatReplay.taskStatus:type_name -> sync.TaskStatus
-	12, // 9: sync.StatResult.value:type_name -> sync.Value
-	17, // 10: sync.StatResult.bucketSummary:type_name -> sync.BucketSummary
-	0,  // 11: sync.Pipe.DataStream:input_type -> sync.DataRequest
-	5,  // 12: sync.Pipe.PutResult:input_type -> sync.Result
-	7,  // 13: sync.Pipe.HasMore:input_type -> sync.Empty
-	10, // 14: sync.Pipe.Sync:input_type -> sync.SyncInfo
-	7,  // 15: sync.Pipe.Start:input_type -> sync.Empty
-	7,  // 16: sync.Pipe.Stop:input_type -> sync.Empty
-	7,  // 17: sync.Pipe.Stat:input_type -> sync.Empty
-	4,  // 18: sync.Pipe.DataStream:output_type -> sync.DataResponse
-	6,  // 19: sync.Pipe.PutResult:output_type -> sync.Replay
-	8,  // 20: sync.Pipe.HasMore:output_type -> sync.HasMoreReplay
-	11, // 21: sync.Pipe.Sync:output_type -> sync.SyncReplay
-	13, // 22: sync.Pipe.Start:output_type -> sync.Status
-	14, // 23: sync.Pipe.Stop:output_type -> sync.StopResult
-	18, // 24: sync.Pipe.Stat:output_type -> sync.StatResult
-	18, // [18:25] is the sub-list for method output_type
-	11, // [11:18] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	3,  // 0: sync.Object.versions:type_name -> sync.Version
+	25, // 1: sync.TaskConfig.aclAccounts:type_name -> sync.TaskConfig.AclAccountsEntry
+	1,  // 2: sync.TaskInfo.srcUri:type_name -> sync.UriInfo
+	1,  // 3: sync.TaskInfo.destUri:type_name -> sync.UriInfo
+	2,  // 4: sync.TaskInfo.objects:type_name -> sync.Object
+	4,  // 5: sync.TaskInfo.config:type_name -> sync.TaskConfig
+	5,  // 6: sync.DataResponse.task:type_name -> sync.TaskInfo
+	5,  // 7: sync.Result.restoring:type_name -> sync.TaskInfo
+	11, // 8: sync.SyncInfo.src:type_name -> sync.Auth
+	11, // 9: sync.SyncInfo.dest:type_name -> sync.Auth
+	4,  // 10: sync.SyncInfo.config:type_name -> sync.TaskConfig
+	16, // 11: sync.SyncInfo.plan:type_name -> sync.PlanBucket
+	13, // 12: sync.SyncInfo.mapping:type_name -> sync.BucketMapping
+	26, // 13: sync.BucketMapping.pairs:type_name -> sync.BucketMapping.PairsEntry
+	27, // 14: sync.SyncReplay.Buckets:type_name -> sync.SyncReplay.Row
+	15, // 15: sync.PlanBucket.copy:type_name -> sync.PlanCount
+	15, // 16: sync.PlanBucket.skip:type_name -> sync.PlanCount
+	15, // 17: sync.PlanBucket.extra:type_name -> sync.PlanCount
+	15, // 18: sync.PlanBucket.conflict:type_name -> sync.PlanCount
+	16, // 19: sync.PlanReplay.buckets:type_name -> sync.PlanBucket
+	18, // 20: sync.Status.value:type_name -> sync.Value
+	21, // 21: sync.StatReplay.taskStatus:type_name -> sync.TaskStatus
+	18, // 22: sync.StatResult.value:type_name -> sync.Value
+	23, // 23: sync.StatResult.bucketSummary:type_name -> sync.BucketSummary
+	0,  // 24: sync.Pipe.DataStream:input_type -> sync.DataRequest
+	7,  // 25: sync.Pipe.PutResult:input_type -> sync.Result
+	9,  // 26: sync.Pipe.HasMore:input_type -> sync.Empty
+	12, // 27: sync.Pipe.Sync:input_type -> sync.SyncInfo
+	12, // 28: sync.Pipe.Plan:input_type -> sync.SyncInfo
+	9,  // 29: sync.Pipe.Start:input_type -> sync.Empty
+	9,  // 30: sync.Pipe.Stop:input_type -> sync.Empty
+	9,  // 31: sync.Pipe.Stat:input_type -> sync.Empty
+	6,  // 32: sync.Pipe.DataStream:output_type -> sync.DataResponse
+	8,  // 33: sync.Pipe.PutResult:output_type -> sync.Replay
+	10, // 34: sync.Pipe.HasMore:output_type -> sync.HasMoreReplay
+	14, // 35: sync.Pipe.Sync:output_type -> sync.SyncReplay
+	17, // 36: sync.Pipe.Plan:output_type -> sync.PlanReplay
+	19, // 37: sync.Pipe.Start:output_type -> sync.Status
+	20, // 38: sync.Pipe.Stop:output_type -> sync.StopResult
+	24, // 39: sync.Pipe.Stat:output_type -> sync.StatResult
+	32, // [32:40] is the sub-list for method output_type
+	24, // [24:32] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_obs_sync_proto_init() }
@@ -1457,7 +2318,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TaskInfo); i {
+			switch v := v.(*Version); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1469,7 +2330,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataResponse); i {
+			switch v := v.(*TaskConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1481,7 +2342,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Result); i {
+			switch v := v.(*TaskInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1493,7 +2354,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Replay); i {
+			switch v := v.(*DataResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1505,7 +2366,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Empty); i {
+			switch v := v.(*Result); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1517,7 +2378,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HasMoreReplay); i {
+			switch v := v.(*Replay); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1529,7 +2390,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Auth); i {
+			switch v := v.(*Empty); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1541,7 +2402,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SyncInfo); i {
+			switch v := v.(*HasMoreReplay); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1553,7 +2414,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SyncReplay); i {
+			switch v := v.(*Auth); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1565,7 +2426,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Value); i {
+			switch v := v.(*SyncInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1577,7 +2438,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Status); i {
+			switch v := v.(*BucketMapping); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1589,7 +2450,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StopResult); i {
+			switch v := v.(*SyncReplay); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1601,7 +2462,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TaskStatus); i {
+			switch v := v.(*PlanCount); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1613,7 +2474,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatReplay); i {
+			switch v := v.(*PlanBucket); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1625,7 +2486,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BucketSummary); i {
+			switch v := v.(*PlanReplay); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1637,7 +2498,7 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatResult); i {
+			switch v := v.(*Value); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1649,6 +2510,78 @@ func file_obs_sync_proto_init() {
 			}
 		}
 		file_obs_sync_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Status); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StopResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TaskStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatReplay); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BucketSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_obs_sync_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SyncReplay_Row); i {
 			case 0:
 				return &v.state
@@ -1667,7 +2600,7 @@ func file_obs_sync_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_obs_sync_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   20,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -26,6 +26,7 @@ type PipeClient interface {
 	PutResult(ctx context.Context, in *Result, opts ...grpc.CallOption) (*Replay, error)
 	HasMore(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HasMoreReplay, error)
 	Sync(ctx context.Context, in *SyncInfo, opts ...grpc.CallOption) (*SyncReplay, error)
+	Plan(ctx context.Context, in *SyncInfo, opts ...grpc.CallOption) (*PlanReplay, error)
 	Start(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Pipe_StartClient, error)
 	Stop(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StopResult, error)
 	Stat(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Pipe_StatClient, error)
@@ -97,6 +98,15 @@ func (c *pipeClient) Sync(ctx context.Context, in *SyncInfo, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *pipeClient) Plan(ctx context.Context, in *SyncInfo, opts ...grpc.CallOption) (*PlanReplay, error) {
+	out := new(PlanReplay)
+	err := c.cc.Invoke(ctx, "/sync.Pipe/Plan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *pipeClient) Start(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Pipe_StartClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Pipe_ServiceDesc.Streams[1], "/sync.Pipe/Start", opts...)
 	if err != nil {
@@ -178,6 +188,7 @@ type PipeServer interface {
 	PutResult(context.Context, *Result) (*Replay, error)
 	HasMore(context.Context, *Empty) (*HasMoreReplay, error)
 	Sync(context.Context, *SyncInfo) (*SyncReplay, error)
+	Plan(context.Context, *SyncInfo) (*PlanReplay, error)
 	Start(*Empty, Pipe_StartServer) error
 	Stop(context.Context, *Empty) (*StopResult, error)
 	Stat(*Empty, Pipe_StatServer) error
@@ -199,6 +210,9 @@ func (UnimplementedPipeServer) HasMore(context.Context, *Empty) (*HasMoreReplay,
 func (UnimplementedPipeServer) Sync(context.Context, *SyncInfo) (*SyncReplay, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
 }
+func (UnimplementedPipeServer) Plan(context.Context, *SyncInfo) (*PlanReplay, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Plan not implemented")
+}
 func (UnimplementedPipeServer) Start(*Empty, Pipe_StartServer) error {
 	return status.Errorf(codes.Unimplemented, "method Start not implemented")
 }
@@ -300,6 +314,24 @@ func _Pipe_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Pipe_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PipeServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sync.Pipe/Plan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PipeServer).Plan(ctx, req.(*SyncInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Pipe_Start_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(Empty)
 	if err := stream.RecvMsg(m); err != nil {
@@ -379,6 +411,10 @@ var Pipe_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Sync",
 			Handler:    _Pipe_Sync_Handler,
 		},
+		{
+			MethodName: "Plan",
+			Handler:    _Pipe_Plan_Handler,
+		},
 		{
 			MethodName: "Stop",
 			Handler:    _Pipe_Stop_Handler,